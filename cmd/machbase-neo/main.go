@@ -6,6 +6,7 @@ import (
 
 	"github.com/machbase/neo-server/v8/jsh/session"
 	"github.com/machbase/neo-server/v8/mods/server"
+	"github.com/machbase/neo-server/v8/mods/tql"
 )
 
 func main() {
@@ -25,6 +26,10 @@ func main() {
 		}
 		flagSet := flag.NewFlagSet("jsh", flag.ExitOnError)
 		os.Exit(session.JshMain(flagSet, []string{self, "jsh"}, os.Args[2:]))
+	} else if len(os.Args) > 2 && os.Args[1] == "tql" && os.Args[2] == "test" {
+		// handling "machbase-neo tql test ./dir"
+		flagSet := flag.NewFlagSet("tql test", flag.ExitOnError)
+		os.Exit(tql.RunTestMain(flagSet, os.Args[3:]))
 	} else {
 		// handling "machbase-neo serve ..." or others
 		os.Exit(server.Main(os.Args))
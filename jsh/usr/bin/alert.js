@@ -0,0 +1,231 @@
+'use strict';
+
+const process = require('process');
+const pretty = require('pretty');
+const neoapi = require('/usr/lib/neoapi');
+const { parseAndRun } = require('/usr/lib/opts');
+
+const optionHelp = { type: 'boolean', short: 'h', description: 'Show this help message', default: false }
+
+const defaultConfig = {
+    usage: 'Usage: alert <command> [options]',
+    options: {
+        help: optionHelp,
+    }
+};
+
+const listConfig = {
+    func: doList,
+    command: 'list',
+    usage: 'alert list',
+    description: 'List all alert rules and their states',
+    options: {
+        help: optionHelp,
+        ...pretty.TableArgOptions,
+    }
+}
+
+const addConfig = {
+    func: doAdd,
+    command: 'add',
+    usage: 'alert add [options] <name> <operator> <threshold>',
+    description: 'Add a new alert rule',
+    options: {
+        help: optionHelp,
+        sql: { type: 'string', description: 'SQL query, the first column of the first row is the value', default: '' },
+        tql: { type: 'string', description: 'Path to the TQL file, the first field of the first record is the value', default: '' },
+        interval: { type: 'string', description: 'Evaluation interval', default: '1m' },
+        for: { type: 'string', description: 'Duration the condition should be met before firing', default: '' },
+        hysteresis: { type: 'string', description: 'Margin the value should go back across the threshold to resolve', default: '' },
+        repeat: { type: 'string', description: 'Interval to notify again while firing', default: '' },
+        message: { type: 'string', description: 'Message template, e.g. "{{.Rule}} is {{.Status}}, {{.Value}}"', default: '' },
+        disabled: { type: 'boolean', description: 'Add the rule without evaluating it', default: false },
+        webhook: { type: 'string', description: 'Webhook URL to post the notifications', default: '' },
+        mqtt: { type: 'string', description: 'MQTT topic to publish the notifications', default: '' },
+        bridge: { type: 'string', description: 'MQTT or NATS bridge of the topic, the built-in broker if omitted', default: '' },
+        smtp: { type: 'string', description: 'SMTP relay address "host:port" to mail the notifications', default: '' },
+        smtpUser: { type: 'string', description: 'SMTP user', default: '' },
+        smtpPassword: { type: 'string', description: 'SMTP password', default: '' },
+        from: { type: 'string', description: 'Mail sender', default: '' },
+        to: { type: 'string', description: 'Mail recipients, separated by comma', default: '' },
+    },
+    positionals: [
+        { name: 'name', description: 'Name of the alert' },
+        { name: 'operator', description: 'Comparison operator: >, >=, <, <=, == or !=' },
+        { name: 'threshold', description: 'Threshold value' },
+    ],
+    longDescription: `
+    ex)
+        alert add --sql="select avg(value) from example where time > now - 1m" \\
+            --for=5m --hysteresis=5 --webhook=http://hooks.example.com/alert high_temp '>' 80
+        alert add --tql=/cpu.tql --mqtt=alerts/cpu --repeat=1h cpu_busy '>=' 90
+    `,
+}
+
+const delConfig = {
+    func: doDel,
+    command: 'del',
+    usage: 'alert del <name>',
+    description: 'Delete an alert rule',
+    options: {
+        help: optionHelp,
+    },
+    positionals: [
+        { name: 'name', description: 'Name of the alert to delete' },
+    ],
+}
+
+const silenceConfig = {
+    func: doSilence,
+    command: 'silence',
+    usage: 'alert silence <name> <duration>',
+    description: 'Suppress the notifications of an alert for the duration',
+    options: {
+        help: optionHelp,
+    },
+    positionals: [
+        { name: 'name', description: 'Name of the alert to silence' },
+        { name: 'duration', description: 'Duration, e.g. 30m, 2h, 1d' },
+    ],
+}
+
+const unsilenceConfig = {
+    func: doUnsilence,
+    command: 'unsilence',
+    usage: 'alert unsilence <name>',
+    description: 'Cancel the silence of an alert',
+    options: {
+        help: optionHelp,
+    },
+    positionals: [
+        { name: 'name', description: 'Name of the alert to unsilence' },
+    ],
+}
+
+parseAndRun(process.argv.slice(2), defaultConfig, [
+    listConfig,
+    addConfig,
+    delConfig,
+    silenceConfig,
+    unsilenceConfig,
+]);
+
+function errorMessage(err) {
+    let message = err.message;
+    //trim 'JSON-RPC error: ' prefix if exists
+    if (message.startsWith('JSON-RPC error: ')) {
+        message = message.substring('JSON-RPC error: '.length);
+    }
+    return message;
+}
+
+function doList(config, args) {
+    const client = new neoapi.Client(config);
+    client.listAlerts()
+        .then((lst) => {
+            let box = pretty.Table(config);
+            box.appendHeader(["NAME", "CONDITION", "INTERVAL", "STATUS", "VALUE", "SINCE", "SILENCED"]);
+            for (const a of lst) {
+                const st = a.state || {};
+                let status = a.disabled ? 'disabled' : (st.status || '');
+                if (st.error) {
+                    status = `${status}, ${st.error}`;
+                }
+                const silenced = st.silencedUntil && new Date(st.silencedUntil) > new Date() ? st.silencedUntil : '';
+                box.append([
+                    a.name,
+                    `${a.operator} ${a.threshold}`,
+                    a.interval,
+                    status,
+                    st.evaluatedAt ? st.value : '',
+                    st.since || '',
+                    silenced,
+                ]);
+            }
+            console.println(box.render());
+        })
+        .catch((err) => {
+            console.println('Error:', err.message);
+        });
+}
+
+function doAdd(config, args) {
+    const client = new neoapi.Client();
+    const name = args.name;
+    const threshold = parseFloat(args.threshold);
+    if (isNaN(threshold)) {
+        console.println(`Error adding alert: invalid threshold '${args.threshold}'`);
+        return;
+    }
+    const channels = [];
+    if (config.webhook) {
+        channels.push({ type: 'webhook', url: config.webhook });
+    }
+    if (config.mqtt) {
+        channels.push({ type: 'mqtt', topic: config.mqtt, bridge: config.bridge });
+    }
+    if (config.smtp) {
+        channels.push({
+            type: 'smtp',
+            addr: config.smtp,
+            user: config.smtpUser,
+            password: config.smtpPassword,
+            from: config.from,
+            to: config.to.split(',').map((s) => s.trim()).filter((s) => s.length > 0),
+        });
+    }
+    const rule = {
+        name: name,
+        disabled: config.disabled || false,
+        interval: config.interval,
+        sql: config.sql,
+        tql: config.tql,
+        operator: args.operator,
+        threshold: threshold,
+        hysteresis: config.hysteresis ? parseFloat(config.hysteresis) : 0,
+        for: config.for,
+        repeatInterval: config.repeat,
+        message: config.message,
+        channels: channels,
+    };
+    client.addAlert(rule)
+        .then(() => {
+            console.println(`Alert '${name}' added successfully.`);
+        })
+        .catch((err) => {
+            console.println('Error adding alert:', errorMessage(err));
+        });
+}
+
+function doDel(config, args) {
+    const client = new neoapi.Client();
+    client.deleteAlert(args.name)
+        .then(() => {
+            console.println(`Alert '${args.name}' deleted successfully.`);
+        })
+        .catch((err) => {
+            console.println('Error deleting alert:', errorMessage(err));
+        });
+}
+
+function doSilence(config, args) {
+    const client = new neoapi.Client();
+    client.silenceAlert(args.name, args.duration)
+        .then((st) => {
+            console.println(`Alert '${args.name}' silenced until ${st.silencedUntil}.`);
+        })
+        .catch((err) => {
+            console.println('Error silencing alert:', errorMessage(err));
+        });
+}
+
+function doUnsilence(config, args) {
+    const client = new neoapi.Client();
+    client.unsilenceAlert(args.name)
+        .then(() => {
+            console.println(`Alert '${args.name}' unsilenced successfully.`);
+        })
+        .catch((err) => {
+            console.println('Error unsilencing alert:', errorMessage(err));
+        });
+}
@@ -32,7 +32,7 @@ const addConfig = {
     description: 'Add a new bridge',
     options: {
         ...globalOptions,
-        type: { type: 'string', short: 't', description: 'Bridge type [sqlite|postgres|mysql|mssql|mqtt|nats]' }
+        type: { type: 'string', short: 't', description: 'Bridge type [sqlite|postgres|mysql|mssql|mqtt|nats|opcua]' }
     },
     positionals: [
         { name: 'name', description: 'Name of the bridge' },
@@ -51,6 +51,10 @@ const addConfig = {
         ex) bridge add -t mqtt my_mqtt "broker=127.0.0.1:1883 id=client-id"
     nats          NATS              https://nats.io
         ex) bridge add -t nats my_nats "server=nats://127.0.0.1:3000 name=client-name"
+    opcua         OPC UA            https://opcfoundation.org
+        ex) bridge add -t opcua my_opc "endpoint=opc.tcp://127.0.0.1:4840 reconnect=10s"
+            bridge add -t opcua my_opc "endpoint=opc.tcp://127.0.0.1:4840 policy=Basic256Sha256 mode=SignAndEncrypt \\
+                cert=/path/cert.pem key=/path/key.pem username=user password=pass"
 `
 };
 
@@ -156,11 +160,11 @@ function listBridges(config, args) {
 
 function addBridge(config, args) {
     if (!config.type) {
-        console.println("Error: Missing bridge type. Use -t option to specify one of [sqlite, postgres, mysql, mssql, mqtt, nats]");
+        console.println("Error: Missing bridge type. Use -t option to specify one of [sqlite, postgres, mysql, mssql, mqtt, nats, opcua]");
         process.exit(1);
     }
-    if (['sqlite', 'postgres', 'mysql', 'mssql', 'mqtt', 'nats'].indexOf(config.type) < 0) {
-        console.println("Error: Invalid bridge type. Use -t option to specify one of [sqlite, postgres, mysql, mssql, mqtt, nats]");
+    if (['sqlite', 'postgres', 'mysql', 'mssql', 'mqtt', 'nats', 'opcua'].indexOf(config.type) < 0) {
+        console.println("Error: Invalid bridge type. Use -t option to specify one of [sqlite, postgres, mysql, mssql, mqtt, nats, opcua]");
         process.exit(1);
     }
     if (!args.name) {
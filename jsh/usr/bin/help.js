@@ -45,6 +45,7 @@ const helpObjects = [
 ];
 
 const helpCommands = [
+    { name: 'alert', description: 'Manage alert rules' },
     { name: 'bridge', description: 'Manage bridges' },
     { name: 'connect', description: 'Connect to a database' },
     { name: 'explain', description: 'Explain a query plan' },
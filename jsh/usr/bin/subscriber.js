@@ -34,18 +34,22 @@ const addConfig = {
         help: optionHelp,
         autostart: { type: 'boolean', description: 'Enable autostart for the subscriber', default: false },
         qos: { type: 'integer', description: 'QoS level for MQTT bridge (0, 1, or 2)', default: 0 },
+        browse: { type: 'boolean', description: 'Browse the topic as a folder for the variables to monitor, OPC UA bridge only', default: false },
+        interval: { type: 'string', description: 'Sampling interval for OPC UA bridge', default: '1s' },
     },
     allowNegative: false,
     positionals: [
         { name: 'name', description: 'Name of the subscriber' },
         { name: 'bridge', description: 'Name of the pre-defined bridge to use' },
-        { name: 'topic', description: 'Topic to subscribe to' },
+        { name: 'topic', description: 'Topic to subscribe to, or node ids separated by comma for OPC UA bridge' },
         { name: 'destination', description: 'Destination to forward messages to (e.g., tql path, writing path descriptor)' },
     ],
     longDescription: `  ex)
     subscriber add --autostart --qos=1 my_lsnr my_mqtt outer/events /my_event.tql
     subscriber add my_append nats_bridge stream.in db/append/EXAMPLE:json
     subscriber add my_writer nats_bridge topic.in  db/write/EXAMPLE:csv:gzip
+    subscriber add --interval=500ms my_opc opc_bridge "ns=1;s=temp,ns=1;s=pressure" db/append/EXAMPLE
+    subscriber add --browse my_folder opc_bridge "ns=1;s=Line1" /opc_values.tql
     `,
 }
 const delConfig = {
@@ -130,7 +134,20 @@ function doAdd(config, args) {
     const destination = args.destination;
     const autostart = config.autostart || false;
     const qos = config.qos || 0;
-    client.addSchedule({ name: name, type: 'SUBSCRIBER', bridge: bridge, topic: topic, task: destination, autostart: autostart, qos: qos })
+    client.listBridges()
+        .then((lst) => {
+            const sch = { name: name, type: 'SUBSCRIBER', bridge: bridge, topic: topic, task: destination, autostart: autostart, qos: qos };
+            const br = (lst || []).find((b) => b.name === bridge);
+            if (br && br.type === 'opcua') {
+                if (config.browse) {
+                    sch.opcua = { browse: topic, interval: config.interval };
+                } else {
+                    const nodes = topic.split(',').map((s) => s.trim()).filter((s) => s.length > 0);
+                    sch.opcua = { nodes: nodes, interval: config.interval };
+                }
+            }
+            return client.addSchedule(sch);
+        })
         .then(() => {
             console.println(`Subscriber '${name}' added successfully.`);
         })
@@ -33,6 +33,7 @@ const addConfig = {
     options: {
         help: optionHelp,
         autostart: { type: 'boolean', description: 'Enable autostart for the timer', default: false },
+        catchUp: { type: 'string', description: 'Run the missed fires on startup: none, last or all', default: 'none' },
     },
     positionals: [
         { name: 'name', description: 'Name of the timer' },
@@ -42,6 +43,7 @@ const addConfig = {
     longDescription: `
     ex)
         timer add --autostart my_sched '@every 10s' /hello.tql
+        timer add --autostart --catch-up=last daily_agg '@daily' /agg.tql
     `,
 }
 
@@ -84,12 +86,33 @@ const stopConfig = {
     ],
 }
 
+const backfillConfig = {
+    func: doBackfill,
+    command: 'backfill',
+    usage: 'timer backfill <name> <from> <to>',
+    description: 'Run a timer for each fire time in the range',
+    options: {
+        help: optionHelp,
+    },
+    positionals: [
+        { name: 'name', description: 'Name of the timer to backfill' },
+        { name: 'from', description: 'Start time, "YYYY-MM-DD", "YYYY-MM-DD HH:MM:SS" or RFC3339' },
+        { name: 'to', description: 'End time (inclusive)' },
+    ],
+    longDescription: `
+    The fire time is passed to the TQL as param("_fire_time") in epoch nanoseconds.
+    ex)
+        timer backfill daily_agg 2026-01-01 2026-01-31
+    `,
+}
+
 parseAndRun(process.argv.slice(2), defaultConfig, [
     listConfig,
     addConfig,
     delConfig,
     startConfig,
     stopConfig,
+    backfillConfig,
 ]);
 
 function doList(config, args) {
@@ -123,7 +146,8 @@ function doAdd(config, args) {
     const spec = args.spec;
     const tqlPath = args.tqlPath;
     const autostart = config.autostart || false;
-    client.addSchedule({ name: name, type: 'TIMER', spec: spec, task: tqlPath, autostart: autostart })
+    const catchUp = config.catchUp || '';
+    client.addSchedule({ name: name, type: 'TIMER', spec: spec, task: tqlPath, autostart: autostart, catchUp: catchUp })
         .then(() => {
             console.println(`Timer '${name}' added successfully.`);
         })
@@ -168,4 +192,17 @@ function doStop(config, args) {
         .catch((err) => {
             console.println('Error stopping timer:', err.message);
         });
-}
\ No newline at end of file
+}
+function doBackfill(config, args) {
+    const client = new neoapi.Client();
+    client.backfillSchedule(args.name, args.from, args.to)
+        .then((runs) => {
+            for (const run of runs) {
+                console.println(run.fireTime, run.success ? 'OK' : 'FAILED', run.elapse, run.error || '');
+            }
+            console.println(`Timer '${args.name}' backfilled ${runs.length} runs.`);
+        })
+        .catch((err) => {
+            console.println('Error backfilling timer:', err.message);
+        });
+}
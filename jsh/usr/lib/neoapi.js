@@ -384,6 +384,15 @@ class Client extends _Client {
 
         return this._executeWithAuth(() => {
             if (type === 'SUBSCRIBER') {
+                if (sch.opcua) {
+                    return this._rpcRequest('schedule.subscriber.add', [{
+                        name,
+                        bridge,
+                        command: task,
+                        autoStart: !!autostart,
+                        opcua: sch.opcua,
+                    }]);
+                }
                 return this._rpcRequest('schedule.subscriber.add', [{
                     name,
                     bridge,
@@ -400,6 +409,14 @@ class Client extends _Client {
                     spec,
                     command: task,
                     autoStart: !!autostart,
+                    catchUp: sch.catchUp || '',
+                }]);
+            } else if (type === 'WORKFLOW') {
+                return this._rpcRequest('schedule.workflow.add', [{
+                    name,
+                    spec,
+                    autoStart: !!autostart,
+                    steps: sch.steps || [],
                 }]);
             } else {
                 throw new Error(`Unsupported schedule type: ${type}`);
@@ -421,6 +438,51 @@ class Client extends _Client {
             return this._rpcRequest('schedule.stop', [name]);
         });
     }
+    scheduleStatus(name) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('schedule.status', [name]);
+        });
+    }
+    backfillSchedule(name, from, to) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('schedule.backfill', [name, from, to]);
+        });
+    }
+    scheduleHistory(name, limit = 0) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('schedule.history', [name, limit]);
+        });
+    }
+    listAlerts() {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('alert.list', []);
+        });
+    }
+    getAlert(name) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('alert.get', [name]);
+        });
+    }
+    addAlert(rule) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('alert.add', [rule]);
+        });
+    }
+    deleteAlert(name) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('alert.delete', [name]);
+        });
+    }
+    silenceAlert(name, duration) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('alert.silence', [name, duration]);
+        });
+    }
+    unsilenceAlert(name) {
+        return this._executeWithAuth(() => {
+            return this._rpcRequest('alert.unsilence', [name]);
+        });
+    }
     listSessions() {
         return this._executeWithAuth(() => {
             return this._rpcRequest('session.list', []);
@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	logging "github.com/machbase/neo-server/v8/mods/logging"
+	"github.com/machbase/neo-server/v8/mods/model"
+	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/robfig/cron/v3"
+)
+
+// BrokerPublisher publishes the payload to the topic of the built-in mqtt broker.
+type BrokerPublisher func(topic string, payload []byte) error
+
+// Evaluator returns the value of the condition of the rule.
+type Evaluator func(ctx context.Context, r *Rule) (float64, error)
+
+func NewService(opts ...Option) *Service {
+	ret := &Service{
+		log:   logging.GetLog("alert"),
+		rules: map[string]*Rule{},
+	}
+	for _, o := range opts {
+		o(ret)
+	}
+	ret.crons = cron.New(
+		cron.WithLocation(time.Local),
+		cron.WithSeconds(),
+	)
+	if ret.evaluator == nil {
+		ret.evaluator = ret.evaluate
+	}
+	return ret
+}
+
+type Service struct {
+	log       logging.Log
+	crons     *cron.Cron
+	tqlLoader tql.Loader
+	publisher BrokerPublisher
+	evaluator Evaluator
+
+	models model.AlertProvider
+
+	mu    sync.RWMutex
+	rules map[string]*Rule
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type Option func(*Service)
+
+func WithProvider(provider model.AlertProvider) Option {
+	return func(s *Service) {
+		s.models = provider
+	}
+}
+
+func WithTqlLoader(ldr tql.Loader) Option {
+	return func(s *Service) {
+		s.tqlLoader = ldr
+	}
+}
+
+// WithBrokerPublisher sets the publisher of the mqtt channels those have no bridge.
+func WithBrokerPublisher(pub BrokerPublisher) Option {
+	return func(s *Service) {
+		s.publisher = pub
+	}
+}
+
+func WithEvaluator(eval Evaluator) Option {
+	return func(s *Service) {
+		s.evaluator = eval
+	}
+}
+
+func (s *Service) Start() error {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	lst, err := s.models.LoadAllAlertRules()
+	if err != nil {
+		return err
+	}
+	for _, def := range lst {
+		if err := s.register(def); err != nil {
+			s.log.Errorf("fail to add alert %s, %s", def.Name, err.Error())
+		} else {
+			s.log.Infof("add alert %s interval=%s", def.Name, def.Interval)
+		}
+	}
+	s.crons.Start()
+	s.log.Info("started.")
+	return nil
+}
+
+func (s *Service) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	ctx := s.crons.Stop()
+	<-ctx.Done()
+	s.log.Info("closed.")
+}
+
+func (s *Service) context() context.Context {
+	if s.ctx == nil {
+		return context.Background()
+	}
+	return s.ctx
+}
+
+// register adds the rule, the rule is evaluated at every interval unless it is disabled.
+func (s *Service) register(def *model.AlertRuleDefinition) error {
+	r, err := NewRule(s, def)
+	if err != nil {
+		return err
+	}
+	key := strings.ToUpper(def.Name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[key]; ok {
+		return fmt.Errorf("alert %q already exists", def.Name)
+	}
+	if !def.Disabled {
+		r.entryId = s.crons.Schedule(cron.Every(r.interval), cron.FuncJob(r.run))
+	}
+	s.rules[key] = r
+	return nil
+}
+
+func (s *Service) unregister(name string) {
+	key := strings.ToUpper(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r, ok := s.rules[key]; ok {
+		s.crons.Remove(r.entryId)
+		delete(s.rules, key)
+	}
+}
+
+func (s *Service) getRule(name string) *Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules[strings.ToUpper(name)]
+}
@@ -0,0 +1,215 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/model"
+)
+
+// Alert is the rule and its current state.
+type Alert struct {
+	Name           string                `json:"name"`
+	Disabled       bool                  `json:"disabled,omitempty"`
+	Interval       string                `json:"interval"`
+	Sql            string                `json:"sql,omitempty"`
+	Tql            string                `json:"tql,omitempty"`
+	Operator       string                `json:"operator"`
+	Threshold      float64               `json:"threshold"`
+	Hysteresis     float64               `json:"hysteresis,omitempty"`
+	For            string                `json:"for,omitempty"`
+	RepeatInterval string                `json:"repeatInterval,omitempty"`
+	Message        string                `json:"message,omitempty"`
+	Channels       []*model.AlertChannel `json:"channels,omitempty"`
+	State          *model.AlertState     `json:"state,omitempty"`
+}
+
+func (s *Service) alertOf(def *model.AlertRuleDefinition) *Alert {
+	ret := &Alert{
+		Name:           def.Name,
+		Disabled:       def.Disabled,
+		Interval:       def.Interval,
+		Sql:            def.Sql,
+		Tql:            def.Tql,
+		Operator:       def.Operator,
+		Threshold:      def.Threshold,
+		Hysteresis:     def.Hysteresis,
+		For:            def.For,
+		RepeatInterval: def.RepeatInterval,
+		Message:        def.Message,
+	}
+	for _, ch := range def.Channels {
+		cp := *ch
+		if cp.Password != "" {
+			// do not expose the password of the smtp relay
+			cp.Password = "****"
+		}
+		ret.Channels = append(ret.Channels, &cp)
+	}
+	if r := s.getRule(def.Name); r != nil {
+		ret.State = r.State()
+	}
+	return ret
+}
+
+type ListAlertResponse struct {
+	Success bool     `json:"success,omitempty"`
+	Reason  string   `json:"reason,omitempty"`
+	Elapse  string   `json:"elapse,omitempty"`
+	Alerts  []*Alert `json:"alerts,omitempty"`
+}
+
+func (s *Service) ListAlert(context.Context) (*ListAlertResponse, error) {
+	tick := time.Now()
+	rsp := &ListAlertResponse{}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+	lst, err := s.models.LoadAllAlertRules()
+	if err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	for _, def := range lst {
+		rsp.Alerts = append(rsp.Alerts, s.alertOf(def))
+	}
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
+type GetAlertRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+type GetAlertResponse struct {
+	Success bool   `json:"success,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Elapse  string `json:"elapse,omitempty"`
+	Alert   *Alert `json:"alert,omitempty"`
+}
+
+func (s *Service) GetAlert(ctx context.Context, req *GetAlertRequest) (*GetAlertResponse, error) {
+	tick := time.Now()
+	rsp := &GetAlertResponse{}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+	def, err := s.models.LoadAlertRule(req.Name)
+	if err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	rsp.Alert = s.alertOf(def)
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
+type AddAlertRequest struct {
+	Rule *model.AlertRuleDefinition `json:"rule"`
+}
+
+type AddAlertResponse struct {
+	Success bool   `json:"success"`
+	Reason  string `json:"reason"`
+	Elapse  string `json:"elapse"`
+}
+
+func (s *Service) AddAlert(ctx context.Context, req *AddAlertRequest) (*AddAlertResponse, error) {
+	tick := time.Now()
+	rsp := &AddAlertResponse{Reason: "not specified"}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+	if req.Rule == nil {
+		rsp.Reason = "alert rule is not specified"
+		return rsp, nil
+	}
+	req.Rule.Name = strings.ToUpper(req.Rule.Name)
+	if len(req.Rule.Name) > 40 {
+		rsp.Reason = "name is too long, should be shorter than 40 characters"
+		return rsp, nil
+	}
+	if s.getRule(req.Rule.Name) != nil {
+		rsp.Reason = fmt.Sprintf("alert %q already exists", req.Rule.Name)
+		return rsp, nil
+	}
+	if err := req.Rule.Validate(); err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	if err := s.models.SaveAlertRule(req.Rule); err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	if err := s.register(req.Rule); err != nil {
+		s.models.RemoveAlertRule(req.Rule.Name)
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
+type DelAlertRequest struct {
+	Name string `json:"name"`
+}
+
+type DelAlertResponse struct {
+	Success bool   `json:"success"`
+	Reason  string `json:"reason"`
+	Elapse  string `json:"elapse"`
+}
+
+func (s *Service) DelAlert(ctx context.Context, req *DelAlertRequest) (*DelAlertResponse, error) {
+	tick := time.Now()
+	rsp := &DelAlertResponse{Reason: "not specified"}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+	s.unregister(req.Name)
+	if err := s.models.RemoveAlertRule(req.Name); err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
+type SilenceAlertRequest struct {
+	Name string `json:"name"`
+	// the notifications are suppressed for the duration, zero cancels the silence
+	Duration time.Duration `json:"duration"`
+}
+
+type SilenceAlertResponse struct {
+	Success bool              `json:"success"`
+	Reason  string            `json:"reason"`
+	Elapse  string            `json:"elapse"`
+	State   *model.AlertState `json:"state,omitempty"`
+}
+
+func (s *Service) SilenceAlert(ctx context.Context, req *SilenceAlertRequest) (*SilenceAlertResponse, error) {
+	tick := time.Now()
+	rsp := &SilenceAlertResponse{Reason: "not specified"}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+	r := s.getRule(req.Name)
+	if r == nil {
+		rsp.Reason = fmt.Sprintf("alert %q not found", req.Name)
+		return rsp, nil
+	}
+	var until time.Time
+	if req.Duration > 0 {
+		until = time.Now().Add(req.Duration)
+	}
+	if err := r.Silence(until); err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	rsp.State = r.State()
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
@@ -0,0 +1,187 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/bridge"
+	"github.com/machbase/neo-server/v8/mods/model"
+)
+
+// Notification is sent to the channels of the rule when the status changes.
+type Notification struct {
+	Rule      string            `json:"rule"`
+	Status    model.AlertStatus `json:"status"`
+	Value     float64           `json:"value"`
+	Operator  string            `json:"operator"`
+	Threshold float64           `json:"threshold"`
+	Message   string            `json:"message"`
+	Since     time.Time         `json:"since"`
+	Time      time.Time         `json:"time"`
+}
+
+func (n *Notification) Subject() string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(string(n.Status)), n.Rule)
+}
+
+const webhookTimeout = 10 * time.Second
+
+// smtpTimeout is the deadline of a smtp session from the dial to the quit.
+const smtpTimeout = 30 * time.Second
+
+// notify sends the notification to every channel,
+// the failure of a channel does not stop the others.
+func (s *Service) notify(channels []*model.AlertChannel, noti *Notification) {
+	for _, ch := range channels {
+		if err := s.send(ch, noti); err != nil {
+			s.log.Warnf("alert %s notify %s, %s", noti.Rule, ch.Type, err.Error())
+		}
+	}
+}
+
+func (s *Service) send(ch *model.AlertChannel, noti *Notification) error {
+	switch ch.Type {
+	case model.ALERT_CHANNEL_WEBHOOK:
+		return s.sendWebhook(ch, noti)
+	case model.ALERT_CHANNEL_MQTT:
+		return s.sendMqtt(ch, noti)
+	case model.ALERT_CHANNEL_SMTP:
+		return s.sendSmtp(ch, noti)
+	}
+	return fmt.Errorf("unsupported channel type %q", ch.Type)
+}
+
+func (s *Service) sendWebhook(ch *model.AlertChannel, noti *Notification) error {
+	body, err := json.Marshal(noti)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(s.context(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ch.Url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+	if rsp.StatusCode < 200 || rsp.StatusCode > 299 {
+		return fmt.Errorf("webhook responds %s", rsp.Status)
+	}
+	return nil
+}
+
+// sendMqtt publishes the notification to the bridge,
+// or to the built-in broker if the bridge is not specified.
+func (s *Service) sendMqtt(ch *model.AlertChannel, noti *Notification) error {
+	payload, err := json.Marshal(noti)
+	if err != nil {
+		return err
+	}
+	if ch.Bridge == "" {
+		if s.publisher == nil {
+			return errors.New("mqtt broker is not available")
+		}
+		return s.publisher(ch.Topic, payload)
+	}
+	br, err := bridge.GetBridge(ch.Bridge)
+	if err != nil {
+		return err
+	}
+	switch br := br.(type) {
+	case *bridge.MqttBridge:
+		_, err = br.Publish(ch.Topic, payload)
+	case *bridge.NatsBridge:
+		_, err = br.Publish(ch.Topic, payload)
+	default:
+		err = fmt.Errorf("bridge '%s' is not a mqtt or nats bridge", ch.Bridge)
+	}
+	return err
+}
+
+func (s *Service) sendSmtp(ch *model.AlertChannel, noti *Notification) error {
+	host := ch.Addr
+	if h, _, err := net.SplitHostPort(ch.Addr); err == nil {
+		host = h
+	}
+	var auth smtp.Auth
+	if ch.User != "" {
+		auth = smtp.PlainAuth("", ch.User, ch.Password, host)
+	}
+	msg := &bytes.Buffer{}
+	fmt.Fprintf(msg, "From: %s\r\n", ch.From)
+	fmt.Fprintf(msg, "To: %s\r\n", strings.Join(ch.To, ", "))
+	fmt.Fprintf(msg, "Subject: %s\r\n", noti.Subject())
+	fmt.Fprintf(msg, "Date: %s\r\n", noti.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(msg, "Content-Type: text/plain; charset=UTF-8\r\n")
+	fmt.Fprintf(msg, "\r\n%s\r\n", noti.Message)
+	return sendMail(ch.Addr, host, auth, ch.From, ch.To, msg.Bytes(), smtpTimeout)
+}
+
+// sendMail works as smtp.SendMail, but the whole session has the deadline,
+// so that a hung relay does not block the evaluations of the rule.
+func sendMail(addr string, host string, auth smtp.Auth, from string, to []string, msg []byte, timeout time.Duration) error {
+	for _, line := range append([]string{from}, to...) {
+		if strings.ContainsAny(line, "\r\n") {
+			return errors.New("smtp: A line must not contain CR or LF")
+		}
+	}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server doesn't support AUTH")
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
@@ -0,0 +1,336 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	logging "github.com/machbase/neo-server/v8/mods/logging"
+	"github.com/machbase/neo-server/v8/mods/model"
+	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/machbase/neo-server/v8/mods/util"
+	"github.com/robfig/cron/v3"
+)
+
+// Rule evaluates the condition of the alert rule and keeps its state.
+type Rule struct {
+	def      *model.AlertRuleDefinition
+	interval time.Duration
+	forDur   time.Duration
+	repeat   time.Duration
+	message  *template.Template
+	entryId  cron.EntryID
+	s        *Service
+	log      logging.Log
+
+	// evaluations of the rule are not overlapped
+	runLock sync.Mutex
+	// guards the state
+	mu    sync.Mutex
+	state *model.AlertState
+}
+
+func NewRule(s *Service, def *model.AlertRuleDefinition) (*Rule, error) {
+	if err := def.Validate(); err != nil {
+		return nil, err
+	}
+	ret := &Rule{
+		def: def,
+		s:   s,
+		log: logging.GetLog(fmt.Sprintf("alert-%s", strings.ToLower(def.Name))),
+	}
+	ret.interval, _ = util.ParseDuration(def.Interval)
+	if def.For != "" {
+		ret.forDur, _ = util.ParseDuration(def.For)
+	}
+	if def.RepeatInterval != "" {
+		ret.repeat, _ = util.ParseDuration(def.RepeatInterval)
+	}
+	if def.Message != "" {
+		tmpl, err := template.New(def.Name).Parse(def.Message)
+		if err != nil {
+			return nil, fmt.Errorf("alert %q invalid message, %s", def.Name, err.Error())
+		}
+		ret.message = tmpl
+	}
+	if s != nil && s.models != nil {
+		state, err := s.models.LoadAlertState(def.Name)
+		if err != nil {
+			return nil, err
+		}
+		ret.state = state
+	} else {
+		ret.state = &model.AlertState{Status: model.ALERT_INACTIVE}
+	}
+	return ret, nil
+}
+
+func (r *Rule) Name() string {
+	return r.def.Name
+}
+
+// State returns the copy of the current state.
+func (r *Rule) State() *model.AlertState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cp := *r.state
+	return &cp
+}
+
+// Silence suppresses the notifications until the time,
+// the zero time cancels the silence.
+func (r *Rule) Silence(until time.Time) error {
+	r.mu.Lock()
+	r.state.SilencedUntil = until
+	cp := *r.state
+	r.mu.Unlock()
+	return r.saveState(&cp)
+}
+
+func (r *Rule) saveState(state *model.AlertState) error {
+	if r.s == nil || r.s.models == nil {
+		return nil
+	}
+	return r.s.models.SaveAlertState(r.def.Name, state)
+}
+
+func (r *Rule) run() {
+	if !r.runLock.TryLock() {
+		r.log.Warn(r.def.Name, "skip, the previous evaluation is still in progress")
+		return
+	}
+	defer r.runLock.Unlock()
+
+	ctx, cancel := context.WithTimeout(r.s.context(), r.interval)
+	defer cancel()
+	value, err := r.s.evaluator(ctx, r)
+	if err == nil && math.IsNaN(value) {
+		err = errors.New("value is not a number")
+	}
+	if err != nil {
+		r.log.Warn(r.def.Name, "evaluation failed", err.Error())
+	}
+
+	r.mu.Lock()
+	noti := r.step(time.Now(), value, err)
+	cp := *r.state
+	r.mu.Unlock()
+
+	if err := r.saveState(&cp); err != nil {
+		r.log.Warn(r.def.Name, "fail to save the state", err.Error())
+	}
+	if noti != nil {
+		r.log.Info(r.def.Name, noti.Status, noti.Message)
+		r.s.notify(r.def.Channels, noti)
+	}
+}
+
+// step moves the state by the value that is evaluated at now,
+// it returns the notification to send or nil. The caller should hold the mu.
+//
+//	inactive, resolved -> pending (-> firing if For is zero)
+//	pending            -> firing after For, inactive if the condition is not met
+//	firing             -> resolved if the value goes back across the threshold over the hysteresis
+//
+// The firing notification is sent once per firing, and again at every RepeatInterval.
+// The resolved notification is sent only if its firing was notified.
+// No notification is sent while the rule is silenced.
+func (r *Rule) step(now time.Time, value float64, evalErr error) *Notification {
+	st := r.state
+	st.EvaluatedAt = now
+	if evalErr != nil {
+		// keep the status, the condition is unknown
+		st.Error = evalErr.Error()
+		return nil
+	}
+	st.Error = ""
+	st.Value = value
+
+	switch st.Status {
+	case model.ALERT_FIRING:
+		if r.resolved(value) {
+			notified := !st.NotifiedAt.Before(st.Since)
+			st.Status, st.Since = model.ALERT_RESOLVED, now
+			if notified && !r.silenced(now) {
+				st.NotifiedAt = now
+				return r.notification(now)
+			}
+			return nil
+		}
+	case model.ALERT_PENDING:
+		if !r.met(value) {
+			st.Status, st.Since = model.ALERT_INACTIVE, now
+			return nil
+		}
+		if now.Sub(st.Since) < r.forDur {
+			return nil
+		}
+		st.Status, st.Since = model.ALERT_FIRING, now
+	default:
+		if !r.met(value) {
+			if st.Status == "" {
+				st.Status, st.Since = model.ALERT_INACTIVE, now
+			}
+			return nil
+		}
+		if r.forDur > 0 {
+			st.Status, st.Since = model.ALERT_PENDING, now
+			return nil
+		}
+		st.Status, st.Since = model.ALERT_FIRING, now
+	}
+
+	// firing
+	if r.silenced(now) {
+		return nil
+	}
+	if st.NotifiedAt.Before(st.Since) || (r.repeat > 0 && now.Sub(st.NotifiedAt) >= r.repeat) {
+		st.NotifiedAt = now
+		return r.notification(now)
+	}
+	return nil
+}
+
+func (r *Rule) silenced(now time.Time) bool {
+	return now.Before(r.state.SilencedUntil)
+}
+
+// met returns true if the value meets the condition.
+func (r *Rule) met(value float64) bool {
+	return compare(r.def.Operator, value, r.def.Threshold)
+}
+
+// resolved returns true if the value goes back across the threshold
+// that is moved by the hysteresis, "!=" ignores the hysteresis.
+func (r *Rule) resolved(value float64) bool {
+	h := r.def.Hysteresis
+	switch r.def.Operator {
+	case ">", ">=":
+		return !compare(r.def.Operator, value, r.def.Threshold-h)
+	case "<", "<=":
+		return !compare(r.def.Operator, value, r.def.Threshold+h)
+	case "==":
+		return math.Abs(value-r.def.Threshold) > h
+	default:
+		return !r.met(value)
+	}
+}
+
+func compare(op string, value float64, threshold float64) bool {
+	switch op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	case "==":
+		return value == threshold
+	case "!=":
+		return value != threshold
+	}
+	return false
+}
+
+func (r *Rule) notification(now time.Time) *Notification {
+	ret := &Notification{
+		Rule:      r.def.Name,
+		Status:    r.state.Status,
+		Value:     r.state.Value,
+		Operator:  r.def.Operator,
+		Threshold: r.def.Threshold,
+		Since:     r.state.Since,
+		Time:      now,
+	}
+	if r.message != nil {
+		buf := &bytes.Buffer{}
+		if err := r.message.Execute(buf, ret); err != nil {
+			ret.Message = err.Error()
+		} else {
+			ret.Message = buf.String()
+		}
+	} else {
+		ret.Message = fmt.Sprintf("%s is %s, value %v %s %v", ret.Rule, ret.Status, ret.Value, ret.Operator, ret.Threshold)
+	}
+	return ret
+}
+
+// alertSqlTql runs the sql of the rule that is passed by the param,
+// so that the sql does not break the script.
+const alertSqlTql = "SQL(param('_alert_sql'))\nCSV()"
+
+// evaluate runs the sql or the tql of the rule,
+// the value is the first field of the first record of the output.
+func (s *Service) evaluate(ctx context.Context, r *Rule) (float64, error) {
+	output := &bytes.Buffer{}
+	task := tql.NewTaskContext(ctx)
+	task.SetParams(map[string][]string{
+		"_alert":     {r.def.Name},
+		"_alert_sql": {r.def.Sql},
+	})
+	task.SetInputReader(nil)
+	task.SetOutputWriter(output)
+	task.SetLogWriter(io.Discard)
+	if r.def.Sql != "" {
+		if err := task.CompileString(alertSqlTql); err != nil {
+			return 0, err
+		}
+	} else {
+		if s.tqlLoader == nil {
+			return 0, errors.New("tql loader is not available")
+		}
+		sc, err := s.tqlLoader.Load(r.def.Tql)
+		if err != nil {
+			return 0, err
+		}
+		if err := task.CompileScript(sc); err != nil {
+			return 0, err
+		}
+	}
+	result := task.Execute()
+	if result == nil {
+		return 0, errors.New("task result is empty")
+	}
+	if result.Err != nil {
+		return 0, result.Err
+	}
+	return parseAlertValue(output.Bytes())
+}
+
+// parseAlertValue returns the first field of the first record of the csv output.
+func parseAlertValue(output []byte) (float64, error) {
+	rd := csv.NewReader(bytes.NewReader(output))
+	rd.FieldsPerRecord = -1
+	rec, err := rd.Read()
+	if err == io.EOF {
+		return 0, errors.New("no record")
+	} else if err != nil {
+		return 0, err
+	}
+	if len(rec) == 0 {
+		return 0, errors.New("no value")
+	}
+	field := strings.TrimSpace(rec[0])
+	switch strings.ToLower(field) {
+	case "true":
+		return 1, nil
+	case "false":
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, fmt.Errorf("value %q is not a number", field)
+	}
+	return v, nil
+}
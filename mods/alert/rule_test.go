@@ -0,0 +1,239 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/model"
+	"github.com/stretchr/testify/require"
+)
+
+func newAlertProvider(t *testing.T) model.AlertProvider {
+	t.Helper()
+	models := model.NewService(model.WithConfigDirPath(t.TempDir()))
+	require.NoError(t, models.Start())
+	t.Cleanup(models.Stop)
+	return models.AlertProvider()
+}
+
+func TestRuleStep(t *testing.T) {
+	r, err := NewRule(nil, &model.AlertRuleDefinition{
+		Name:           "cpu",
+		Interval:       "10s",
+		Sql:            "select 1",
+		Operator:       ">",
+		Threshold:      80,
+		Hysteresis:     5,
+		For:            "20s",
+		RepeatInterval: "1m",
+		Message:        "{{.Rule}} {{.Status}} {{.Value}}",
+	})
+	require.NoError(t, err)
+
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+	tests := []struct {
+		sec    int
+		value  float64
+		err    error
+		status model.AlertStatus
+		noti   model.AlertStatus
+	}{
+		{0, 50, nil, model.ALERT_INACTIVE, ""},
+		{10, 90, nil, model.ALERT_PENDING, ""},
+		{20, 70, nil, model.ALERT_INACTIVE, ""},
+		{30, 90, nil, model.ALERT_PENDING, ""},
+		{40, 91, nil, model.ALERT_PENDING, ""},
+		{50, 92, nil, model.ALERT_FIRING, model.ALERT_FIRING},
+		// deduplicated
+		{60, 93, nil, model.ALERT_FIRING, ""},
+		// the error keeps the status
+		{70, 0, errors.New("timeout"), model.ALERT_FIRING, ""},
+		// within the hysteresis
+		{80, 78, nil, model.ALERT_FIRING, ""},
+		// repeat interval
+		{110, 90, nil, model.ALERT_FIRING, model.ALERT_FIRING},
+		{120, 75, nil, model.ALERT_RESOLVED, model.ALERT_RESOLVED},
+		{130, 76, nil, model.ALERT_RESOLVED, ""},
+	}
+	for _, tc := range tests {
+		noti := r.step(at(tc.sec), tc.value, tc.err)
+		require.Equal(t, tc.status, r.state.Status, "at %ds", tc.sec)
+		if tc.noti == "" {
+			require.Nil(t, noti, "at %ds", tc.sec)
+		} else {
+			require.NotNil(t, noti, "at %ds", tc.sec)
+			require.Equal(t, tc.noti, noti.Status, "at %ds", tc.sec)
+		}
+		if tc.err != nil {
+			require.Equal(t, tc.err.Error(), r.state.Error)
+		}
+	}
+	require.Equal(t, at(120), r.state.Since)
+
+	noti := r.notification(at(130))
+	require.Equal(t, "cpu resolved 76", noti.Message)
+	require.Equal(t, "[RESOLVED] cpu", noti.Subject())
+}
+
+func TestRuleSilence(t *testing.T) {
+	r, err := NewRule(nil, &model.AlertRuleDefinition{
+		Name:      "temp",
+		Interval:  "10s",
+		Sql:       "select 1",
+		Operator:  "<",
+		Threshold: 10,
+	})
+	require.NoError(t, err)
+
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.state.SilencedUntil = t0.Add(time.Minute)
+
+	// fires while silenced, no notification
+	require.Nil(t, r.step(t0, 5, nil))
+	require.Equal(t, model.ALERT_FIRING, r.state.Status)
+	// the firing is notified after the silence
+	noti := r.step(t0.Add(2*time.Minute), 5, nil)
+	require.NotNil(t, noti)
+	require.Equal(t, "temp is firing, value 5 < 10", noti.Message)
+	require.Nil(t, r.step(t0.Add(3*time.Minute), 5, nil))
+	require.NotNil(t, r.step(t0.Add(4*time.Minute), 15, nil))
+
+	// the resolved is not notified if its firing was not notified
+	r.state.SilencedUntil = t0.Add(10 * time.Minute)
+	require.Nil(t, r.step(t0.Add(5*time.Minute), 5, nil))
+	r.state.SilencedUntil = time.Time{}
+	require.Nil(t, r.step(t0.Add(6*time.Minute), 15, nil))
+	require.Equal(t, model.ALERT_RESOLVED, r.state.Status)
+}
+
+func TestParseAlertValue(t *testing.T) {
+	tests := []struct {
+		output string
+		value  float64
+		err    string
+	}{
+		{"12.5,2026-01-01\n3,4\n", 12.5, ""},
+		{"true\n", 1, ""},
+		{"", 0, "no record"},
+		{"abc\n", 0, `value "abc" is not a number`},
+	}
+	for _, tc := range tests {
+		v, err := parseAlertValue([]byte(tc.output))
+		if tc.err != "" {
+			require.EqualError(t, err, tc.err)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, tc.value, v)
+		}
+	}
+}
+
+func TestServiceNotify(t *testing.T) {
+	var mu sync.Mutex
+	received := []*Notification{}
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		noti := &Notification{}
+		if err := json.NewDecoder(r.Body).Decode(noti); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, noti)
+		mu.Unlock()
+	}))
+	defer svr.Close()
+	receivedLen := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}
+
+	published := map[string][]byte{}
+	value := 100.0
+	svc := NewService(
+		WithProvider(newAlertProvider(t)),
+		WithBrokerPublisher(func(topic string, payload []byte) error {
+			published[topic] = payload
+			return nil
+		}),
+		WithEvaluator(func(ctx context.Context, r *Rule) (float64, error) {
+			return value, nil
+		}),
+	)
+	rsp, err := svc.AddAlert(context.TODO(), &AddAlertRequest{Rule: &model.AlertRuleDefinition{
+		Name:      "disk",
+		Disabled:  true,
+		Interval:  "1m",
+		Sql:       "select 1",
+		Operator:  ">=",
+		Threshold: 90,
+		Channels: []*model.AlertChannel{
+			{Type: "webhook", Url: svr.URL},
+			{Type: "mqtt", Topic: "alerts/disk"},
+		},
+	}})
+	require.NoError(t, err)
+	require.True(t, rsp.Success, rsp.Reason)
+
+	r := svc.getRule("disk")
+	require.NotNil(t, r)
+	r.run()
+	require.Equal(t, 1, receivedLen())
+	require.Equal(t, "DISK", received[0].Rule)
+	require.Equal(t, model.ALERT_FIRING, received[0].Status)
+	require.Contains(t, string(published["alerts/disk"]), `"status":"firing"`)
+
+	// silenced, the state is persisted
+	silence, err := svc.SilenceAlert(context.TODO(), &SilenceAlertRequest{Name: "disk", Duration: time.Hour})
+	require.NoError(t, err)
+	require.True(t, silence.Success, silence.Reason)
+	value = 10
+	r.run()
+	require.Equal(t, 1, receivedLen())
+
+	get, err := svc.GetAlert(context.TODO(), &GetAlertRequest{Name: "disk"})
+	require.NoError(t, err)
+	require.True(t, get.Success, get.Reason)
+	require.Equal(t, model.ALERT_RESOLVED, get.Alert.State.Status)
+	state, err := svc.models.LoadAlertState("disk")
+	require.NoError(t, err)
+	require.Equal(t, model.ALERT_RESOLVED, state.Status)
+	require.True(t, state.SilencedUntil.After(time.Now()))
+
+	del, err := svc.DelAlert(context.TODO(), &DelAlertRequest{Name: "disk"})
+	require.NoError(t, err)
+	require.True(t, del.Success, del.Reason)
+	require.Nil(t, svc.getRule("disk"))
+}
+
+func TestSendMailTimeout(t *testing.T) {
+	// the relay accepts the connection but never greets
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	tick := time.Now()
+	err = sendMail(ln.Addr().String(), "127.0.0.1", nil, "neo@example.com", []string{"ops@example.com"}, []byte("hello"), 200*time.Millisecond)
+	require.Error(t, err)
+	require.Less(t, time.Since(tick), 5*time.Second)
+
+	err = sendMail(ln.Addr().String(), "127.0.0.1", nil, "neo@example.com\r\nRCPT TO:<x>", nil, []byte("hello"), time.Second)
+	require.EqualError(t, err, "smtp: A line must not contain CR or LF")
+}
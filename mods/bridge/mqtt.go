@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"fmt"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +30,11 @@ type MqttBridge struct {
 	connectListeners    []func(any)
 	disconnectListeners []func(any)
 
+	// paho keeps one handler per topic, the subscriptions of the same topic
+	// share the handler that dispatches the messages to all of them.
+	subsMu sync.RWMutex
+	subs   map[string]*mqttTopic
+
 	serverAddresses    []string
 	keepAlive          time.Duration
 	cleanSession       bool
@@ -308,12 +314,25 @@ func (c *MqttBridge) OnDisconnect(cb func(br any)) {
 	}
 }
 
+type mqttTopic struct {
+	// serialises the subscribe and unsubscribe calls of the topic to the broker
+	mu     sync.Mutex
+	refs   int // number of callers waiting for or holding mu, guarded by subsMu
+	client paho.Client
+	qos    byte // the QoS subscribed to the broker, the maximum of the subscriptions
+	subs   []*MqttSubscription
+}
+
 type MqttSubscription struct {
 	bridge     *MqttBridge
 	topic      string
+	qos        byte
+	cb         func(topic string, payload []byte, msgId int, dup bool, retained bool)
 	writeStats *WriteStats
 }
 
+// Unsubscribe removes the subscription, the topic is unsubscribed
+// from the broker when it is the last subscription of the topic.
 func (ns *MqttSubscription) Unsubscribe() error {
 	if ns.bridge == nil {
 		return fmt.Errorf("mqtt connection is unavailable")
@@ -322,8 +341,23 @@ func (ns *MqttSubscription) Unsubscribe() error {
 	if client == nil || !client.IsConnected() {
 		return fmt.Errorf("mqtt connection is unavailable")
 	}
+	c := ns.bridge
+	ent := c.lockTopic(ns.topic)
+	defer c.unlockTopic(ns.topic, ent)
+
+	c.subsMu.Lock()
+	idx := slices.Index(ent.subs, ns)
+	if idx >= 0 {
+		ent.subs = slices.Delete(ent.subs, idx, idx+1)
+	}
+	last := idx >= 0 && len(ent.subs) == 0 && ent.client == client
+	c.subsMu.Unlock()
+	if !last {
+		return nil
+	}
+	ent.qos = 0
 	token := client.Unsubscribe(ns.topic)
-	success := token.WaitTimeout(ns.bridge.unsubscribeTimeout)
+	success := token.WaitTimeout(c.unsubscribeTimeout)
 	if !success {
 		return fmt.Errorf("mqtt unsubscribe timeout")
 	}
@@ -338,28 +372,95 @@ func (ns *MqttSubscription) AddInserted(delta uint64) {
 	atomic.AddUint64(&ns.writeStats.Inserted, delta)
 }
 
+// Subscribe subscribes the topic, the subscriptions of the same topic share the broker subscription
+// that is subscribed at the highest QoS of them.
 func (c *MqttBridge) Subscribe(topic string, qos byte, cb func(topic string, payload []byte, msgId int, dup bool, retained bool)) (*MqttSubscription, error) {
 	client := c.getClient()
 	if client == nil || !client.IsConnected() {
 		return nil, fmt.Errorf("mqtt connection is unavailable")
 	}
-	token := client.Subscribe(topic, qos, func(_ paho.Client, msg paho.Message) {
-		atomic.AddUint64(&c.inMsgs, 1)
-		atomic.AddUint64(&c.inBytes, uint64(len(msg.Payload())))
-		cb(msg.Topic(), msg.Payload(), int(msg.MessageID()), msg.Duplicate(), msg.Retained())
-	})
-	success := token.WaitTimeout(c.subscribeTimeout)
-	if !success {
-		return nil, fmt.Errorf("mqtt subscribe timeout")
-	}
 	ret := &MqttSubscription{
 		bridge:     c,
 		topic:      topic,
+		qos:        qos,
+		cb:         cb,
 		writeStats: &c.WriteStats,
 	}
+	ent := c.lockTopic(topic)
+	defer c.unlockTopic(topic, ent)
+
+	c.subsMu.Lock()
+	if ent.client != client {
+		// the subscriptions of the previous client are not valid anymore
+		ent.client, ent.qos, ent.subs = client, 0, nil
+	}
+	subscribed := len(ent.subs) > 0
+	c.subsMu.Unlock()
+
+	if !subscribed || qos > ent.qos {
+		// subscribing the topic again replaces the broker subscription with the higher QoS
+		token := client.Subscribe(topic, max(qos, ent.qos), func(_ paho.Client, msg paho.Message) {
+			atomic.AddUint64(&c.inMsgs, 1)
+			atomic.AddUint64(&c.inBytes, uint64(len(msg.Payload())))
+			c.dispatch(topic, msg)
+		})
+		success := token.WaitTimeout(c.subscribeTimeout)
+		if !success {
+			return nil, fmt.Errorf("mqtt subscribe timeout")
+		}
+		ent.qos = max(qos, ent.qos)
+	}
+
+	c.subsMu.Lock()
+	ent.subs = append(ent.subs, ret)
+	c.subsMu.Unlock()
 	return ret, nil
 }
 
+// lockTopic returns the entry of the topic with its mu locked,
+// the caller should release it with unlockTopic.
+func (c *MqttBridge) lockTopic(topic string) *mqttTopic {
+	c.subsMu.Lock()
+	if c.subs == nil {
+		c.subs = map[string]*mqttTopic{}
+	}
+	ent, ok := c.subs[topic]
+	if !ok {
+		ent = &mqttTopic{}
+		c.subs[topic] = ent
+	}
+	ent.refs++
+	c.subsMu.Unlock()
+
+	ent.mu.Lock()
+	return ent
+}
+
+// unlockTopic releases the entry of the topic,
+// the entry is removed when it has no subscriptions and no waiting callers.
+func (c *MqttBridge) unlockTopic(topic string, ent *mqttTopic) {
+	ent.mu.Unlock()
+
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	ent.refs--
+	if ent.refs == 0 && len(ent.subs) == 0 {
+		delete(c.subs, topic)
+	}
+}
+
+func (c *MqttBridge) dispatch(topic string, msg paho.Message) {
+	c.subsMu.RLock()
+	var subs []*MqttSubscription
+	if ent, ok := c.subs[topic]; ok {
+		subs = slices.Clone(ent.subs)
+	}
+	c.subsMu.RUnlock()
+	for _, sub := range subs {
+		sub.cb(msg.Topic(), msg.Payload(), int(msg.MessageID()), msg.Duplicate(), msg.Retained())
+	}
+}
+
 func (c *MqttBridge) Publish(topic string, payload any) (bool, error) {
 	client := c.getClient()
 	if client == nil || !client.IsConnected() {
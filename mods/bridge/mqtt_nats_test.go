@@ -33,6 +33,19 @@ func (t mqttTokenStub) Error() error {
 	return t.err
 }
 
+// mqttWaitTokenStub blocks in WaitTimeout until the release is closed
+type mqttWaitTokenStub struct {
+	mqttTokenStub
+	waiting chan struct{}
+	release chan struct{}
+}
+
+func (t mqttWaitTokenStub) WaitTimeout(time.Duration) bool {
+	close(t.waiting)
+	<-t.release
+	return t.ok
+}
+
 type mqttClientStub struct {
 	connected         bool
 	publishToken      paho.Token
@@ -41,7 +54,11 @@ type mqttClientStub struct {
 	publishedTopic    string
 	publishedPayload  any
 	subscribedTopic   string
+	subscribedQoS     byte
 	unsubscribedTopic string
+	subscribeCount    int
+	unsubscribeCount  int
+	handlers          map[string]paho.MessageHandler
 }
 
 func (c *mqttClientStub) IsConnected() bool {
@@ -69,8 +86,14 @@ func (c *mqttClientStub) Publish(topic string, _ byte, _ bool, payload any) paho
 	return mqttTokenStub{ok: true}
 }
 
-func (c *mqttClientStub) Subscribe(topic string, _ byte, _ paho.MessageHandler) paho.Token {
+func (c *mqttClientStub) Subscribe(topic string, qos byte, handler paho.MessageHandler) paho.Token {
 	c.subscribedTopic = topic
+	c.subscribedQoS = qos
+	c.subscribeCount++
+	if c.handlers == nil {
+		c.handlers = map[string]paho.MessageHandler{}
+	}
+	c.handlers[topic] = handler
 	if c.subscribeToken != nil {
 		return c.subscribeToken
 	}
@@ -85,6 +108,7 @@ func (c *mqttClientStub) Unsubscribe(topics ...string) paho.Token {
 	if len(topics) > 0 {
 		c.unsubscribedTopic = topics[0]
 	}
+	c.unsubscribeCount++
 	if c.unsubscribeToken != nil {
 		return c.unsubscribeToken
 	}
@@ -93,6 +117,26 @@ func (c *mqttClientStub) Unsubscribe(topics ...string) paho.Token {
 
 func (c *mqttClientStub) AddRoute(string, paho.MessageHandler) {}
 
+// publish delivers the message to the handler of the topic as the broker does
+func (c *mqttClientStub) publish(topic string, payload []byte) {
+	if handler, ok := c.handlers[topic]; ok {
+		handler(c, &mqttMessageStub{topic: topic, payload: payload})
+	}
+}
+
+type mqttMessageStub struct {
+	topic   string
+	payload []byte
+}
+
+func (m *mqttMessageStub) Duplicate() bool   { return false }
+func (m *mqttMessageStub) Qos() byte         { return 0 }
+func (m *mqttMessageStub) Retained() bool    { return false }
+func (m *mqttMessageStub) Topic() string     { return m.topic }
+func (m *mqttMessageStub) MessageID() uint16 { return 0 }
+func (m *mqttMessageStub) Payload() []byte   { return m.payload }
+func (m *mqttMessageStub) Ack()              {}
+
 func (c *mqttClientStub) OptionsReader() paho.ClientOptionsReader {
 	return paho.NewOptionsReader(paho.NewClientOptions())
 }
@@ -152,8 +196,16 @@ func TestMqttBridgePublishSubscribeAndStats(t *testing.T) {
 	require.NoError(t, sub.Unsubscribe())
 	require.Equal(t, "topic/a", client.unsubscribedTopic)
 
+	// unsubscribing twice does not touch the broker again
+	client.unsubscribedTopic = ""
+	require.NoError(t, sub.Unsubscribe())
+	require.Empty(t, client.unsubscribedTopic)
+
+	sub, err = br.Subscribe("topic/a", 1, func(string, []byte, int, bool, bool) {})
+	require.NoError(t, err)
 	client.unsubscribeToken = mqttTokenStub{ok: false}
 	require.EqualError(t, sub.Unsubscribe(), "mqtt unsubscribe timeout")
+	client.unsubscribeToken = nil
 
 	client.subscribeToken = mqttTokenStub{ok: false}
 	_, err = br.Subscribe("topic/b", 1, func(string, []byte, int, bool, bool) {})
@@ -165,6 +217,121 @@ func TestMqttBridgePublishSubscribeAndStats(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestMqttBridgeSharedTopic(t *testing.T) {
+	br := NewMqttBridge("mqtt_test", "")
+	client := &mqttClientStub{connected: true}
+	br.setClient(client)
+	br.alive.Store(true)
+
+	// a subscriber schedule and a SUBSCRIBE() of tql on the same topic
+	var schedRecv, tqlRecv []string
+	schedSub, err := br.Subscribe("topic/a", 1, func(_ string, payload []byte, _ int, _ bool, _ bool) {
+		schedRecv = append(schedRecv, string(payload))
+	})
+	require.NoError(t, err)
+	tqlSub, err := br.Subscribe("topic/a", 0, func(_ string, payload []byte, _ int, _ bool, _ bool) {
+		tqlRecv = append(tqlRecv, string(payload))
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, client.subscribeCount)
+
+	client.publish("topic/a", []byte("1"))
+	require.Equal(t, []string{"1"}, schedRecv)
+	require.Equal(t, []string{"1"}, tqlRecv)
+	require.Equal(t, uint64(1), br.Stats().InMsgs)
+
+	// the end of the tql does not unsubscribe the topic of the schedule
+	require.NoError(t, tqlSub.Unsubscribe())
+	require.Equal(t, 0, client.unsubscribeCount)
+	client.publish("topic/a", []byte("2"))
+	require.Equal(t, []string{"1", "2"}, schedRecv)
+	require.Equal(t, []string{"1"}, tqlRecv)
+
+	require.NoError(t, schedSub.Unsubscribe())
+	require.Equal(t, 1, client.unsubscribeCount)
+	require.Equal(t, "topic/a", client.unsubscribedTopic)
+
+	// the subscriptions of the previous connection are not valid anymore
+	schedSub, err = br.Subscribe("topic/a", 1, func(string, []byte, int, bool, bool) {})
+	require.NoError(t, err)
+	reconnected := &mqttClientStub{connected: true}
+	br.setClient(reconnected)
+	_, err = br.Subscribe("topic/a", 1, func(string, []byte, int, bool, bool) {})
+	require.NoError(t, err)
+	require.Equal(t, 1, reconnected.subscribeCount)
+	require.NoError(t, schedSub.Unsubscribe())
+	require.Equal(t, 0, reconnected.unsubscribeCount)
+}
+
+func TestMqttBridgeSharedTopicQoS(t *testing.T) {
+	br := NewMqttBridge("mqtt_test", "")
+	client := &mqttClientStub{connected: true}
+	br.setClient(client)
+	br.alive.Store(true)
+	nop := func(string, []byte, int, bool, bool) {}
+
+	// a SUBSCRIBE() at QoS 0 does not downgrade the schedule at QoS 1
+	schedSub, err := br.Subscribe("topic/a", 1, nop)
+	require.NoError(t, err)
+	_, err = br.Subscribe("topic/a", 0, nop)
+	require.NoError(t, err)
+	require.Equal(t, 1, client.subscribeCount)
+	require.Equal(t, byte(1), client.subscribedQoS)
+
+	// a higher QoS subscribes the topic again
+	_, err = br.Subscribe("topic/a", 2, nop)
+	require.NoError(t, err)
+	require.Equal(t, 2, client.subscribeCount)
+	require.Equal(t, byte(2), client.subscribedQoS)
+
+	// the failed subscribe does not keep the topic
+	client.subscribeToken = mqttTokenStub{ok: false}
+	_, err = br.Subscribe("topic/b", 1, nop)
+	require.EqualError(t, err, "mqtt subscribe timeout")
+	require.NotContains(t, br.subs, "topic/b")
+	client.subscribeToken = nil
+	require.NoError(t, schedSub.Unsubscribe())
+	require.Equal(t, 0, client.unsubscribeCount)
+}
+
+func TestMqttBridgeSubscribeWaitsUnsubscribe(t *testing.T) {
+	br := NewMqttBridge("mqtt_test", "")
+	client := &mqttClientStub{connected: true}
+	br.setClient(client)
+	br.alive.Store(true)
+	nop := func(string, []byte, int, bool, bool) {}
+
+	sub, err := br.Subscribe("topic/a", 1, nop)
+	require.NoError(t, err)
+
+	token := mqttWaitTokenStub{
+		mqttTokenStub: mqttTokenStub{ok: true},
+		waiting:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	client.unsubscribeToken = token
+	unsubscribed := make(chan error)
+	go func() { unsubscribed <- sub.Unsubscribe() }()
+	<-token.waiting
+
+	// the subscribe of the topic waits until the broker has unsubscribed it
+	subscribed := make(chan error)
+	go func() {
+		_, err := br.Subscribe("topic/a", 1, nop)
+		subscribed <- err
+	}()
+	select {
+	case <-subscribed:
+		t.Fatal("subscribe should wait for the unsubscribe of the topic")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(token.release)
+	require.NoError(t, <-unsubscribed)
+	require.NoError(t, <-subscribed)
+	require.Equal(t, 1, client.unsubscribeCount)
+	require.Equal(t, 2, client.subscribeCount)
+}
+
 func TestMqttBridgeUnavailablePaths(t *testing.T) {
 	br := NewMqttBridge("mqtt_test", "")
 	_, err := br.Subscribe("topic", 1, func(string, []byte, int, bool, bool) {})
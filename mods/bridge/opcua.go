@@ -0,0 +1,550 @@
+package bridge
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+	"github.com/machbase/neo-server/v8/mods/logging"
+)
+
+type OpcuaBridge struct {
+	log  logging.Log
+	name string
+	path string
+
+	clientMu sync.RWMutex
+	client   *opcua.Client
+	alive    atomic.Bool
+	stopSig  chan bool
+
+	listenerMu          sync.Mutex
+	connectListeners    []func(any)
+	disconnectListeners []func(any)
+
+	endpoint         string
+	securityPolicy   string
+	securityMode     ua.MessageSecurityMode
+	certPath         string
+	keyPath          string
+	username         string
+	password         string
+	reconnectMaxWait time.Duration
+	requestTimeout   time.Duration
+
+	inMsgs uint64
+	WriteStats
+}
+
+func NewOpcuaBridge(name string, path string) *OpcuaBridge {
+	return &OpcuaBridge{
+		log:     logging.GetLog("opcua-bridge"),
+		name:    name,
+		path:    path,
+		stopSig: make(chan bool),
+
+		securityPolicy:   "None",
+		securityMode:     ua.MessageSecurityModeInvalid,
+		reconnectMaxWait: 10 * time.Second,
+		requestTimeout:   10 * time.Second,
+	}
+}
+
+var opcuaSecurityPolicies = []string{
+	"None", "Basic128Rsa15", "Basic256", "Basic256Sha256", "Aes128_Sha256_RsaOaep", "Aes256_Sha256_RsaPss",
+}
+
+// BeforeRegister parses the options of the path,
+//
+//	endpoint=opc.tcp://host:4840 policy=Basic256Sha256 mode=SignAndEncrypt cert=/path/cert.pem key=/path/key.pem
+//	username=user password=pass reconnect=10s timeout=10s
+func (c *OpcuaBridge) BeforeRegister() error {
+	fields := strings.Fields(c.path)
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(kv[0])
+		val := strings.TrimSpace(kv[1])
+		switch key {
+		case "endpoint", "server", "host":
+			c.endpoint = val
+		case "policy":
+			c.securityPolicy = ""
+			for _, p := range opcuaSecurityPolicies {
+				if strings.EqualFold(p, val) {
+					c.securityPolicy = p
+				}
+			}
+			if c.securityPolicy == "" {
+				return fmt.Errorf("bridge '%s' invalid security policy %q", c.name, val)
+			}
+		case "mode":
+			switch strings.ToLower(val) {
+			case "none":
+				c.securityMode = ua.MessageSecurityModeNone
+			case "sign":
+				c.securityMode = ua.MessageSecurityModeSign
+			case "signandencrypt":
+				c.securityMode = ua.MessageSecurityModeSignAndEncrypt
+			default:
+				return fmt.Errorf("bridge '%s' invalid security mode %q", c.name, val)
+			}
+		case "cert":
+			c.certPath = val
+		case "key":
+			c.keyPath = val
+		case "username":
+			c.username = val
+		case "password":
+			c.password = val
+		case "reconnect":
+			if d, err := time.ParseDuration(val); err == nil && d >= time.Second {
+				c.reconnectMaxWait = d
+			}
+		case "timeout":
+			if d, err := time.ParseDuration(val); err == nil && d > 0 {
+				c.requestTimeout = d
+			}
+		default:
+			c.log.Infof("unknown option, %s=%s", key, val)
+		}
+	}
+	if c.securityMode == ua.MessageSecurityModeInvalid {
+		if c.securityPolicy == "None" {
+			c.securityMode = ua.MessageSecurityModeNone
+		} else {
+			c.securityMode = ua.MessageSecurityModeSignAndEncrypt
+		}
+	}
+	if (c.securityPolicy == "None") != (c.securityMode == ua.MessageSecurityModeNone) {
+		return fmt.Errorf("bridge '%s' security policy %s does not match mode %s", c.name, c.securityPolicy, c.securityMode)
+	}
+	if c.securityPolicy != "None" && (c.certPath == "" || c.keyPath == "") {
+		return fmt.Errorf("bridge '%s' security policy %s requires cert and key", c.name, c.securityPolicy)
+	}
+	if c.endpoint == "" {
+		c.log.Warnf("bridge '%s' no endpoint", c.name)
+		return nil
+	}
+	go c.run()
+	return nil
+}
+
+func (c *OpcuaBridge) AfterUnregister() error {
+	if c.alive.Load() {
+		c.stopSig <- true
+	}
+	return nil
+}
+
+func (c *OpcuaBridge) String() string {
+	return fmt.Sprintf("bridge '%s' (opcua)", c.name)
+}
+
+func (c *OpcuaBridge) Name() string {
+	return c.name
+}
+
+func (c *OpcuaBridge) StatsSnapshot() BridgeTrafficStats {
+	return BridgeTrafficStats{
+		InMsgs:   atomic.LoadUint64(&c.inMsgs),
+		Appended: atomic.LoadUint64(&c.Appended),
+		Inserted: atomic.LoadUint64(&c.Inserted),
+	}
+}
+
+func (c *OpcuaBridge) IsConnected() bool {
+	client := c.getClient()
+	return c.alive.Load() && client != nil && client.State() == opcua.Connected
+}
+
+func (c *OpcuaBridge) TestConnection() (bool, string) {
+	if !c.IsConnected() {
+		return false, "not connected"
+	}
+	return true, "success"
+}
+
+func (c *OpcuaBridge) getClient() *opcua.Client {
+	c.clientMu.RLock()
+	defer c.clientMu.RUnlock()
+	return c.client
+}
+
+func (c *OpcuaBridge) setClient(client *opcua.Client) {
+	c.clientMu.Lock()
+	c.client = client
+	c.clientMu.Unlock()
+}
+
+// run keeps the connection, the client reconnects by itself and recovers the subscriptions
+// if the connection is lost. If the client is closed, e.g. the server was down at the start,
+// it connects again with a new client, and the connect listeners subscribe again.
+func (c *OpcuaBridge) run() {
+	var fallbackWait = 1 * time.Second
+	ticker := time.NewTicker(1 * time.Millisecond)
+	c.alive.Store(true)
+	for c.alive.Load() {
+		select {
+		case <-ticker.C:
+			client := c.getClient()
+			if client != nil && client.State() != opcua.Closed {
+				continue
+			}
+			c.log.Tracef("bridge [%s] connecting... %s", c.name, c.endpoint)
+			if client, err := c.connect(); err == nil {
+				c.setClient(client)
+				c.log.Tracef("bridge [%s] connected.", c.name)
+				go c.notifyConnectListeners()
+				ticker.Reset(time.Second)
+				fallbackWait = 1 * time.Second
+			} else {
+				c.log.Tracef("bridge [%s] connect failed, %s, fallback wait %s.", c.name, err.Error(), fallbackWait)
+				go c.notifyDisconnectListeners()
+				ticker.Reset(fallbackWait)
+				fallbackWait *= 2
+				if fallbackWait > c.reconnectMaxWait {
+					fallbackWait = c.reconnectMaxWait
+				}
+			}
+		case <-c.stopSig:
+			c.alive.Store(false)
+		}
+	}
+	ticker.Stop()
+	if client := c.getClient(); client != nil {
+		client.Close(context.Background())
+		c.setClient(nil)
+	}
+}
+
+func (c *OpcuaBridge) connect() (*opcua.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	opts := []opcua.Option{
+		opcua.AutoReconnect(true),
+		opcua.ReconnectInterval(c.reconnectMaxWait),
+		opcua.RequestTimeout(c.requestTimeout),
+	}
+	authMode := ua.UserTokenTypeAnonymous
+	if c.username != "" {
+		authMode = ua.UserTokenTypeUserName
+		opts = append(opts, opcua.AuthUsername(c.username, c.password))
+	}
+	if c.certPath != "" && c.keyPath != "" {
+		pair, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+		if err != nil {
+			return nil, err
+		}
+		rsaKey, ok := pair.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T, RSA private key required", pair.PrivateKey)
+		}
+		opts = append(opts, opcua.Certificate(pair.Certificate[0]), opcua.PrivateKey(rsaKey))
+	}
+
+	endpoints, err := opcua.GetEndpoints(ctx, c.endpoint)
+	if err != nil {
+		return nil, err
+	}
+	ep, err := selectOpcuaEndpoint(endpoints, ua.SecurityPolicyURIPrefix+c.securityPolicy, c.securityMode, authMode)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, opcua.SecurityFromEndpoint(ep, authMode))
+
+	client, err := opcua.NewClient(c.endpoint, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Connect(ctx); err != nil {
+		client.Close(context.Background())
+		return nil, err
+	}
+	return client, nil
+}
+
+// selectOpcuaEndpoint returns the endpoint of the highest security level
+// that matches the security policy, the mode and the auth mode.
+func selectOpcuaEndpoint(endpoints []*ua.EndpointDescription, policyURI string, mode ua.MessageSecurityMode, authMode ua.UserTokenType) (*ua.EndpointDescription, error) {
+	var ret *ua.EndpointDescription
+	for _, e := range endpoints {
+		if e.SecurityPolicyURI != policyURI || e.SecurityMode != mode {
+			continue
+		}
+		authMatched := false
+		for _, tok := range e.UserIdentityTokens {
+			if tok.TokenType == authMode {
+				authMatched = true
+				break
+			}
+		}
+		if !authMatched {
+			continue
+		}
+		if ret == nil || e.SecurityLevel > ret.SecurityLevel {
+			ret = e
+		}
+	}
+	if ret == nil {
+		return nil, fmt.Errorf("no matching endpoint for policy=%s mode=%s auth=%s",
+			strings.TrimPrefix(policyURI, ua.SecurityPolicyURIPrefix), mode, authMode)
+	}
+	return ret, nil
+}
+
+func (c *OpcuaBridge) notifyConnectListeners() {
+	c.listenerMu.Lock()
+	listeners := append([]func(any){}, c.connectListeners...)
+	c.listenerMu.Unlock()
+	for _, cb := range listeners {
+		cb(c)
+	}
+}
+
+func (c *OpcuaBridge) notifyDisconnectListeners() {
+	c.listenerMu.Lock()
+	listeners := append([]func(any){}, c.disconnectListeners...)
+	c.listenerMu.Unlock()
+	for _, cb := range listeners {
+		cb(c)
+	}
+}
+
+func (c *OpcuaBridge) OnConnect(cb func(br any)) {
+	if cb == nil {
+		return
+	}
+	c.listenerMu.Lock()
+	c.connectListeners = append(c.connectListeners, cb)
+	c.listenerMu.Unlock()
+	if c.IsConnected() {
+		cb(c)
+	}
+}
+
+func (c *OpcuaBridge) OnDisconnect(cb func(br any)) {
+	if cb == nil {
+		return
+	}
+	c.listenerMu.Lock()
+	c.disconnectListeners = append(c.disconnectListeners, cb)
+	c.listenerMu.Unlock()
+	if !c.IsConnected() {
+		cb(c)
+	}
+}
+
+// OpcuaDataChange is a value of the monitored node.
+type OpcuaDataChange struct {
+	NodeId string
+	Time   time.Time
+	Value  any
+	Status ua.StatusCode
+}
+
+// Float64 returns the value as float64 if it is a number or a boolean.
+func (dc *OpcuaDataChange) Float64() (float64, bool) {
+	switch v := dc.Value.(type) {
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case int8:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, !math.IsNaN(v)
+	}
+	return 0, false
+}
+
+type OpcuaSubscription struct {
+	sub        *opcua.Subscription
+	cancel     context.CancelFunc
+	done       chan struct{}
+	writeStats *WriteStats
+}
+
+func (ns *OpcuaSubscription) Unsubscribe() error {
+	ns.cancel()
+	<-ns.done
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return ns.sub.Cancel(ctx)
+}
+
+func (ns *OpcuaSubscription) AddAppended(delta uint64) {
+	atomic.AddUint64(&ns.writeStats.Appended, delta)
+}
+
+func (ns *OpcuaSubscription) AddInserted(delta uint64) {
+	atomic.AddUint64(&ns.writeStats.Inserted, delta)
+}
+
+// Subscribe monitors the values of the nodes, the callback is called with the changes
+// of every publish, those are sampled at the interval.
+func (c *OpcuaBridge) Subscribe(nodes []string, interval time.Duration, cb func(changes []*OpcuaDataChange)) (*OpcuaSubscription, error) {
+	client := c.getClient()
+	if client == nil || client.State() != opcua.Connected {
+		return nil, errors.New("opcua connection is unavailable")
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no nodes to monitor")
+	}
+	items := make([]*ua.MonitoredItemCreateRequest, len(nodes))
+	for i, n := range nodes {
+		nodeId, err := ua.ParseNodeID(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node id %q, %s", n, err.Error())
+		}
+		items[i] = opcua.NewMonitoredItemCreateRequestWithDefaults(nodeId, ua.AttributeIDValue, uint32(i))
+		items[i].RequestedParameters.SamplingInterval = float64(interval.Milliseconds())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notifyCh := make(chan *opcua.PublishNotificationData, 100)
+	sub, err := client.Subscribe(ctx, &opcua.SubscriptionParameters{Interval: interval}, notifyCh)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rsp, err := sub.Monitor(ctx, ua.TimestampsToReturnBoth, items...)
+	if err == nil {
+		for i, r := range rsp.Results {
+			if r.StatusCode != ua.StatusOK {
+				err = fmt.Errorf("monitor %q, %s", nodes[i], r.StatusCode.Error())
+				break
+			}
+		}
+	}
+	if err != nil {
+		cancel()
+		sub.Cancel(context.Background())
+		return nil, err
+	}
+
+	ret := &OpcuaSubscription{sub: sub, cancel: cancel, done: make(chan struct{}), writeStats: &c.WriteStats}
+	go func() {
+		defer close(ret.done)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case data := <-notifyCh:
+				if data.Error != nil {
+					c.log.Warnf("bridge [%s] subscription, %s", c.name, data.Error.Error())
+					continue
+				}
+				dcn, ok := data.Value.(*ua.DataChangeNotification)
+				if !ok {
+					continue
+				}
+				changes := make([]*OpcuaDataChange, 0, len(dcn.MonitoredItems))
+				for _, item := range dcn.MonitoredItems {
+					if int(item.ClientHandle) >= len(nodes) || item.Value == nil {
+						continue
+					}
+					dc := &OpcuaDataChange{
+						NodeId: nodes[item.ClientHandle],
+						Time:   item.Value.SourceTimestamp,
+						Status: item.Value.Status,
+					}
+					if dc.Time.IsZero() {
+						dc.Time = item.Value.ServerTimestamp
+					}
+					if dc.Time.IsZero() {
+						dc.Time = time.Now()
+					}
+					if item.Value.Value != nil {
+						dc.Value = item.Value.Value.Value()
+					}
+					changes = append(changes, dc)
+				}
+				atomic.AddUint64(&c.inMsgs, uint64(len(changes)))
+				if len(changes) > 0 {
+					cb(changes)
+				}
+			}
+		}
+	}()
+	return ret, nil
+}
+
+// opcuaBrowseMaxDepth limits the depth of the folders to browse.
+const opcuaBrowseMaxDepth = 10
+
+// Browse returns the node ids of the variables under the folder, including the sub folders.
+func (c *OpcuaBridge) Browse(folder string) ([]string, error) {
+	client := c.getClient()
+	if client == nil || client.State() != opcua.Connected {
+		return nil, errors.New("opcua connection is unavailable")
+	}
+	nodeId, err := ua.ParseNodeID(folder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node id %q, %s", folder, err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	ret := []string{}
+	visited := map[string]bool{}
+	var browse func(n *ua.NodeID, depth int) error
+	browse = func(n *ua.NodeID, depth int) error {
+		if depth > opcuaBrowseMaxDepth || visited[n.String()] {
+			return nil
+		}
+		visited[n.String()] = true
+		refs, err := client.Node(n).References(ctx, id.HierarchicalReferences, ua.BrowseDirectionForward, ua.NodeClassAll, true)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if ref.NodeID == nil || ref.NodeID.NodeID == nil {
+				continue
+			}
+			switch ref.NodeClass {
+			case ua.NodeClassVariable:
+				ret = append(ret, ref.NodeID.NodeID.String())
+			case ua.NodeClassObject:
+				if err := browse(ref.NodeID.NodeID, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := browse(nodeId, 0); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
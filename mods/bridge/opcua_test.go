@@ -0,0 +1,159 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua/id"
+	opc_server "github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpcuaBridgeOptions(t *testing.T) {
+	br := NewOpcuaBridge("opcua_test", "policy=basic256sha256 cert=/tmp/cert.pem key=/tmp/key.pem username=user password=pass reconnect=30s timeout=5s unknown=value")
+	require.NoError(t, br.BeforeRegister())
+	require.Equal(t, "Basic256Sha256", br.securityPolicy)
+	require.Equal(t, ua.MessageSecurityModeSignAndEncrypt, br.securityMode)
+	require.Equal(t, "user", br.username)
+	require.Equal(t, "pass", br.password)
+	require.Equal(t, 30*time.Second, br.reconnectMaxWait)
+	require.Equal(t, 5*time.Second, br.requestTimeout)
+	require.False(t, br.IsConnected())
+	require.Equal(t, "bridge 'opcua_test' (opcua)", br.String())
+	require.Equal(t, "opcua_test", br.Name())
+
+	ok, reason := br.TestConnection()
+	require.False(t, ok)
+	require.Equal(t, "not connected", reason)
+
+	called := false
+	br.OnDisconnect(func(any) { called = true })
+	require.True(t, called)
+
+	_, err := br.Subscribe([]string{"ns=1;s=rw_int32"}, time.Second, func([]*OpcuaDataChange) {})
+	require.EqualError(t, err, "opcua connection is unavailable")
+	_, err = br.Browse("ns=1;i=85")
+	require.EqualError(t, err, "opcua connection is unavailable")
+
+	tests := []struct {
+		path string
+		err  string
+	}{
+		{"policy=Basic512", `bridge 'opcua_test' invalid security policy "Basic512"`},
+		{"mode=encrypt", `bridge 'opcua_test' invalid security mode "encrypt"`},
+		{"policy=None mode=Sign", `bridge 'opcua_test' security policy None does not match mode MessageSecurityModeSign`},
+		{"policy=Basic256", `bridge 'opcua_test' security policy Basic256 requires cert and key`},
+	}
+	for _, tc := range tests {
+		require.EqualError(t, NewOpcuaBridge("opcua_test", tc.path).BeforeRegister(), tc.err, tc.path)
+	}
+}
+
+func TestOpcuaDataChangeFloat64(t *testing.T) {
+	tests := []struct {
+		value any
+		ret   float64
+		ok    bool
+	}{
+		{true, 1, true},
+		{int32(-5), -5, true},
+		{uint16(7), 7, true},
+		{float32(1.5), 1.5, true},
+		{12.34, 12.34, true},
+		{"text", 0, false},
+		{nil, 0, false},
+	}
+	for _, tc := range tests {
+		v, ok := (&OpcuaDataChange{Value: tc.value}).Float64()
+		require.Equal(t, tc.ok, ok, "%v", tc.value)
+		require.Equal(t, tc.ret, v, "%v", tc.value)
+	}
+}
+
+func TestOpcuaBridgeSubscribe(t *testing.T) {
+	svr := opc_server.New(
+		opc_server.EndPoint("localhost", 4850),
+		opc_server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		opc_server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+	)
+	rootNS, _ := svr.Namespace(0)
+	nodeNS := opc_server.NewNodeNameSpace(svr, "NodeNamespace")
+	svr.AddNamespace(nodeNS)
+	nnsObj := nodeNS.Objects()
+	rootNS.Objects().AddRef(nnsObj, id.HasComponent, true)
+	for _, name := range []string{"temp", "pressure"} {
+		n := nodeNS.AddNewVariableStringNode(name, int32(5))
+		n.SetAttribute(ua.AttributeIDDataType, opc_server.DataValueFromValue(ua.NewNumericExpandedNodeID(0, 6)))
+		nnsObj.AddRef(n, id.HasComponent, true)
+	}
+	require.NoError(t, svr.Start(context.Background()))
+	defer svr.Close()
+
+	br := NewOpcuaBridge("opcua_sub", "endpoint=opc.tcp://localhost:4850 reconnect=1s")
+	require.NoError(t, br.BeforeRegister())
+	defer br.AfterUnregister()
+
+	connected := make(chan struct{}, 1)
+	br.OnConnect(func(any) {
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+	})
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for opcua connection")
+	}
+	ok, reason := br.TestConnection()
+	require.True(t, ok, reason)
+
+	nodes, err := br.Browse(nnsObj.ID().String())
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"ns=1;s=temp", "ns=1;s=pressure"}, nodes)
+
+	received := make(chan *OpcuaDataChange, 10)
+	sub, err := br.Subscribe([]string{"ns=1;s=temp"}, 100*time.Millisecond, func(changes []*OpcuaDataChange) {
+		for _, dc := range changes {
+			received <- dc
+		}
+	})
+	require.NoError(t, err)
+
+	next := func() *OpcuaDataChange {
+		select {
+		case dc := <-received:
+			return dc
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for opcua data change")
+			return nil
+		}
+	}
+	// the initial value
+	dc := next()
+	require.Equal(t, "ns=1;s=temp", dc.NodeId)
+	require.Equal(t, int32(5), dc.Value)
+	require.Equal(t, ua.StatusOK, dc.Status)
+	require.False(t, dc.Time.IsZero())
+
+	status := nodeNS.SetAttribute(ua.NewStringNodeID(nodeNS.ID(), "temp"), ua.AttributeIDValue, opc_server.DataValueFromValue(int32(42)))
+	require.Equal(t, ua.StatusOK, status)
+	dc = next()
+	require.Equal(t, int32(42), dc.Value)
+	require.GreaterOrEqual(t, br.StatsSnapshot().InMsgs, uint64(2))
+
+	sub.AddAppended(3)
+	require.Equal(t, uint64(3), br.StatsSnapshot().Appended)
+	require.NoError(t, sub.Unsubscribe())
+
+	_, err = br.Subscribe([]string{"ns=x;i=1"}, time.Second, func([]*OpcuaDataChange) {})
+	require.EqualError(t, err, `invalid node id "ns=x;i=1", opcua: invalid namespace id: ns=x;i=1`)
+	_, err = br.Subscribe(nil, time.Second, func([]*OpcuaDataChange) {})
+	require.EqualError(t, err, "no nodes to monitor")
+
+	// close the client before the server, otherwise it waits for the request timeout
+	require.NoError(t, br.AfterUnregister())
+	require.Eventually(t, func() bool { return br.getClient() == nil }, 5*time.Second, 10*time.Millisecond)
+}
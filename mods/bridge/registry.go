@@ -29,6 +29,8 @@ func Register(def *model.BridgeDefinition) (err error) {
 		br = NewMqttBridge(def.Name, def.Path)
 	case model.BRIDGE_NATS:
 		br = NewNatsBridge(def.Name, def.Path)
+	case model.BRIDGE_OPCUA:
+		br = NewOpcuaBridge(def.Name, def.Path)
 	default:
 		return fmt.Errorf("undefined bridge type %s, unable to register", def.Type)
 	}
@@ -5,6 +5,8 @@ import (
 
 	client "github.com/machbase/neo-client/v2"
 	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/arrow"
 	"github.com/machbase/neo-server/v8/mods/codec/internal/box"
 	"github.com/machbase/neo-server/v8/mods/codec/internal/chart"
 	"github.com/machbase/neo-server/v8/mods/codec/internal/csv"
@@ -13,6 +15,7 @@ import (
 	"github.com/machbase/neo-server/v8/mods/codec/internal/json"
 	"github.com/machbase/neo-server/v8/mods/codec/internal/markdown"
 	"github.com/machbase/neo-server/v8/mods/codec/internal/ndjson"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/parquet"
 	"github.com/machbase/neo-server/v8/mods/codec/internal/templ"
 	"github.com/machbase/neo-server/v8/mods/codec/opts"
 )
@@ -34,6 +37,8 @@ const ECHART_SURFACE3D = "echart.surface3d"
 const ECHART_SCATTER3D = "echart.scatter3d"
 const ECHART_BAR3D = "echart.bar3d"
 const GEOMAP = "geomap"
+const ARROW = "arrow"
+const PARQUET = "parquet"
 
 type RowsEncoder interface {
 	Open() error
@@ -52,6 +57,8 @@ var (
 	_ RowsEncoder = (*html.Exporter)(nil)
 	_ RowsEncoder = (*geomap.GeoMap)(nil)
 	_ RowsEncoder = (*ndjson.Exporter)(nil)
+	_ RowsEncoder = (*arrow.Exporter)(nil)
+	_ RowsEncoder = (*parquet.Exporter)(nil)
 )
 
 type RowsDecoder interface {
@@ -60,10 +67,16 @@ type RowsDecoder interface {
 	NextRow() ([]any, []string, error)
 }
 
+// RowError is returned by RowsDecoder.NextRow() when the row is consumed
+// but can not be decoded, the caller can skip it and continue to the next row.
+type RowError = internal.RowError
+
 var (
 	_ RowsDecoder = (*json.Decoder)(nil)
 	_ RowsDecoder = (*csv.Decoder)(nil)
 	_ RowsDecoder = (*ndjson.Decoder)(nil)
+	_ RowsDecoder = (*arrow.Decoder)(nil)
+	_ RowsDecoder = (*parquet.Decoder)(nil)
 )
 
 func NewEncoder(encoderType string, opts ...opts.Option) RowsEncoder {
@@ -101,6 +114,10 @@ func NewEncoder(encoderType string, opts ...opts.Option) RowsEncoder {
 		ret = geomap.New()
 	case NDJSON:
 		ret = ndjson.NewEncoder()
+	case ARROW:
+		ret = arrow.NewEncoder()
+	case PARQUET:
+		ret = parquet.NewEncoder()
 	default: // "json"
 		ret = json.NewEncoder()
 	}
@@ -117,6 +134,10 @@ func NewDecoder(decoderType string, opts ...opts.Option) RowsDecoder {
 		ret = csv.NewDecoder()
 	case NDJSON:
 		ret = ndjson.NewDecoder()
+	case ARROW:
+		ret = arrow.NewDecoder()
+	case PARQUET:
+		ret = parquet.NewDecoder()
 	default: // "json"
 		ret = json.NewDecoder()
 	}
@@ -33,7 +33,7 @@ func TestNewEncoderAndDiscardSink(t *testing.T) {
 		BOX, CSV, JSON, NDJSON, MARKDOWN, HTML, TEXT,
 		ECHART, ECHART_LINE, ECHART_SCATTER, ECHART_BAR,
 		ECHART_LINE3D, ECHART_SURFACE3D, ECHART_SCATTER3D, ECHART_BAR3D,
-		GEOMAP, DISCARD, "unknown",
+		GEOMAP, ARROW, PARQUET, DISCARD, "unknown",
 	}
 	for _, typ := range types {
 		enc := NewEncoder(typ, opts.OutputStream(buf))
@@ -0,0 +1,237 @@
+package arrow
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
+)
+
+type Decoder struct {
+	input         io.Reader
+	reader        array.RecordReader
+	columnNames   []string
+	columnTypes   []api.DataType
+	headerColumns bool
+	timeformat    string
+	timeLocation  *time.Location
+	tableName     string
+
+	record goarrow.Record
+	rowIdx int
+	nrow   int64
+
+	headerNames []string
+	headerTypes []api.DataType
+	headerErr   error
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{}
+}
+
+func (dec *Decoder) SetInputStream(in io.Reader) {
+	dec.input = in
+}
+
+func (dec *Decoder) SetTimeformat(format string) {
+	dec.timeformat = format
+}
+
+func (dec *Decoder) SetTimeLocation(tz *time.Location) {
+	dec.timeLocation = tz
+}
+
+func (dec *Decoder) SetTableName(tableName string) {
+	dec.tableName = tableName
+}
+
+// SetHeaderColumns makes the decoder map the fields of the schema
+// onto the columns of the table by name, otherwise by position.
+func (dec *Decoder) SetHeaderColumns(headerColumns bool) {
+	dec.headerColumns = headerColumns
+}
+
+func (dec *Decoder) SetColumns(names ...string) {
+	dec.columnNames = names
+}
+
+func (dec *Decoder) SetColumnTypes(types ...api.DataType) {
+	dec.columnTypes = types
+}
+
+func (dec *Decoder) Open() {
+	if dec.input == nil {
+		return
+	}
+	rr, err := ipc.NewReader(dec.input, ipc.WithAllocator(memory.DefaultAllocator))
+	if err != nil {
+		dec.OpenRecords(nil, err)
+		return
+	}
+	dec.OpenRecords(rr, nil)
+}
+
+// OpenRecords starts decoding the record batches of rr,
+// the reader is released when all records are consumed.
+// If err is not nil, it is returned by the following NextRow().
+func (dec *Decoder) OpenRecords(rr array.RecordReader, err error) {
+	if err != nil {
+		dec.headerErr = err
+		return
+	}
+	if dec.timeformat == "" {
+		dec.timeformat = "ns"
+	}
+	if dec.timeLocation == nil {
+		dec.timeLocation = time.UTC
+	}
+	dec.reader = rr
+
+	fields := rr.Schema().Fields()
+	if dec.headerColumns {
+		for _, field := range fields {
+			colIdx := -1
+			for i, name := range dec.columnNames {
+				if strings.EqualFold(name, field.Name) {
+					colIdx = i
+					break
+				}
+			}
+			if colIdx < 0 {
+				dec.headerErr = fmt.Errorf("schema field '%s' not found in columns of table %q", field.Name, dec.tableName)
+				return
+			}
+			dec.headerNames = append(dec.headerNames, dec.columnNames[colIdx])
+			dec.headerTypes = append(dec.headerTypes, dec.columnTypes[colIdx])
+		}
+	} else {
+		if len(fields) > len(dec.columnTypes) {
+			dec.headerErr = fmt.Errorf("too many columns (%d); table '%s' has %d columns",
+				len(fields), dec.tableName, len(dec.columnTypes))
+			return
+		}
+		dec.headerTypes = dec.columnTypes[:len(fields)]
+	}
+}
+
+func (dec *Decoder) NextRow() ([]any, []string, error) {
+	if dec.headerErr != nil {
+		return nil, nil, dec.headerErr
+	}
+	if dec.reader == nil {
+		return nil, nil, io.EOF
+	}
+	for dec.record == nil || dec.rowIdx >= int(dec.record.NumRows()) {
+		if !dec.reader.Next() {
+			err := dec.reader.Err()
+			dec.reader.Release()
+			dec.reader, dec.record = nil, nil
+			if err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, io.EOF
+		}
+		dec.record = dec.reader.Record()
+		dec.rowIdx = 0
+	}
+	row := dec.rowIdx
+	dec.rowIdx++
+	dec.nrow++
+
+	values := make([]any, len(dec.headerTypes))
+	for i, columnType := range dec.headerTypes {
+		field := FieldValue(dec.record.Column(i), row)
+		if field == nil {
+			continue
+		}
+		value, err := columnType.Apply(field, dec.timeformat, dec.timeLocation)
+		if err != nil {
+			return nil, nil, &internal.RowError{Column: dec.columnName(i),
+				Err: fmt.Errorf("rows[%d] field[%s] is not a %s, but %T",
+					dec.nrow, dec.record.ColumnName(i), columnType, field)}
+		}
+		values[i] = value
+	}
+	if len(dec.headerNames) > 0 {
+		return values, dec.headerNames, nil
+	}
+	return values, nil, nil
+}
+
+// Close releases the record reader if the records are not consumed to the end.
+func (dec *Decoder) Close() error {
+	if dec.reader != nil {
+		dec.reader.Release()
+		dec.reader, dec.record = nil, nil
+	}
+	return nil
+}
+
+func (dec *Decoder) columnName(idx int) string {
+	if len(dec.headerNames) > 0 {
+		return dec.headerNames[idx]
+	} else if idx < len(dec.columnNames) {
+		return dec.columnNames[idx]
+	}
+	return ""
+}
+
+// FieldValue returns the value of the array at the index i as a go native type.
+// It returns nil if the value is null.
+func FieldValue(arr goarrow.Array, i int) any {
+	if arr.IsNull(i) {
+		return nil
+	}
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return a.Value(i)
+	case *array.Int8:
+		return a.Value(i)
+	case *array.Int16:
+		return a.Value(i)
+	case *array.Int32:
+		return a.Value(i)
+	case *array.Int64:
+		return a.Value(i)
+	case *array.Uint8:
+		return a.Value(i)
+	case *array.Uint16:
+		return a.Value(i)
+	case *array.Uint32:
+		return a.Value(i)
+	case *array.Uint64:
+		return a.Value(i)
+	case *array.Float16:
+		return a.Value(i).Float32()
+	case *array.Float32:
+		return a.Value(i)
+	case *array.Float64:
+		return a.Value(i)
+	case *array.String:
+		return a.Value(i)
+	case *array.LargeString:
+		return a.Value(i)
+	case *array.Binary:
+		// the buffer of the record is reused after the record is released
+		return append([]byte(nil), a.Value(i)...)
+	case *array.LargeBinary:
+		return append([]byte(nil), a.Value(i)...)
+	case *array.Timestamp:
+		unit := a.DataType().(*goarrow.TimestampType).Unit
+		return a.Value(i).ToTime(unit)
+	case *array.Date32:
+		return a.Value(i).ToTime()
+	case *array.Date64:
+		return a.Value(i).ToTime()
+	default:
+		return arr.ValueStr(i)
+	}
+}
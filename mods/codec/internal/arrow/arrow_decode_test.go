@@ -0,0 +1,71 @@
+package arrow_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/arrow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrowDecoder(t *testing.T) {
+	ts := time.Unix(0, 1670380342000000000).In(time.UTC)
+	buf := &bytes.Buffer{}
+	enc := arrow.NewEncoder()
+	enc.SetOutputStream(buf)
+	enc.SetColumns("value", "name", "time")
+	enc.SetColumnTypes("double", "string", "datetime")
+	require.NoError(t, enc.Open())
+	require.NoError(t, enc.AddRow([]any{1.0001, "my-car", ts}))
+	require.NoError(t, enc.AddRow([]any{nil, "my-car", ts.Add(time.Second)}))
+	enc.Close()
+
+	dec := arrow.NewDecoder()
+	dec.SetInputStream(bytes.NewReader(buf.Bytes()))
+	dec.SetTableName("example")
+	dec.SetHeaderColumns(true)
+	dec.SetColumnTypes(api.COLUMN_TYPE_VARCHAR, api.COLUMN_TYPE_DATETIME, api.COLUMN_TYPE_DOUBLE)
+	dec.SetColumns("NAME", "TIME", "VALUE")
+	dec.Open()
+
+	values, columns, err := dec.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, []string{"VALUE", "NAME", "TIME"}, columns)
+	require.Equal(t, 1.0001, values[0])
+	require.Equal(t, "my-car", values[1])
+	require.Equal(t, ts.UnixNano(), values[2].(time.Time).UnixNano())
+
+	values, columns, err = dec.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, []string{"VALUE", "NAME", "TIME"}, columns)
+	require.Nil(t, values[0])
+	require.Equal(t, ts.Add(time.Second).UnixNano(), values[2].(time.Time).UnixNano())
+
+	_, _, err = dec.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestArrowDecoderUnknownField(t *testing.T) {
+	buf := &bytes.Buffer{}
+	enc := arrow.NewEncoder()
+	enc.SetOutputStream(buf)
+	enc.SetColumns("name", "unknown")
+	enc.SetColumnTypes("string", "double")
+	require.NoError(t, enc.Open())
+	require.NoError(t, enc.AddRow([]any{"my-car", 1.0}))
+	enc.Close()
+
+	dec := arrow.NewDecoder()
+	dec.SetInputStream(bytes.NewReader(buf.Bytes()))
+	dec.SetTableName("example")
+	dec.SetHeaderColumns(true)
+	dec.SetColumnTypes(api.COLUMN_TYPE_VARCHAR, api.COLUMN_TYPE_DATETIME, api.COLUMN_TYPE_DOUBLE)
+	dec.SetColumns("NAME", "TIME", "VALUE")
+	dec.Open()
+
+	_, _, err := dec.NextRow()
+	require.EqualError(t, err, `schema field 'unknown' not found in columns of table "example"`)
+}
@@ -0,0 +1,284 @@
+package arrow
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/facility"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
+)
+
+// DefaultBatchSize is the number of rows that are buffered
+// before a record batch is written to the output stream.
+const DefaultBatchSize = 4096
+
+type Exporter struct {
+	internal.RowsEncoderBase
+	output   io.Writer
+	showRows bool
+
+	rows    *RecordBuilder
+	writer  *ipc.Writer
+	timeLoc *time.Location
+	logger  facility.Logger
+
+	colNames []string
+	colTypes []api.DataType
+
+	closeOnce sync.Once
+}
+
+func NewEncoder() *Exporter {
+	return &Exporter{
+		timeLoc: time.UTC,
+		logger:  facility.DiscardLogger,
+	}
+}
+
+func (ex *Exporter) ContentType() string {
+	return "application/vnd.apache.arrow.stream"
+}
+
+func (ex *Exporter) SetOutputStream(o io.Writer) {
+	ex.output = o
+}
+
+func (ex *Exporter) SetTimeLocation(tz *time.Location) {
+	if tz == nil {
+		return
+	}
+	ex.timeLoc = tz
+}
+
+func (ex *Exporter) SetLogger(l facility.Logger) {
+	if l == nil {
+		return
+	}
+	ex.logger = l
+}
+
+func (ex *Exporter) SetRownum(show bool) {
+	ex.showRows = show
+}
+
+func (ex *Exporter) SetColumns(labels ...string) {
+	ex.colNames = labels
+}
+
+func (ex *Exporter) SetColumnTypes(types ...api.DataType) {
+	ex.colTypes = types
+}
+
+func (ex *Exporter) Open() error {
+	if ex.output == nil {
+		return fmt.Errorf("arrow encoder has no output stream")
+	}
+	ex.rows = NewRecordBuilder(ex.colNames, ex.colTypes, ex.showRows, ex.timeLoc)
+	ex.writer = ipc.NewWriter(ex.output,
+		ipc.WithSchema(ex.rows.Schema()),
+		ipc.WithAllocator(memory.DefaultAllocator))
+	return nil
+}
+
+func (ex *Exporter) Close() {
+	ex.closeOnce.Do(func() {
+		if ex.writer == nil {
+			return
+		}
+		if err := ex.writeBatch(); err != nil {
+			ex.logger.LogError("arrow write batch", err)
+		}
+		if err := ex.writer.Close(); err != nil {
+			ex.logger.LogError("arrow close", err)
+		}
+		ex.rows.Release()
+		if closer, ok := ex.output.(io.Closer); ok {
+			closer.Close()
+		}
+	})
+}
+
+func (ex *Exporter) Flush(heading bool) {
+	if err := ex.writeBatch(); err != nil {
+		ex.logger.LogError("arrow write batch", err)
+	}
+	if flusher, ok := ex.output.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+}
+
+func (ex *Exporter) AddRow(values []any) error {
+	if err := ex.rows.Append(values); err != nil {
+		return err
+	}
+	if ex.rows.Len() >= DefaultBatchSize {
+		return ex.writeBatch()
+	}
+	return nil
+}
+
+func (ex *Exporter) writeBatch() error {
+	if ex.rows == nil || ex.rows.Len() == 0 {
+		return nil
+	}
+	rec := ex.rows.NewRecord()
+	defer rec.Release()
+	return ex.writer.Write(rec)
+}
+
+// RecordBuilder accumulates rows into an arrow record batch.
+// The schema is derived from the column names and types of the result set.
+type RecordBuilder struct {
+	schema  *goarrow.Schema
+	builder *array.RecordBuilder
+	rownum  bool
+	nrow    int64
+	pending int
+}
+
+func NewRecordBuilder(names []string, types []api.DataType, rownum bool, tz *time.Location) *RecordBuilder {
+	fields := make([]goarrow.Field, 0, len(names)+1)
+	if rownum {
+		fields = append(fields, goarrow.Field{Name: "ROWNUM", Type: goarrow.PrimitiveTypes.Int64})
+	}
+	for i, name := range names {
+		typ := api.DataTypeString
+		if i < len(types) {
+			typ = types[i]
+		}
+		fields = append(fields, goarrow.Field{Name: name, Type: ArrowType(typ, tz), Nullable: true})
+	}
+	schema := goarrow.NewSchema(fields, nil)
+	return &RecordBuilder{
+		schema:  schema,
+		builder: array.NewRecordBuilder(memory.DefaultAllocator, schema),
+		rownum:  rownum,
+	}
+}
+
+func (rb *RecordBuilder) Schema() *goarrow.Schema {
+	return rb.schema
+}
+
+// Len returns the number of rows that are not yet flushed into a record.
+func (rb *RecordBuilder) Len() int {
+	return rb.pending
+}
+
+// Append adds a row to the pending rows. The values are converted before
+// any of them goes into the builders, so that a bad row leaves no partial row
+// and all builders keep the same length.
+func (rb *RecordBuilder) Append(values []any) error {
+	rb.nrow++
+	offset := 0
+	if rb.rownum {
+		offset = 1
+	}
+	if len(values)+offset != len(rb.schema.Fields()) {
+		return fmt.Errorf("rows[%d] number of columns not matched (%d); expected %d columns",
+			rb.nrow, len(values), len(rb.schema.Fields())-offset)
+	}
+	converted := make([]any, len(values))
+	for i, v := range values {
+		field := rb.schema.Field(i + offset)
+		cv, err := ConvertValue(field.Type, v)
+		if err != nil {
+			return fmt.Errorf("rows[%d] column %q, %w", rb.nrow, field.Name, err)
+		}
+		converted[i] = cv
+	}
+	if rb.rownum {
+		rb.builder.Field(0).(*array.Int64Builder).Append(rb.nrow)
+	}
+	for i, v := range converted {
+		if v == nil {
+			rb.builder.Field(i + offset).AppendNull()
+		} else {
+			appendConverted(rb.builder.Field(i+offset), v)
+		}
+	}
+	rb.pending++
+	return nil
+}
+
+// NewRecord returns the record of the pending rows, the caller should release it.
+func (rb *RecordBuilder) NewRecord() goarrow.Record {
+	rb.pending = 0
+	return rb.builder.NewRecord()
+}
+
+func (rb *RecordBuilder) Release() {
+	rb.builder.Release()
+}
+
+// ArrowType returns arrow data type that corresponds to the column type.
+func ArrowType(typ api.DataType, tz *time.Location) goarrow.DataType {
+	switch typ {
+	case api.DataTypeBoolean:
+		return goarrow.FixedWidthTypes.Boolean
+	case api.DataTypeByte:
+		return goarrow.PrimitiveTypes.Uint8
+	case api.DataTypeInt16:
+		return goarrow.PrimitiveTypes.Int16
+	case api.DataTypeUInt16:
+		return goarrow.PrimitiveTypes.Uint16
+	case api.DataTypeInt32:
+		return goarrow.PrimitiveTypes.Int32
+	case api.DataTypeUInt32:
+		return goarrow.PrimitiveTypes.Uint32
+	case api.DataTypeInt64:
+		return goarrow.PrimitiveTypes.Int64
+	case api.DataTypeUInt64:
+		return goarrow.PrimitiveTypes.Uint64
+	case api.DataTypeFloat32:
+		return goarrow.PrimitiveTypes.Float32
+	case api.DataTypeFloat64:
+		return goarrow.PrimitiveTypes.Float64
+	case api.DataTypeDatetime:
+		return &goarrow.TimestampType{Unit: goarrow.Nanosecond, TimeZone: TimeZoneName(tz)}
+	case api.DataTypeBinary:
+		return goarrow.BinaryTypes.Binary
+	default:
+		// string, json, ipv4, ipv6 and any other types are exported as string
+		return goarrow.BinaryTypes.String
+	}
+}
+
+// TimeZoneName returns the IANA name of the location for the timestamp type.
+// The readers do not understand "Local", it is resolved by $TZ or /etc/localtime,
+// and falls back to "UTC" if it can not be resolved.
+func TimeZoneName(tz *time.Location) string {
+	if tz == nil {
+		return "UTC"
+	}
+	if name := tz.String(); name != "Local" {
+		return name
+	}
+	if env, ok := os.LookupEnv("TZ"); ok {
+		env = strings.TrimPrefix(env, ":")
+		if env == "" {
+			return "UTC"
+		}
+		if _, err := time.LoadLocation(env); err == nil {
+			return env
+		}
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if idx := strings.LastIndex(target, "zoneinfo/"); idx >= 0 {
+			name := target[idx+len("zoneinfo/"):]
+			if _, err := time.LoadLocation(name); err == nil {
+				return name
+			}
+		}
+	}
+	return "UTC"
+}
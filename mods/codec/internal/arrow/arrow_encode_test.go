@@ -0,0 +1,149 @@
+package arrow_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/machbase/neo-server/v8/mods/codec/facility"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/arrow"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArrowEncode(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := arrow.NewEncoder()
+	enc.SetOutputStream(out)
+	enc.SetRownum(true)
+	enc.SetColumns("name", "time", "value", "data")
+	enc.SetColumnTypes("string", "datetime", "double", "binary")
+	require.NoError(t, enc.Open())
+
+	ts := time.Unix(0, 1670380342000000000).In(time.UTC)
+	nrows := arrow.DefaultBatchSize + 10
+	for i := 0; i < nrows; i++ {
+		require.NoError(t, enc.AddRow([]any{"my-car", ts.Add(time.Duration(i) * time.Second), float64(i) * 1.5, []byte{0x01, 0x02}}))
+	}
+	require.NoError(t, enc.AddRow([]any{nil, nil, nil, nil}))
+	enc.Close()
+	require.Equal(t, "application/vnd.apache.arrow.stream", enc.ContentType())
+
+	rd, err := ipc.NewReader(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	defer rd.Release()
+
+	schema := rd.Schema()
+	require.Equal(t, 5, len(schema.Fields()))
+	require.Equal(t, "ROWNUM", schema.Field(0).Name)
+	require.Equal(t, goarrow.STRING, schema.Field(1).Type.ID())
+	require.Equal(t, goarrow.TIMESTAMP, schema.Field(2).Type.ID())
+	require.Equal(t, goarrow.FLOAT64, schema.Field(3).Type.ID())
+	require.Equal(t, goarrow.BINARY, schema.Field(4).Type.ID())
+
+	total := 0
+	batches := 0
+	for rd.Next() {
+		rec := rd.Record()
+		if batches == 0 {
+			require.Equal(t, int64(1), rec.Column(0).(*array.Int64).Value(0))
+			require.Equal(t, "my-car", rec.Column(1).(*array.String).Value(0))
+			require.Equal(t, goarrow.Timestamp(ts.UnixNano()), rec.Column(2).(*array.Timestamp).Value(0))
+			require.Equal(t, 0.0, rec.Column(3).(*array.Float64).Value(0))
+			require.Equal(t, []byte{0x01, 0x02}, rec.Column(4).(*array.Binary).Value(0))
+		}
+		total += int(rec.NumRows())
+		batches++
+	}
+	require.NoError(t, rd.Err())
+	require.Equal(t, 2, batches)
+	require.Equal(t, nrows+1, total)
+}
+
+func TestArrowEncodeColumnMismatch(t *testing.T) {
+	enc := arrow.NewEncoder()
+	enc.SetOutputStream(&bytes.Buffer{})
+	enc.SetColumns("name", "value")
+	enc.SetColumnTypes("string", "double")
+	require.NoError(t, enc.Open())
+	require.Error(t, enc.AddRow([]any{"only-one"}))
+	require.Error(t, enc.AddRow([]any{"name", "not-a-number"}))
+	enc.Close()
+}
+
+func TestArrowEncodeBadRow(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := arrow.NewEncoder()
+	enc.SetOutputStream(out)
+	enc.SetRownum(true)
+	enc.SetColumns("name", "value", "count")
+	enc.SetColumnTypes("string", "double", "int32")
+	require.NoError(t, enc.Open())
+	require.NoError(t, enc.AddRow([]any{"a", 1.0, 1}))
+	// the bad rows do not leave a partial row in the builders
+	require.Error(t, enc.AddRow([]any{"only-one"}))
+	require.Error(t, enc.AddRow([]any{"b", 2.0, "not-a-number"}))
+	require.Error(t, enc.AddRow([]any{"c", "not-a-number", 3}))
+	require.NoError(t, enc.AddRow([]any{"d", nil, 4}))
+	enc.Close()
+
+	rd, err := ipc.NewReader(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	defer rd.Release()
+	total := 0
+	for rd.Next() {
+		rec := rd.Record()
+		for i := 0; i < int(rec.NumCols()); i++ {
+			require.Equal(t, int(rec.NumRows()), rec.Column(i).Len())
+		}
+		total += int(rec.NumRows())
+	}
+	require.NoError(t, rd.Err())
+	require.Equal(t, 2, total)
+}
+
+func TestArrowTimeZoneName(t *testing.T) {
+	require.Equal(t, "UTC", arrow.TimeZoneName(nil))
+	require.Equal(t, "UTC", arrow.TimeZoneName(time.UTC))
+	seoul, err := time.LoadLocation("Asia/Seoul")
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Seoul", arrow.TimeZoneName(seoul))
+
+	// "Local" is not a valid zone for the readers
+	typ := arrow.ArrowType("datetime", time.Local).(*goarrow.TimestampType)
+	require.NotEqual(t, "Local", typ.TimeZone)
+	local := time.FixedZone("Local", 0)
+	t.Setenv("TZ", "Asia/Seoul")
+	require.Equal(t, "Asia/Seoul", arrow.TimeZoneName(local))
+	t.Setenv("TZ", "")
+	require.Equal(t, "UTC", arrow.TimeZoneName(local))
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+type errorLogger struct {
+	facility.Logger
+	errs []string
+}
+
+func (l *errorLogger) LogError(args ...any) { l.errs = append(l.errs, fmt.Sprint(args...)) }
+
+func TestArrowEncodeWriteError(t *testing.T) {
+	logger := &errorLogger{Logger: facility.DiscardLogger}
+	enc := arrow.NewEncoder()
+	enc.SetOutputStream(failWriter{})
+	enc.SetLogger(logger)
+	enc.SetColumns("name")
+	enc.SetColumnTypes("string")
+	require.NoError(t, enc.Open())
+	require.NoError(t, enc.AddRow([]any{"my-car"}))
+	enc.Close()
+	require.NotEmpty(t, logger.errs)
+	require.Contains(t, logger.errs[0], "disk full")
+}
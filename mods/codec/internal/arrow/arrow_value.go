@@ -0,0 +1,302 @@
+package arrow
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	client "github.com/machbase/neo-client/v2"
+	"github.com/machbase/neo-client/v2/api"
+)
+
+// AppendValue appends the value to the builder converting it into the builder's type.
+// nil value is appended as null.
+func AppendValue(b array.Builder, value any) error {
+	v, err := ConvertValue(b.Type(), value)
+	if err != nil {
+		return err
+	}
+	appendConverted(b, v)
+	return nil
+}
+
+// ConvertValue converts the value into the go type that the builder of the data type takes,
+// so that a row can be checked before any of its values goes into the builders.
+// nil value is returned as nil.
+func ConvertValue(dt goarrow.DataType, value any) (any, error) {
+	value = client.Unbox(value)
+	if value == nil {
+		return nil, nil
+	}
+	switch dt.ID() {
+	case goarrow.BOOL:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		default:
+			n, ok := toFloat64(value)
+			if !ok {
+				return nil, errIncompatible(value, "boolean")
+			}
+			return n != 0, nil
+		}
+	case goarrow.UINT8:
+		n, ok := toUint64(value)
+		if !ok || n > math.MaxUint8 {
+			return nil, errIncompatible(value, "byte")
+		}
+		return uint8(n), nil
+	case goarrow.INT16:
+		n, ok := toInt64(value)
+		if !ok || n < math.MinInt16 || n > math.MaxInt16 {
+			return nil, errIncompatible(value, "int16")
+		}
+		return int16(n), nil
+	case goarrow.UINT16:
+		n, ok := toUint64(value)
+		if !ok || n > math.MaxUint16 {
+			return nil, errIncompatible(value, "uint16")
+		}
+		return uint16(n), nil
+	case goarrow.INT32:
+		n, ok := toInt64(value)
+		if !ok || n < math.MinInt32 || n > math.MaxInt32 {
+			return nil, errIncompatible(value, "int32")
+		}
+		return int32(n), nil
+	case goarrow.UINT32:
+		n, ok := toUint64(value)
+		if !ok || n > math.MaxUint32 {
+			return nil, errIncompatible(value, "uint32")
+		}
+		return uint32(n), nil
+	case goarrow.INT64:
+		n, ok := toInt64(value)
+		if !ok {
+			return nil, errIncompatible(value, "int64")
+		}
+		return n, nil
+	case goarrow.UINT64:
+		n, ok := toUint64(value)
+		if !ok {
+			return nil, errIncompatible(value, "uint64")
+		}
+		return n, nil
+	case goarrow.FLOAT32:
+		n, ok := toFloat64(value)
+		if !ok {
+			return nil, errIncompatible(value, "float")
+		}
+		return float32(n), nil
+	case goarrow.FLOAT64:
+		n, ok := toFloat64(value)
+		if !ok {
+			return nil, errIncompatible(value, "double")
+		}
+		return n, nil
+	case goarrow.TIMESTAMP:
+		switch v := value.(type) {
+		case time.Time:
+			return goarrow.Timestamp(v.UnixNano()), nil
+		default:
+			n, ok := toInt64(value)
+			if !ok {
+				return nil, errIncompatible(value, "datetime")
+			}
+			return goarrow.Timestamp(n), nil
+		}
+	case goarrow.BINARY:
+		switch v := value.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		default:
+			return nil, errIncompatible(value, "binary")
+		}
+	case goarrow.STRING:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case api.JSONString:
+			return string(v), nil
+		case net.IP:
+			return v.String(), nil
+		case []byte:
+			return string(v), nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprintf("%v", v), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported arrow type %s", dt)
+	}
+}
+
+// appendConverted appends the value that ConvertValue returned,
+// the value that does not fit the builder is appended as null.
+func appendConverted(b array.Builder, value any) {
+	switch v := value.(type) {
+	case bool:
+		if bb, ok := b.(*array.BooleanBuilder); ok {
+			bb.Append(v)
+			return
+		}
+	case uint8:
+		if bb, ok := b.(*array.Uint8Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case int16:
+		if bb, ok := b.(*array.Int16Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case uint16:
+		if bb, ok := b.(*array.Uint16Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case int32:
+		if bb, ok := b.(*array.Int32Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case uint32:
+		if bb, ok := b.(*array.Uint32Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case int64:
+		if bb, ok := b.(*array.Int64Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case uint64:
+		if bb, ok := b.(*array.Uint64Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case float32:
+		if bb, ok := b.(*array.Float32Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case float64:
+		if bb, ok := b.(*array.Float64Builder); ok {
+			bb.Append(v)
+			return
+		}
+	case goarrow.Timestamp:
+		if bb, ok := b.(*array.TimestampBuilder); ok {
+			bb.Append(v)
+			return
+		}
+	case []byte:
+		if bb, ok := b.(*array.BinaryBuilder); ok {
+			bb.Append(v)
+			return
+		}
+	case string:
+		if bb, ok := b.(*array.StringBuilder); ok {
+			bb.Append(v)
+			return
+		}
+	}
+	b.AppendNull()
+}
+
+func errIncompatible(value any, typ string) error {
+	return fmt.Errorf("incompatible value %v (%T) for %s", value, value, typ)
+}
+
+func toInt64(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case uint:
+		if uint64(v) > math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case float32:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case time.Time:
+		return v.UnixNano(), true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func toUint64(value any) (uint64, bool) {
+	switch v := value.(type) {
+	case uint64:
+		return v, true
+	case uint:
+		return uint64(v), true
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		return n, err == nil
+	default:
+		n, ok := toInt64(value)
+		if !ok || n < 0 {
+			return 0, false
+		}
+		return uint64(n), true
+	}
+}
+
+func toFloat64(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case string:
+		n, err := strconv.ParseFloat(v, 64)
+		return n, err == nil
+	default:
+		n, ok := toInt64(value)
+		return float64(n), ok
+	}
+}
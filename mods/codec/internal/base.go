@@ -21,3 +21,19 @@ func (reb *RowsEncoderBase) DelHttpHeader(key string) {
 	}
 	delete(reb.httpHeaders, key)
 }
+
+// RowError is returned by the decoders when a row is read but
+// its values can not be converted into the column types.
+// The decoder can continue to the next row after a RowError.
+type RowError struct {
+	Column string // empty if the error is not about a specific column
+	Err    error
+}
+
+func (re *RowError) Error() string {
+	return re.Err.Error()
+}
+
+func (re *RowError) Unwrap() error {
+	return re.Err
+}
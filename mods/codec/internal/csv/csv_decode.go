@@ -11,6 +11,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
 	"golang.org/x/text/encoding"
 )
 
@@ -136,11 +137,11 @@ func (dec *Decoder) NextRow() ([]any, []string, error) {
 	}
 
 	if len(dec.headerTypes) > 0 && len(fields) != len(dec.headerTypes) {
-		return nil, nil, fmt.Errorf("too many columns (%d); CSV header has %d fields",
-			len(fields), len(dec.columnTypes))
+		return nil, nil, &internal.RowError{Err: fmt.Errorf("too many columns (%d); CSV header has %d fields",
+			len(fields), len(dec.columnTypes))}
 	} else if len(fields) > len(dec.columnTypes) {
-		return nil, nil, fmt.Errorf("too many columns (%d); table '%s' has %d columns",
-			len(fields), dec.tableName, len(dec.columnTypes))
+		return nil, nil, &internal.RowError{Err: fmt.Errorf("too many columns (%d); table '%s' has %d columns",
+			len(fields), dec.tableName, len(dec.columnTypes))}
 	}
 
 	values := make([]any, 0, len(dec.columnTypes))
@@ -170,7 +171,7 @@ func (dec *Decoder) NextRow() ([]any, []string, error) {
 			value = nil
 		} else {
 			if value, err = columnType.Apply(field, dec.timeformat, dec.timeLocation); err != nil {
-				errs = append(errs, err)
+				errs = append(errs, &internal.RowError{Column: dec.columnName(i), Err: err})
 			}
 		}
 		values = append(values, value)
@@ -184,3 +185,12 @@ func (dec *Decoder) NextRow() ([]any, []string, error) {
 		return values, nil, nil
 	}
 }
+
+func (dec *Decoder) columnName(idx int) string {
+	if len(dec.headerNames) > 0 {
+		return dec.headerNames[idx]
+	} else if idx < len(dec.columnNames) {
+		return dec.columnNames[idx]
+	}
+	return ""
+}
@@ -8,10 +8,12 @@ import (
 	"time"
 
 	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
 )
 
 type Decoder struct {
 	columnTypes  []api.DataType
+	columnNames  []string
 	reader       *gojson.Decoder
 	dataDepth    int
 	nrow         int64
@@ -41,6 +43,10 @@ func (dec *Decoder) SetTableName(tableName string) {
 	dec.tableName = tableName
 }
 
+func (dec *Decoder) SetColumns(names ...string) {
+	dec.columnNames = names
+}
+
 func (dec *Decoder) SetColumnTypes(types ...api.DataType) {
 	dec.columnTypes = types
 }
@@ -57,8 +63,8 @@ func (dec *Decoder) NextRow() ([]any, []string, error) {
 	dec.nrow++
 
 	if len(fields) != len(dec.columnTypes) {
-		return nil, nil, fmt.Errorf("rows[%d] number of columns not matched (%d); table '%s' has %d columns",
-			dec.nrow, len(fields), dec.tableName, len(dec.columnTypes))
+		return nil, nil, &internal.RowError{Err: fmt.Errorf("rows[%d] number of columns not matched (%d); table '%s' has %d columns",
+			dec.nrow, len(fields), dec.tableName, len(dec.columnTypes))}
 	}
 
 	values := make([]any, len(dec.columnTypes))
@@ -69,7 +75,12 @@ func (dec *Decoder) NextRow() ([]any, []string, error) {
 		}
 		values[i], err = dec.columnTypes[i].Apply(field, dec.timeformat, dec.timeLocation)
 		if err != nil {
-			return nil, nil, fmt.Errorf("rows[%d] column[%d] is not a %s, but %T", dec.nrow, i, dec.columnTypes[i], field)
+			colName := ""
+			if i < len(dec.columnNames) {
+				colName = dec.columnNames[i]
+			}
+			return nil, nil, &internal.RowError{Column: colName,
+				Err: fmt.Errorf("rows[%d] column[%d] is not a %s, but %T", dec.nrow, i, dec.columnTypes[i], field)}
 		}
 	}
 	return values, nil, nil
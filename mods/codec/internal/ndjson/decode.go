@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
 )
 
 type Decoder struct {
@@ -99,7 +100,8 @@ func (dec *Decoder) NextRow() ([]any, []string, error) {
 		}
 		value, err = dec.columnTypes[idx].Apply(field, dec.timeformat, dec.timeLocation)
 		if err != nil {
-			return nil, nil, fmt.Errorf("rows[%d] field[%s] is not a %s, but %T", dec.nrow, colName, dec.columnTypes[idx], field)
+			return nil, nil, &internal.RowError{Column: colName,
+				Err: fmt.Errorf("rows[%d] field[%s] is not a %s, but %T", dec.nrow, colName, dec.columnTypes[idx], field)}
 		}
 		dec.values = append(dec.values, value)
 	}
@@ -0,0 +1,112 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	goparquet "github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/arrow"
+)
+
+// Decoder reads the row groups of a parquet file as arrow record batches,
+// the columns are decoded in the same way as arrow.Decoder does.
+type Decoder struct {
+	*arrow.Decoder
+	input io.Reader
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{Decoder: arrow.NewDecoder()}
+}
+
+func (dec *Decoder) SetInputStream(in io.Reader) {
+	dec.input = in
+}
+
+func (dec *Decoder) Open() {
+	if dec.input == nil {
+		return
+	}
+	dec.OpenRecords(dec.openFile())
+}
+
+func (dec *Decoder) openFile() (array.RecordReader, error) {
+	// parquet requires random access to read the footer first,
+	// a stream is spooled into a temp file unless it is seekable or already in memory.
+	var spool *os.File
+	src, ok := dec.input.(goparquet.ReaderAtSeeker)
+	if !ok {
+		if buf, isBuf := dec.input.(*bytes.Buffer); isBuf {
+			src = bytes.NewReader(buf.Bytes())
+		} else {
+			f, err := spoolFile(dec.input)
+			if err != nil {
+				return nil, err
+			}
+			spool, src = f, f
+		}
+	}
+	pf, err := file.NewParquetReader(src)
+	if err != nil {
+		removeSpool(spool)
+		return nil, err
+	}
+	props := pqarrow.ArrowReadProperties{BatchSize: arrow.DefaultBatchSize}
+	fr, err := pqarrow.NewFileReader(pf, props, memory.DefaultAllocator)
+	if err != nil {
+		pf.Close()
+		removeSpool(spool)
+		return nil, err
+	}
+	rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		pf.Close()
+		removeSpool(spool)
+		return nil, err
+	}
+	return &fileRecordReader{RecordReader: rr, file: pf, spool: spool}, nil
+}
+
+func spoolFile(in io.Reader) (*os.File, error) {
+	f, err := os.CreateTemp("", "neo-parquet-*")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(f, in); err != nil {
+		removeSpool(f)
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		removeSpool(f)
+		return nil, err
+	}
+	return f, nil
+}
+
+func removeSpool(f *os.File) {
+	if f == nil {
+		return
+	}
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// fileRecordReader closes the parquet file and removes the spooled file
+// when the reader is released.
+type fileRecordReader struct {
+	pqarrow.RecordReader
+	file  *file.Reader
+	spool *os.File
+}
+
+func (r *fileRecordReader) Release() {
+	r.RecordReader.Release()
+	r.file.Close()
+	removeSpool(r.spool)
+}
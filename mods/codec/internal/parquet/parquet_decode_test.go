@@ -0,0 +1,121 @@
+package parquet_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetDecoder(t *testing.T) {
+	ts := time.Unix(0, 1670380342000000000).In(time.UTC)
+	buf := &bytes.Buffer{}
+	enc := parquet.NewEncoder()
+	enc.SetOutputStream(buf)
+	enc.SetColumns("name", "time", "value")
+	enc.SetColumnTypes("string", "datetime", "double")
+	require.NoError(t, enc.Open())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, enc.AddRow([]any{"my-car", ts.Add(time.Duration(i) * time.Second), float64(i)}))
+	}
+	enc.Close()
+
+	dec := parquet.NewDecoder()
+	dec.SetInputStream(bytes.NewBuffer(buf.Bytes()))
+	dec.SetTableName("example")
+	dec.SetHeaderColumns(true)
+	dec.SetColumnTypes(api.COLUMN_TYPE_VARCHAR, api.COLUMN_TYPE_DATETIME, api.COLUMN_TYPE_DOUBLE)
+	dec.SetColumns("NAME", "TIME", "VALUE")
+	dec.Open()
+
+	for i := 0; i < 10; i++ {
+		values, columns, err := dec.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, []string{"NAME", "TIME", "VALUE"}, columns)
+		require.Equal(t, "my-car", values[0])
+		require.Equal(t, ts.Add(time.Duration(i)*time.Second).UnixNano(), values[1].(time.Time).UnixNano())
+		require.Equal(t, float64(i), values[2])
+	}
+	_, _, err := dec.NextRow()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestParquetDecoderInvalidFile(t *testing.T) {
+	dec := parquet.NewDecoder()
+	dec.SetInputStream(bytes.NewBufferString("not a parquet file"))
+	dec.SetColumnTypes(api.COLUMN_TYPE_VARCHAR)
+	dec.SetColumns("NAME")
+	dec.Open()
+
+	_, _, err := dec.NextRow()
+	require.Error(t, err)
+}
+
+func TestParquetDecoderSpool(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("TMPDIR", tmpDir)
+
+	buf := &bytes.Buffer{}
+	enc := parquet.NewEncoder()
+	enc.SetOutputStream(buf)
+	enc.SetColumns("name", "value")
+	enc.SetColumnTypes("string", "double")
+	require.NoError(t, enc.Open())
+	for i := 0; i < 10; i++ {
+		require.NoError(t, enc.AddRow([]any{"my-car", float64(i)}))
+	}
+	enc.Close()
+
+	newDecoder := func() *parquet.Decoder {
+		dec := parquet.NewDecoder()
+		// a stream that is neither seekable nor in memory
+		dec.SetInputStream(io.MultiReader(bytes.NewReader(buf.Bytes())))
+		dec.SetTableName("example")
+		dec.SetHeaderColumns(true)
+		dec.SetColumnTypes(api.COLUMN_TYPE_VARCHAR, api.COLUMN_TYPE_DOUBLE)
+		dec.SetColumns("NAME", "VALUE")
+		dec.Open()
+		return dec
+	}
+	spooled := func() int {
+		entries, err := os.ReadDir(tmpDir)
+		require.NoError(t, err)
+		return len(entries)
+	}
+
+	// removed when all rows are consumed
+	dec := newDecoder()
+	require.Equal(t, 1, spooled())
+	for i := 0; i < 10; i++ {
+		values, _, err := dec.NextRow()
+		require.NoError(t, err)
+		require.Equal(t, float64(i), values[1])
+	}
+	_, _, err := dec.NextRow()
+	require.Equal(t, io.EOF, err)
+	require.Equal(t, 0, spooled())
+	require.NoError(t, dec.Close())
+
+	// removed when the decoder is closed in the middle
+	dec = newDecoder()
+	_, _, err = dec.NextRow()
+	require.NoError(t, err)
+	require.Equal(t, 1, spooled())
+	require.NoError(t, dec.Close())
+	require.Equal(t, 0, spooled())
+
+	// removed when the input is not a parquet file
+	dec = parquet.NewDecoder()
+	dec.SetInputStream(io.MultiReader(bytes.NewBufferString("not a parquet file")))
+	dec.SetColumnTypes(api.COLUMN_TYPE_VARCHAR)
+	dec.SetColumns("NAME")
+	dec.Open()
+	_, _, err = dec.NextRow()
+	require.Error(t, err)
+	require.Equal(t, 0, spooled())
+}
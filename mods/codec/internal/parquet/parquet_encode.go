@@ -0,0 +1,137 @@
+package parquet
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	goparquet "github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/compress"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/codec/facility"
+	"github.com/machbase/neo-server/v8/mods/codec/internal"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/arrow"
+)
+
+// DefaultRowGroupSize is the number of rows that are buffered
+// before a row group is written to the output stream.
+const DefaultRowGroupSize = 64 * 1024
+
+type Exporter struct {
+	internal.RowsEncoderBase
+	output   io.Writer
+	showRows bool
+
+	rows    *arrow.RecordBuilder
+	writer  *pqarrow.FileWriter
+	timeLoc *time.Location
+	logger  facility.Logger
+
+	colNames []string
+	colTypes []api.DataType
+
+	closeOnce sync.Once
+}
+
+func NewEncoder() *Exporter {
+	return &Exporter{
+		timeLoc: time.UTC,
+		logger:  facility.DiscardLogger,
+	}
+}
+
+func (ex *Exporter) ContentType() string {
+	return "application/vnd.apache.parquet"
+}
+
+func (ex *Exporter) SetOutputStream(o io.Writer) {
+	ex.output = o
+}
+
+func (ex *Exporter) SetTimeLocation(tz *time.Location) {
+	if tz == nil {
+		return
+	}
+	ex.timeLoc = tz
+}
+
+func (ex *Exporter) SetLogger(l facility.Logger) {
+	if l == nil {
+		return
+	}
+	ex.logger = l
+}
+
+func (ex *Exporter) SetRownum(show bool) {
+	ex.showRows = show
+}
+
+func (ex *Exporter) SetColumns(labels ...string) {
+	ex.colNames = labels
+}
+
+func (ex *Exporter) SetColumnTypes(types ...api.DataType) {
+	ex.colTypes = types
+}
+
+func (ex *Exporter) Open() error {
+	if ex.output == nil {
+		return fmt.Errorf("parquet encoder has no output stream")
+	}
+	ex.rows = arrow.NewRecordBuilder(ex.colNames, ex.colTypes, ex.showRows, ex.timeLoc)
+	props := goparquet.NewWriterProperties(
+		goparquet.WithCompression(compress.Codecs.Snappy),
+		goparquet.WithMaxRowGroupLength(DefaultRowGroupSize),
+	)
+	w, err := pqarrow.NewFileWriter(ex.rows.Schema(), ex.output, props, pqarrow.DefaultWriterProps())
+	if err != nil {
+		ex.rows.Release()
+		ex.rows = nil
+		return err
+	}
+	ex.writer = w
+	return nil
+}
+
+// Close writes the pending rows and the footer of the parquet file.
+// The output stream is closed if it implements io.Closer.
+func (ex *Exporter) Close() {
+	ex.closeOnce.Do(func() {
+		if ex.writer == nil {
+			return
+		}
+		if err := ex.writeRowGroup(); err != nil {
+			ex.logger.LogError("parquet write row group", err)
+		}
+		if err := ex.writer.Close(); err != nil {
+			ex.logger.LogError("parquet close", err)
+		}
+		ex.rows.Release()
+	})
+}
+
+// Flush does nothing until the row group is filled,
+// since a parquet file is not readable before the footer is written.
+func (ex *Exporter) Flush(heading bool) {
+}
+
+func (ex *Exporter) AddRow(values []any) error {
+	if err := ex.rows.Append(values); err != nil {
+		return err
+	}
+	if ex.rows.Len() >= DefaultRowGroupSize {
+		return ex.writeRowGroup()
+	}
+	return nil
+}
+
+func (ex *Exporter) writeRowGroup() error {
+	if ex.rows == nil || ex.rows.Len() == 0 {
+		return nil
+	}
+	rec := ex.rows.NewRecord()
+	defer rec.Release()
+	return ex.writer.Write(rec)
+}
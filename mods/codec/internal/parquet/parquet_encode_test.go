@@ -0,0 +1,48 @@
+package parquet_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	goarrow "github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet/file"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/machbase/neo-server/v8/mods/codec/internal/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParquetEncode(t *testing.T) {
+	out := &bytes.Buffer{}
+	enc := parquet.NewEncoder()
+	enc.SetOutputStream(out)
+	enc.SetColumns("name", "time", "value")
+	enc.SetColumnTypes("string", "datetime", "double")
+	require.NoError(t, enc.Open())
+
+	ts := time.Unix(0, 1670380342000000000).In(time.UTC)
+	for i := 0; i < 100; i++ {
+		require.NoError(t, enc.AddRow([]any{"my-car", ts.Add(time.Duration(i) * time.Second), float64(i)}))
+	}
+	enc.Close()
+	require.Equal(t, "application/vnd.apache.parquet", enc.ContentType())
+
+	pf, err := file.NewParquetReader(bytes.NewReader(out.Bytes()))
+	require.NoError(t, err)
+	defer pf.Close()
+	require.Equal(t, int64(100), pf.NumRows())
+
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{}, memory.DefaultAllocator)
+	require.NoError(t, err)
+	tbl, err := fr.ReadTable(context.Background())
+	require.NoError(t, err)
+	defer tbl.Release()
+
+	schema := tbl.Schema()
+	require.Equal(t, "name", schema.Field(0).Name)
+	require.Equal(t, goarrow.STRING, schema.Field(0).Type.ID())
+	require.Equal(t, goarrow.TIMESTAMP, schema.Field(1).Type.ID())
+	require.Equal(t, goarrow.FLOAT64, schema.Field(2).Type.ID())
+}
@@ -125,7 +125,7 @@ func BriefCount(count int) Option {
 
 // SetCharsetEncoding
 //
-//	mods/codec/internal/csv/csv_decode.go:43:1
+//	mods/codec/internal/csv/csv_decode.go:44:1
 type CanSetCharsetEncoding interface {
 	SetCharsetEncoding(charset encoding.Encoding)
 }
@@ -245,13 +245,16 @@ func ChartOption(opt string) Option {
 
 // SetColumnTypes
 //
+//	mods/codec/internal/arrow/arrow_decode.go:66:1
+//	mods/codec/internal/arrow/arrow_encode.go:65:1
 //	mods/codec/internal/box/box_encode.go:104:1
-//	mods/codec/internal/csv/csv_decode.go:81:1
+//	mods/codec/internal/csv/csv_decode.go:82:1
 //	mods/codec/internal/csv/csv_encode.go:97:1
-//	mods/codec/internal/json/json_decode.go:44:1
+//	mods/codec/internal/json/json_decode.go:50:1
 //	mods/codec/internal/json/json_encode.go:95:1
-//	mods/codec/internal/ndjson/decode.go:56:1
+//	mods/codec/internal/ndjson/decode.go:57:1
 //	mods/codec/internal/ndjson/encode.go:90:1
+//	mods/codec/internal/parquet/parquet_encode.go:65:1
 type CanSetColumnTypes interface {
 	SetColumnTypes(types ...api.DataType)
 }
@@ -266,13 +269,17 @@ func ColumnTypes(types ...api.DataType) Option {
 
 // SetColumns
 //
+//	mods/codec/internal/arrow/arrow_decode.go:62:1
+//	mods/codec/internal/arrow/arrow_encode.go:61:1
 //	mods/codec/internal/box/box_encode.go:100:1
-//	mods/codec/internal/csv/csv_decode.go:77:1
+//	mods/codec/internal/csv/csv_decode.go:78:1
 //	mods/codec/internal/csv/csv_encode.go:93:1
+//	mods/codec/internal/json/json_decode.go:46:1
 //	mods/codec/internal/json/json_encode.go:91:1
 //	mods/codec/internal/markdown/md_encode.go:65:1
-//	mods/codec/internal/ndjson/decode.go:48:1
+//	mods/codec/internal/ndjson/decode.go:49:1
 //	mods/codec/internal/ndjson/encode.go:86:1
+//	mods/codec/internal/parquet/parquet_encode.go:61:1
 //	mods/codec/internal/templ/templ.go:67:1
 type CanSetColumns interface {
 	SetColumns(names ...string)
@@ -318,7 +325,7 @@ func DataZoom(typ string, minPercentage float32, maxPercentage float32) Option {
 
 // SetDelimiter
 //
-//	mods/codec/internal/csv/csv_decode.go:68:1
+//	mods/codec/internal/csv/csv_decode.go:69:1
 //	mods/codec/internal/csv/csv_encode.go:88:1
 type CanSetDelimiter interface {
 	SetDelimiter(newDelimiter string)
@@ -395,7 +402,7 @@ func GridSize(args ...float64) Option {
 // SetHeader
 //
 //	mods/codec/internal/box/box_encode.go:80:1
-//	mods/codec/internal/csv/csv_decode.go:60:1
+//	mods/codec/internal/csv/csv_decode.go:61:1
 //	mods/codec/internal/csv/csv_encode.go:84:1
 //	mods/codec/internal/json/json_encode.go:83:1
 //	mods/codec/internal/ndjson/encode.go:78:1
@@ -413,7 +420,8 @@ func Header(show bool) Option {
 
 // SetHeaderColumns
 //
-//	mods/codec/internal/csv/csv_decode.go:64:1
+//	mods/codec/internal/arrow/arrow_decode.go:58:1
+//	mods/codec/internal/csv/csv_decode.go:65:1
 type CanSetHeaderColumns interface {
 	SetHeaderColumns(headerColumns bool)
 }
@@ -429,7 +437,7 @@ func HeaderColumns(headerColumns bool) Option {
 // SetHeading
 //
 //	mods/codec/internal/box/box_encode.go:84:1
-//	mods/codec/internal/csv/csv_decode.go:56:1
+//	mods/codec/internal/csv/csv_decode.go:57:1
 //	mods/codec/internal/csv/csv_encode.go:80:1
 //	mods/codec/internal/json/json_encode.go:87:1
 //	mods/codec/internal/ndjson/encode.go:82:1
@@ -492,9 +500,11 @@ func InitialLocation(latlon *nums.LatLon, zoomLevel int) Option {
 
 // SetInputStream
 //
-//	mods/codec/internal/csv/csv_decode.go:39:1
-//	mods/codec/internal/json/json_decode.go:28:1
-//	mods/codec/internal/ndjson/decode.go:32:1
+//	mods/codec/internal/arrow/arrow_decode.go:40:1
+//	mods/codec/internal/csv/csv_decode.go:40:1
+//	mods/codec/internal/json/json_decode.go:30:1
+//	mods/codec/internal/ndjson/decode.go:33:1
+//	mods/codec/internal/parquet/parquet_decode.go:27:1
 type CanSetInputStream interface {
 	SetInputStream(in io.Reader)
 }
@@ -616,6 +626,7 @@ func Opacity(opacity float64) Option {
 
 // SetOutputStream
 //
+//	mods/codec/internal/arrow/arrow_encode.go:46:1
 //	mods/codec/internal/box/box_encode.go:54:1
 //	mods/codec/internal/chart/chart.go:75:1
 //	mods/codec/internal/csv/csv_encode.go:55:1
@@ -623,6 +634,7 @@ func Opacity(opacity float64) Option {
 //	mods/codec/internal/json/json_encode.go:53:1
 //	mods/codec/internal/markdown/md_encode.go:61:1
 //	mods/codec/internal/ndjson/encode.go:48:1
+//	mods/codec/internal/parquet/parquet_encode.go:46:1
 //	mods/codec/internal/templ/templ.go:55:1
 type CanSetOutputStream interface {
 	SetOutputStream(o io.Writer)
@@ -672,11 +684,13 @@ func Precision(precision int) Option {
 
 // SetRownum
 //
+//	mods/codec/internal/arrow/arrow_encode.go:57:1
 //	mods/codec/internal/box/box_encode.go:76:1
 //	mods/codec/internal/csv/csv_encode.go:75:1
 //	mods/codec/internal/json/json_encode.go:79:1
 //	mods/codec/internal/markdown/md_encode.go:85:1
 //	mods/codec/internal/ndjson/encode.go:74:1
+//	mods/codec/internal/parquet/parquet_encode.go:57:1
 type CanSetRownum interface {
 	SetRownum(show bool)
 }
@@ -782,9 +796,10 @@ func Subtitle(str string) Option {
 
 // SetTableName
 //
-//	mods/codec/internal/csv/csv_decode.go:73:1
-//	mods/codec/internal/json/json_decode.go:40:1
-//	mods/codec/internal/ndjson/decode.go:44:1
+//	mods/codec/internal/arrow/arrow_decode.go:52:1
+//	mods/codec/internal/csv/csv_decode.go:74:1
+//	mods/codec/internal/json/json_decode.go:42:1
+//	mods/codec/internal/ndjson/decode.go:45:1
 type CanSetTableName interface {
 	SetTableName(tableName string)
 }
@@ -874,14 +889,17 @@ func TileTemplate(url string) Option {
 
 // SetTimeLocation
 //
+//	mods/codec/internal/arrow/arrow_decode.go:48:1
+//	mods/codec/internal/arrow/arrow_encode.go:50:1
 //	mods/codec/internal/box/box_encode.go:63:1
-//	mods/codec/internal/csv/csv_decode.go:51:1
+//	mods/codec/internal/csv/csv_decode.go:52:1
 //	mods/codec/internal/csv/csv_encode.go:63:1
-//	mods/codec/internal/json/json_decode.go:36:1
+//	mods/codec/internal/json/json_decode.go:38:1
 //	mods/codec/internal/json/json_encode.go:64:1
 //	mods/codec/internal/markdown/md_encode.go:73:1
-//	mods/codec/internal/ndjson/decode.go:40:1
+//	mods/codec/internal/ndjson/decode.go:41:1
 //	mods/codec/internal/ndjson/encode.go:59:1
+//	mods/codec/internal/parquet/parquet_encode.go:50:1
 type CanSetTimeLocation interface {
 	SetTimeLocation(tz *time.Location)
 }
@@ -896,14 +914,15 @@ func TimeLocation(tz *time.Location) Option {
 
 // SetTimeformat
 //
+//	mods/codec/internal/arrow/arrow_decode.go:44:1
 //	mods/codec/internal/box/box_encode.go:58:1
 //	mods/codec/internal/chart/chartcompat.go:285:1
-//	mods/codec/internal/csv/csv_decode.go:47:1
+//	mods/codec/internal/csv/csv_decode.go:48:1
 //	mods/codec/internal/csv/csv_encode.go:59:1
-//	mods/codec/internal/json/json_decode.go:32:1
+//	mods/codec/internal/json/json_decode.go:34:1
 //	mods/codec/internal/json/json_encode.go:57:1
 //	mods/codec/internal/markdown/md_encode.go:69:1
-//	mods/codec/internal/ndjson/decode.go:36:1
+//	mods/codec/internal/ndjson/decode.go:37:1
 //	mods/codec/internal/ndjson/encode.go:52:1
 type CanSetTimeformat interface {
 	SetTimeformat(f string)
@@ -17,6 +17,7 @@ import (
 )
 
 var scanDirs = []string{
+	"../internal/arrow",
 	"../internal/chart",
 	"../internal/box",
 	"../internal/csv",
@@ -25,6 +26,7 @@ var scanDirs = []string{
 	"../internal/markdown",
 	"../internal/geomap",
 	"../internal/templ",
+	"../internal/parquet",
 }
 
 // add function names here not to generate
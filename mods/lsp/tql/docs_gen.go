@@ -30,6 +30,35 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# ARGS\n\n## Kind\n\nstatement source\n\n## Category\n\ncontext source\n\n## Signatures\n\n```text\nARGS()\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| none | no | no | none | none |\n\n## Description\n\n`ARGS()` generates one record from values passed by a parent TQL flow. It is intended as the source of a sub-flow inside `WHEN(..., do(..., { ... }))`.\n\n## Examples\n\n### Use parent flow arguments\n\n```js\nFAKE(json({ [1, 'hello'], [2, 'world'] }))\nWHEN(value(0) == 2, do(value(0), strToUpper(value(1)), {\n    ARGS()\n    WHEN(true, doLog('OUTPUT:', value(0), value(1)))\n    DISCARD()\n}))\nCSV()\n```\n\n## Related\n\nargs, WHEN, do, doLog, DISCARD",
 		Related: []string{"args", "WHEN", "do", "doLog", "DISCARD"},
 	},
+	"ARROW": {
+		Label: "ARROW",
+		Kind: "statement sink",
+		Category: "binary encoder",
+		Signatures: []tqlDocSignature{
+			{Label: "ARROW(options...)", Parameters: []string{"options"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "options", Required: false, Repeat: true, Accepts: "helper", Suggestions: []string{"tz", "rownum"}},
+		},
+		Description: "`ARROW()` generates an Apache Arrow IPC stream (`application/vnd.apache.arrow.stream`). The schema is derived from the column names and types of the result, datetime columns are encoded as nanosecond timestamps, and the rows are written in record batches so that large results can be streamed to dataframe clients such as pandas or polars.",
+		Markdown: "# ARROW\n\n## Kind\n\nstatement sink\n\n## Category\n\nbinary encoder\n\n## Signatures\n\n```text\nARROW(options...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| options | no | yes | helper | tz, rownum |\n\n## Description\n\n`ARROW()` generates an Apache Arrow IPC stream (`application/vnd.apache.arrow.stream`). The schema is derived from the column names and types of the result, datetime columns are encoded as nanosecond timestamps, and the rows are written in record batches so that large results can be streamed to dataframe clients such as pandas or polars.\n\n## Examples\n\n### Arrow query output\n\n```js\nSQL(`select * from example where name = 'neo_load1' limit 100`)\nARROW(tz('UTC'))\n```\n\n## Related\n\nPARQUET, NDJSON, CSV, tz",
+		Related: []string{"PARQUET", "NDJSON", "CSV", "tz"},
+	},
+	"ASSERT": {
+		Label: "ASSERT",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "ASSERT(condition, message)", Parameters: []string{"condition", "message"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "condition", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value", "key", "param", "len"}},
+			{Name: "message", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'assertion failed'"}},
+		},
+		Description: "`ASSERT()` passes the current record to the next node if the condition is true. Otherwise the record is dropped and the script fails with the message. It is useful to verify the intermediate records of a script that is run by `machbase-neo tql test`.",
+		Markdown: "# ASSERT\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nASSERT(condition, message)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| condition | yes | no | expression | value, key, param, len |\n| message | yes | no | literal:string | 'assertion failed' |\n\n## Description\n\n`ASSERT()` passes the current record to the next node if the condition is true. Otherwise the record is dropped and the script fails with the message. It is useful to verify the intermediate records of a script that is run by `machbase-neo tql test`.\n\n## Examples\n\n### Verify the values\n\n```js\nFAKE(linspace(1, 3, 3))\nASSERT(value(0) >= 1, 'value should be >= 1')\nCSV()\n```\n\n## Related\n\nFILTER, value, key, param",
+		Related: []string{"FILTER", "value", "key", "param"},
+	},
 	"BOX": {
 		Label: "BOX",
 		Kind: "statement map",
@@ -56,6 +85,22 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# BOXPLOT\n\n## Kind\n\nstatement map\n\n## Category\n\nmaps stat\n\n## Signatures\n\n```text\nBOXPLOT(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nBOXPLOT()\n```\n\n## Related\n\nTODO",
 	},
+	"BRANCH": {
+		Label: "BRANCH",
+		Kind: "statement source",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "BRANCH(name)", Parameters: []string{"name"}},
+			{Label: "BRANCH(name, file(path))", Parameters: []string{"name", "file(path)"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "name", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'name'"}},
+			{Name: "file", Required: false, Repeat: false, Accepts: "file", Suggestions: []string{"file('/path')"}},
+		},
+		Description: "`BRANCH()` begins a branch that receives the records from `TEE()`. The branches follow the main pipeline, and each of them should end with its own sink. The output of the branch sink is discarded unless `file()` is given, in which case the output is written to the file. The database sinks like `APPEND()` and `INSERT()` work in the branches as they do in the main pipeline. A branch can feed only the branches defined after itself.",
+		Markdown: "# BRANCH\n\n## Kind\n\nstatement source\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\nBRANCH(name)\nBRANCH(name, file(path))\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | 'name' |\n| file | no | no | file | file('/path') |\n\n## Description\n\n`BRANCH()` begins a branch that receives the records from `TEE()`. The branches follow the main pipeline, and each of them should end with its own sink. The output of the branch sink is discarded unless `file()` is given, in which case the output is written to the file. The database sinks like `APPEND()` and `INSERT()` work in the branches as they do in the main pipeline. A branch can feed only the branches defined after itself.\n\n## Examples\n\n### Write a summary into a file\n\n```js\nFAKE(linspace(1, 10, 10))\nTEE('summary')\nCSV()\n\nBRANCH('summary', file('/summary.json'))\nGROUP( by('total'), sum(value(0)) )\nJSON()\n```\n\n## Related\n\nTEE, PIPE, file",
+		Related: []string{"TEE", "PIPE", "file"},
+	},
 	"BYTES": {
 		Label: "BYTES",
 		Kind: "statement source",
@@ -257,6 +302,19 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# DROP\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nDROP(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nDROP()\n```\n\n## Related\n\nTODO",
 	},
+	"ENVELOPE": {
+		Label: "ENVELOPE",
+		Kind: "statement map",
+		Category: "fourier transform",
+		Signatures: []tqlDocSignature{
+			{Label: "ENVELOPE()"},
+		},
+		Slots: []tqlDocSlot{
+		},
+		Description: "`ENVELOPE()` computes the amplitude envelope of the (time, value) samples that `GROUPBYKEY()` makes, which is the magnitude of the analytic signal by the Hilbert transform. It yields a record of the (time, envelope) tuples in the same shape of the input, so that the envelope spectrum for the bearing fault analysis is obtained by `FFT()` after band-pass filtering by `MAP_BANDPASS()`. The record that has fewer than 16 samples is dropped.",
+		Markdown: "# ENVELOPE\n\n## Kind\n\nstatement map\n\n## Category\n\nfourier transform\n\n## Signatures\n\n```text\nENVELOPE()\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| none | no | no | none | none |\n\n## Description\n\n`ENVELOPE()` computes the amplitude envelope of the (time, value) samples that `GROUPBYKEY()` makes, which is the magnitude of the analytic signal by the Hilbert transform. It yields a record of the (time, envelope) tuples in the same shape of the input, so that the envelope spectrum for the bearing fault analysis is obtained by `FFT()` after band-pass filtering by `MAP_BANDPASS()`. The record that has fewer than 16 samples is dropped.\n\n## Examples\n\n### Envelope spectrum\n\n```js\nFAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0), freq(200, 2.0)))\nMAP_BANDPASS(1, value(1), 150, 250, 1000)\nMAPKEY('samples')\nGROUPBYKEY()\nENVELOPE()\nFFT(maxHz(100))\nCSV(precision(4))\n```\n\n## Related\n\nFFT, MAP_BANDPASS, MAP_HIGHPASS",
+		Related: []string{"FFT", "MAP_BANDPASS", "MAP_HIGHPASS"},
+	},
 	"FAKE": {
 		Label: "FAKE",
 		Kind: "statement source",
@@ -422,6 +480,21 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# INSERT\n\n## Kind\n\nstatement sink\n\n## Category\n\ndatabase sink\n\n## Signatures\n\n```text\nINSERT(columns..., table)\nINSERT(bridge, columns..., table)\nINSERT(columns..., table, tag)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| bridge | no | no | helper:bridge | bridge |\n| columns | yes | yes | literal:string | column names |\n| table | yes | no | helper:table | table |\n| tag | no | no | helper:tag | tag |\n\n## Description\n\n`INSERT()` stores incoming records into a database table by executing an INSERT statement for each record. `tag()` can be used for tag tables, and `bridge()` can redirect inserts to a bridged database.\n\n## Examples\n\n### Insert records\n\n```js\nFAKE(json({\n    ['temperature', 1708582790, 23.45],\n    ['temperature', 1708582791, 24.56]\n}))\nMAPVALUE(1, value(1) * 1000000000)\nINSERT('name', 'time', 'value', table('example'))\n```\n\n### Insert with tag\n\n```js\nFAKE(json({ [1708582792, 32.34], [1708582793, 33.45] }))\nMAPVALUE(0, value(0) * 1000000000)\nINSERT('time', 'value', table('example'), tag('temperature'))\n```\n\n## Related\n\nbridge, table, tag, APPEND, PUSHVALUE, MAPVALUE",
 		Related: []string{"bridge", "table", "tag", "APPEND", "PUSHVALUE", "MAPVALUE"},
 	},
+	"JOIN": {
+		Label: "JOIN",
+		Kind: "statement map",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "JOIN(name, on(mainIdx, pipeIdx), tolerance(tol), 'inner' | 'left')", Parameters: []string{"name", "on(mainIdx", "pipeIdx)", "tolerance(tol)", "'left'"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "name", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'name'"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "expression", Suggestions: []string{"on", "tolerance", "'inner'", "'left'"}},
+		},
+		Description: "`JOIN()` aligns the records with the records of the named sub-pipeline by the join columns, and appends the values of the nearest record within the tolerance except the join column. Both of the pipelines should be in ascending order of the join columns. The join columns are `on(0, 0)` and the tolerance is `0` by default, the tolerance of time columns can be a duration like `tolerance('1s')`. The records that have no match are dropped by `'inner'` (default) or yielded with `NULL` values by `'left'`.",
+		Markdown: "# JOIN\n\n## Kind\n\nstatement map\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\nJOIN(name, on(mainIdx, pipeIdx), tolerance(tol), 'inner' | 'left')\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | 'name' |\n| options | no | yes | expression | on, tolerance, 'inner', 'left' |\n\n## Description\n\n`JOIN()` aligns the records with the records of the named sub-pipeline by the join columns, and appends the values of the nearest record within the tolerance except the join column. Both of the pipelines should be in ascending order of the join columns. The join columns are `on(0, 0)` and the tolerance is `0` by default, the tolerance of time columns can be a duration like `tolerance('1s')`. The records that have no match are dropped by `'inner'` (default) or yielded with `NULL` values by `'left'`.\n\n## Examples\n\n### Align two series within a second\n\n```js\nSQL('select time, value from example where name = ?', 'temp')\nPIPE('temp')\n\nSQL('select time, value from example where name = ?', 'humid')\nJOIN('temp', on(0), tolerance('1s'), 'left')\nCSV(timeformat('default'))\n```\n\n## Related\n\nPIPE, MERGE, UNION, on, tolerance",
+		Related: []string{"PIPE", "MERGE", "UNION", "on", "tolerance"},
+	},
 	"JSON": {
 		Label: "JSON",
 		Kind: "statement sink",
@@ -493,6 +566,44 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# MAP_AVG\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_AVG(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nMAP_AVG()\n```\n\n## Related\n\nTODO",
 	},
+	"MAP_BANDPASS": {
+		Label: "MAP_BANDPASS",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_BANDPASS(idx, value, low, high, sampleRate [, order])", Parameters: []string{"idx", "value", "low", "high", "sampleRate", "order"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "low", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"100"}},
+			{Name: "high", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"300"}},
+			{Name: "sampleRate", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"1000"}},
+			{Name: "order", Required: false, Repeat: false, Accepts: "literal:int", Suggestions: []string{"4"}},
+		},
+		Description: "`MAP_BANDPASS()` filters the value by the Butterworth band-pass filter that passes `low` ~ `high` Hz, and replaces the value at `idx` with the result. The filter is the cascade of the high-pass and the low-pass filters of `order` (default 4). `sampleRate` is the number of the samples per second, the values should be evenly spaced. A string option renames the column.",
+		Markdown: "# MAP_BANDPASS\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_BANDPASS(idx, value, low, high, sampleRate [, order])\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| low | yes | no | literal:float | 100 |\n| high | yes | no | literal:float | 300 |\n| sampleRate | yes | no | literal:float | 1000 |\n| order | no | no | literal:int | 4 |\n\n## Description\n\n`MAP_BANDPASS()` filters the value by the Butterworth band-pass filter that passes `low` ~ `high` Hz, and replaces the value at `idx` with the result. The filter is the cascade of the high-pass and the low-pass filters of `order` (default 4). `sampleRate` is the number of the samples per second, the values should be evenly spaced. A string option renames the column.\n\n## Examples\n\n### Isolate a band\n\n```js\nFAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0), freq(200, 2.0)))\nMAP_BANDPASS(1, value(1), 150, 250, 1000)\nCSV(precision(4))\n```\n\n## Related\n\nMAP_HIGHPASS, MAP_LOWPASS, ENVELOPE",
+		Related: []string{"MAP_HIGHPASS", "MAP_LOWPASS", "ENVELOPE"},
+	},
+	"MAP_CUSUM": {
+		Label: "MAP_CUSUM",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_CUSUM(idx, value, baseline, k, h, ...)", Parameters: []string{"idx", "value", "baseline", "k", "h"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "baseline", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"20"}},
+			{Name: "k", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"0.5"}},
+			{Name: "h", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"5.0"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "noWait(), lazy(), literal:string", Suggestions: []string{"noWait(true)"}},
+		},
+		Description: "`MAP_CUSUM()` is the two-sided cumulative sum control chart for the change-point detection. The first `baseline` values establish the mean and the standard deviation, then the standardized deviations larger than the slack `k` are accumulated. The score is the upper sum, or the negative lower sum if it is larger, and the flag is `|score| > h`. The sums restart after a change is flagged. The score and the flag columns are inserted at `idx`, the score is `NULL` during the baseline unless `noWait(true)`. With `lazy(true)` the whole series is the baseline.",
+		Markdown: "# MAP_CUSUM\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_CUSUM(idx, value, baseline, k, h, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| baseline | yes | no | literal:int | 20 |\n| k | yes | no | literal:float | 0.5 |\n| h | yes | no | literal:float | 5.0 |\n| options | no | yes | noWait(), lazy(), literal:string | noWait(true) |\n\n## Description\n\n`MAP_CUSUM()` is the two-sided cumulative sum control chart for the change-point detection. The first `baseline` values establish the mean and the standard deviation, then the standardized deviations larger than the slack `k` are accumulated. The score is the upper sum, or the negative lower sum if it is larger, and the flag is `|score| > h`. The sums restart after a change is flagged. The score and the flag columns are inserted at `idx`, the score is `NULL` during the baseline unless `noWait(true)`. With `lazy(true)` the whole series is the baseline.\n\n## Examples\n\n### Change point\n\n```js\nFAKE( linspace(0, 1, 50) )\nMAPVALUE(0, value(0) < 0.6 ? 1.0 + simplex(1, value(0)*10)*0.1 : 1.3)\nMAP_CUSUM(1, value(0), 20, 0.5, 5.0)\nCSV( precision(2) )\n```\n\n## Related\n\nMAP_EWMA, MAP_ZSCORE, noWait, lazy",
+		Related: []string{"MAP_EWMA", "MAP_ZSCORE", "noWait", "lazy"},
+	},
 	"MAP_DIFF": {
 		Label: "MAP_DIFF",
 		Kind: "statement map",
@@ -519,6 +630,42 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# MAP_DISTANCE\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_DISTANCE(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nMAP_DISTANCE()\n```\n\n## Related\n\nTODO",
 	},
+	"MAP_EWMA": {
+		Label: "MAP_EWMA",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_EWMA(idx, value, lambda, threshold, ...)", Parameters: []string{"idx", "value", "lambda", "threshold"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "lambda", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"0.2"}},
+			{Name: "threshold", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"3.0"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "noWait(), lazy(), literal:string", Suggestions: []string{"noWait(true)"}},
+		},
+		Description: "`MAP_EWMA()` is the exponentially weighted moving average control chart with the smoothing factor `0 < lambda <= 1`. The score is the distance of the moving average from the mean of the values so far, in the unit of the standard deviation of the moving average, it flags the small but persistent shifts. The score and the boolean flag `|score| > threshold` columns are inserted at `idx`. The score is `NULL` until `1/lambda` values are seen unless `noWait(true)`. With `lazy(true)` the moving average is compared with the mean of the whole series.",
+		Markdown: "# MAP_EWMA\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_EWMA(idx, value, lambda, threshold, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| lambda | yes | no | literal:float | 0.2 |\n| threshold | yes | no | literal:float | 3.0 |\n| options | no | yes | noWait(), lazy(), literal:string | noWait(true) |\n\n## Description\n\n`MAP_EWMA()` is the exponentially weighted moving average control chart with the smoothing factor `0 < lambda <= 1`. The score is the distance of the moving average from the mean of the values so far, in the unit of the standard deviation of the moving average, it flags the small but persistent shifts. The score and the boolean flag `|score| > threshold` columns are inserted at `idx`. The score is `NULL` until `1/lambda` values are seen unless `noWait(true)`. With `lazy(true)` the moving average is compared with the mean of the whole series.\n\n## Examples\n\n### Level shift\n\n```js\nFAKE( linspace(0, 1, 50) )\nMAPVALUE(0, value(0) < 0.6 ? 1.0 + simplex(1, value(0)*10)*0.1 : 1.5)\nMAP_EWMA(1, value(0), 0.2, 3.0)\nCSV( precision(2) )\n```\n\n## Related\n\nMAP_ZSCORE, MAP_CUSUM, noWait, lazy",
+		Related: []string{"MAP_ZSCORE", "MAP_CUSUM", "noWait", "lazy"},
+	},
+	"MAP_HIGHPASS": {
+		Label: "MAP_HIGHPASS",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_HIGHPASS(idx, value, cutoff, sampleRate [, order])", Parameters: []string{"idx", "value", "cutoff", "sampleRate", "order"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "cutoff", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"10"}},
+			{Name: "sampleRate", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"1000"}},
+			{Name: "order", Required: false, Repeat: false, Accepts: "literal:int", Suggestions: []string{"4"}},
+		},
+		Description: "`MAP_HIGHPASS()` filters the value by the Butterworth high-pass filter of the `cutoff` frequency (Hz) and `order` (default 4), and replaces the value at `idx` with the result. `sampleRate` is the number of the samples per second, the values should be evenly spaced. The filter is designed by the bilinear transform, and its state is carried over the records. A string option renames the column.",
+		Markdown: "# MAP_HIGHPASS\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_HIGHPASS(idx, value, cutoff, sampleRate [, order])\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| cutoff | yes | no | literal:float | 10 |\n| sampleRate | yes | no | literal:float | 1000 |\n| order | no | no | literal:int | 4 |\n\n## Description\n\n`MAP_HIGHPASS()` filters the value by the Butterworth high-pass filter of the `cutoff` frequency (Hz) and `order` (default 4), and replaces the value at `idx` with the result. `sampleRate` is the number of the samples per second, the values should be evenly spaced. The filter is designed by the bilinear transform, and its state is carried over the records. A string option renames the column.\n\n## Examples\n\n### Remove the drift\n\n```js\nFAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0), freq(200, 2.0)))\nMAP_HIGHPASS(1, value(1), 100, 1000)\nCSV(precision(4))\n```\n\n## Related\n\nMAP_BANDPASS, MAP_LOWPASS, ENVELOPE",
+		Related: []string{"MAP_BANDPASS", "MAP_LOWPASS", "ENVELOPE"},
+	},
 	"MAP_KALMAN": {
 		Label: "MAP_KALMAN",
 		Kind: "statement map",
@@ -545,6 +692,24 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# MAP_LOWPASS\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_LOWPASS(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nMAP_LOWPASS()\n```\n\n## Related\n\nTODO",
 	},
+	"MAP_MAD": {
+		Label: "MAP_MAD",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_MAD(idx, value, window, threshold, ...)", Parameters: []string{"idx", "value", "window", "threshold"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "window", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"10"}},
+			{Name: "threshold", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"3.5"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "noWait(), lazy(), literal:string", Suggestions: []string{"noWait(true)"}},
+		},
+		Description: "`MAP_MAD()` scores the value against the median and the median absolute deviation of the previous `window` values, the modified z-score `0.6745 * (value - median) / MAD`. Unlike `MAP_ZSCORE()`, the outliers in the window do not inflate the scale. The score and the boolean flag `|score| > threshold` columns are inserted at `idx`, the threshold 3.5 is commonly used. The score is `NULL` until the window is filled unless `noWait(true)`, `lazy(true)` scores the records against the whole series at the end of the stream.",
+		Markdown: "# MAP_MAD\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_MAD(idx, value, window, threshold, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| window | yes | no | literal:int | 10 |\n| threshold | yes | no | literal:float | 3.5 |\n| options | no | yes | noWait(), lazy(), literal:string | noWait(true) |\n\n## Description\n\n`MAP_MAD()` scores the value against the median and the median absolute deviation of the previous `window` values, the modified z-score `0.6745 * (value - median) / MAD`. Unlike `MAP_ZSCORE()`, the outliers in the window do not inflate the scale. The score and the boolean flag `|score| > threshold` columns are inserted at `idx`, the threshold 3.5 is commonly used. The score is `NULL` until the window is filled unless `noWait(true)`, `lazy(true)` scores the records against the whole series at the end of the stream.\n\n## Examples\n\n### Robust outliers\n\n```js\nFAKE( json({[1.0], [1.1], [0.9], [1.0], [1.2], [9.0], [1.1]}) )\nMAP_MAD(1, value(0), 5, 3.5)\nCSV( precision(2) )\n```\n\n## Related\n\nMAP_ZSCORE, MAP_SEASONAL, noWait, lazy",
+		Related: []string{"MAP_ZSCORE", "MAP_SEASONAL", "noWait", "lazy"},
+	},
 	"MAP_MOVAVG": {
 		Label: "MAP_MOVAVG",
 		Kind: "statement map",
@@ -571,6 +736,43 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# MAP_NONEGDIFF\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_NONEGDIFF(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nMAP_NONEGDIFF()\n```\n\n## Related\n\nTODO",
 	},
+	"MAP_SEASONAL": {
+		Label: "MAP_SEASONAL",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_SEASONAL(idx, value, period, cycles, threshold, ...)", Parameters: []string{"idx", "value", "period", "cycles", "threshold"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "period", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"24"}},
+			{Name: "cycles", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"3"}},
+			{Name: "threshold", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"3.5"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "noWait(), lazy(), literal:string", Suggestions: []string{"noWait(true)"}},
+		},
+		Description: "`MAP_SEASONAL()` decomposes the series into the trend, the seasonal and the residual components like STL, and scores the residual robustly by the median absolute deviation, so that the values those are normal for the time of the cycle are not flagged. `period` is the number of the values in a cycle, and the seasonal component of each phase is the median of the last `cycles` cycles. The score and the boolean flag `|score| > threshold` columns are inserted at `idx`. The score is `NULL` until `cycles` cycles are seen unless `noWait(true)`. With `lazy(true)` the whole series is decomposed at the end of the stream.",
+		Markdown: "# MAP_SEASONAL\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_SEASONAL(idx, value, period, cycles, threshold, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| period | yes | no | literal:int | 24 |\n| cycles | yes | no | literal:int | 3 |\n| threshold | yes | no | literal:float | 3.5 |\n| options | no | yes | noWait(), lazy(), literal:string | noWait(true) |\n\n## Description\n\n`MAP_SEASONAL()` decomposes the series into the trend, the seasonal and the residual components like STL, and scores the residual robustly by the median absolute deviation, so that the values those are normal for the time of the cycle are not flagged. `period` is the number of the values in a cycle, and the seasonal component of each phase is the median of the last `cycles` cycles. The score and the boolean flag `|score| > threshold` columns are inserted at `idx`. The score is `NULL` until `cycles` cycles are seen unless `noWait(true)`. With `lazy(true)` the whole series is decomposed at the end of the stream.\n\n## Examples\n\n### Hourly cycle of a day\n\n```js\nFAKE( arrange(0, 95, 1) )\nMAPVALUE(0, value(0) == 60 ? 30 : 10 + 5 * sin(2 * PI * mod(value(0), 24) / 24))\nMAP_SEASONAL(1, value(0), 24, 2, 3.5)\nCSV( precision(2) )\n```\n\n## Related\n\nMAP_MAD, MAP_ZSCORE, noWait, lazy",
+		Related: []string{"MAP_MAD", "MAP_ZSCORE", "noWait", "lazy"},
+	},
+	"MAP_ZSCORE": {
+		Label: "MAP_ZSCORE",
+		Kind: "statement map",
+		Category: "map monad",
+		Signatures: []tqlDocSignature{
+			{Label: "MAP_ZSCORE(idx, value, window, threshold, ...)", Parameters: []string{"idx", "value", "window", "threshold"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "idx", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"0"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+			{Name: "window", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"10"}},
+			{Name: "threshold", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"3.0"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "noWait(), lazy(), literal:string", Suggestions: []string{"noWait(true)"}},
+		},
+		Description: "`MAP_ZSCORE()` scores the value against the mean and the standard deviation of the previous `window` values, and inserts the score and the boolean flag `|score| > threshold` columns at `idx`. The score is `NULL` until the window is filled, `noWait(true)` scores the values as soon as possible. With `lazy(true)` the records are held until the end of the stream and scored against the mean and the standard deviation of the whole series. A string option renames the score column.",
+		Markdown: "# MAP_ZSCORE\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nMAP_ZSCORE(idx, value, window, threshold, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| idx | yes | no | literal:int | 0 |\n| value | yes | no | expression | value(0) |\n| window | yes | no | literal:int | 10 |\n| threshold | yes | no | literal:float | 3.0 |\n| options | no | yes | noWait(), lazy(), literal:string | noWait(true) |\n\n## Description\n\n`MAP_ZSCORE()` scores the value against the mean and the standard deviation of the previous `window` values, and inserts the score and the boolean flag `|score| > threshold` columns at `idx`. The score is `NULL` until the window is filled, `noWait(true)` scores the values as soon as possible. With `lazy(true)` the records are held until the end of the stream and scored against the mean and the standard deviation of the whole series. A string option renames the score column.\n\n## Examples\n\n### Rolling z-score\n\n```js\nFAKE( json({[1.0], [1.1], [0.9], [1.0], [1.2], [9.0], [1.1]}) )\nMAP_ZSCORE(1, value(0), 5, 3.0)\nCSV( precision(2) )\n```\n\n## Related\n\nMAP_EWMA, MAP_MAD, MAP_CUSUM, MAP_SEASONAL, noWait, lazy",
+		Related: []string{"MAP_EWMA", "MAP_MAD", "MAP_CUSUM", "MAP_SEASONAL", "noWait", "lazy"},
+	},
 	"MARKDOWN": {
 		Label: "MARKDOWN",
 		Kind: "statement sink",
@@ -585,6 +787,21 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# MARKDOWN\n\n## Kind\n\nstatement sink\n\n## Category\n\ntext encoder\n\n## Signatures\n\n```text\nMARKDOWN(options...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| options | no | yes | helper | tz, sqlTimeformat, ansiTimeformat |\n\n## Description\n\n`MARKDOWN()` renders incoming records as a Markdown table, or as HTML when the HTML option is enabled. It can also omit long result rows with brief output options.\n\n## Examples\n\n### Markdown table\n\n```js\nFAKE(csv(`\n10,The first line\n20,2nd line\n30,Third line\n`))\nMARKDOWN()\n```\n\n## Related\n\nHTML, TEXT, CSV, tz, sqlTimeformat, ansiTimeformat",
 		Related: []string{"HTML", "TEXT", "CSV", "tz", "sqlTimeformat", "ansiTimeformat"},
 	},
+	"MERGE": {
+		Label: "MERGE",
+		Kind: "statement map",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "MERGE(name, on(mainIdx, pipeIdx))", Parameters: []string{"name", "on(mainIdx", "pipeIdx)"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "name", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'name'"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "expression", Suggestions: []string{"on"}},
+		},
+		Description: "`MERGE()` yields the records and the records of the named sub-pipeline in ascending order of the merge columns, `on(0, 0)` by default. Both of the pipelines should be in ascending order of the merge columns. If the columns are equal, the record of the current pipeline goes first.",
+		Markdown: "# MERGE\n\n## Kind\n\nstatement map\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\nMERGE(name, on(mainIdx, pipeIdx))\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | 'name' |\n| options | no | yes | expression | on |\n\n## Description\n\n`MERGE()` yields the records and the records of the named sub-pipeline in ascending order of the merge columns, `on(0, 0)` by default. Both of the pipelines should be in ascending order of the merge columns. If the columns are equal, the record of the current pipeline goes first.\n\n## Examples\n\n### Merge two series\n\n```js\nFAKE(linspace(0, 8, 5))\nPIPE('even')\n\nFAKE(linspace(1, 9, 5))\nMERGE('even')\nCSV()\n```\n\n## Related\n\nPIPE, JOIN, UNION, on",
+		Related: []string{"PIPE", "JOIN", "UNION", "on"},
+	},
 	"NDJSON": {
 		Label: "NDJSON",
 		Kind: "statement sink",
@@ -599,6 +816,49 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# NDJSON\n\n## Kind\n\nstatement sink\n\n## Category\n\njson encoder\n\n## Signatures\n\n```text\nNDJSON(options...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| options | no | yes | helper | tz, sqlTimeformat, ansiTimeformat, cache |\n\n## Description\n\n`NDJSON()` generates newline-delimited JSON, where each output line is a complete JSON object. It is useful for large or streaming datasets and terminates output with two consecutive newlines.\n\n## Examples\n\n### NDJSON query output\n\n```js\nSQL(`select * from example where name = 'neo_load1' limit 3`)\nNDJSON(sqlTimeformat('DEFAULT'), tz('local'), rownum(true))\n```\n\n## Related\n\nJSON, CSV, tz, sqlTimeformat, ansiTimeformat, cache",
 		Related: []string{"JSON", "CSV", "tz", "sqlTimeformat", "ansiTimeformat", "cache"},
 	},
+	"PARQUET": {
+		Label: "PARQUET",
+		Kind: "statement sink",
+		Category: "binary encoder",
+		Signatures: []tqlDocSignature{
+			{Label: "PARQUET(options...)", Parameters: []string{"options"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "options", Required: false, Repeat: true, Accepts: "helper", Suggestions: []string{"tz", "rownum"}},
+		},
+		Description: "`PARQUET()` generates an Apache Parquet file (`application/vnd.apache.parquet`) compressed with snappy. The schema is derived from the column names and types of the result and datetime columns are encoded as nanosecond timestamps. Since a parquet file is readable only after its footer is written, the output is complete when the task finishes.",
+		Markdown: "# PARQUET\n\n## Kind\n\nstatement sink\n\n## Category\n\nbinary encoder\n\n## Signatures\n\n```text\nPARQUET(options...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| options | no | yes | helper | tz, rownum |\n\n## Description\n\n`PARQUET()` generates an Apache Parquet file (`application/vnd.apache.parquet`) compressed with snappy. The schema is derived from the column names and types of the result and datetime columns are encoded as nanosecond timestamps. Since a parquet file is readable only after its footer is written, the output is complete when the task finishes.\n\n## Examples\n\n### Parquet query output\n\n```js\nSQL(`select * from example where name = 'neo_load1' limit 100`)\nPARQUET()\n```\n\n## Related\n\nARROW, CSV, tz",
+		Related: []string{"ARROW", "CSV", "tz"},
+	},
+	"PEAKS": {
+		Label: "PEAKS",
+		Kind: "statement map",
+		Category: "fourier transform",
+		Signatures: []tqlDocSignature{
+			{Label: "PEAKS(minHeight [, minDistance])", Parameters: []string{"minHeight", "minDistance"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "minHeight", Required: true, Repeat: false, Accepts: "literal:float", Suggestions: []string{"0.5"}},
+			{Name: "minDistance", Required: false, Repeat: false, Accepts: "literal:int", Suggestions: []string{"3"}},
+		},
+		Description: "`PEAKS()` keeps the tuples whose second element is a local maximum not less than `minHeight`, e.g. the (hz, amplitude) tuples of `FFT()` or the (time, value) samples of `GROUPBYKEY()`. A plateau is a peak at its first tuple. If `minDistance` is given, the lower peaks those are closer than `minDistance` tuples to a higher peak are removed.",
+		Markdown: "# PEAKS\n\n## Kind\n\nstatement map\n\n## Category\n\nfourier transform\n\n## Signatures\n\n```text\nPEAKS(minHeight [, minDistance])\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| minHeight | yes | no | literal:float | 0.5 |\n| minDistance | no | no | literal:int | 3 |\n\n## Description\n\n`PEAKS()` keeps the tuples whose second element is a local maximum not less than `minHeight`, e.g. the (hz, amplitude) tuples of `FFT()` or the (time, value) samples of `GROUPBYKEY()`. A plateau is a peak at its first tuple. If `minDistance` is given, the lower peaks those are closer than `minDistance` tuples to a higher peak are removed.\n\n## Examples\n\n### Dominant frequencies\n\n```js\nFAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0), freq(200, 2.0)))\nMAPKEY('samples')\nGROUPBYKEY()\nFFT(maxHz(500))\nPEAKS(0.5)\nFLATTEN()\nPOPKEY()\nCSV(precision(2))\n```\n\n## Related\n\nFFT, PSD",
+		Related: []string{"FFT", "PSD"},
+	},
+	"PIPE": {
+		Label: "PIPE",
+		Kind: "statement sink",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "PIPE(name)", Parameters: []string{"name"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "name", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'name'"}},
+		},
+		Description: "`PIPE()` ends a named sub-pipeline. The statements from the previous `PIPE()` (or the beginning of the script) to `PIPE()` run concurrently with the main pipeline, and the records are consumed by `JOIN()`, `MERGE()` or `UNION()` of the pipelines that follow. A sub-pipeline can be consumed only once, and it can refer to the sub-pipelines defined before itself only. The last pipeline of the script is the main pipeline that should end with a sink.",
+		Markdown: "# PIPE\n\n## Kind\n\nstatement sink\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\nPIPE(name)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | 'name' |\n\n## Description\n\n`PIPE()` ends a named sub-pipeline. The statements from the previous `PIPE()` (or the beginning of the script) to `PIPE()` run concurrently with the main pipeline, and the records are consumed by `JOIN()`, `MERGE()` or `UNION()` of the pipelines that follow. A sub-pipeline can be consumed only once, and it can refer to the sub-pipelines defined before itself only. The last pipeline of the script is the main pipeline that should end with a sink.\n\n## Examples\n\n### Join two sources\n\n```js\nFAKE(linspace(0, 4, 5))\nMAPVALUE(1, value(0) * 10)\nPIPE('tens')\n\nFAKE(linspace(0, 4, 5))\nJOIN('tens', on(0))\nCSV()\n```\n\n## Related\n\nJOIN, MERGE, UNION",
+		Related: []string{"JOIN", "MERGE", "UNION"},
+	},
 	"POPKEY": {
 		Label: "POPKEY",
 		Kind: "statement map",
@@ -625,6 +885,21 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# POPVALUE\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nPOPVALUE(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nPOPVALUE()\n```\n\n## Related\n\nTODO",
 	},
+	"PSD": {
+		Label: "PSD",
+		Kind: "statement map",
+		Category: "fourier transform",
+		Signatures: []tqlDocSignature{
+			{Label: "PSD(size, ...)", Parameters: []string{"size"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "size", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"256"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "minHz(), maxHz()", Suggestions: []string{"maxHz(500)"}},
+		},
+		Description: "`PSD()` estimates the one-sided power spectral density (unit²/Hz) of the (time, value) samples that `GROUPBYKEY()` makes, by the Welch's method that averages the periodograms of the Hann windowed segments of `size` samples with 50% overlap. Compared with `FFT()`, the estimate is less noisy at the cost of the frequency resolution `sampleRate/size`. It yields a record of the (hz, psd) tuples excluding DC, `FLATTEN()` makes a record of each tuple. The record that has fewer than 16 samples is dropped.",
+		Markdown: "# PSD\n\n## Kind\n\nstatement map\n\n## Category\n\nfourier transform\n\n## Signatures\n\n```text\nPSD(size, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| size | yes | no | literal:int | 256 |\n| options | no | yes | minHz(), maxHz() | maxHz(500) |\n\n## Description\n\n`PSD()` estimates the one-sided power spectral density (unit²/Hz) of the (time, value) samples that `GROUPBYKEY()` makes, by the Welch's method that averages the periodograms of the Hann windowed segments of `size` samples with 50% overlap. Compared with `FFT()`, the estimate is less noisy at the cost of the frequency resolution `sampleRate/size`. It yields a record of the (hz, psd) tuples excluding DC, `FLATTEN()` makes a record of each tuple. The record that has fewer than 16 samples is dropped.\n\n## Examples\n\n### Power spectral density\n\n```js\nFAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0), freq(200, 2.0)))\nMAPKEY('samples')\nGROUPBYKEY()\nPSD(256, maxHz(300))\nFLATTEN()\nPOPKEY()\nCSV(precision(4))\n```\n\n## Related\n\nFFT, SPECTROGRAM, PEAKS, minHz, maxHz",
+		Related: []string{"FFT", "SPECTROGRAM", "PEAKS", "minHz", "maxHz"},
+	},
 	"PUSHKEY": {
 		Label: "PUSHKEY",
 		Kind: "statement map",
@@ -722,6 +997,21 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# SET\n\n## Kind\n\nstatement map\n\n## Category\n\ncontext\n\n## Signatures\n\n```text\nSET(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nSET()\n```\n\n## Related\n\nTODO",
 	},
+	"SET_STATE": {
+		Label: "SET_STATE",
+		Kind: "statement map",
+		Category: "context",
+		Signatures: []tqlDocSignature{
+			{Label: "SET_STATE(key, value)", Parameters: []string{"key", "value"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "key", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"state key"}},
+			{Name: "value", Required: true, Repeat: false, Accepts: "any", Suggestions: []string{"value(0)"}},
+		},
+		Description: "`SET_STATE()` passes the records to the next as they are, and keeps the value of the last record as the new state of the key. When the script runs by a timer schedule, the new states are saved only if the script finishes successfully, so that a failed run starts over from the last successful checkpoint on the next run. The value should be a time, number, boolean or string, `nil` removes the key. If no record passes through, the state of the key is not changed.",
+		Markdown: "# SET_STATE\n\n## Kind\n\nstatement map\n\n## Category\n\ncontext\n\n## Signatures\n\n```text\nSET_STATE(key, value)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| key | yes | no | literal:string | state key |\n| value | yes | no | any | value(0) |\n\n## Description\n\n`SET_STATE()` passes the records to the next as they are, and keeps the value of the last record as the new state of the key. When the script runs by a timer schedule, the new states are saved only if the script finishes successfully, so that a failed run starts over from the last successful checkpoint on the next run. The value should be a time, number, boolean or string, `nil` removes the key. If no record passes through, the state of the key is not changed.\n\n## Examples\n\n### Incremental aggregation\n\n```js\nSQL('select name, time, value from example where time > ? order by time', state('last', 0))\nSET_STATE('last', value(1))\nGROUP( by(value(0)), avg(value(2)) )\nAPPEND(table('example_avg'))\n```\n\n## Related\n\nstate, param",
+		Related: []string{"state", "param"},
+	},
 	"SHELL": {
 		Label: "SHELL",
 		Draft: true,
@@ -736,6 +1026,22 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# SHELL\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nSHELL(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nSHELL()\n```\n\n## Related\n\nTODO",
 	},
+	"SPECTROGRAM": {
+		Label: "SPECTROGRAM",
+		Kind: "statement map",
+		Category: "fourier transform",
+		Signatures: []tqlDocSignature{
+			{Label: "SPECTROGRAM(size, hop, ...)", Parameters: []string{"size", "hop"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "size", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"256"}},
+			{Name: "hop", Required: true, Repeat: false, Accepts: "literal:int", Suggestions: []string{"128"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "minHz(), maxHz()", Suggestions: []string{"maxHz(500)"}},
+		},
+		Description: "`SPECTROGRAM()` takes the record of the (time, value) samples that `GROUPBYKEY()` makes, like `FFT()`, and computes the short-time Fourier transform of the Hann windowed segments of `size` samples at every `hop` samples. It yields a record of the (time, hz, amplitude) tuples, where the time is the center of the segment. The amplitude is corrected by the gain of the window, so that a sinusoid of amplitude A shows the peak A. `FLATTEN()` turns the tuples into the records those `CHART_SURFACE3D()` and the heatmap charts take. The samples should be evenly spaced, the record that has fewer samples than `size` is dropped.",
+		Markdown: "# SPECTROGRAM\n\n## Kind\n\nstatement map\n\n## Category\n\nfourier transform\n\n## Signatures\n\n```text\nSPECTROGRAM(size, hop, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| size | yes | no | literal:int | 256 |\n| hop | yes | no | literal:int | 128 |\n| options | no | yes | minHz(), maxHz() | maxHz(500) |\n\n## Description\n\n`SPECTROGRAM()` takes the record of the (time, value) samples that `GROUPBYKEY()` makes, like `FFT()`, and computes the short-time Fourier transform of the Hann windowed segments of `size` samples at every `hop` samples. It yields a record of the (time, hz, amplitude) tuples, where the time is the center of the segment. The amplitude is corrected by the gain of the window, so that a sinusoid of amplitude A shows the peak A. `FLATTEN()` turns the tuples into the records those `CHART_SURFACE3D()` and the heatmap charts take. The samples should be evenly spaced, the record that has fewer samples than `size` is dropped.\n\n## Examples\n\n### Spectrogram\n\n```js\nFAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0), freq(200, 2.0)))\nMAPKEY('samples')\nGROUPBYKEY()\nSPECTROGRAM(200, 100, maxHz(300))\nFLATTEN()\nPOPKEY()\nCHART_SURFACE3D()\n```\n\n## Related\n\nFFT, PSD, FLATTEN, CHART_SURFACE3D, minHz, maxHz",
+		Related: []string{"FFT", "PSD", "FLATTEN", "CHART_SURFACE3D", "minHz", "maxHz"},
+	},
 	"SQL": {
 		Label: "SQL",
 		Kind: "statement source",
@@ -787,6 +1093,23 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# STRING\n\n## Kind\n\nstatement source\n\n## Category\n\nstring source\n\n## Signatures\n\n```text\nSTRING(src)\nSTRING(src, options...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| src | yes | no | stream|string|helper:file|helper:payload | payload, file |\n| options | no | yes | helper | separator, trimspace |\n\n## Description\n\n`STRING()` splits input text by separator and yields records with string values. Without a separator it reads the whole input as one record; with `trimspace(true)` it trims spaces from each split value.\n\n## Examples\n\n### Split lines\n\n```js\nSTRING(payload() ?? `12345\n    23456\n    78901`, separator('\\n'), trimspace(true))\nCSV()\n```\n\n### Read remote text\n\n```js\nSTRING(file(`http://example.com/data/words.txt`), separator('\\n'))\nCSV()\n```\n\n## Related\n\nBYTES, file, payload, separator, trimspace",
 		Related: []string{"BYTES", "file", "payload", "separator", "trimspace"},
 	},
+	"SUBSCRIBE": {
+		Label: "SUBSCRIBE",
+		Kind: "statement source",
+		Category: "bridge",
+		Signatures: []tqlDocSignature{
+			{Label: "SUBSCRIBE(topic [, limit(), duration()])", Parameters: []string{"topic", "limit()", "duration()"}},
+			{Label: "SUBSCRIBE(bridge, topic [, limit(), duration()])", Parameters: []string{"bridge", "topic", "limit()", "duration()"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "bridge", Required: false, Repeat: false, Accepts: "helper", Suggestions: []string{"bridge"}},
+			{Name: "topic", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'sensor/#'"}},
+			{Name: "options", Required: false, Repeat: true, Accepts: "helper", Suggestions: []string{"limit", "duration"}},
+		},
+		Description: "`SUBSCRIBE()` produces a record of `TIME`, `TOPIC` and `PAYLOAD` for every message of the topic as it arrives. Without a bridge it subscribes the topic filter of the built-in MQTT broker; with `bridge()` it subscribes the topic of the MQTT or NATS bridge.\n\nThe task keeps running until `limit(count)` messages are received, the `duration()` is elapsed, or the task is cancelled, for example when the HTTP client is disconnected. `limit(offset, count)` skips the first offset messages. The sink flushes every record, so `NDJSON()` and `CSV()` stream the records over the chunked HTTP response. The messages are dropped with a warning log if the task is slower than the topic.",
+		Markdown: "# SUBSCRIBE\n\n## Kind\n\nstatement source\n\n## Category\n\nbridge\n\n## Signatures\n\n```text\nSUBSCRIBE(topic [, limit(), duration()])\nSUBSCRIBE(bridge, topic [, limit(), duration()])\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| bridge | no | no | helper | bridge |\n| topic | yes | no | literal:string | 'sensor/#' |\n| options | no | yes | helper | limit, duration |\n\n## Description\n\n`SUBSCRIBE()` produces a record of `TIME`, `TOPIC` and `PAYLOAD` for every message of the topic as it arrives. Without a bridge it subscribes the topic filter of the built-in MQTT broker; with `bridge()` it subscribes the topic of the MQTT or NATS bridge.\n\nThe task keeps running until `limit(count)` messages are received, the `duration()` is elapsed, or the task is cancelled, for example when the HTTP client is disconnected. `limit(offset, count)` skips the first offset messages. The sink flushes every record, so `NDJSON()` and `CSV()` stream the records over the chunked HTTP response. The messages are dropped with a warning log if the task is slower than the topic.\n\n## Examples\n\n### Built-in broker\n\n```js\nSUBSCRIBE('sensor/#', duration('10m'))\nNDJSON(timeformat('rfc3339'))\n```\n\n### MQTT bridge\n\n```js\nSUBSCRIBE(bridge('my_mqtt'), 'factory/+/temp', limit(100))\nMAPVALUE(2, parseFloat(value(2)))\nCSV()\n```\n\n## Related\n\nbridge, limit, duration, NDJSON, CSV",
+		Related: []string{"bridge", "limit", "duration", "NDJSON", "CSV"},
+	},
 	"TAKE": {
 		Label: "TAKE",
 		Kind: "statement map",
@@ -800,6 +1123,20 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# TAKE\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nTAKE(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nTAKE()\n```\n\n## Related\n\nTODO",
 	},
+	"TEE": {
+		Label: "TEE",
+		Kind: "statement map",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "TEE(name, ...)", Parameters: []string{"name"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "name", Required: true, Repeat: true, Accepts: "literal:string", Suggestions: []string{"'name'"}},
+		},
+		Description: "`TEE()` passes the records to the next as they are, and duplicates them to the named branches that begin with `BRANCH()`. Each branch has its own chain of statements and sink, so that a script writes the same records into several destinations without reading the source again. `TEE()` waits until all branches receive the record, the slowest branch throttles the pipeline. The sink of the main pipeline is always the output of the script (e.g. HTTP response), and the script fails if any of the branches fails.",
+		Markdown: "# TEE\n\n## Kind\n\nstatement map\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\nTEE(name, ...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | yes | literal:string | 'name' |\n\n## Description\n\n`TEE()` passes the records to the next as they are, and duplicates them to the named branches that begin with `BRANCH()`. Each branch has its own chain of statements and sink, so that a script writes the same records into several destinations without reading the source again. `TEE()` waits until all branches receive the record, the slowest branch throttles the pipeline. The sink of the main pipeline is always the output of the script (e.g. HTTP response), and the script fails if any of the branches fails.\n\n## Examples\n\n### Append rows and archive them as CSV\n\n```js\nSQL('select name, time, value from example where time > ?', param('from') ?? 0)\nTEE('archive')\nAPPEND(table('example_copy'))\n\nBRANCH('archive', file('/archive/example.csv'))\nCSV(timeformat('default'))\n```\n\n## Related\n\nBRANCH, PIPE",
+		Related: []string{"BRANCH", "PIPE"},
+	},
 	"TEXT": {
 		Label: "TEXT",
 		Kind: "statement sink",
@@ -853,6 +1190,20 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# TRANSPOSE\n\n## Kind\n\nstatement map\n\n## Category\n\nmap monad\n\n## Signatures\n\n```text\nTRANSPOSE(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nTRANSPOSE()\n```\n\n## Related\n\nTODO",
 	},
+	"UNION": {
+		Label: "UNION",
+		Kind: "statement map",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "UNION(name)", Parameters: []string{"name"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "name", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'name'"}},
+		},
+		Description: "`UNION()` yields the records and the records of the named sub-pipeline as they arrive, the order between the pipelines is not guaranteed. Use `MERGE()` for the ordered result.",
+		Markdown: "# UNION\n\n## Kind\n\nstatement map\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\nUNION(name)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | 'name' |\n\n## Description\n\n`UNION()` yields the records and the records of the named sub-pipeline as they arrive, the order between the pipelines is not guaranteed. Use `MERGE()` for the ordered result.\n\n## Examples\n\n### Combine two sources\n\n```js\nFAKE(linspace(0, 8, 5))\nPIPE('even')\n\nFAKE(linspace(1, 9, 5))\nUNION('even')\nCSV()\n```\n\n## Related\n\nPIPE, JOIN, MERGE",
+		Related: []string{"PIPE", "JOIN", "MERGE"},
+	},
 	"WHEN": {
 		Label: "WHEN",
 		Kind: "statement map",
@@ -1383,6 +1734,20 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# dump\n\n## Kind\n\nhelper\n\n## Category\n\ndatabase source\n\n## Signatures\n\n```text\ndump(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\ndump()\n```\n\n## Related\n\nTODO",
 	},
+	"duration": {
+		Label: "duration",
+		Kind: "helper",
+		Category: "bridge",
+		Signatures: []tqlDocSignature{
+			{Label: "duration(d)", Parameters: []string{"d"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "d", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"'1m'"}},
+		},
+		Description: "`duration()` sets how long `SUBSCRIBE()` keeps receiving the messages. It accepts a duration string such as `'30s'` or `'10m'`, or a number in nanoseconds.",
+		Markdown: "# duration\n\n## Kind\n\nhelper\n\n## Category\n\nbridge\n\n## Signatures\n\n```text\nduration(d)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| d | yes | no | literal:string | '1m' |\n\n## Description\n\n`duration()` sets how long `SUBSCRIBE()` keeps receiving the messages. It accepts a duration string such as `'30s'` or `'10m'`, or a number in nanoseconds.\n\n## Examples\n\n### Basic\n\n```js\nSUBSCRIBE('sensor/#', duration('30s'))\nNDJSON()\n```\n\n## Related\n\nSUBSCRIBE, limit",
+		Related: []string{"SUBSCRIBE", "limit"},
+	},
 	"element": {
 		Label: "element",
 		Kind: "helper",
@@ -1764,9 +2129,9 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 			{Name: "offset", Required: false, Repeat: false, Accepts: "literal:number", Suggestions: []string{"0"}},
 			{Name: "count", Required: true, Repeat: false, Accepts: "literal:number", Suggestions: []string{"1000"}},
 		},
-		Description: "`limit()` supplies the LIMIT clause to `SQL_SELECT()`. With one argument it is treated as count; with two arguments it is offset and count.",
-		Markdown: "# limit\n\n## Kind\n\nhelper\n\n## Category\n\ndatabase source\n\n## Signatures\n\n```text\nlimit(count)\nlimit(offset, count)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| offset | no | no | literal:number | 0 |\n| count | yes | no | literal:number | 1000 |\n\n## Description\n\n`limit()` supplies the LIMIT clause to `SQL_SELECT()`. With one argument it is treated as count; with two arguments it is offset and count.\n\n## Examples\n\n### Basic\n\n```js\nSQL_SELECT('time', 'value', from('example', 'temperature'), between('last-10s', 'last'), limit(1000))\nCSV()\n```\n\n## Related\n\nSQL_SELECT, from, between",
-		Related: []string{"SQL_SELECT", "from", "between"},
+		Description: "`limit()` supplies the LIMIT clause to `SQL_SELECT()`. With one argument it is treated as count; with two arguments it is offset and count.\n\nIn `SUBSCRIBE()` it stops the task after count messages, skipping the first offset messages.",
+		Markdown: "# limit\n\n## Kind\n\nhelper\n\n## Category\n\ndatabase source\n\n## Signatures\n\n```text\nlimit(count)\nlimit(offset, count)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| offset | no | no | literal:number | 0 |\n| count | yes | no | literal:number | 1000 |\n\n## Description\n\n`limit()` supplies the LIMIT clause to `SQL_SELECT()`. With one argument it is treated as count; with two arguments it is offset and count.\n\nIn `SUBSCRIBE()` it stops the task after count messages, skipping the first offset messages.\n\n## Examples\n\n### Basic\n\n```js\nSQL_SELECT('time', 'value', from('example', 'temperature'), between('last-10s', 'last'), limit(1000))\nCSV()\n```\n\n## Related\n\nSQL_SELECT, from, between, SUBSCRIBE",
+		Related: []string{"SQL_SELECT", "from", "between", "SUBSCRIBE"},
 	},
 	"linspace": {
 		Label: "linspace",
@@ -2101,6 +2466,22 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# nullValue\n\n## Kind\n\nhelper\n\n## Category\n\narrays and dictionaries\n\n## Signatures\n\n```text\nnullValue(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nnullValue()\n```\n\n## Related\n\nTODO",
 	},
+	"on": {
+		Label: "on",
+		Kind: "helper",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "on(idx)", Parameters: []string{"idx"}},
+			{Label: "on(mainIdx, pipeIdx)", Parameters: []string{"mainIdx", "pipeIdx"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "mainIdx", Required: true, Repeat: false, Accepts: "number", Suggestions: []string{"0"}},
+			{Name: "pipeIdx", Required: false, Repeat: false, Accepts: "number", Suggestions: []string{"0"}},
+		},
+		Description: "Option of `JOIN()` and `MERGE()` that selects the value index of the join column of the current pipeline and of the sub-pipeline. `on(idx)` uses the same index for both.",
+		Markdown: "# on\n\n## Kind\n\nhelper\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\non(idx)\non(mainIdx, pipeIdx)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| mainIdx | yes | no | number | 0 |\n| pipeIdx | no | no | number | 0 |\n\n## Description\n\nOption of `JOIN()` and `MERGE()` that selects the value index of the join column of the current pipeline and of the sub-pipeline. `on(idx)` uses the same index for both.\n\n## Examples\n\n### Basic\n\n```js\nJOIN('other', on(0, 1))\n```\n\n## Related\n\nJOIN, MERGE, tolerance",
+		Related: []string{"JOIN", "MERGE", "tolerance"},
+	},
 	"once": {
 		Label: "once",
 		Kind: "helper",
@@ -2164,8 +2545,8 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Slots: []tqlDocSlot{
 			{Name: "name", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"query parameter name"}},
 		},
-		Description: "`param()` returns a requested query parameter when a TQL script is called via HTTP.",
-		Markdown: "# param\n\n## Kind\n\nhelper\n\n## Category\n\ncontext\n\n## Signatures\n\n```text\nparam(name)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | query parameter name |\n\n## Description\n\n`param()` returns a requested query parameter when a TQL script is called via HTTP.\n\n## Examples\n\n### Basic\n\n```js\nSQL(`SELECT time, value FROM example WHERE name = ?`, param('name') ?? 'temperature')\nCSV()\n```\n\n## Related\n\nSQL, payload, escapeParam",
+		Description: "`param()` returns a requested query parameter when a TQL script is called via HTTP.\n\nWhen a TQL script runs by a timer schedule, `param('_fire_time')` is the time that the run is scheduled at,\nin epoch nanoseconds. It is the time in the past for the catch-up and backfill runs.",
+		Markdown: "# param\n\n## Kind\n\nhelper\n\n## Category\n\ncontext\n\n## Signatures\n\n```text\nparam(name)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| name | yes | no | literal:string | query parameter name |\n\n## Description\n\n`param()` returns a requested query parameter when a TQL script is called via HTTP.\n\nWhen a TQL script runs by a timer schedule, `param('_fire_time')` is the time that the run is scheduled at,\nin epoch nanoseconds. It is the time in the past for the catch-up and backfill runs.\n\n## Examples\n\n### Basic\n\n```js\nSQL(`SELECT time, value FROM example WHERE name = ?`, param('name') ?? 'temperature')\nCSV()\n```\n\n### Timer fire time\n\n```js\nSQL(`SELECT count(*) FROM example WHERE time >= ? AND time < ?`,\n    timeAdd(parseTime(param('_fire_time'), 'ns'), '-1d'),\n    parseTime(param('_fire_time'), 'ns'))\nCSV()\n```\n\n## Related\n\nSQL, payload, escapeParam",
 		Related: []string{"SQL", "payload", "escapeParam"},
 	},
 	"parseBool": {
@@ -2458,6 +2839,21 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# separator\n\n## Kind\n\nhelper\n\n## Category\n\nbytes\n\n## Signatures\n\n```text\nseparator(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\nseparator()\n```\n\n## Related\n\nTODO",
 	},
+	"sessionwindow": {
+		Label: "sessionwindow",
+		Kind: "helper",
+		Category: "conversion",
+		Signatures: []tqlDocSignature{
+			{Label: "sessionwindow(gap [, key])", Parameters: []string{"gap", "key"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "gap", Required: true, Repeat: false, Accepts: "literal:string, duration", Suggestions: []string{"'10s'"}},
+			{Name: "key", Required: false, Repeat: false, Accepts: "expression", Suggestions: []string{"value(0)"}},
+		},
+		Description: "`sessionwindow()` is the option of `by()` in `GROUP()` that groups the records into the sessions of each `key`, a session is closed when no record of the key comes for longer than `gap`. The `by()` column is replaced by the key (if given), the time of the first and the last record and the count of the session, followed by the aggregators of `GROUP()` such as `first`, `last`, `mean` and `quantile`. The sessions are closed by the time of the records, a session is yielded as soon as a record of any key arrives later than `gap` after the last record of the session, so it works on the unbounded streams. The sessions still open are yielded at the end of the stream. `lazy(true)` holds all sessions until the end of the stream.",
+		Markdown: "# sessionwindow\n\n## Kind\n\nhelper\n\n## Category\n\nconversion\n\n## Signatures\n\n```text\nsessionwindow(gap [, key])\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| gap | yes | no | literal:string, duration | '10s' |\n| key | no | no | expression | value(0) |\n\n## Description\n\n`sessionwindow()` is the option of `by()` in `GROUP()` that groups the records into the sessions of each `key`, a session is closed when no record of the key comes for longer than `gap`. The `by()` column is replaced by the key (if given), the time of the first and the last record and the count of the session, followed by the aggregators of `GROUP()` such as `first`, `last`, `mean` and `quantile`. The sessions are closed by the time of the records, a session is yielded as soon as a record of any key arrives later than `gap` after the last record of the session, so it works on the unbounded streams. The sessions still open are yielded at the end of the stream. `lazy(true)` holds all sessions until the end of the stream.\n\n## Examples\n\n### Running periods of the machines\n\n```js\nSQL(`select name, time, value from example where name in ('m1', 'm2') and value > 0 order by time`)\nGROUP( by(value(1), sessionwindow('30s', value(0))), mean(value(2), \"AVG\") )\nCSV( timeformat('default') )\n```\n\n## Related\n\nslidingwindow, timewindow, by, GROUP",
+		Related: []string{"slidingwindow", "timewindow", "by", "GROUP"},
+	},
 	"simplex": {
 		Label: "simplex",
 		Kind: "helper",
@@ -2503,6 +2899,21 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# sinh\n\n## Kind\n\nhelper\n\n## Category\n\nmath\n\n## Signatures\n\n```text\nsinh(x)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| x | yes | no | number | value, expression |\n\n## Description\n\nMath function returning the hyperbolic sine of x. The official manual notes that math functions do not guarantee bit-identical results across system architectures.\n\n## Examples\n\n### Basic\n\n```js\nMAPVALUE(0, sinh(value(0)))\nCSV()\n```\n\n## Related\n\nMAPVALUE, value",
 		Related: []string{"MAPVALUE", "value"},
 	},
+	"slidingwindow": {
+		Label: "slidingwindow",
+		Kind: "helper",
+		Category: "conversion",
+		Signatures: []tqlDocSignature{
+			{Label: "slidingwindow(size, step)", Parameters: []string{"size", "step"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "size", Required: true, Repeat: false, Accepts: "literal:string, duration", Suggestions: []string{"'5m'"}},
+			{Name: "step", Required: true, Repeat: false, Accepts: "literal:string, duration", Suggestions: []string{"'30s'"}},
+		},
+		Description: "`slidingwindow()` is the option of `by()` in `GROUP()` that groups the records into the overlapping windows of `size` those start at every `step`, so that a record belongs to `size/step` windows. The windows are aligned to the multiples of `step`. The `by()` column is replaced by the start, the end and the count of the window, followed by the aggregators of `GROUP()` such as `first`, `last`, `mean` and `quantile`. A window is yielded as soon as a record at or after its end arrives, so it works on the unbounded streams, the windows still open are yielded at the end of the stream. The records those arrive after their windows are closed are ignored. `lazy(true)` holds all windows until the end of the stream.",
+		Markdown: "# slidingwindow\n\n## Kind\n\nhelper\n\n## Category\n\nconversion\n\n## Signatures\n\n```text\nslidingwindow(size, step)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| size | yes | no | literal:string, duration | '5m' |\n| step | yes | no | literal:string, duration | '30s' |\n\n## Description\n\n`slidingwindow()` is the option of `by()` in `GROUP()` that groups the records into the overlapping windows of `size` those start at every `step`, so that a record belongs to `size/step` windows. The windows are aligned to the multiples of `step`. The `by()` column is replaced by the start, the end and the count of the window, followed by the aggregators of `GROUP()` such as `first`, `last`, `mean` and `quantile`. A window is yielded as soon as a record at or after its end arrives, so it works on the unbounded streams, the windows still open are yielded at the end of the stream. The records those arrive after their windows are closed are ignored. `lazy(true)` holds all windows until the end of the stream.\n\n## Examples\n\n### Moving average of 5 minutes every 30 seconds\n\n```js\nSQL(`select time, value from example where name = 'temp' and time > now - 1h order by time`)\nGROUP( by(value(0), slidingwindow('5m', '30s')), mean(value(1), \"AVG\"), max(value(1), \"MAX\") )\nCSV( timeformat('default') )\n```\n\n## Related\n\nsessionwindow, timewindow, by, GROUP",
+		Related: []string{"sessionwindow", "timewindow", "by", "GROUP"},
+	},
 	"sphere": {
 		Label: "sphere",
 		Kind: "helper",
@@ -2544,6 +2955,22 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Markdown: "# sqrt\n\n## Kind\n\nhelper\n\n## Category\n\nmath\n\n## Signatures\n\n```text\nsqrt(x)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| x | yes | no | number | value, expression |\n\n## Description\n\nMath function returning the square root of x. The official manual notes that math functions do not guarantee bit-identical results across system architectures.\n\n## Examples\n\n### Basic\n\n```js\nMAPVALUE(0, sqrt(value(0)))\nCSV()\n```\n\n## Related\n\nMAPVALUE, value",
 		Related: []string{"MAPVALUE", "value"},
 	},
+	"state": {
+		Label: "state",
+		Kind: "helper",
+		Category: "context",
+		Signatures: []tqlDocSignature{
+			{Label: "state(key)", Parameters: []string{"key"}},
+			{Label: "state(key, default)", Parameters: []string{"key", "default"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "key", Required: true, Repeat: false, Accepts: "literal:string", Suggestions: []string{"state key"}},
+			{Name: "default", Required: false, Repeat: false, Accepts: "any", Suggestions: []string{"0"}},
+		},
+		Description: "`state()` returns the value of the key that the last successful run of the script stored by `SET_STATE()`, or the default value if the key does not exist. The states are kept per timer schedule, a script that is not run by a timer always gets the default value. The value does not change while the script is running, the updates by `SET_STATE()` are visible from the next run. Time values are stored as time, so that `state()` returns the same type that was set.",
+		Markdown: "# state\n\n## Kind\n\nhelper\n\n## Category\n\ncontext\n\n## Signatures\n\n```text\nstate(key)\nstate(key, default)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| key | yes | no | literal:string | state key |\n| default | no | no | any | 0 |\n\n## Description\n\n`state()` returns the value of the key that the last successful run of the script stored by `SET_STATE()`, or the default value if the key does not exist. The states are kept per timer schedule, a script that is not run by a timer always gets the default value. The value does not change while the script is running, the updates by `SET_STATE()` are visible from the next run. Time values are stored as time, so that `state()` returns the same type that was set.\n\n## Examples\n\n### Read new rows since the last run\n\n```js\nSQL('select name, time, value from example where time > ? order by time', state('last', 0))\nSET_STATE('last', value(1))\nAPPEND(table('example_copy'))\n```\n\n## Related\n\nSET_STATE, param",
+		Related: []string{"SET_STATE", "param"},
+	},
 	"statz": {
 		Label: "statz",
 		Kind: "helper",
@@ -3153,6 +3580,20 @@ var generatedTqlDocs = map[string]tqlDocInfo{
 		Description: "TODO",
 		Markdown: "# timewindow\n\n## Kind\n\nhelper\n\n## Category\n\nconversion\n\n## Signatures\n\n```text\ntimewindow(...)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| args | no | yes | expression | TODO |\n\n## Description\n\nTODO\n\n## Examples\n\n### Basic\n\n```js\ntimewindow()\n```\n\n## Related\n\nTODO",
 	},
+	"tolerance": {
+		Label: "tolerance",
+		Kind: "helper",
+		Category: "pipelines",
+		Signatures: []tqlDocSignature{
+			{Label: "tolerance(tol)", Parameters: []string{"tol"}},
+		},
+		Slots: []tqlDocSlot{
+			{Name: "tol", Required: true, Repeat: false, Accepts: "duration, number", Suggestions: []string{"'1s'", "0"}},
+		},
+		Description: "Option of `JOIN()` that sets the maximum distance of the join columns to be aligned. A duration string like `'500ms'` applies to time columns, a number is the distance of numeric columns or nanoseconds of time columns.",
+		Markdown: "# tolerance\n\n## Kind\n\nhelper\n\n## Category\n\npipelines\n\n## Signatures\n\n```text\ntolerance(tol)\n```\n\n## Slots\n\n| Slot | Required | Repeat | Accepts | Suggestions |\n| --- | --- | --- | --- | --- |\n| tol | yes | no | duration, number | '1s', 0 |\n\n## Description\n\nOption of `JOIN()` that sets the maximum distance of the join columns to be aligned. A duration string like `'500ms'` applies to time columns, a number is the distance of numeric columns or nanoseconds of time columns.\n\n## Examples\n\n### Basic\n\n```js\nJOIN('other', on(0), tolerance('500ms'))\n```\n\n## Related\n\nJOIN, on",
+		Related: []string{"JOIN", "on"},
+	},
 	"trimspace": {
 		Label: "trimspace",
 		Kind: "helper",
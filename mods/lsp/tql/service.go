@@ -107,6 +107,22 @@ var tqlLanguageSymbols = []base.SymbolInfo{
 		Documentation: "Pragma that runs SQL() on a dedicated native thread for the script execution.",
 		InsertText:    "//+ sql-thread-lock",
 	},
+	{
+		Label:         "#include",
+		Kind:          base.CompletionKeyword,
+		Category:      "directive",
+		Detail:        "include a .tql library or a .js module",
+		Documentation: "Directive that loads the #define functions of a .tql file, or a javascript module for SCRIPT(), from the server file system.",
+		InsertText:    `#include "lib/common.tql"`,
+	},
+	{
+		Label:         "#define",
+		Kind:          base.CompletionKeyword,
+		Category:      "directive",
+		Detail:        "user-defined function",
+		Documentation: "Directive that defines a function by an expression, e.g. #define celsius(f) (f - 32) * 5 / 9",
+		InsertText:    "#define name(x) x",
+	},
 }
 
 func NewService() *Service {
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/util"
+)
+
+const (
+	ALERT_CHANNEL_WEBHOOK = "webhook"
+	ALERT_CHANNEL_MQTT    = "mqtt"
+	ALERT_CHANNEL_SMTP    = "smtp"
+)
+
+// AlertRuleDefinition is the rule of an alert, the condition is evaluated at every Interval.
+//
+// The value of the condition is the first column of the first row of the Sql query,
+// or of the first record of the Tql script. The condition is met when
+// the value compared with the Threshold by the Operator is true.
+// The rule fires if the condition is met for the For duration,
+// and it is resolved when the value goes back across the Threshold over the Hysteresis.
+// The firing rule notifies the channels again at every RepeatInterval, if it is specified.
+type AlertRuleDefinition struct {
+	Name           string          `json:"-"`
+	Disabled       bool            `json:"disabled,omitempty"`
+	Interval       string          `json:"interval"`
+	Sql            string          `json:"sql,omitempty"`
+	Tql            string          `json:"tql,omitempty"`
+	Operator       string          `json:"operator"`
+	Threshold      float64         `json:"threshold"`
+	Hysteresis     float64         `json:"hysteresis,omitempty"`
+	For            string          `json:"for,omitempty"`
+	RepeatInterval string          `json:"repeatInterval,omitempty"`
+	Message        string          `json:"message,omitempty"`
+	Channels       []*AlertChannel `json:"channels,omitempty"`
+}
+
+// AlertChannel is where the notifications of the alert are sent.
+type AlertChannel struct {
+	Type string `json:"type"`
+	// webhook, the notification is posted in JSON
+	Url string `json:"url,omitempty"`
+	// mqtt, published to the built-in broker if the bridge is empty
+	Bridge string `json:"bridge,omitempty"`
+	Topic  string `json:"topic,omitempty"`
+	// smtp relay, "host:port"
+	Addr     string   `json:"addr,omitempty"`
+	User     string   `json:"user,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+var alertNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+var alertOperators = []string{">", ">=", "<", "<=", "==", "!="}
+
+func (def *AlertRuleDefinition) Validate() error {
+	if !alertNameRegexp.MatchString(def.Name) {
+		return fmt.Errorf("invalid alert name %q", def.Name)
+	}
+	if (def.Sql == "") == (def.Tql == "") {
+		return fmt.Errorf("alert %q should have either sql or tql", def.Name)
+	}
+	if d, err := util.ParseDuration(def.Interval); err != nil || d <= 0 {
+		return fmt.Errorf("alert %q invalid interval %q", def.Name, def.Interval)
+	}
+	for _, v := range []string{def.For, def.RepeatInterval} {
+		if v == "" {
+			continue
+		}
+		if d, err := util.ParseDuration(v); err != nil || d < 0 {
+			return fmt.Errorf("alert %q invalid duration %q", def.Name, v)
+		}
+	}
+	valid := false
+	for _, op := range alertOperators {
+		if def.Operator == op {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("alert %q invalid operator %q", def.Name, def.Operator)
+	}
+	if def.Hysteresis < 0 {
+		return fmt.Errorf("alert %q hysteresis should not be negative", def.Name)
+	}
+	for i, ch := range def.Channels {
+		if err := ch.Validate(); err != nil {
+			return fmt.Errorf("alert %q channel %d, %s", def.Name, i, err.Error())
+		}
+	}
+	return nil
+}
+
+func (ch *AlertChannel) Validate() error {
+	switch strings.ToLower(ch.Type) {
+	case ALERT_CHANNEL_WEBHOOK:
+		u, err := url.Parse(ch.Url)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook url %q", ch.Url)
+		}
+	case ALERT_CHANNEL_MQTT:
+		if ch.Topic == "" {
+			return fmt.Errorf("mqtt topic is not specified")
+		}
+	case ALERT_CHANNEL_SMTP:
+		if ch.Addr == "" || ch.From == "" || len(ch.To) == 0 {
+			return fmt.Errorf("smtp requires addr, from and to")
+		}
+	default:
+		return fmt.Errorf("unsupported channel type %q", ch.Type)
+	}
+	ch.Type = strings.ToLower(ch.Type)
+	return nil
+}
+
+type AlertStatus string
+
+const (
+	ALERT_INACTIVE AlertStatus = "inactive"
+	ALERT_PENDING  AlertStatus = "pending"
+	ALERT_FIRING   AlertStatus = "firing"
+	ALERT_RESOLVED AlertStatus = "resolved"
+)
+
+// AlertState is the persisted state of the alert rule.
+type AlertState struct {
+	Status AlertStatus `json:"status"`
+	// time of the last transition of the status
+	Since       time.Time `json:"since"`
+	Value       float64   `json:"value"`
+	EvaluatedAt time.Time `json:"evaluatedAt"`
+	Error       string    `json:"error,omitempty"`
+	// time of the last notification of the firing status, for the deduplication
+	NotifiedAt    time.Time `json:"notifiedAt"`
+	SilencedUntil time.Time `json:"silencedUntil"`
+}
+
+type AlertProvider interface {
+	LoadAllAlertRules() ([]*AlertRuleDefinition, error)
+	LoadAlertRule(name string) (*AlertRuleDefinition, error)
+	SaveAlertRule(def *AlertRuleDefinition) error
+	RemoveAlertRule(name string) error
+	LoadAlertState(name string) (*AlertState, error)
+	SaveAlertState(name string, state *AlertState) error
+}
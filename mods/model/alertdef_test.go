@@ -0,0 +1,99 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertRuleValidate(t *testing.T) {
+	valid := func() *AlertRuleDefinition {
+		return &AlertRuleDefinition{Name: "cpu", Interval: "10s", Sql: "select 1", Operator: ">", Threshold: 80}
+	}
+	tests := []struct {
+		name   string
+		modify func(def *AlertRuleDefinition)
+		err    string
+	}{
+		{"ok", func(def *AlertRuleDefinition) {}, ""},
+		{"name", func(def *AlertRuleDefinition) { def.Name = "cpu load" }, `invalid alert name "cpu load"`},
+		{"both", func(def *AlertRuleDefinition) { def.Tql = "cpu.tql" }, `alert "cpu" should have either sql or tql`},
+		{"none", func(def *AlertRuleDefinition) { def.Sql = "" }, `alert "cpu" should have either sql or tql`},
+		{"interval", func(def *AlertRuleDefinition) { def.Interval = "0s" }, `alert "cpu" invalid interval "0s"`},
+		{"for", func(def *AlertRuleDefinition) { def.For = "soon" }, `alert "cpu" invalid duration "soon"`},
+		{"operator", func(def *AlertRuleDefinition) { def.Operator = "=>" }, `alert "cpu" invalid operator "=>"`},
+		{"hysteresis", func(def *AlertRuleDefinition) { def.Hysteresis = -1 }, `alert "cpu" hysteresis should not be negative`},
+		{"webhook", func(def *AlertRuleDefinition) {
+			def.Channels = []*AlertChannel{{Type: "webhook", Url: "ftp://host"}}
+		}, `alert "cpu" channel 0, invalid webhook url "ftp://host"`},
+		{"mqtt", func(def *AlertRuleDefinition) {
+			def.Channels = []*AlertChannel{{Type: "MQTT"}}
+		}, `alert "cpu" channel 0, mqtt topic is not specified`},
+		{"smtp", func(def *AlertRuleDefinition) {
+			def.Channels = []*AlertChannel{{Type: "smtp", Addr: "localhost:25"}}
+		}, `alert "cpu" channel 0, smtp requires addr, from and to`},
+		{"unknown", func(def *AlertRuleDefinition) {
+			def.Channels = []*AlertChannel{{Type: "slack"}}
+		}, `alert "cpu" channel 0, unsupported channel type "slack"`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			def := valid()
+			tc.modify(def)
+			err := def.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestAlertProvider(t *testing.T) {
+	s := NewService(WithConfigDirPath(t.TempDir()))
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	ap := s.AlertProvider()
+	require.NoError(t, ap.SaveAlertRule(&AlertRuleDefinition{
+		Name:      "cpu",
+		Interval:  "10s",
+		Sql:       "select 1",
+		Operator:  ">",
+		Threshold: 80,
+		Channels:  []*AlertChannel{{Type: "MQTT", Topic: "alerts/cpu"}},
+	}))
+	require.Error(t, ap.SaveAlertRule(&AlertRuleDefinition{Name: "bad"}))
+
+	def, err := ap.LoadAlertRule("cpu")
+	require.NoError(t, err)
+	require.Equal(t, "CPU", def.Name)
+	require.Equal(t, ALERT_CHANNEL_MQTT, def.Channels[0].Type)
+
+	st, err := ap.LoadAlertState("cpu")
+	require.NoError(t, err)
+	require.Equal(t, ALERT_INACTIVE, st.Status)
+
+	since := time.Unix(1700000000, 0)
+	require.NoError(t, ap.SaveAlertState("cpu", &AlertState{Status: ALERT_FIRING, Since: since, Value: 91.5}))
+	st, err = ap.LoadAlertState("CPU")
+	require.NoError(t, err)
+	require.Equal(t, ALERT_FIRING, st.Status)
+	require.Equal(t, 91.5, st.Value)
+	require.True(t, since.Equal(st.Since))
+
+	// the state directory should not be listed as a rule
+	list, err := ap.LoadAllAlertRules()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	require.NoError(t, ap.RemoveAlertRule("cpu"))
+	st, err = ap.LoadAlertState("cpu")
+	require.NoError(t, err)
+	require.Equal(t, ALERT_INACTIVE, st.Status)
+	list, err = ap.LoadAllAlertRules()
+	require.NoError(t, err)
+	require.Len(t, list, 0)
+}
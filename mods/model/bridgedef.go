@@ -13,6 +13,7 @@ const (
 	BRIDGE_MSSQL    BridgeType = "mssql"
 	BRIDGE_MQTT     BridgeType = "mqtt"
 	BRIDGE_NATS     BridgeType = "nats"
+	BRIDGE_OPCUA    BridgeType = "opcua"
 )
 
 func ParseBridgeType(typ string) (BridgeType, error) {
@@ -29,6 +30,8 @@ func ParseBridgeType(typ string) (BridgeType, error) {
 		return BRIDGE_MQTT, nil
 	case "nats":
 		return BRIDGE_NATS, nil
+	case "opcua", "opc-ua":
+		return BRIDGE_OPCUA, nil
 	default:
 		return "", fmt.Errorf("unsupported bridge type: %s", typ)
 	}
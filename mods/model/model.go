@@ -1,6 +1,7 @@
 package model
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/gofrs/uuid/v5"
 	"github.com/machbase/neo-server/v8/mods/logging"
@@ -34,6 +36,8 @@ type Service interface {
 	ShellProvider() ShellProvider
 	BridgeProvider() BridgeProvider
 	ScheduleProvider() ScheduleProvider
+	QueryProvider() QueryProvider
+	AlertProvider() AlertProvider
 	Start() error
 	Stop()
 }
@@ -46,11 +50,19 @@ type svr struct {
 	log       logging.Log
 	configDir string
 
-	schedDir  string
-	bridgeDir string
-	shellDir  string
+	schedDir   string
+	stateDir   string
+	historyDir string
+	bridgeDir  string
+	shellDir   string
+	queryDir   string
+	alertDir   string
+	alertState string
 
 	experimentMode func() bool
+
+	historyLock  sync.Mutex
+	historyCount map[string]int // number of the runs in the history file, guarded by historyLock
 }
 
 func WithConfigDirPath(path string) Option {
@@ -74,10 +86,30 @@ func (s *svr) Start() error {
 	if err := s.mkDirIfNotExists(s.schedDir, 0755); err != nil {
 		return fmt.Errorf("schedule defs, %s", err.Error())
 	}
+	s.stateDir = filepath.Join(s.schedDir, "state")
+	if err := s.mkDirIfNotExists(s.stateDir, 0755); err != nil {
+		return fmt.Errorf("schedule states, %s", err.Error())
+	}
+	s.historyDir = filepath.Join(s.schedDir, "history")
+	if err := s.mkDirIfNotExists(s.historyDir, 0755); err != nil {
+		return fmt.Errorf("schedule history, %s", err.Error())
+	}
 	s.shellDir = filepath.Join(s.configDir, "shell")
 	if err := s.mkDirIfNotExists(s.shellDir, 0700); err != nil {
 		return fmt.Errorf("shell defs, %s", err.Error())
 	}
+	s.queryDir = filepath.Join(s.configDir, "queries")
+	if err := s.mkDirIfNotExists(s.queryDir, 0755); err != nil {
+		return fmt.Errorf("query defs, %s", err.Error())
+	}
+	s.alertDir = filepath.Join(s.configDir, "alerts")
+	if err := s.mkDirIfNotExists(s.alertDir, 0700); err != nil {
+		return fmt.Errorf("alert defs, %s", err.Error())
+	}
+	s.alertState = filepath.Join(s.alertDir, "state")
+	if err := s.mkDirIfNotExists(s.alertState, 0700); err != nil {
+		return fmt.Errorf("alert states, %s", err.Error())
+	}
 	return nil
 }
 
@@ -96,6 +128,14 @@ func (s *svr) ScheduleProvider() ScheduleProvider {
 	return s
 }
 
+func (s *svr) QueryProvider() QueryProvider {
+	return s
+}
+
+func (s *svr) AlertProvider() AlertProvider {
+	return s
+}
+
 func (s *svr) LoadAllSchedules() ([]*ScheduleDefinition, error) {
 	ret := []*ScheduleDefinition{}
 	err := s.iterateScheduleDefs(func(define *ScheduleDefinition) bool {
@@ -128,21 +168,39 @@ func (s *svr) SaveSchedule(def *ScheduleDefinition) error {
 		s.log.Warn("schedule save def file", err.Error())
 		return err
 	}
-	name := strings.ToUpper(def.Name)
+	path := filepath.Join(s.schedDir, fmt.Sprintf("%s.json", scheduleFileName(def.Name)))
+	return os.WriteFile(path, buf, 00600)
+}
+
+func scheduleFileName(name string) string {
+	name = strings.ToUpper(name)
 	name = strings.ReplaceAll(name, "/", "_")
 	name = strings.ReplaceAll(name, "\\", "_")
 	name = strings.ReplaceAll(name, "'", "_")
 	name = strings.ReplaceAll(name, "$", "_")
 	name = strings.ReplaceAll(name, "*", "_")
 	name = strings.ReplaceAll(name, "?", "_")
-	path := filepath.Join(s.schedDir, fmt.Sprintf("%s.json", name))
-	return os.WriteFile(path, buf, 00600)
+	return name
 }
 
 func (s *svr) RemoveSchedule(name string) error {
 	name = strings.ToUpper(name)
 	path := filepath.Join(s.schedDir, fmt.Sprintf("%s.json", name))
-	return os.Remove(path)
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	statePath := filepath.Join(s.stateDir, fmt.Sprintf("%s.json", scheduleFileName(name)))
+	if err := os.Remove(statePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.log.Warn("schedule remove state file", err.Error())
+	}
+	historyPath := s.scheduleHistoryPath(name)
+	s.historyLock.Lock()
+	delete(s.historyCount, historyPath)
+	if err := os.Remove(historyPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.log.Warn("schedule remove history file", err.Error())
+	}
+	s.historyLock.Unlock()
+	return nil
 }
 
 func (s *svr) UpdateSchedule(def *ScheduleDefinition) error {
@@ -153,9 +211,174 @@ func (s *svr) UpdateSchedule(def *ScheduleDefinition) error {
 	model.AutoStart = def.AutoStart
 	model.Task = def.Task
 	model.Schedule = def.Schedule
+	model.Retry = def.Retry
+	model.CatchUp = def.CatchUp
 	return s.SaveSchedule(model)
 }
 
+// LoadScheduleState returns the state of the schedule that is saved by the last successful run,
+// it returns an empty state if the schedule has never been saved the state.
+func (s *svr) LoadScheduleState(name string) (ScheduleState, error) {
+	path := filepath.Join(s.stateDir, fmt.Sprintf("%s.json", scheduleFileName(name)))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ScheduleState{}, nil
+		}
+		return nil, err
+	}
+	ret := ScheduleState{}
+	if err := json.Unmarshal(content, &ret); err != nil {
+		s.log.Warn("schedule load state format", err.Error())
+		return nil, err
+	}
+	return ret, nil
+}
+
+// SaveScheduleState replaces the state of the schedule atomically,
+// the state file is written into a temporary file and then renamed.
+func (s *svr) SaveScheduleState(name string, state ScheduleState) error {
+	buf, err := state.MarshalJSON()
+	if err != nil {
+		s.log.Warn("schedule save state", err.Error())
+		return err
+	}
+	path := filepath.Join(s.stateDir, fmt.Sprintf("%s.json", scheduleFileName(name)))
+	return writeFileAtomic(path, buf)
+}
+
+// scheduleHistoryPath returns the path of the history file of the schedule,
+// the file has a run in json per line.
+func (s *svr) scheduleHistoryPath(name string) string {
+	return filepath.Join(s.historyDir, fmt.Sprintf("%s.jsonl", scheduleFileName(name)))
+}
+
+// LoadScheduleRuns returns the history of the runs of the schedule in the order of the start time,
+// it returns an empty list if the schedule has never been run.
+func (s *svr) LoadScheduleRuns(name string) ([]*ScheduleRun, error) {
+	runs, err := s.readScheduleRuns(s.scheduleHistoryPath(name))
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > ScheduleRunHistoryLimit {
+		runs = runs[len(runs)-ScheduleRunHistoryLimit:]
+	}
+	return runs, nil
+}
+
+// readScheduleRuns returns all runs of the history file,
+// the broken line (e.g. the last line of the interrupted write) is skipped.
+func (s *svr) readScheduleRuns(path string) ([]*ScheduleRun, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []*ScheduleRun{}, nil
+		}
+		return nil, err
+	}
+	ret := []*ScheduleRun{}
+	for line := range bytes.Lines(content) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		run := &ScheduleRun{}
+		if err := json.Unmarshal(line, run); err != nil {
+			s.log.Warn("schedule load history format", err.Error())
+			continue
+		}
+		ret = append(ret, run)
+	}
+	return ret, nil
+}
+
+// AddScheduleRun appends the run to the history of the schedule,
+// the history file is trimmed to the recent ScheduleRunHistoryLimit runs
+// when it grows to twice of the limit.
+func (s *svr) AddScheduleRun(name string, run *ScheduleRun) error {
+	buf, err := json.Marshal(run)
+	if err != nil {
+		s.log.Warn("schedule save history", err.Error())
+		return err
+	}
+	buf = append(buf, '\n')
+
+	s.historyLock.Lock()
+	defer s.historyLock.Unlock()
+	path := s.scheduleHistoryPath(name)
+	count, ok := s.historyCount[path]
+	if !ok {
+		// the broken history should not block the new runs
+		runs, _ := s.readScheduleRuns(path)
+		count = len(runs)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	// the broken last line of the interrupted write should not take the new run
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		last := []byte{0}
+		if _, err := f.ReadAt(last, fi.Size()-1); err == nil && last[0] != '\n' {
+			buf = append([]byte{'\n'}, buf...)
+		}
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	count++
+
+	if count >= 2*ScheduleRunHistoryLimit {
+		runs, err := s.LoadScheduleRuns(name)
+		if err != nil {
+			return err
+		}
+		lines := []byte{}
+		for _, r := range runs {
+			b, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			lines = append(append(lines, b...), '\n')
+		}
+		if err := writeFileAtomic(path, lines); err != nil {
+			return err
+		}
+		count = len(runs)
+	}
+	if s.historyCount == nil {
+		s.historyCount = map[string]int{}
+	}
+	s.historyCount[path] = count
+	return nil
+}
+
+// writeFileAtomic replaces the file atomically,
+// the content is written into a temporary file and then renamed.
+func writeFileAtomic(path string, buf []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
 func (s *svr) LoadAllBridges() ([]*BridgeDefinition, error) {
 	ret := []*BridgeDefinition{}
 	err := s.iterateBridgeDefs(func(define *BridgeDefinition) bool {
@@ -196,6 +419,156 @@ func (s *svr) RemoveBridge(name string) error {
 	return os.Remove(path)
 }
 
+func (s *svr) LoadAllQueries() ([]*QueryDefinition, error) {
+	ret := []*QueryDefinition{}
+	err := s.iterateQueryDefs(func(define *QueryDefinition) bool {
+		ret = append(ret, define)
+		return true
+	})
+	return ret, err
+}
+
+func (s *svr) LoadQuery(name string) (*QueryDefinition, error) {
+	name = strings.ToUpper(name)
+	if !queryNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid query name %q", name)
+	}
+	path := filepath.Join(s.queryDir, fmt.Sprintf("%s.json", name))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		s.log.Warn("query load def file", err.Error())
+		return nil, err
+	}
+	def := &QueryDefinition{}
+	if err := json.Unmarshal(content, def); err != nil {
+		s.log.Warn("query load def format", err.Error())
+		return nil, err
+	}
+	def.Name = name
+	return def, nil
+}
+
+func (s *svr) SaveQuery(def *QueryDefinition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(def, "", "\t")
+	if err != nil {
+		s.log.Warn("query save def file", err.Error())
+		return err
+	}
+	name := strings.ToUpper(def.Name)
+	path := filepath.Join(s.queryDir, fmt.Sprintf("%s.json", name))
+	return os.WriteFile(path, buf, 00600)
+}
+
+func (s *svr) RemoveQuery(name string) error {
+	name = strings.ToUpper(name)
+	if !queryNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid query name %q", name)
+	}
+	path := filepath.Join(s.queryDir, fmt.Sprintf("%s.json", name))
+	return os.Remove(path)
+}
+
+func (s *svr) LoadAllAlertRules() ([]*AlertRuleDefinition, error) {
+	ret := []*AlertRuleDefinition{}
+	entries, err := os.ReadDir(s.alertDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") || entry.IsDir() {
+			continue
+		}
+		def, err := s.LoadAlertRule(strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		ret = append(ret, def)
+	}
+	return ret, nil
+}
+
+func (s *svr) LoadAlertRule(name string) (*AlertRuleDefinition, error) {
+	name = strings.ToUpper(name)
+	if !alertNameRegexp.MatchString(name) {
+		return nil, fmt.Errorf("invalid alert name %q", name)
+	}
+	path := filepath.Join(s.alertDir, fmt.Sprintf("%s.json", name))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		s.log.Warn("alert load def file", err.Error())
+		return nil, err
+	}
+	def := &AlertRuleDefinition{}
+	if err := json.Unmarshal(content, def); err != nil {
+		s.log.Warn("alert load def format", err.Error())
+		return nil, err
+	}
+	def.Name = name
+	return def, nil
+}
+
+func (s *svr) SaveAlertRule(def *AlertRuleDefinition) error {
+	if err := def.Validate(); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(def, "", "\t")
+	if err != nil {
+		s.log.Warn("alert save def file", err.Error())
+		return err
+	}
+	name := strings.ToUpper(def.Name)
+	path := filepath.Join(s.alertDir, fmt.Sprintf("%s.json", name))
+	return os.WriteFile(path, buf, 00600)
+}
+
+func (s *svr) RemoveAlertRule(name string) error {
+	name = strings.ToUpper(name)
+	if !alertNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid alert name %q", name)
+	}
+	path := filepath.Join(s.alertDir, fmt.Sprintf("%s.json", name))
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	statePath := filepath.Join(s.alertState, fmt.Sprintf("%s.json", name))
+	if err := os.Remove(statePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.log.Warn("alert remove state file", err.Error())
+	}
+	return nil
+}
+
+// LoadAlertState returns the last state of the alert rule,
+// it returns the inactive state if the rule has never been evaluated.
+func (s *svr) LoadAlertState(name string) (*AlertState, error) {
+	name = strings.ToUpper(name)
+	path := filepath.Join(s.alertState, fmt.Sprintf("%s.json", name))
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &AlertState{Status: ALERT_INACTIVE}, nil
+		}
+		return nil, err
+	}
+	ret := &AlertState{}
+	if err := json.Unmarshal(content, ret); err != nil {
+		s.log.Warn("alert load state format", err.Error())
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (s *svr) SaveAlertState(name string, state *AlertState) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	name = strings.ToUpper(name)
+	return writeFileAtomic(filepath.Join(s.alertState, fmt.Sprintf("%s.json", name)), buf)
+}
+
 func (s *svr) SetDefaultShellCommand(cmd string) {
 	reservedWebShellDef[SHELLID_SHELL].Command = cmd
 }
@@ -461,3 +834,34 @@ func (s *svr) iterateScheduleDefs(cb func(*ScheduleDefinition) bool) error {
 	}
 	return nil
 }
+
+func (s *svr) iterateQueryDefs(cb func(*QueryDefinition) bool) error {
+	if cb == nil {
+		return nil
+	}
+	entries, err := os.ReadDir(s.queryDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") || entry.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(s.queryDir, entry.Name()))
+		if err != nil {
+			s.log.Warn("query iterate def file", err.Error())
+			continue
+		}
+		def := &QueryDefinition{}
+		if err = json.Unmarshal(content, def); err != nil {
+			s.log.Warn("query iterate def format", err.Error())
+			continue
+		}
+		def.Name = strings.TrimSuffix(entry.Name(), ".json")
+		flag := cb(def)
+		if !flag {
+			break
+		}
+	}
+	return nil
+}
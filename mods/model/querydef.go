@@ -0,0 +1,149 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	QUERY_PARAM_STRING   = "string"
+	QUERY_PARAM_INT      = "int"
+	QUERY_PARAM_FLOAT    = "float"
+	QUERY_PARAM_BOOL     = "bool"
+	QUERY_PARAM_DATETIME = "datetime"
+)
+
+// ParseQueryParamType returns the normalized name of the parameter type.
+func ParseQueryParamType(typ string) (string, error) {
+	switch strings.ToLower(typ) {
+	case "", "string", "varchar", "text":
+		return QUERY_PARAM_STRING, nil
+	case "int", "integer", "long":
+		return QUERY_PARAM_INT, nil
+	case "float", "double":
+		return QUERY_PARAM_FLOAT, nil
+	case "bool", "boolean":
+		return QUERY_PARAM_BOOL, nil
+	case "datetime", "time":
+		return QUERY_PARAM_DATETIME, nil
+	default:
+		return "", fmt.Errorf("unsupported parameter type: %s", typ)
+	}
+}
+
+// QueryParamDefinition describes a bind parameter of the named query.
+// The parameters are bound to the placeholders '?' of the sql text in the order of definition.
+type QueryParamDefinition struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// Parse converts the string value into the type of the parameter.
+// datetime accepts an epoch nanoseconds or RFC3339 string.
+func (p *QueryParamDefinition) Parse(str string) (any, error) {
+	switch p.Type {
+	case QUERY_PARAM_INT:
+		return strconv.ParseInt(str, 10, 64)
+	case QUERY_PARAM_FLOAT:
+		return strconv.ParseFloat(str, 64)
+	case QUERY_PARAM_BOOL:
+		return strconv.ParseBool(str)
+	case QUERY_PARAM_DATETIME:
+		if n, err := strconv.ParseInt(str, 10, 64); err == nil {
+			return time.Unix(0, n), nil
+		}
+		return time.Parse(time.RFC3339Nano, str)
+	default:
+		return str, nil
+	}
+}
+
+type QueryDefinition struct {
+	Name        string                  `json:"-"`
+	SqlText     string                  `json:"sql"`
+	Params      []*QueryParamDefinition `json:"params,omitempty"`
+	Format      string                  `json:"format,omitempty"`
+	Description string                  `json:"description,omitempty"`
+}
+
+var queryNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9_\-]+$`)
+
+// queryReservedParams are the options of the query request that the named query handler reads
+// from the same query string (or form), a parameter can not take these names.
+var queryReservedParams = []string{
+	"q", "p", "format", "binaryformat", "compress", "timeformat", "tz", "precision",
+	"rownum", "heading", "header", "transpose", "rowsflatten", "rowsarray",
+	"boxstyle", "boxseparatecolumns", "boxdrawborder",
+}
+
+func (def *QueryDefinition) Validate() error {
+	if !queryNameRegexp.MatchString(def.Name) {
+		return fmt.Errorf("invalid query name %q", def.Name)
+	}
+	if strings.TrimSpace(def.SqlText) == "" {
+		return fmt.Errorf("query %q has no sql text", def.Name)
+	}
+	names := map[string]bool{}
+	for _, p := range def.Params {
+		if !queryNameRegexp.MatchString(p.Name) {
+			return fmt.Errorf("invalid parameter name %q", p.Name)
+		}
+		if slices.Contains(queryReservedParams, strings.ToLower(p.Name)) {
+			return fmt.Errorf("parameter name %q is reserved", p.Name)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate parameter name %q", p.Name)
+		}
+		names[p.Name] = true
+		typ, err := ParseQueryParamType(p.Type)
+		if err != nil {
+			return fmt.Errorf("parameter %q, %s", p.Name, err.Error())
+		}
+		p.Type = typ
+		if p.Default != "" {
+			if _, err := p.Parse(p.Default); err != nil {
+				return fmt.Errorf("parameter %q invalid default value %q", p.Name, p.Default)
+			}
+		}
+	}
+	return nil
+}
+
+// Bind returns the values of the parameters in the order of definition.
+// lookup returns the value of the named parameter and whether it is given.
+// Missing parameter takes the default value, or NULL if it has no default.
+func (def *QueryDefinition) Bind(lookup func(name string) (string, bool)) ([]any, error) {
+	ret := make([]any, len(def.Params))
+	for i, p := range def.Params {
+		str, ok := lookup(p.Name)
+		if !ok || str == "" {
+			if p.Default == "" {
+				if p.Required {
+					return nil, fmt.Errorf("parameter %q is required", p.Name)
+				}
+				ret[i] = nil
+				continue
+			}
+			str = p.Default
+		}
+		v, err := p.Parse(str)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q is not a %s, %q", p.Name, p.Type, str)
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+type QueryProvider interface {
+	LoadAllQueries() ([]*QueryDefinition, error)
+	LoadQuery(name string) (*QueryDefinition, error)
+	SaveQuery(def *QueryDefinition) error
+	RemoveQuery(name string) error
+}
@@ -0,0 +1,99 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryDefinitionBind(t *testing.T) {
+	def := &QueryDefinition{
+		Name:    "tag_range",
+		SqlText: "select * from example where name = ? and time between ? and ? and value > ?",
+		Params: []*QueryParamDefinition{
+			{Name: "tag", Type: "varchar", Required: true},
+			{Name: "from", Type: "datetime", Default: "0"},
+			{Name: "to", Type: "datetime"},
+			{Name: "min", Type: "double", Default: "1.5"},
+		},
+	}
+	require.NoError(t, def.Validate())
+	require.Equal(t, QUERY_PARAM_STRING, def.Params[0].Type)
+	require.Equal(t, QUERY_PARAM_FLOAT, def.Params[3].Type)
+
+	values := map[string]string{"tag": "temp", "to": "2024-01-15T04:10:59Z"}
+	lookup := func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+	params, err := def.Bind(lookup)
+	require.NoError(t, err)
+	require.Equal(t, []any{"temp", time.Unix(0, 0), time.Unix(1705291859, 0).UTC(), 1.5}, params)
+
+	values = map[string]string{"min": "x"}
+	_, err = def.Bind(lookup)
+	require.EqualError(t, err, `parameter "tag" is required`)
+
+	values = map[string]string{"tag": "temp", "min": "x"}
+	_, err = def.Bind(lookup)
+	require.EqualError(t, err, `parameter "min" is not a float, "x"`)
+}
+
+func TestQueryDefinitionValidate(t *testing.T) {
+	tests := []struct {
+		def *QueryDefinition
+		err string
+	}{
+		{&QueryDefinition{Name: "a/b", SqlText: "select 1"}, `invalid query name "a/b"`},
+		{&QueryDefinition{Name: "q", SqlText: " "}, `query "q" has no sql text`},
+		{&QueryDefinition{Name: "q", SqlText: "select ?", Params: []*QueryParamDefinition{{Name: "v", Type: "blob"}}},
+			`parameter "v", unsupported parameter type: blob`},
+		{&QueryDefinition{Name: "q", SqlText: "select ?, ?", Params: []*QueryParamDefinition{{Name: "v"}, {Name: "v"}}},
+			`duplicate parameter name "v"`},
+		{&QueryDefinition{Name: "q", SqlText: "select ?", Params: []*QueryParamDefinition{{Name: "v", Type: "int", Default: "one"}}},
+			`parameter "v" invalid default value "one"`},
+		// the options of the query request are not parameters
+		{&QueryDefinition{Name: "q", SqlText: "select ?", Params: []*QueryParamDefinition{{Name: "format"}}},
+			`parameter name "format" is reserved`},
+		{&QueryDefinition{Name: "q", SqlText: "select ?", Params: []*QueryParamDefinition{{Name: "p"}}},
+			`parameter name "p" is reserved`},
+		{&QueryDefinition{Name: "q", SqlText: "select ?", Params: []*QueryParamDefinition{{Name: "TZ"}}},
+			`parameter name "TZ" is reserved`},
+		{&QueryDefinition{Name: "q", SqlText: "select ?", Params: []*QueryParamDefinition{{Name: "rowsFlatten"}}},
+			`parameter name "rowsFlatten" is reserved`},
+	}
+	for _, tt := range tests {
+		require.EqualError(t, tt.def.Validate(), tt.err)
+	}
+}
+
+func TestQueryProvider(t *testing.T) {
+	s := NewService(WithConfigDirPath(t.TempDir()))
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	qp := s.QueryProvider()
+	def := &QueryDefinition{
+		Name:    "last_value",
+		SqlText: "select value from example where name = ? order by time desc limit 1",
+		Params:  []*QueryParamDefinition{{Name: "tag", Required: true}},
+		Format:  "csv",
+	}
+	require.NoError(t, qp.SaveQuery(def))
+
+	loaded, err := qp.LoadQuery("Last_Value")
+	require.NoError(t, err)
+	require.Equal(t, "LAST_VALUE", loaded.Name)
+	require.Equal(t, def.SqlText, loaded.SqlText)
+	require.Equal(t, QUERY_PARAM_STRING, loaded.Params[0].Type)
+
+	list, err := qp.LoadAllQueries()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "LAST_VALUE", list[0].Name)
+
+	require.NoError(t, qp.RemoveQuery("last_value"))
+	_, err = qp.LoadQuery("last_value")
+	require.Error(t, err)
+}
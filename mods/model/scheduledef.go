@@ -1,6 +1,10 @@
 package model
 
-import "strings"
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
 
 type ScheduleType string
 
@@ -8,6 +12,7 @@ const (
 	SCHEDULE_UNDEFINED  ScheduleType = ""
 	SCHEDULE_TIMER      ScheduleType = "timer"
 	SCHEDULE_SUBSCRIBER ScheduleType = "subscriber"
+	SCHEDULE_WORKFLOW   ScheduleType = "workflow"
 )
 
 func (typ ScheduleType) String() string {
@@ -18,6 +23,8 @@ func (typ ScheduleType) String() string {
 		return "TIMER"
 	case SCHEDULE_SUBSCRIBER:
 		return "SUBSCRIBER"
+	case SCHEDULE_WORKFLOW:
+		return "WORKFLOW"
 	}
 }
 
@@ -29,6 +36,8 @@ func ParseScheduleType(typ string) ScheduleType {
 		return SCHEDULE_TIMER
 	case "SUBSCRIBER":
 		return SCHEDULE_SUBSCRIBER
+	case "WORKFLOW":
+		return SCHEDULE_WORKFLOW
 	}
 }
 
@@ -38,7 +47,7 @@ type ScheduleDefinition struct {
 	AutoStart bool         `json:"autoStart"`
 	Task      string       `json:"task"`
 
-	// timer task
+	// timer and workflow task
 	Schedule string `json:"schedule,omitempty"`
 	// subscriber task
 	Bridge string `json:"bridge,omitempty"`
@@ -48,6 +57,86 @@ type ScheduleDefinition struct {
 	// nats subscriber only
 	QueueName  string `json:"queue,omitempty"`
 	StreamName string `json:"stream,omitempty"`
+	// opcua subscriber only, the node ids to monitor or the folder to browse the variables,
+	// and the sampling interval
+	Nodes    []string `json:"nodes,omitempty"`
+	Browse   string   `json:"browse,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+	// timer task only, nil means no retry and stop on failure
+	Retry *RetryPolicy `json:"retry,omitempty"`
+	// timer task only, how the runs missed while the server was down are executed on startup
+	CatchUp CatchUpPolicy `json:"catchUp,omitempty"`
+	// workflow task only
+	Steps []*WorkflowStep `json:"steps,omitempty"`
+}
+
+type CatchUpPolicy string
+
+const (
+	// missed runs are skipped
+	CATCHUP_NONE CatchUpPolicy = ""
+	// only the latest missed run is executed
+	CATCHUP_LAST CatchUpPolicy = "last"
+	// all missed runs are executed in order, up to CatchUpMaxRuns
+	CATCHUP_ALL CatchUpPolicy = "all"
+)
+
+// CatchUpMaxRuns is the limit of the missed runs those are executed by CATCHUP_ALL,
+// the older runs are skipped.
+const CatchUpMaxRuns = 100
+
+func ParseCatchUpPolicy(policy string) (CatchUpPolicy, error) {
+	switch strings.ToLower(policy) {
+	case "", "none":
+		return CATCHUP_NONE, nil
+	case "last":
+		return CATCHUP_LAST, nil
+	case "all":
+		return CATCHUP_ALL, nil
+	default:
+		return CATCHUP_NONE, fmt.Errorf("invalid catch-up policy %q", policy)
+	}
+}
+
+// WorkflowStep is a TQL task of the workflow.
+//
+// A step without edges starts when the workflow is fired, otherwise it waits until
+// all of its upstream steps are finished, and runs
+// if every step of OnSuccess succeeded and any step of OnFailure failed.
+// The steps of After are the upstream regardless of the result.
+// A step that does not run is skipped, and its downstream see it as not succeeded.
+// The outputs of the succeeded upstream steps are the input payload of the step.
+type WorkflowStep struct {
+	Name      string   `json:"name"`
+	Task      string   `json:"task"`
+	After     []string `json:"after,omitempty"`
+	OnSuccess []string `json:"on_success,omitempty"`
+	OnFailure []string `json:"on_failure,omitempty"`
+}
+
+// Upstream returns the names of the steps that the step depends on, without duplicates.
+func (step *WorkflowStep) Upstream() []string {
+	ret := []string{}
+	for _, lst := range [][]string{step.After, step.OnSuccess, step.OnFailure} {
+		for _, name := range lst {
+			if !slices.Contains(ret, name) {
+				ret = append(ret, name)
+			}
+		}
+	}
+	return ret
+}
+
+// RetryPolicy is how the timer task retries a failed run.
+//
+// A failed run is retried up to MaxAttempts-1 times, the delay before the n-th retry
+// is Backoff*2^(n-1) and limited by MaxBackoff.
+// If all attempts failed, the schedule is stopped unless KeepRunning is set.
+type RetryPolicy struct {
+	MaxAttempts int    `json:"maxAttempts,omitempty"`
+	Backoff     string `json:"backoff,omitempty"`
+	MaxBackoff  string `json:"maxBackoff,omitempty"`
+	KeepRunning bool   `json:"keepRunning,omitempty"`
 }
 
 type ScheduleProvider interface {
@@ -56,4 +145,8 @@ type ScheduleProvider interface {
 	SaveSchedule(def *ScheduleDefinition) error
 	RemoveSchedule(name string) error
 	UpdateSchedule(def *ScheduleDefinition) error
+	LoadScheduleState(name string) (ScheduleState, error)
+	SaveScheduleState(name string, state ScheduleState) error
+	LoadScheduleRuns(name string) ([]*ScheduleRun, error)
+	AddScheduleRun(name string, run *ScheduleRun) error
 }
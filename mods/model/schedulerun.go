@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// ScheduleRunHistoryLimit is the number of the recent runs those are kept in the history of a schedule.
+const ScheduleRunHistoryLimit = 100
+
+// ScheduleRun is the result of a run of the timer task,
+// a run consists of the attempts those are retried by the RetryPolicy.
+// The run of the workflow has the results of the steps.
+type ScheduleRun struct {
+	// the time that the run is scheduled at, it is passed to the tql as param("_fire_time")
+	FireTime time.Time       `json:"fireTime"`
+	Trigger  ScheduleTrigger `json:"trigger,omitempty"`
+	Start    time.Time       `json:"start"`
+	End      time.Time       `json:"end"`
+	Elapse   string          `json:"elapse"`
+	Attempts int             `json:"attempts"`
+	Success  bool            `json:"success"`
+	Records  int64           `json:"records"`
+	Message  string          `json:"message,omitempty"`
+	Error    string          `json:"error,omitempty"`
+	// log output of the tql of the last attempt
+	Log string `json:"log,omitempty"`
+	// workflow only
+	Steps []*WorkflowStepRun `json:"steps,omitempty"`
+}
+
+// ScheduleTrigger is what made the run, empty means the timer.
+type ScheduleTrigger string
+
+const (
+	TRIGGER_TIMER    ScheduleTrigger = ""
+	TRIGGER_CATCHUP  ScheduleTrigger = "catchup"
+	TRIGGER_BACKFILL ScheduleTrigger = "backfill"
+)
+
+type WorkflowStepState string
+
+const (
+	WORKFLOW_STEP_WAITING WorkflowStepState = "WAITING"
+	WORKFLOW_STEP_RUNNING WorkflowStepState = "RUNNING"
+	WORKFLOW_STEP_SUCCESS WorkflowStepState = "SUCCESS"
+	WORKFLOW_STEP_FAILED  WorkflowStepState = "FAILED"
+	WORKFLOW_STEP_SKIPPED WorkflowStepState = "SKIPPED"
+)
+
+// WorkflowStepRun is the result of a step in a run of the workflow.
+type WorkflowStepRun struct {
+	Name    string            `json:"name"`
+	State   WorkflowStepState `json:"state"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	Elapse  string            `json:"elapse,omitempty"`
+	Records int64             `json:"records"`
+	Error   string            `json:"error,omitempty"`
+	Log     string            `json:"log,omitempty"`
+}
@@ -0,0 +1,120 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleRuns(t *testing.T) {
+	s := NewService(WithConfigDirPath(t.TempDir()))
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	sp := s.ScheduleProvider()
+	require.NoError(t, sp.SaveSchedule(&ScheduleDefinition{
+		Name:     "nightly",
+		Type:     SCHEDULE_TIMER,
+		Task:     "nightly.tql",
+		Schedule: "0 0 3 * * *",
+		Retry:    &RetryPolicy{MaxAttempts: 3, Backoff: "10s", KeepRunning: true},
+	}))
+	def, err := sp.LoadSchedule("nightly")
+	require.NoError(t, err)
+	require.Equal(t, &RetryPolicy{MaxAttempts: 3, Backoff: "10s", KeepRunning: true}, def.Retry)
+
+	runs, err := sp.LoadScheduleRuns("nightly")
+	require.NoError(t, err)
+	require.Empty(t, runs)
+
+	start := time.Unix(1700000000, 0)
+	for i := 0; i < ScheduleRunHistoryLimit+5; i++ {
+		run := &ScheduleRun{
+			Start:    start.Add(time.Duration(i) * time.Hour),
+			End:      start.Add(time.Duration(i)*time.Hour + time.Second),
+			Elapse:   "1s",
+			Attempts: 1,
+			Success:  i%2 == 0,
+			Records:  int64(i),
+		}
+		if !run.Success {
+			run.Attempts = 3
+			run.Error = fmt.Sprintf("failure %d", i)
+			run.Log = "[ERROR] connection refused\n"
+		}
+		require.NoError(t, sp.AddScheduleRun("nightly", run))
+	}
+
+	runs, err = sp.LoadScheduleRuns("NIGHTLY")
+	require.NoError(t, err)
+	require.Len(t, runs, ScheduleRunHistoryLimit)
+	require.Equal(t, int64(5), runs[0].Records)
+	require.Equal(t, "failure 5", runs[0].Error)
+	require.Equal(t, 3, runs[0].Attempts)
+	require.Equal(t, "[ERROR] connection refused\n", runs[0].Log)
+	last := runs[len(runs)-1]
+	require.Equal(t, int64(ScheduleRunHistoryLimit+4), last.Records)
+	require.True(t, last.Success)
+	require.True(t, last.Start.Equal(start.Add(time.Duration(ScheduleRunHistoryLimit+4)*time.Hour)))
+
+	// the history file should not be listed as a schedule
+	list, err := sp.LoadAllSchedules()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	require.NoError(t, sp.RemoveSchedule("nightly"))
+	runs, err = sp.LoadScheduleRuns("nightly")
+	require.NoError(t, err)
+	require.Empty(t, runs)
+}
+
+func TestScheduleRunsTrim(t *testing.T) {
+	dir := t.TempDir()
+	s := NewService(WithConfigDirPath(dir))
+	require.NoError(t, s.Start())
+	defer s.Stop()
+	sp := s.ScheduleProvider()
+
+	path := filepath.Join(dir, "schedules", "history", "HOURLY.jsonl")
+	countLines := func() int {
+		content, err := os.ReadFile(path)
+		require.NoError(t, err)
+		return bytes.Count(content, []byte("\n"))
+	}
+
+	// the run is appended as a line, without rewriting the history
+	for i := 0; i < 2*ScheduleRunHistoryLimit-1; i++ {
+		require.NoError(t, sp.AddScheduleRun("hourly", &ScheduleRun{Records: int64(i)}))
+	}
+	require.Equal(t, 2*ScheduleRunHistoryLimit-1, countLines())
+	runs, err := sp.LoadScheduleRuns("hourly")
+	require.NoError(t, err)
+	require.Len(t, runs, ScheduleRunHistoryLimit)
+	require.Equal(t, int64(ScheduleRunHistoryLimit-1), runs[0].Records)
+
+	// trimmed to the limit when it grows to twice of the limit
+	require.NoError(t, sp.AddScheduleRun("hourly", &ScheduleRun{Records: int64(2*ScheduleRunHistoryLimit - 1)}))
+	require.Equal(t, ScheduleRunHistoryLimit, countLines())
+	runs, err = sp.LoadScheduleRuns("hourly")
+	require.NoError(t, err)
+	require.Len(t, runs, ScheduleRunHistoryLimit)
+	require.Equal(t, int64(ScheduleRunHistoryLimit), runs[0].Records)
+	require.Equal(t, int64(2*ScheduleRunHistoryLimit-1), runs[len(runs)-1].Records)
+
+	// the broken line of the interrupted write is skipped
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"records":`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, sp.AddScheduleRun("hourly", &ScheduleRun{Records: -1}))
+	runs, err = sp.LoadScheduleRuns("hourly")
+	require.NoError(t, err)
+	require.Len(t, runs, ScheduleRunHistoryLimit)
+	require.Equal(t, int64(-1), runs[len(runs)-1].Records)
+}
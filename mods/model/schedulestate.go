@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ScheduleState is the checkpoint that the task of a schedule carries over to the next run,
+// the keys are set by SET_STATE() of the tql script and read by state().
+//
+// The values are persisted with the types, so that time.Time and int64 survive the round trip.
+//
+//	{"last": {"type": "time", "value": "1700000000000000000"}}
+type ScheduleState map[string]any
+
+type scheduleStateValue struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+func (st ScheduleState) MarshalJSON() ([]byte, error) {
+	values := make(map[string]scheduleStateValue, len(st))
+	for k, v := range st {
+		switch val := v.(type) {
+		case time.Time:
+			values[k] = scheduleStateValue{Type: "time", Value: strconv.FormatInt(val.UnixNano(), 10)}
+		case int64:
+			values[k] = scheduleStateValue{Type: "int64", Value: strconv.FormatInt(val, 10)}
+		case float64:
+			values[k] = scheduleStateValue{Type: "float64", Value: strconv.FormatFloat(val, 'g', -1, 64)}
+		case bool:
+			values[k] = scheduleStateValue{Type: "bool", Value: strconv.FormatBool(val)}
+		case string:
+			values[k] = scheduleStateValue{Type: "string", Value: val}
+		default:
+			return nil, fmt.Errorf("state %q unsupported type %T", k, v)
+		}
+	}
+	return json.Marshal(values)
+}
+
+func (st *ScheduleState) UnmarshalJSON(data []byte) error {
+	values := map[string]scheduleStateValue{}
+	if err := json.Unmarshal(data, &values); err != nil {
+		return err
+	}
+	ret := make(ScheduleState, len(values))
+	for k, v := range values {
+		var err error
+		switch v.Type {
+		case "time":
+			var ts int64
+			if ts, err = strconv.ParseInt(v.Value, 10, 64); err == nil {
+				ret[k] = time.Unix(0, ts)
+			}
+		case "int64":
+			ret[k], err = strconv.ParseInt(v.Value, 10, 64)
+		case "float64":
+			ret[k], err = strconv.ParseFloat(v.Value, 64)
+		case "bool":
+			ret[k], err = strconv.ParseBool(v.Value)
+		case "string":
+			ret[k] = v.Value
+		default:
+			err = fmt.Errorf("unsupported type %q", v.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("state %q %s", k, err.Error())
+		}
+	}
+	*st = ret
+	return nil
+}
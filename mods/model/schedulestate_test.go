@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleState(t *testing.T) {
+	s := NewService(WithConfigDirPath(t.TempDir()))
+	require.NoError(t, s.Start())
+	defer s.Stop()
+
+	sp := s.ScheduleProvider()
+	require.NoError(t, sp.SaveSchedule(&ScheduleDefinition{Name: "incr", Type: SCHEDULE_TIMER, Task: "incr.tql", Schedule: "@every 1m"}))
+
+	st, err := sp.LoadScheduleState("incr")
+	require.NoError(t, err)
+	require.Empty(t, st)
+
+	ts := time.Unix(0, 1700000000123456789)
+	require.NoError(t, sp.SaveScheduleState("incr", ScheduleState{
+		"last":  ts,
+		"count": int64(1234567890123456789),
+		"avg":   1.5,
+		"ok":    true,
+		"tag":   "sensor-1",
+	}))
+
+	st, err = sp.LoadScheduleState("INCR")
+	require.NoError(t, err)
+	require.Equal(t, ts.UnixNano(), st["last"].(time.Time).UnixNano())
+	require.Equal(t, int64(1234567890123456789), st["count"])
+	require.Equal(t, 1.5, st["avg"])
+	require.Equal(t, true, st["ok"])
+	require.Equal(t, "sensor-1", st["tag"])
+
+	require.EqualError(t, sp.SaveScheduleState("incr", ScheduleState{"bad": []int{1}}),
+		`state "bad" unsupported type []int`)
+
+	// the state file should not be listed as a schedule
+	list, err := sp.LoadAllSchedules()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	require.NoError(t, sp.RemoveSchedule("incr"))
+	st, err = sp.LoadScheduleState("incr")
+	require.NoError(t, err)
+	require.Empty(t, st)
+}
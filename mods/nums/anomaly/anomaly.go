@@ -0,0 +1,398 @@
+// Package anomaly implements the streaming anomaly detectors.
+//
+// A detector scores the values one by one in the order of arrival with Push(),
+// or scores the whole series at once with Batch(), which learns the statistics
+// from the entire series before scoring.
+// The score is signed and in the unit of the (robust) standard deviation,
+// so that |score| > threshold flags the anomaly.
+package anomaly
+
+import (
+	"math"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+type Detector interface {
+	// Push adds the value and returns the score of it,
+	// ready is false while the detector has not seen enough values yet.
+	// The score is NaN if it can not be computed at all.
+	Push(v float64) (score float64, ready bool)
+	// Batch returns the scores of the series, NaN for the values those can not be scored.
+	Batch(values []float64) []float64
+}
+
+var (
+	_ Detector = (*ZScore)(nil)
+	_ Detector = (*EWMA)(nil)
+	_ Detector = (*MAD)(nil)
+	_ Detector = (*CUSUM)(nil)
+	_ Detector = (*Seasonal)(nil)
+)
+
+// madScale makes the median absolute deviation consistent with the standard deviation of the normal distribution.
+const madScale = 0.6745
+
+// deviation returns diff/scale, it is ±Inf if scale is zero but diff is not.
+func deviation(diff float64, scale float64) float64 {
+	if scale == 0 || math.IsNaN(scale) {
+		if diff == 0 {
+			return 0
+		}
+		return math.Inf(int(math.Copysign(1, diff)))
+	}
+	return diff / scale
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// medianAbsDev returns the median and the median absolute deviation of the values.
+func medianAbsDev(values []float64) (float64, float64) {
+	m := median(values)
+	abs := make([]float64, len(values))
+	for i, v := range values {
+		abs[i] = math.Abs(v - m)
+	}
+	return m, median(abs)
+}
+
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) < 2 {
+		return math.NaN(), math.NaN()
+	}
+	return stat.MeanStdDev(values, nil)
+}
+
+// window keeps the latest n values.
+type window struct {
+	size   int
+	values []float64
+}
+
+func (w *window) push(v float64) {
+	w.values = append(w.values, v)
+	if len(w.values) > w.size {
+		w.values = w.values[len(w.values)-w.size:]
+	}
+}
+
+func (w *window) full() bool {
+	return len(w.values) >= w.size
+}
+
+// ZScore scores the value against the mean and the standard deviation
+// of the previous values in the rolling window.
+type ZScore struct {
+	win window
+}
+
+func NewZScore(size int) *ZScore {
+	return &ZScore{win: window{size: size}}
+}
+
+func (z *ZScore) Push(v float64) (float64, bool) {
+	ready := z.win.full()
+	score := math.NaN()
+	if mean, std := meanStdDev(z.win.values); !math.IsNaN(mean) {
+		score = deviation(v-mean, std)
+	}
+	z.win.push(v)
+	return score, ready
+}
+
+func (z *ZScore) Batch(values []float64) []float64 {
+	ret := make([]float64, len(values))
+	mean, std := meanStdDev(values)
+	for i, v := range values {
+		if math.IsNaN(mean) {
+			ret[i] = math.NaN()
+		} else {
+			ret[i] = deviation(v-mean, std)
+		}
+	}
+	return ret
+}
+
+// MAD scores the value against the median and the median absolute deviation
+// of the previous values in the rolling window, it is robust to the outliers in the window.
+type MAD struct {
+	win window
+}
+
+func NewMAD(size int) *MAD {
+	return &MAD{win: window{size: size}}
+}
+
+func (d *MAD) Push(v float64) (float64, bool) {
+	ready := d.win.full()
+	score := math.NaN()
+	if len(d.win.values) > 0 {
+		m, mad := medianAbsDev(d.win.values)
+		score = deviation(madScale*(v-m), mad)
+	}
+	d.win.push(v)
+	return score, ready
+}
+
+func (d *MAD) Batch(values []float64) []float64 {
+	ret := make([]float64, len(values))
+	m, mad := medianAbsDev(values)
+	for i, v := range values {
+		ret[i] = deviation(madScale*(v-m), mad)
+	}
+	return ret
+}
+
+// EWMA is the exponentially weighted moving average control chart.
+// The score is the distance of the moving average from the mean of the values so far,
+// in the unit of the standard deviation of the moving average.
+type EWMA struct {
+	lambda float64
+	n      int
+	mean   float64
+	m2     float64
+	ewma   float64
+}
+
+// NewEWMA returns the EWMA chart with the smoothing factor 0 < lambda <= 1.
+func NewEWMA(lambda float64) *EWMA {
+	return &EWMA{lambda: lambda}
+}
+
+func (e *EWMA) Push(v float64) (float64, bool) {
+	if e.n == 0 {
+		e.ewma = v
+	} else {
+		e.ewma = e.lambda*v + (1-e.lambda)*e.ewma
+	}
+	// Welford's online algorithm for the mean and the variance
+	e.n++
+	delta := v - e.mean
+	e.mean += delta / float64(e.n)
+	e.m2 += delta * (v - e.mean)
+
+	ready := float64(e.n) >= math.Ceil(1/e.lambda)
+	if e.n < 2 {
+		return math.NaN(), ready
+	}
+	std := math.Sqrt(e.m2 / float64(e.n-1))
+	return deviation(e.ewma-e.mean, e.sigma(std, e.n)), ready
+}
+
+// sigma returns the standard deviation of the moving average at the n-th value.
+func (e *EWMA) sigma(std float64, n int) float64 {
+	return std * math.Sqrt(e.lambda/(2-e.lambda)*(1-math.Pow(1-e.lambda, 2*float64(n))))
+}
+
+func (e *EWMA) Batch(values []float64) []float64 {
+	ret := make([]float64, len(values))
+	mean, std := meanStdDev(values)
+	ewma := mean
+	for i, v := range values {
+		if math.IsNaN(mean) {
+			ret[i] = math.NaN()
+			continue
+		}
+		ewma = e.lambda*v + (1-e.lambda)*ewma
+		ret[i] = deviation(ewma-mean, e.sigma(std, i+1))
+	}
+	return ret
+}
+
+// CUSUM is the two-sided cumulative sum control chart for the change-point detection.
+// The first values up to the baseline size establish the mean and the standard deviation,
+// then the deviations larger than the slack k are accumulated.
+// The score is the upper sum if it is larger than the lower sum, or the negative lower sum.
+// The sums are restarted after the score exceeds the decision interval h.
+type CUSUM struct {
+	baseline []float64
+	size     int
+	k        float64
+	h        float64
+	mean     float64
+	std      float64
+	upper    float64
+	lower    float64
+}
+
+func NewCUSUM(baselineSize int, k float64, h float64) *CUSUM {
+	return &CUSUM{size: baselineSize, k: k, h: h}
+}
+
+func (c *CUSUM) Push(v float64) (float64, bool) {
+	if len(c.baseline) < c.size {
+		c.baseline = append(c.baseline, v)
+		mean, std := meanStdDev(c.baseline)
+		if math.IsNaN(mean) {
+			return math.NaN(), false
+		}
+		c.mean, c.std = mean, std
+		return deviation(v-mean, std), false
+	}
+	return c.step(v), true
+}
+
+func (c *CUSUM) step(v float64) float64 {
+	s := deviation(v-c.mean, c.std)
+	c.upper = math.Max(0, c.upper+s-c.k)
+	c.lower = math.Max(0, c.lower-s-c.k)
+	score := c.upper
+	if c.lower > c.upper {
+		score = -c.lower
+	}
+	if math.Abs(score) > c.h {
+		c.upper, c.lower = 0, 0
+	}
+	return score
+}
+
+func (c *CUSUM) Batch(values []float64) []float64 {
+	ret := make([]float64, len(values))
+	mean, std := meanStdDev(values)
+	batch := &CUSUM{k: c.k, h: c.h, mean: mean, std: std}
+	for i, v := range values {
+		if math.IsNaN(mean) {
+			ret[i] = math.NaN()
+		} else {
+			ret[i] = batch.step(v)
+		}
+	}
+	return ret
+}
+
+// Seasonal scores the residual of the seasonal-trend decomposition, like STL.
+//
+// The seasonal component of a phase is the median of the detrended values of the phase in the last cycles,
+// the trend is the moving median of the deseasonalized values of the last period,
+// and the residual is scored against the median and the median absolute deviation of the previous residuals.
+// The medians keep a spike from leaking into the trend and the seasonal components.
+type Seasonal struct {
+	period    int
+	cycles    int
+	n         int
+	values    window
+	detrended []window // by the phase
+	residuals window
+}
+
+func NewSeasonal(period int, cycles int) *Seasonal {
+	ret := &Seasonal{
+		period:    period,
+		cycles:    cycles,
+		values:    window{size: period},
+		detrended: make([]window, period),
+		residuals: window{size: period * cycles},
+	}
+	for i := range ret.detrended {
+		ret.detrended[i] = window{size: cycles}
+	}
+	return ret
+}
+
+// seasonal returns the seasonal component of the phase, zero if the phase has not been seen yet.
+func (s *Seasonal) seasonal(phase int) float64 {
+	if w := s.detrended[phase]; len(w.values) > 0 {
+		return median(w.values)
+	}
+	return 0
+}
+
+// trend returns the moving median of the deseasonalized values in the window.
+func (s *Seasonal) trend() float64 {
+	values := s.values.values
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	first := s.n - len(values)
+	deseasonalized := make([]float64, len(values))
+	for i, v := range values {
+		deseasonalized[i] = v - s.seasonal((first+i)%s.period)
+	}
+	return median(deseasonalized)
+}
+
+func (s *Seasonal) Push(v float64) (float64, bool) {
+	phase := s.n % s.period
+	trend := s.trend()
+	s.values.push(v)
+	s.n++
+	if math.IsNaN(trend) {
+		return math.NaN(), false
+	}
+
+	score := math.NaN()
+	seasonal := &s.detrended[phase]
+	if len(seasonal.values) > 0 {
+		residual := v - trend - median(seasonal.values)
+		if len(s.residuals.values) > 0 {
+			m, mad := medianAbsDev(s.residuals.values)
+			score = deviation(madScale*(residual-m), mad)
+		}
+		s.residuals.push(residual)
+	}
+	ready := seasonal.full() && len(s.residuals.values) > s.period
+	seasonal.push(v - trend)
+	return score, ready
+}
+
+func (s *Seasonal) Batch(values []float64) []float64 {
+	n := len(values)
+	ret := make([]float64, n)
+	if n == 0 {
+		return ret
+	}
+	// centered moving median of the period
+	movingMedian := func(series []float64) []float64 {
+		ret := make([]float64, len(series))
+		for i := range series {
+			from, to := max(0, i-s.period/2), min(len(series), i-s.period/2+s.period)
+			ret[i] = median(series[from:to])
+		}
+		return ret
+	}
+	seasonalOf := func(trend []float64) []float64 {
+		byPhase := make([][]float64, s.period)
+		for i, v := range values {
+			byPhase[i%s.period] = append(byPhase[i%s.period], v-trend[i])
+		}
+		ret := make([]float64, s.period)
+		for p := range byPhase {
+			if len(byPhase[p]) > 0 {
+				ret[p] = median(byPhase[p])
+			}
+		}
+		return ret
+	}
+	// the first pass estimates the seasonal components with the trend of the raw values,
+	// the second pass refines the trend with the deseasonalized values.
+	trend := movingMedian(values)
+	seasonal := seasonalOf(trend)
+	deseasonalized := make([]float64, n)
+	for i, v := range values {
+		deseasonalized[i] = v - seasonal[i%s.period]
+	}
+	trend = movingMedian(deseasonalized)
+	seasonal = seasonalOf(trend)
+
+	residuals := make([]float64, n)
+	for i, v := range values {
+		residuals[i] = v - trend[i] - seasonal[i%s.period]
+	}
+	m, mad := medianAbsDev(residuals)
+	for i, r := range residuals {
+		ret[i] = deviation(madScale*(r-m), mad)
+	}
+	return ret
+}
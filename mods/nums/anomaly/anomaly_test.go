@@ -0,0 +1,140 @@
+package anomaly
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// series returns a periodic series with a little noise and a spike at the given index.
+func series(n int, spikeAt int) []float64 {
+	ret := make([]float64, n)
+	for i := range ret {
+		ret[i] = 10 + float64(i%4) + 0.1*math.Sin(float64(i)*1.7)
+		if i == spikeAt {
+			ret[i] += 20
+		}
+	}
+	return ret
+}
+
+func pushAll(d Detector, values []float64) ([]float64, []bool) {
+	scores := make([]float64, len(values))
+	ready := make([]bool, len(values))
+	for i, v := range values {
+		scores[i], ready[i] = d.Push(v)
+	}
+	return scores, ready
+}
+
+func TestZScore(t *testing.T) {
+	scores, ready := pushAll(NewZScore(8), series(20, 15))
+	require.True(t, math.IsNaN(scores[0]))
+	require.False(t, ready[7])
+	require.True(t, ready[8])
+	for i := 8; i < 20; i++ {
+		if i == 15 {
+			require.Greater(t, scores[i], 3.0)
+		} else {
+			require.Less(t, math.Abs(scores[i]), 3.0, "index %d", i)
+		}
+	}
+
+	batch := NewZScore(8).Batch(series(20, 15))
+	require.Greater(t, batch[15], 3.0)
+	require.Less(t, math.Abs(batch[0]), 3.0)
+}
+
+func TestConstantWindow(t *testing.T) {
+	// the step after the constant values is scored infinite, the same value is zero
+	values := []float64{1, 1, 1, 1, 1, 5, 1}
+	scores, _ := pushAll(NewZScore(5), values)
+	require.Equal(t, 0.0, scores[4])
+	require.True(t, math.IsInf(scores[5], 1))
+	scores, _ = pushAll(NewMAD(5), values)
+	require.True(t, math.IsInf(scores[5], 1))
+	require.Equal(t, 0.0, scores[6])
+}
+
+func TestMAD(t *testing.T) {
+	// the outlier in the window does not mask the next outlier
+	values := series(20, 12)
+	values[14] += 20
+	scores, ready := pushAll(NewMAD(8), values)
+	require.True(t, ready[12])
+	require.Greater(t, scores[12], 3.0)
+	require.Greater(t, scores[14], 3.0)
+	require.Less(t, math.Abs(scores[13]), 3.0)
+
+	batch := NewMAD(8).Batch(values)
+	require.Greater(t, batch[12], 3.0)
+	require.Less(t, math.Abs(batch[13]), 3.0)
+}
+
+func TestEWMA(t *testing.T) {
+	values := make([]float64, 60)
+	for i := range values {
+		values[i] = 10 + float64(i%2)
+		if i >= 40 {
+			values[i] += 5 // level shift
+		}
+	}
+	scores, ready := pushAll(NewEWMA(0.2), values)
+	require.False(t, ready[3])
+	require.True(t, ready[4])
+	require.Less(t, math.Abs(scores[39]), 3.0)
+	require.Greater(t, scores[45], 3.0)
+
+	batch := NewEWMA(0.2).Batch(values)
+	require.Less(t, batch[20], 0.0)
+	require.Greater(t, batch[50], 3.0)
+}
+
+func TestCUSUM(t *testing.T) {
+	values := make([]float64, 40)
+	for i := range values {
+		values[i] = 10 + float64(i%2)
+		if i >= 20 {
+			values[i] += 2 // small shift
+		}
+	}
+	scores, ready := pushAll(NewCUSUM(10, 0.5, 5), values)
+	require.False(t, ready[9])
+	require.True(t, ready[10])
+	for i := 10; i < 20; i++ {
+		require.LessOrEqual(t, math.Abs(scores[i]), 5.0, "index %d", i)
+	}
+	alarm := -1
+	for i := 20; i < 40; i++ {
+		if scores[i] > 5 {
+			alarm = i
+			break
+		}
+	}
+	require.GreaterOrEqual(t, alarm, 20)
+	require.Less(t, alarm, 25)
+	// restarted after the alarm
+	require.Less(t, scores[alarm+1], scores[alarm])
+
+	batch := NewCUSUM(10, 0.5, 5).Batch(values)
+	require.Len(t, batch, 40)
+}
+
+func TestSeasonal(t *testing.T) {
+	values := series(40, 30)
+	scores, ready := pushAll(NewSeasonal(4, 3), values)
+	require.False(t, ready[4])
+	require.True(t, ready[20])
+	for i := 20; i < 40; i++ {
+		if i == 30 {
+			require.Greater(t, scores[i], 3.0)
+		} else {
+			require.Less(t, math.Abs(scores[i]), 3.0, "index %d", i)
+		}
+	}
+
+	batch := NewSeasonal(4, 3).Batch(values)
+	require.Greater(t, batch[30], 3.0)
+	require.Less(t, math.Abs(batch[10]), 3.0)
+}
@@ -0,0 +1,136 @@
+package fft
+
+import (
+	"fmt"
+	"math"
+)
+
+// Butterworth is the digital Butterworth filter of the cascaded second-order sections,
+// designed by the bilinear transform with the pre-warped cutoff frequencies.
+// The band-pass filter is the cascade of the high-pass and the low-pass filters of the order.
+type Butterworth struct {
+	sections []*biquad
+}
+
+func NewLowPass(order int, cutoff float64, sampleRate float64) (*Butterworth, error) {
+	if err := checkCutoff(order, cutoff, sampleRate); err != nil {
+		return nil, err
+	}
+	return &Butterworth{sections: butterworthSections(order, cutoff, sampleRate, false)}, nil
+}
+
+func NewHighPass(order int, cutoff float64, sampleRate float64) (*Butterworth, error) {
+	if err := checkCutoff(order, cutoff, sampleRate); err != nil {
+		return nil, err
+	}
+	return &Butterworth{sections: butterworthSections(order, cutoff, sampleRate, true)}, nil
+}
+
+func NewBandPass(order int, low float64, high float64, sampleRate float64) (*Butterworth, error) {
+	if low >= high {
+		return nil, fmt.Errorf("low cutoff %v should be less than high cutoff %v", low, high)
+	}
+	if err := checkCutoff(order, low, sampleRate); err != nil {
+		return nil, err
+	}
+	if err := checkCutoff(order, high, sampleRate); err != nil {
+		return nil, err
+	}
+	sections := butterworthSections(order, low, sampleRate, true)
+	sections = append(sections, butterworthSections(order, high, sampleRate, false)...)
+	return &Butterworth{sections: sections}, nil
+}
+
+func checkCutoff(order int, cutoff float64, sampleRate float64) error {
+	if order < 1 {
+		return fmt.Errorf("order should be larger than 0")
+	}
+	if sampleRate <= 0 {
+		return fmt.Errorf("sample rate should be positive")
+	}
+	if cutoff <= 0 || cutoff >= sampleRate/2 {
+		return fmt.Errorf("cutoff %v should be between 0 and the Nyquist frequency %v", cutoff, sampleRate/2)
+	}
+	return nil
+}
+
+// Step filters the next sample.
+func (bw *Butterworth) Step(x float64) float64 {
+	for _, s := range bw.sections {
+		x = s.step(x)
+	}
+	return x
+}
+
+// Filter filters the samples from the initial state.
+func (bw *Butterworth) Filter(values []float64) []float64 {
+	bw.Reset()
+	ret := make([]float64, len(values))
+	for i, v := range values {
+		ret[i] = bw.Step(v)
+	}
+	return ret
+}
+
+func (bw *Butterworth) Reset() {
+	for _, s := range bw.sections {
+		s.z1, s.z2 = 0, 0
+	}
+}
+
+// biquad is the second-order section of the transposed direct form II,
+// a first-order section has b2 = a2 = 0.
+type biquad struct {
+	b0, b1, b2 float64
+	a1, a2     float64
+	z1, z2     float64
+}
+
+func (s *biquad) step(x float64) float64 {
+	y := s.b0*x + s.z1
+	s.z1 = s.b1*x - s.a1*y + s.z2
+	s.z2 = s.b2*x - s.a2*y
+	return y
+}
+
+func butterworthSections(order int, cutoff float64, sampleRate float64, highPass bool) []*biquad {
+	k := math.Tan(math.Pi * cutoff / sampleRate)
+	ret := []*biquad{}
+	if order%2 == 1 {
+		s := &biquad{a1: (k - 1) / (k + 1)}
+		if highPass {
+			s.b0 = 1 / (1 + k)
+			s.b1 = -s.b0
+		} else {
+			s.b0 = k / (1 + k)
+			s.b1 = s.b0
+		}
+		ret = append(ret, s)
+	}
+	for i := 0; i < order/2; i++ {
+		// Q of the pole pairs of the Butterworth polynomial
+		var theta float64
+		if order%2 == 0 {
+			theta = math.Pi * float64(2*i+1) / float64(2*order)
+		} else {
+			theta = math.Pi * float64(i+1) / float64(order)
+		}
+		q := 1 / (2 * math.Cos(theta))
+		norm := 1 / (1 + k/q + k*k)
+		s := &biquad{
+			a1: 2 * (k*k - 1) * norm,
+			a2: (1 - k/q + k*k) * norm,
+		}
+		if highPass {
+			s.b0 = norm
+			s.b1 = -2 * norm
+			s.b2 = norm
+		} else {
+			s.b0 = k * k * norm
+			s.b1 = 2 * s.b0
+			s.b2 = s.b0
+		}
+		ret = append(ret, s)
+	}
+	return ret
+}
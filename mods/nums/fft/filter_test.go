@@ -0,0 +1,48 @@
+package fft_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/machbase/neo-server/v8/mods/nums/fft"
+	"github.com/stretchr/testify/require"
+)
+
+// gain returns the steady state amplitude ratio of the filter at the frequency.
+func gain(t *testing.T, bw *fft.Butterworth, freq float64, fs float64) float64 {
+	t.Helper()
+	out := bw.Filter(sine(4000, fs, freq, 1.0))
+	peak := 0.0
+	for _, v := range out[2000:] {
+		peak = math.Max(peak, math.Abs(v))
+	}
+	return peak
+}
+
+func TestButterworth(t *testing.T) {
+	fs := 1000.0
+	for _, order := range []int{1, 2, 3, 4} {
+		lp, err := fft.NewLowPass(order, 50, fs)
+		require.NoError(t, err)
+		require.InDelta(t, 1.0, gain(t, lp, 5, fs), 0.01)
+		require.InDelta(t, math.Sqrt(0.5), gain(t, lp, 50, fs), 0.01, "order %d", order)
+		require.Less(t, gain(t, lp, 400, fs), 0.1)
+
+		hp, err := fft.NewHighPass(order, 50, fs)
+		require.NoError(t, err)
+		require.Less(t, gain(t, hp, 5, fs), 0.2)
+		require.InDelta(t, math.Sqrt(0.5), gain(t, hp, 50, fs), 0.01, "order %d", order)
+		require.InDelta(t, 1.0, gain(t, hp, 300, fs), 0.03)
+	}
+
+	bp, err := fft.NewBandPass(4, 40, 160, fs)
+	require.NoError(t, err)
+	require.Less(t, gain(t, bp, 5, fs), 0.01)
+	require.InDelta(t, 1.0, gain(t, bp, 80, fs), 0.05)
+	require.Less(t, gain(t, bp, 450, fs), 0.01)
+
+	_, err = fft.NewBandPass(4, 160, 40, fs)
+	require.EqualError(t, err, "low cutoff 160 should be less than high cutoff 40")
+	_, err = fft.NewHighPass(2, 600, fs)
+	require.EqualError(t, err, "cutoff 600 should be between 0 and the Nyquist frequency 500")
+}
@@ -0,0 +1,48 @@
+package fft
+
+import "sort"
+
+// FindPeaks returns the indexes of the local maxima those are at least minHeight,
+// in the increasing order.
+// If minDistance > 1, the lower peaks those are closer than minDistance samples
+// to a higher peak are removed.
+func FindPeaks(values []float64, minHeight float64, minDistance int) []int {
+	candidates := []int{}
+	for i := 1; i < len(values)-1; i++ {
+		if values[i] < minHeight || values[i] <= values[i-1] {
+			continue
+		}
+		// the plateau is a peak at its first sample
+		j := i
+		for j+1 < len(values) && values[j+1] == values[i] {
+			j++
+		}
+		if j+1 < len(values) && values[j+1] < values[i] {
+			candidates = append(candidates, i)
+		}
+		i = j
+	}
+	if minDistance <= 1 || len(candidates) < 2 {
+		return candidates
+	}
+	byHeight := append([]int{}, candidates...)
+	sort.SliceStable(byHeight, func(a, b int) bool { return values[byHeight[a]] > values[byHeight[b]] })
+	removed := map[int]bool{}
+	for _, p := range byHeight {
+		if removed[p] {
+			continue
+		}
+		for _, q := range candidates {
+			if q != p && !removed[q] && q-p < minDistance && p-q < minDistance {
+				removed[q] = true
+			}
+		}
+	}
+	ret := []int{}
+	for _, p := range candidates {
+		if !removed[p] {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,16 @@
+package fft_test
+
+import (
+	"testing"
+
+	"github.com/machbase/neo-server/v8/mods/nums/fft"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPeaks(t *testing.T) {
+	values := []float64{0, 2, 1, 5, 5, 3, 4, 0, 1, 0, 6, 0}
+	require.Equal(t, []int{1, 3, 6, 8, 10}, fft.FindPeaks(values, 0, 0))
+	require.Equal(t, []int{1, 3, 6, 10}, fft.FindPeaks(values, 2, 0))
+	require.Equal(t, []int{3, 10}, fft.FindPeaks(values, 0, 4))
+	require.Empty(t, fft.FindPeaks(values[:2], 0, 0))
+}
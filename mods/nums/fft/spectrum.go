@@ -0,0 +1,167 @@
+package fft
+
+import (
+	"math"
+	"math/cmplx"
+	"time"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// SampleRate returns the sampling frequency in Hz of the evenly spaced sample times,
+// it returns 0 if the times are not enough or not increasing.
+func SampleRate(times []time.Time) float64 {
+	if len(times) < 2 {
+		return 0
+	}
+	duration := times[len(times)-1].Sub(times[0])
+	if duration <= 0 {
+		return 0
+	}
+	return float64(len(times)-1) / duration.Seconds()
+}
+
+// Hann returns the Hann window of the size.
+func Hann(size int) []float64 {
+	ret := make([]float64, size)
+	if size == 1 {
+		ret[0] = 1
+		return ret
+	}
+	for i := range ret {
+		ret[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(size-1))
+	}
+	return ret
+}
+
+// Frame is the spectrum of a segment of the samples.
+type Frame struct {
+	Offset    int       // index of the first sample of the segment
+	Amplitude []float64 // amplitude of Hz(), excluding DC
+}
+
+// Spectrogram is the short-time Fourier transform of the samples.
+type Spectrogram struct {
+	Hz     []float64
+	Frames []Frame
+}
+
+// STFT computes the amplitude spectra of the Hann windowed segments of the size,
+// those start at every hop samples.
+// The amplitude is corrected by the coherent gain of the window,
+// so that a sinusoid of amplitude A in a segment shows the peak A.
+func STFT(values []float64, sampleRate float64, size int, hop int) *Spectrogram {
+	if size < 2 || hop < 1 || len(values) < size || sampleRate <= 0 {
+		return nil
+	}
+	window := Hann(size)
+	gain := 0.0
+	for _, w := range window {
+		gain += w
+	}
+	transform := fourier.NewFFT(size)
+	coeff := make([]complex128, size/2+1)
+	segment := make([]float64, size)
+
+	ret := &Spectrogram{}
+	for i := 1; i < len(coeff); i++ {
+		ret.Hz = append(ret.Hz, transform.Freq(i)*sampleRate)
+	}
+	for offset := 0; offset+size <= len(values); offset += hop {
+		for i := range segment {
+			segment[i] = values[offset+i] * window[i]
+		}
+		transform.Coefficients(coeff, segment)
+		frame := Frame{Offset: offset, Amplitude: make([]float64, len(coeff)-1)}
+		for i, c := range coeff[1:] {
+			frame.Amplitude[i] = cmplx.Abs(c) * 2 / gain
+		}
+		ret.Frames = append(ret.Frames, frame)
+	}
+	return ret
+}
+
+// Welch estimates the one-sided power spectral density (unit^2/Hz) by the Welch's method,
+// averaging the periodograms of the Hann windowed segments of the size with 50% overlap.
+// The size is reduced to the number of the samples if it is larger.
+func Welch(values []float64, sampleRate float64, size int) ([]float64, []float64) {
+	if size > len(values) {
+		size = len(values)
+	}
+	if size < 2 || sampleRate <= 0 {
+		return nil, nil
+	}
+	window := Hann(size)
+	scale := 0.0
+	for _, w := range window {
+		scale += w * w
+	}
+	scale *= sampleRate
+	hop := size / 2
+	if hop < 1 {
+		hop = 1
+	}
+	transform := fourier.NewFFT(size)
+	coeff := make([]complex128, size/2+1)
+	segment := make([]float64, size)
+	psd := make([]float64, len(coeff))
+	count := 0
+	for offset := 0; offset+size <= len(values); offset += hop {
+		// remove the mean of the segment (detrend constant)
+		mean := 0.0
+		for i := range segment {
+			mean += values[offset+i]
+		}
+		mean /= float64(size)
+		for i := range segment {
+			segment[i] = (values[offset+i] - mean) * window[i]
+		}
+		transform.Coefficients(coeff, segment)
+		for i, c := range coeff {
+			p := real(c)*real(c) + imag(c)*imag(c)
+			if i != 0 && !(size%2 == 0 && i == len(coeff)-1) {
+				// one-sided, except DC and Nyquist
+				p *= 2
+			}
+			psd[i] += p / scale
+		}
+		count++
+	}
+	hz := make([]float64, len(coeff))
+	for i := range psd {
+		psd[i] /= float64(count)
+		hz[i] = transform.Freq(i) * sampleRate
+	}
+	return hz, psd
+}
+
+// Envelope returns the amplitude envelope of the samples,
+// that is the magnitude of the analytic signal by the Hilbert transform.
+func Envelope(values []float64) []float64 {
+	n := len(values)
+	if n == 0 {
+		return nil
+	}
+	transform := fourier.NewCmplxFFT(n)
+	seq := make([]complex128, n)
+	for i, v := range values {
+		seq[i] = complex(v, 0)
+	}
+	coeff := transform.Coefficients(nil, seq)
+	// keep DC (and Nyquist), double the positive frequencies, zero the negatives
+	for i := 1; i < n; i++ {
+		switch {
+		case n%2 == 0 && i == n/2:
+		case i < (n+1)/2:
+			coeff[i] *= 2
+		default:
+			coeff[i] = 0
+		}
+	}
+	analytic := transform.Sequence(nil, coeff)
+	ret := make([]float64, n)
+	for i, c := range analytic {
+		ret[i] = cmplx.Abs(c) / float64(n)
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package fft_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/nums/fft"
+	"github.com/stretchr/testify/require"
+)
+
+// sine returns n samples of the sinusoids at the sample rate.
+func sine(n int, sampleRate float64, freqAmps ...float64) []float64 {
+	ret := make([]float64, n)
+	for i := range ret {
+		t := float64(i) / sampleRate
+		for f := 0; f+1 < len(freqAmps); f += 2 {
+			ret[i] += freqAmps[f+1] * math.Sin(2*math.Pi*freqAmps[f]*t)
+		}
+	}
+	return ret
+}
+
+func TestSampleRate(t *testing.T) {
+	ts := time.Unix(1685714509, 0)
+	times := []time.Time{ts, ts.Add(time.Millisecond), ts.Add(2 * time.Millisecond)}
+	require.InDelta(t, 1000.0, fft.SampleRate(times), 1e-9)
+	require.Equal(t, 0.0, fft.SampleRate(times[:1]))
+}
+
+func TestSTFT(t *testing.T) {
+	fs := 1000.0
+	// 50Hz for the first half, 200Hz for the second half
+	values := append(sine(1000, fs, 50, 1.0), sine(1000, fs, 200, 2.0)...)
+	sp := fft.STFT(values, fs, 200, 100)
+	require.NotNil(t, sp)
+	require.Len(t, sp.Frames, 19)
+	require.Len(t, sp.Hz, 100)
+	require.InDelta(t, 5.0, sp.Hz[0], 1e-9)
+
+	peak := func(frame fft.Frame) (float64, float64) {
+		idx := 0
+		for i, a := range frame.Amplitude {
+			if a > frame.Amplitude[idx] {
+				idx = i
+			}
+		}
+		return sp.Hz[idx], frame.Amplitude[idx]
+	}
+	hz, ampl := peak(sp.Frames[0])
+	require.InDelta(t, 50.0, hz, 1e-9)
+	require.InDelta(t, 1.0, ampl, 0.01)
+	hz, ampl = peak(sp.Frames[len(sp.Frames)-1])
+	require.InDelta(t, 200.0, hz, 1e-9)
+	require.InDelta(t, 2.0, ampl, 0.02)
+
+	require.Nil(t, fft.STFT(values[:100], fs, 200, 100))
+}
+
+func TestWelch(t *testing.T) {
+	fs := 1000.0
+	values := sine(4096, fs, 125, 2.0)
+	hz, psd := fft.Welch(values, fs, 256)
+	require.Len(t, hz, 129)
+	require.Len(t, psd, 129)
+	idx := 0
+	total := 0.0
+	for i, p := range psd {
+		if p > psd[idx] {
+			idx = i
+		}
+		total += p * (hz[1] - hz[0])
+	}
+	require.InDelta(t, 125.0, hz[idx], 1e-9)
+	// the power of the sinusoid is A^2/2
+	require.InDelta(t, 2.0, total, 0.05)
+}
+
+func TestEnvelope(t *testing.T) {
+	fs := 1000.0
+	// 200Hz carrier modulated by 5Hz
+	values := make([]float64, 1000)
+	for i := range values {
+		ti := float64(i) / fs
+		values[i] = (1 + 0.5*math.Sin(2*math.Pi*5*ti)) * math.Sin(2*math.Pi*200*ti)
+	}
+	env := fft.Envelope(values)
+	require.Len(t, env, len(values))
+	for i := 100; i < 900; i++ {
+		ti := float64(i) / fs
+		require.InDelta(t, 1+0.5*math.Sin(2*math.Pi*5*ti), env[i], 0.01, "index %d", i)
+	}
+}
@@ -1,5 +1,7 @@
 package nums
 
+import "math"
+
 // Ram-Douglas-Peucker simplify
 func SimplifyPath(points []Point, ep float64) []Point {
 	if len(points) <= 2 {
@@ -17,3 +19,50 @@ func SimplifyPath(points []Point, ep float64) []Point {
 
 	return []Point{points[0], points[len(points)-1]}
 }
+
+// LTTB downsamples the points to the threshold number of points by Largest-Triangle-Three-Buckets,
+// it returns the indices of the selected points in ascending order.
+// The points should be sorted by X. All indices are returned if threshold is not less than
+// the number of the points or less than 3.
+func LTTB(points []Point, threshold int) []int {
+	n := len(points)
+	if threshold >= n || threshold < 3 {
+		ret := make([]int, n)
+		for i := range ret {
+			ret[i] = i
+		}
+		return ret
+	}
+	ret := make([]int, 0, threshold)
+	ret = append(ret, 0)
+
+	every := float64(n-2) / float64(threshold-2)
+	a := 0
+	for i := 0; i < threshold-2; i++ {
+		// average of the next bucket
+		avgStart := int(float64(i+1)*every) + 1
+		avgEnd := min(int(float64(i+2)*every)+1, n)
+		var avgX, avgY float64
+		for j := avgStart; j < avgEnd; j++ {
+			avgX += points[j][0]
+			avgY += points[j][1]
+		}
+		avgX /= float64(avgEnd - avgStart)
+		avgY /= float64(avgEnd - avgStart)
+
+		// the point of the current bucket that makes the largest triangle
+		start := int(float64(i)*every) + 1
+		end := int(float64(i+1)*every) + 1
+		maxArea, next := -1.0, start
+		for j := start; j < end; j++ {
+			area := math.Abs((points[a][0]-avgX)*(points[j][1]-points[a][1]) -
+				(points[a][0]-points[j][0])*(avgY-points[a][1]))
+			if area > maxArea {
+				maxArea, next = area, j
+			}
+		}
+		ret = append(ret, next)
+		a = next
+	}
+	return append(ret, n-1)
+}
@@ -1,6 +1,9 @@
 package nums
 
-import "testing"
+import (
+	"slices"
+	"testing"
+)
 
 func TestSimplifyPath(t *testing.T) {
 	points := []Point{
@@ -54,3 +57,33 @@ func TestSeekMostDistantPoint(t *testing.T) {
 		t.Errorf("maximum distance is incorrect, got %f", maxDist)
 	}
 }
+
+func TestLTTB(t *testing.T) {
+	points := []Point{
+		{0, 0},
+		{1, 2},
+		{2, 7},
+		{3, 1},
+		{4, 8},
+		{5, 2},
+		{6, 8},
+		{7, 3},
+		{8, 3},
+		{9, 0},
+	}
+	tests := []struct {
+		threshold int
+		expect    []int
+	}{
+		{threshold: 0, expect: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{threshold: 10, expect: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+		{threshold: 3, expect: []int{0, 4, 9}},
+		{threshold: 6, expect: []int{0, 2, 3, 6, 7, 9}},
+	}
+	for _, tt := range tests {
+		ret := LTTB(points, tt.threshold)
+		if !slices.Equal(ret, tt.expect) {
+			t.Errorf("threshold %d, expect %v, got %v", tt.threshold, tt.expect, ret)
+		}
+	}
+}
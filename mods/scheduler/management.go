@@ -3,6 +3,7 @@ package scheduler
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/machbase/neo-server/v8/mods/model"
@@ -26,6 +27,14 @@ type Schedule struct {
 	Bridge    string `json:"bridge,omitempty"`
 	Topic     string `json:"topic,omitempty"`
 	QoS       int32  `json:"QoS,omitempty"`
+
+	Nodes    []string `json:"nodes,omitempty"`
+	Browse   string   `json:"browse,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+
+	Retry   *model.RetryPolicy    `json:"retry,omitempty"`
+	CatchUp string                `json:"catchUp,omitempty"`
+	Steps   []*model.WorkflowStep `json:"steps,omitempty"`
 }
 
 func (s *Service) ListSchedule(context.Context) (*ListScheduleResponse, error) {
@@ -50,6 +59,12 @@ func (s *Service) ListSchedule(context.Context) (*ListScheduleResponse, error) {
 			Bridge:    define.Bridge,
 			Topic:     define.Topic,
 			QoS:       int32(define.QoS),
+			Nodes:     define.Nodes,
+			Browse:    define.Browse,
+			Interval:  define.Interval,
+			Retry:     define.Retry,
+			CatchUp:   string(define.CatchUp),
+			Steps:     define.Steps,
 		}
 		if ent := GetEntry(define.Name); ent != nil {
 			if err := ent.Error(); err != nil {
@@ -94,6 +109,12 @@ func (s *Service) GetSchedule(ctx context.Context, req *GetScheduleRequest) (*Ge
 			Bridge:    define.Bridge,
 			Topic:     define.Topic,
 			QoS:       int32(define.QoS),
+			Nodes:     define.Nodes,
+			Browse:    define.Browse,
+			Interval:  define.Interval,
+			Retry:     define.Retry,
+			CatchUp:   string(define.CatchUp),
+			Steps:     define.Steps,
 		}
 		if ent := GetEntry(define.Name); ent != nil {
 			rsp.Schedule.State = ent.Status().String()
@@ -111,11 +132,16 @@ type AddScheduleRequest struct {
 	Schedule  string            `json:"schedule,omitempty"`
 	Bridge    string            `json:"bridge,omitempty"`
 	Opt       AddScheduleOption `json:"opt"`
+
+	Retry   *model.RetryPolicy    `json:"retry,omitempty"`
+	CatchUp string                `json:"catchUp,omitempty"`
+	Steps   []*model.WorkflowStep `json:"steps,omitempty"`
 }
 
 type AddScheduleOption struct {
-	Mqtt *MqttOption `json:"mqtt,omitempty"`
-	Nats *NatsOption `json:"nats,omitempty"`
+	Mqtt  *MqttOption  `json:"mqtt,omitempty"`
+	Nats  *NatsOption  `json:"nats,omitempty"`
+	Opcua *OpcuaOption `json:"opcua,omitempty"`
 }
 
 type MqttOption struct {
@@ -129,6 +155,12 @@ type NatsOption struct {
 	StreamName string `json:"StreamName,omitempty"`
 }
 
+type OpcuaOption struct {
+	Nodes    []string `json:"Nodes,omitempty"`
+	Browse   string   `json:"Browse,omitempty"`
+	Interval string   `json:"Interval,omitempty"`
+}
+
 type AddScheduleResponse struct {
 	Success bool   `json:"success"`
 	Reason  string `json:"reason"`
@@ -155,6 +187,8 @@ func (s *Service) AddSchedule(ctx context.Context, req *AddScheduleRequest) (*Ad
 	def.Schedule = req.Schedule
 	def.Task = req.Task
 	def.Type = model.ParseScheduleType(req.Type)
+	def.Retry = req.Retry
+	def.Steps = req.Steps
 	if req.Opt.Mqtt != nil {
 		def.Topic = req.Opt.Mqtt.Topic
 		def.QoS = int(req.Opt.Mqtt.QoS)
@@ -162,6 +196,10 @@ func (s *Service) AddSchedule(ctx context.Context, req *AddScheduleRequest) (*Ad
 		def.Topic = req.Opt.Nats.Subject
 		def.QueueName = req.Opt.Nats.QueueName
 		def.StreamName = req.Opt.Nats.StreamName
+	} else if req.Opt.Opcua != nil {
+		def.Nodes = req.Opt.Opcua.Nodes
+		def.Browse = req.Opt.Opcua.Browse
+		def.Interval = req.Opt.Opcua.Interval
 	}
 
 	switch def.Type {
@@ -181,11 +219,40 @@ func (s *Service) AddSchedule(ctx context.Context, req *AddScheduleRequest) (*Ad
 			rsp.Reason = err.Error()
 			return rsp, nil
 		}
+		if _, err := newRetryPolicy(req.Retry); err != nil {
+			rsp.Reason = err.Error()
+			return rsp, nil
+		}
+		if policy, err := model.ParseCatchUpPolicy(req.CatchUp); err != nil {
+			rsp.Reason = err.Error()
+			return rsp, nil
+		} else {
+			def.CatchUp = policy
+		}
+	case model.SCHEDULE_WORKFLOW:
+		if def.Schedule == "" {
+			rsp.Reason = "schedule of workflow type should be specified with timer spec"
+			return rsp, nil
+		}
+		if _, err := parseSchedule(req.Schedule); err != nil {
+			rsp.Reason = err.Error()
+			return rsp, nil
+		}
+		if err := validateWorkflow(req.Steps); err != nil {
+			rsp.Reason = err.Error()
+			return rsp, nil
+		}
 	case model.SCHEDULE_SUBSCRIBER:
-		if def.Bridge == "" || def.Topic == "" {
-			rsp.Reason = "schedule of subscriber type should be specified with bridge and topic"
+		if def.Bridge == "" || (def.Topic == "" && len(def.Nodes) == 0 && def.Browse == "") {
+			rsp.Reason = "schedule of subscriber type should be specified with bridge and topic, or nodes of opcua"
 			return rsp, nil
 		}
+		if def.Interval != "" {
+			if d, err := time.ParseDuration(def.Interval); err != nil || d <= 0 {
+				rsp.Reason = fmt.Sprintf("invalid interval %q", def.Interval)
+				return rsp, nil
+			}
+		}
 		if def.Task == "" {
 			rsp.Reason = "destination task (tql path) is not specified"
 			return rsp, nil
@@ -242,6 +309,9 @@ type UpdateScheduleRequest struct {
 	Bridge    string `json:"bridge,omitempty"`
 	Topic     string `json:"topic,omitempty"`
 	QoS       int32  `json:"QoS,omitempty"`
+
+	Retry   *model.RetryPolicy `json:"retry,omitempty"`
+	CatchUp string             `json:"catchUp,omitempty"`
 }
 
 type UpdateScheduleResponse struct {
@@ -266,14 +336,37 @@ func (s *Service) UpdateSchedule(ctx context.Context, req *UpdateScheduleRequest
 		rsp.Reason = err.Error()
 		return rsp, nil
 	}
+	if _, err := newRetryPolicy(req.Retry); err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	catchUp, err := model.ParseCatchUpPolicy(req.CatchUp)
+	if err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
 
-	sd := &model.ScheduleDefinition{
-		Name:      req.Name,
-		Task:      req.Task,
-		Schedule:  req.Schedule,
-		AutoStart: req.AutoStart,
-		Type:      model.SCHEDULE_TIMER,
+	// keep the type and the steps of the stored schedule, a workflow stays a workflow
+	sd, err := s.models.LoadSchedule(req.Name)
+	if err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
 	}
+	switch sd.Type {
+	case model.SCHEDULE_TIMER:
+		sd.Task = req.Task
+	case model.SCHEDULE_WORKFLOW:
+		if req.Task != "" {
+			sd.Task = req.Task
+		}
+	default:
+		rsp.Reason = fmt.Sprintf("schedule '%s' of %s type can not be updated as a timer", req.Name, sd.Type)
+		return rsp, nil
+	}
+	sd.Schedule = req.Schedule
+	sd.AutoStart = req.AutoStart
+	sd.Retry = req.Retry
+	sd.CatchUp = catchUp
 	if err := s.models.UpdateSchedule(sd); err != nil {
 		rsp.Reason = err.Error()
 		return rsp, nil
@@ -346,6 +439,176 @@ func (s *Service) StopSchedule(ctx context.Context, req *StopScheduleRequest) (*
 	return rsp, nil
 }
 
+type GetScheduleHistoryRequest struct {
+	Name  string `json:"name,omitempty"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type GetScheduleHistoryResponse struct {
+	Success bool                 `json:"success,omitempty"`
+	Reason  string               `json:"reason,omitempty"`
+	Elapse  string               `json:"elapse,omitempty"`
+	Runs    []*model.ScheduleRun `json:"runs,omitempty"`
+}
+
+// GetScheduleHistory returns the recent runs of the timer or workflow schedule, the latest run comes first.
+// If the limit is positive, only the latest limit runs are returned.
+func (s *Service) GetScheduleHistory(ctx context.Context, req *GetScheduleHistoryRequest) (*GetScheduleHistoryResponse, error) {
+	tick := time.Now()
+	rsp := &GetScheduleHistoryResponse{}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+
+	if ent := GetEntry(req.Name); ent == nil {
+		rsp.Reason = fmt.Sprintf("schedule '%s' is not found", req.Name)
+		return rsp, nil
+	}
+	runs, err := s.models.LoadScheduleRuns(req.Name)
+	if err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	slices.Reverse(runs)
+	if req.Limit > 0 && len(runs) > req.Limit {
+		runs = runs[:req.Limit]
+	}
+	rsp.Runs = runs
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
+type GetScheduleStatusRequest struct {
+	Name string `json:"name,omitempty"`
+}
+
+type GetScheduleStatusResponse struct {
+	Success bool            `json:"success,omitempty"`
+	Reason  string          `json:"reason,omitempty"`
+	Elapse  string          `json:"elapse,omitempty"`
+	Status  *ScheduleStatus `json:"status,omitempty"`
+}
+
+type ScheduleStatus struct {
+	Name    string             `json:"name"`
+	State   string             `json:"state"`
+	Error   string             `json:"error,omitempty"`
+	Next    *time.Time         `json:"next,omitempty"`
+	LastRun *model.ScheduleRun `json:"lastRun,omitempty"`
+	// workflow only, the steps of the run in progress or the last run
+	Steps []*model.WorkflowStepRun `json:"steps,omitempty"`
+	// number of the last runs those failed in a row
+	Failures int `json:"failures"`
+}
+
+// GetScheduleStatus returns the state of the schedule, the time of the next run
+// and the last run of the timer and workflow schedule.
+func (s *Service) GetScheduleStatus(ctx context.Context, req *GetScheduleStatusRequest) (*GetScheduleStatusResponse, error) {
+	tick := time.Now()
+	rsp := &GetScheduleStatusResponse{}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+
+	ent := GetEntry(req.Name)
+	if ent == nil {
+		rsp.Reason = fmt.Sprintf("schedule '%s' is not found", req.Name)
+		return rsp, nil
+	}
+	status := &ScheduleStatus{
+		Name:  ent.Name(),
+		State: ent.Status().String(),
+	}
+	if err := ent.Error(); err != nil {
+		status.Error = err.Error()
+	}
+	var next time.Time
+	var hasHistory bool
+	switch e := ent.(type) {
+	case *TimerEntry:
+		next, hasHistory = e.Next(), true
+	case *WorkflowEntry:
+		next, hasHistory = e.Next(), true
+		status.Steps = e.StepRuns()
+	}
+	if !next.IsZero() {
+		status.Next = &next
+	}
+	if hasHistory {
+		runs, err := s.models.LoadScheduleRuns(req.Name)
+		if err != nil {
+			rsp.Reason = err.Error()
+			return rsp, nil
+		}
+		if len(runs) > 0 {
+			status.LastRun = runs[len(runs)-1]
+		}
+		for i := len(runs) - 1; i >= 0 && !runs[i].Success; i-- {
+			status.Failures++
+		}
+	}
+	rsp.Status = status
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
+type BackfillScheduleRequest struct {
+	Name string    `json:"name,omitempty"`
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type BackfillScheduleResponse struct {
+	Success bool                 `json:"success,omitempty"`
+	Reason  string               `json:"reason,omitempty"`
+	Elapse  string               `json:"elapse,omitempty"`
+	Runs    []*model.ScheduleRun `json:"runs,omitempty"`
+}
+
+// BackfillSchedule replays the timer schedule for each fire time in the range [From, To],
+// the fire time is passed to the tql as param("_fire_time") in epoch nanoseconds.
+// It returns the runs without the log output, those are kept in the history.
+func (s *Service) BackfillSchedule(ctx context.Context, req *BackfillScheduleRequest) (*BackfillScheduleResponse, error) {
+	tick := time.Now()
+	rsp := &BackfillScheduleResponse{}
+	defer func() {
+		rsp.Elapse = time.Since(tick).String()
+	}()
+
+	ent := GetEntry(req.Name)
+	if ent == nil {
+		rsp.Reason = fmt.Sprintf("schedule '%s' is not found", req.Name)
+		return rsp, nil
+	}
+	timer, ok := ent.(*TimerEntry)
+	if !ok {
+		rsp.Reason = fmt.Sprintf("schedule '%s' is not a timer", req.Name)
+		return rsp, nil
+	}
+	runs, err := timer.Backfill(ctx, req.From, req.To)
+	for _, run := range runs {
+		cp := *run
+		cp.Log = ""
+		rsp.Runs = append(rsp.Runs, &cp)
+	}
+	if err != nil {
+		rsp.Reason = err.Error()
+		return rsp, nil
+	}
+	failed := 0
+	for _, run := range runs {
+		if !run.Success {
+			failed++
+		}
+	}
+	if failed > 0 {
+		rsp.Reason = fmt.Sprintf("%d of %d runs failed", failed, len(runs))
+		return rsp, nil
+	}
+	rsp.Success, rsp.Reason = true, "success"
+	return rsp, nil
+}
+
 func parseSchedule(schedule string) (cron.Schedule, error) {
 	scheduleParser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
 	if s, err := scheduleParser.Parse(schedule); err != nil {
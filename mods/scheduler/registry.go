@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -122,7 +123,7 @@ func Register(s *Service, def *model.ScheduleDefinition) error {
 	var ent Entry
 	var err error
 	switch def.Type {
-	case model.SCHEDULE_TIMER:
+	case model.SCHEDULE_TIMER, model.SCHEDULE_WORKFLOW:
 		if ent, ok := registry[strings.ToUpper(def.Name)]; ok {
 			status := ent.Status()
 			if status == RUNNING {
@@ -134,7 +135,11 @@ func Register(s *Service, def *model.ScheduleDefinition) error {
 		} else {
 			initRegister = true
 		}
-		ent, err = NewTimerEntry(s, def)
+		if def.Type == model.SCHEDULE_TIMER {
+			ent, err = NewTimerEntry(s, def)
+		} else {
+			ent, err = NewWorkflowEntry(s, def)
+		}
 	case model.SCHEDULE_SUBSCRIBER:
 		if _, ok := registry[strings.ToUpper(def.Name)]; !ok {
 			initRegister = true
@@ -157,6 +162,14 @@ func Register(s *Service, def *model.ScheduleDefinition) error {
 		}
 		be.setState(prevState)
 	}
+	if we, ok := ent.(*WorkflowEntry); ok {
+		for _, step := range we.Steps {
+			if _, err := s.tqlLoader.Load(step.Task); err != nil {
+				we.setState(FAILED)
+				return fmt.Errorf("workflow step '%s', %s", step.Name, err.Error())
+			}
+		}
+	}
 
 	if initRegister {
 		if !ent.AutoStart() {
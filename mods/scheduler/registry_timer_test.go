@@ -1,9 +1,12 @@
 package scheduler
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/gopcua/opcua/ua"
 	"github.com/machbase/neo-server/v8/mods/bridge"
 	"github.com/machbase/neo-server/v8/mods/logging"
 	"github.com/machbase/neo-server/v8/mods/model"
@@ -22,6 +25,14 @@ func (l schedulerLoaderStub) Load(string) (*tql.Script, error) {
 	return nil, l.err
 }
 
+func newScheduleProvider(t *testing.T) model.ScheduleProvider {
+	t.Helper()
+	models := model.NewService(model.WithConfigDirPath(t.TempDir()))
+	require.NoError(t, models.Start())
+	t.Cleanup(models.Stop)
+	return models.ScheduleProvider()
+}
+
 type schedulerEntryStub struct {
 	BaseEntry
 	startCount int
@@ -172,6 +183,126 @@ func TestTimerEntryDoTaskLoadFailure(t *testing.T) {
 	require.Equal(t, STOP, ent.Status())
 }
 
+func TestTimerEntryDoTaskRetryKeepRunning(t *testing.T) {
+	models := newScheduleProvider(t)
+	svc := &Service{crons: cron.New(), models: models, tqlLoader: schedulerLoaderStub{err: errors.New("load failed")}}
+	ent, err := NewTimerEntry(svc, &model.ScheduleDefinition{
+		Name:     "task_retry",
+		Task:     "task.tql",
+		Schedule: "*/5 * * * *",
+		Retry:    &model.RetryPolicy{MaxAttempts: 3, Backoff: "1ms", KeepRunning: true},
+	})
+	require.NoError(t, err)
+	require.NoError(t, ent.Start())
+	t.Cleanup(func() { ent.Stop() })
+
+	ent.doTask()
+	require.Equal(t, RUNNING, ent.Status())
+	require.EqualError(t, ent.Error(), "load failed")
+
+	runs, err := models.LoadScheduleRuns("task_retry")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, 3, runs[0].Attempts)
+	require.False(t, runs[0].Success)
+	require.Equal(t, "load failed", runs[0].Error)
+}
+
+func TestTimerEntryFireTimes(t *testing.T) {
+	ent, err := NewTimerEntry(&Service{}, &model.ScheduleDefinition{Name: "fires", Task: "task.tql", Schedule: "0 0 0 * * *"})
+	require.NoError(t, err)
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2026, 1, 10, 0, 0, 0, 0, time.Local)
+
+	fires, total, err := ent.fireTimes(from, to, 100)
+	require.NoError(t, err)
+	require.Equal(t, 10, total)
+	require.Len(t, fires, 10)
+	require.True(t, fires[0].Equal(from))
+	require.True(t, fires[9].Equal(to))
+
+	// keeps the latest ones
+	fires, total, err = ent.fireTimes(from, to, 3)
+	require.NoError(t, err)
+	require.Equal(t, 10, total)
+	require.Len(t, fires, 3)
+	require.True(t, fires[0].Equal(time.Date(2026, 1, 8, 0, 0, 0, 0, time.Local)))
+}
+
+func TestTimerEntryBackfillAndCatchUp(t *testing.T) {
+	models := newScheduleProvider(t)
+	svc := &Service{crons: cron.New(cron.WithSeconds()), models: models, tqlLoader: schedulerLoaderStub{err: errors.New("load failed")}}
+	ent, err := NewTimerEntry(svc, &model.ScheduleDefinition{
+		Name:     "daily",
+		Task:     "task.tql",
+		Schedule: "0 0 0 * * *",
+		CatchUp:  model.CATCHUP_ALL,
+	})
+	require.NoError(t, err)
+	require.NoError(t, ent.Start())
+	t.Cleanup(func() { ent.Stop() })
+
+	// the failed backfill runs do not stop the schedule
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+	runs, err := ent.Backfill(context.Background(), from, from.Add(48*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, runs, 3)
+	require.Equal(t, RUNNING, ent.Status())
+	for i, run := range runs {
+		require.Equal(t, model.TRIGGER_BACKFILL, run.Trigger)
+		require.True(t, run.FireTime.Equal(from.Add(time.Duration(i)*24*time.Hour)))
+		require.False(t, run.Success)
+	}
+	_, err = ent.Backfill(context.Background(), from, from.Add(-time.Hour))
+	require.Error(t, err)
+	_, err = ent.Backfill(context.Background(), from, from.AddDate(5, 0, 0))
+	require.EqualError(t, err, "too many runs in the backfill range, 1827 (max 1000)")
+
+	// no catch-up without the last timer run, backfill runs are not counted
+	ent.catchUp(from.AddDate(0, 0, 10))
+	history, err := models.LoadScheduleRuns("daily")
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	require.NoError(t, models.AddScheduleRun("daily", &model.ScheduleRun{FireTime: from.AddDate(0, 0, 7), Success: true}))
+	ent.Retry.keepRunning = true
+	ent.catchUp(from.AddDate(0, 0, 10).Add(time.Hour))
+	history, err = models.LoadScheduleRuns("daily")
+	require.NoError(t, err)
+	require.Len(t, history, 7)
+	for i, run := range history[4:] {
+		require.Equal(t, model.TRIGGER_CATCHUP, run.Trigger)
+		require.True(t, run.FireTime.Equal(from.AddDate(0, 0, 8+i)))
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	p, err := newRetryPolicy(nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, p.maxAttempts)
+	require.False(t, p.keepRunning)
+
+	p, err = newRetryPolicy(&model.RetryPolicy{MaxAttempts: 5, Backoff: "1s", MaxBackoff: "5s"})
+	require.NoError(t, err)
+	require.Equal(t, 5, p.maxAttempts)
+	require.Equal(t, time.Second, p.delay(1))
+	require.Equal(t, 2*time.Second, p.delay(2))
+	require.Equal(t, 4*time.Second, p.delay(3))
+	require.Equal(t, 5*time.Second, p.delay(4))
+
+	_, err = newRetryPolicy(&model.RetryPolicy{MaxAttempts: -1})
+	require.EqualError(t, err, "invalid retry maxAttempts -1")
+	_, err = newRetryPolicy(&model.RetryPolicy{Backoff: "soon"})
+	require.EqualError(t, err, `invalid retry backoff "soon"`)
+
+	_, err = NewTimerEntry(&Service{}, &model.ScheduleDefinition{
+		Name:  "bad_retry",
+		Task:  "task.tql",
+		Retry: &model.RetryPolicy{MaxBackoff: "-1s"},
+	})
+	require.EqualError(t, err, `invalid retry maxBackoff "-1s"`)
+}
+
 func TestSubscriberEntryStartStopValidation(t *testing.T) {
 	bridge.UnregisterAll()
 	t.Cleanup(bridge.UnregisterAll)
@@ -266,6 +397,72 @@ func TestSubscriberEntryTasksHandleTqlLoadError(t *testing.T) {
 	require.Equal(t, STOP, natsEntry.Status())
 }
 
+func TestSubscriberEntryOpcua(t *testing.T) {
+	bridge.UnregisterAll()
+	t.Cleanup(bridge.UnregisterAll)
+
+	_, err := NewSubscriberEntry(&Service{}, &model.ScheduleDefinition{
+		Name:     "opcua_interval",
+		Task:     "db/append/table",
+		Bridge:   "opcua_sub",
+		Nodes:    []string{"ns=1;s=temp"},
+		Interval: "often",
+	})
+	require.EqualError(t, err, `invalid interval "often"`)
+
+	require.NoError(t, bridge.Register(&model.BridgeDefinition{Type: model.BRIDGE_OPCUA, Name: "opcua_sub"}))
+	emptyNodes, err := NewSubscriberEntry(&Service{}, &model.ScheduleDefinition{
+		Name:   "empty_nodes",
+		Task:   "db/append/table",
+		Bridge: "opcua_sub",
+	})
+	require.NoError(t, err)
+	require.EqualError(t, emptyNodes.Start(), "empty nodes are not allowed, subscribe to bridge 'opcua_sub' (opcua)")
+	require.Equal(t, FAILED, emptyNodes.Status())
+
+	// waits for the connection, the values are written as json regardless of the format of the path
+	waiting, err := NewSubscriberEntry(&Service{}, &model.ScheduleDefinition{
+		Name:     "waiting_opcua",
+		Task:     "db/append/table:csv",
+		Bridge:   "opcua_sub",
+		Nodes:    []string{"ns=1;s=temp"},
+		Interval: "500ms",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 500*time.Millisecond, waiting.Interval)
+	require.NoError(t, waiting.Start())
+	require.Equal(t, STARTING, waiting.Status())
+	require.Equal(t, "json", waiting.wd.Format)
+	require.Equal(t, "ns", waiting.wd.Timeformat)
+	require.NoError(t, waiting.Stop())
+	require.Equal(t, STOP, waiting.Status())
+
+	// the listeners of the bridge are registered once for the entry
+	br, err := bridge.GetBridge("opcua_sub")
+	require.NoError(t, err)
+	require.Same(t, br, waiting.opcuaListened)
+	require.NoError(t, waiting.Start())
+	require.Equal(t, STARTING, waiting.Status())
+	require.Same(t, br, waiting.opcuaListened)
+	require.NoError(t, waiting.Stop())
+	require.Equal(t, STOP, waiting.Status())
+
+	wd, err := util.NewWriteDescriptor("task.tql")
+	require.NoError(t, err)
+	tqlEntry := &SubscriberEntry{
+		BaseEntry: NewBaseEntry("opcua_task", RUNNING, false),
+		TaskTql:   "task.tql",
+		s:         &Service{tqlLoader: schedulerLoaderStub{err: errors.New("load failed")}},
+		log:       logging.GetLog("subscriber-task-test"),
+		wd:        wd,
+	}
+	// the values of bad quality are ignored
+	tqlEntry.doOpcuaTask([]*bridge.OpcuaDataChange{{NodeId: "ns=1;s=temp", Time: time.Now(), Value: 1.5, Status: ua.StatusBad}})
+	require.Equal(t, RUNNING, tqlEntry.Status())
+	tqlEntry.doOpcuaTask([]*bridge.OpcuaDataChange{{NodeId: "ns=1;s=temp", Time: time.Now(), Value: 1.5, Status: ua.StatusOK}})
+	require.Equal(t, STOP, tqlEntry.Status())
+}
+
 func TestSubscriberEntryMqttOnConnectUnavailable(t *testing.T) {
 	entry, err := NewSubscriberEntry(&Service{}, &model.ScheduleDefinition{
 		Name:   "mqtt_connect",
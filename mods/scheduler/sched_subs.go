@@ -5,12 +5,16 @@ import (
 	"compress/gzip"
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gopcua/opcua/ua"
 	client "github.com/machbase/neo-client/v2"
 	"github.com/machbase/neo-client/v2/api"
 	"github.com/machbase/neo-server/v8/mods/bridge"
@@ -35,16 +39,23 @@ type SubscriberEntry struct {
 	QueueName  string // nats only, Queue Group
 	StreamName string // nats only, JetStream
 
+	Nodes    []string      // opcua only, node ids to monitor
+	Browse   string        // opcua only, folder to browse the variables to monitor
+	Interval time.Duration // opcua only, sampling interval
+
 	s   *Service
 	log logging.Log
 
-	shouldSubscribe bool
+	shouldSubscribe bool // guarded by mu
 	ctx             context.Context
 	ctxCancel       context.CancelFunc
 	conn            *sql.Conn
 	appender        *client.Appender
 	appenderClose   func() error
-	subscription    bridge.Subscription
+	subscription    bridge.Subscription // guarded by mu
+
+	// the opcua bridge that the connect listeners of the entry are registered to
+	opcuaListened *bridge.OpcuaBridge
 
 	wd *util.WriteDescriptor
 }
@@ -52,6 +63,14 @@ type SubscriberEntry struct {
 var _ Entry = (*SubscriberEntry)(nil)
 
 func NewSubscriberEntry(s *Service, def *model.ScheduleDefinition) (*SubscriberEntry, error) {
+	interval := time.Second
+	if def.Interval != "" {
+		if d, err := time.ParseDuration(def.Interval); err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid interval %q", def.Interval)
+		} else {
+			interval = d
+		}
+	}
 	ret := &SubscriberEntry{
 		BaseEntry:  NewBaseEntry(def.Name, STOP, def.AutoStart),
 		TaskTql:    def.Task,
@@ -60,6 +79,9 @@ func NewSubscriberEntry(s *Service, def *model.ScheduleDefinition) (*SubscriberE
 		QoS:        def.QoS,
 		QueueName:  def.QueueName,
 		StreamName: def.StreamName,
+		Nodes:      def.Nodes,
+		Browse:     def.Browse,
+		Interval:   interval,
 		s:          s,
 		log:        logging.GetLog(fmt.Sprintf("subscriber-%s", strings.ToLower(def.Name))),
 	}
@@ -68,7 +90,7 @@ func NewSubscriberEntry(s *Service, def *model.ScheduleDefinition) (*SubscriberE
 
 func (ent *SubscriberEntry) Start() error {
 	ent.setStateError(STARTING, nil)
-	ent.shouldSubscribe = true
+	ent.setShouldSubscribe(true)
 	ent.ctx, ent.ctxCancel = context.WithCancel(context.Background())
 
 	ent.log.Infof("starting, bridge=%s, topic=%s", ent.Bridge, ent.Topic)
@@ -87,6 +109,8 @@ func (ent *SubscriberEntry) Start() error {
 			return ent.startMqtt(br)
 		case *bridge.NatsBridge:
 			return ent.startNats(br)
+		case *bridge.OpcuaBridge:
+			return ent.startOpcua(br)
 		default:
 			err := fmt.Errorf("%s is not a bridge of subscriber type", br0.String())
 			ent.setStateError(FAILED, err)
@@ -95,18 +119,63 @@ func (ent *SubscriberEntry) Start() error {
 	}
 }
 
+func (ent *SubscriberEntry) setShouldSubscribe(flag bool) {
+	ent.mu.Lock()
+	ent.shouldSubscribe = flag
+	ent.mu.Unlock()
+}
+
+func (ent *SubscriberEntry) isShouldSubscribe() bool {
+	ent.mu.RLock()
+	defer ent.mu.RUnlock()
+	return ent.shouldSubscribe
+}
+
+func (ent *SubscriberEntry) getSubscription() bridge.Subscription {
+	ent.mu.RLock()
+	defer ent.mu.RUnlock()
+	return ent.subscription
+}
+
+// swapSubscription replaces the subscription of the entry and returns the previous one.
+// If the entry has been stopped in the meantime, sub is not kept but returned back.
+func (ent *SubscriberEntry) swapSubscription(sub bridge.Subscription) bridge.Subscription {
+	ent.mu.Lock()
+	defer ent.mu.Unlock()
+	if sub != nil && !ent.shouldSubscribe {
+		return sub
+	}
+	prev := ent.subscription
+	ent.subscription = sub
+	return prev
+}
+
+// keepSubscription makes sub the subscription of the entry,
+// it returns false if the entry has been stopped and sub is unsubscribed.
+func (ent *SubscriberEntry) keepSubscription(sub bridge.Subscription) bool {
+	prev := ent.swapSubscription(sub)
+	if prev != nil {
+		prev.Unsubscribe()
+	}
+	return prev != sub
+}
+
 func (ent *SubscriberEntry) doMqttOnConnect(br *bridge.MqttBridge) {
-	if !ent.shouldSubscribe {
+	if !ent.isShouldSubscribe() {
 		return
 	}
+	// the topic is shared with the other subscribers of the bridge,
+	// drop the handler of the previous subscription not to receive twice.
+	if prev := ent.swapSubscription(nil); prev != nil {
+		prev.Unsubscribe()
+	}
 	if subscription, err := br.Subscribe(ent.Topic, byte(ent.QoS), ent.doMqttTask); err != nil {
 		ent.setStateError(FAILED, err)
 	} else {
 		if subscription == nil {
 			err := fmt.Errorf("fail to subscribe %s %s", br.String(), ent.Topic)
 			ent.setStateError(FAILED, err)
-		} else {
-			ent.subscription = subscription
+		} else if ent.keepSubscription(subscription) {
 			ent.setStateError(RUNNING, nil)
 		}
 	}
@@ -127,7 +196,7 @@ func (ent *SubscriberEntry) startMqtt(br *bridge.MqttBridge) error {
 		ent.doMqttOnConnect(br)
 	})
 	br.OnDisconnect(func(bridge any) {
-		if ent.shouldSubscribe {
+		if ent.isShouldSubscribe() {
 			ent.setState(STARTING)
 		} else {
 			ent.setState(STOP)
@@ -156,19 +225,77 @@ func (ent *SubscriberEntry) startNats(br *bridge.NatsBridge) error {
 		if sub == nil {
 			err := fmt.Errorf("fail to subscribe %s %s", br.String(), ent.Topic)
 			ent.setStateError(FAILED, err)
-		} else {
-			ent.subscription = sub
+		} else if ent.keepSubscription(sub) {
 			ent.setState(RUNNING)
 		}
 	}
 	return nil
 }
 
+func (ent *SubscriberEntry) doOpcuaOnConnect(br *bridge.OpcuaBridge) {
+	if !ent.isShouldSubscribe() {
+		return
+	}
+	// the subscription of the previous connection is not valid anymore
+	if prev := ent.swapSubscription(nil); prev != nil {
+		prev.Unsubscribe()
+	}
+	nodes := ent.Nodes
+	if ent.Browse != "" {
+		if found, err := br.Browse(ent.Browse); err != nil {
+			ent.setStateError(FAILED, err)
+			return
+		} else {
+			nodes = append(slices.Clone(nodes), found...)
+		}
+	}
+	if subscription, err := br.Subscribe(nodes, ent.Interval, ent.doOpcuaTask); err != nil {
+		ent.setStateError(FAILED, err)
+	} else if ent.keepSubscription(subscription) {
+		ent.setStateError(RUNNING, nil)
+	}
+}
+
+func (ent *SubscriberEntry) startOpcua(br *bridge.OpcuaBridge) error {
+	if len(ent.Nodes) == 0 && ent.Browse == "" {
+		err := fmt.Errorf("empty nodes are not allowed, subscribe to %s", br.String())
+		ent.setStateError(FAILED, err)
+		return err
+	}
+	if !ent.wd.IsTqlDestination() {
+		// the values are not a payload of the client, those are written as (name, time, value)
+		ent.wd.Format = "json"
+		ent.wd.Timeformat = "ns"
+		ent.wd.Compress = ""
+	}
+	if ent.opcuaListened == br {
+		// the listeners of the previous Start() are still registered
+		if br.IsConnected() {
+			ent.doOpcuaOnConnect(br)
+		}
+		return ent.Error()
+	}
+	ent.opcuaListened = br
+	// the listener subscribes again when the bridge makes a new connection,
+	// it is called immediately if the bridge is already connected.
+	br.OnConnect(func(bridge any) {
+		ent.doOpcuaOnConnect(br)
+	})
+	br.OnDisconnect(func(bridge any) {
+		if ent.isShouldSubscribe() {
+			ent.setState(STARTING)
+		} else {
+			ent.setState(STOP)
+		}
+	})
+	return ent.Error()
+}
+
 func (ent *SubscriberEntry) Stop() error {
 	ent.log.Infof("stopping, bridge=%s, topic=%s", ent.Bridge, ent.Topic)
 
 	ent.setStateError(STOPPING, nil)
-	ent.shouldSubscribe = false
+	ent.setShouldSubscribe(false)
 	defer func() {
 		if ent.appender != nil {
 			ent.appender.Close()
@@ -185,7 +312,8 @@ func (ent *SubscriberEntry) Stop() error {
 		}
 	}()
 
-	if ent.subscription == nil {
+	subscription := ent.swapSubscription(nil)
+	if subscription == nil {
 		ent.setState(STOP)
 		return nil
 	}
@@ -197,9 +325,11 @@ func (ent *SubscriberEntry) Stop() error {
 		var err error
 		switch br0.(type) {
 		case *bridge.MqttBridge:
-			err = ent.subscription.Unsubscribe()
+			err = subscription.Unsubscribe()
 		case *bridge.NatsBridge:
-			err = ent.subscription.Unsubscribe()
+			err = subscription.Unsubscribe()
+		case *bridge.OpcuaBridge:
+			err = subscription.Unsubscribe()
 		default:
 			err := fmt.Errorf("%s is not a bridge of subscriber type", br0.String())
 			ent.setStateError(FAILED, err)
@@ -280,6 +410,70 @@ func (ent *SubscriberEntry) doNatsTask(natsMsg *nats.Msg) {
 	}
 }
 
+// doOpcuaTask writes the values of the good quality, the node id is used as the tag name.
+// The TQL destination receives the values as CSV records of (name, time in epoch nanoseconds, value).
+func (ent *SubscriberEntry) doOpcuaTask(changes []*bridge.OpcuaDataChange) {
+	tick := time.Now()
+	rsp := &Reason{Reason: "not specified"}
+
+	defer func() {
+		state, err := ent.statusError()
+		if err != nil {
+			ent.log.Warn(ent.name, ent.TaskTql, state.String(), err.Error(), time.Since(tick).String())
+		} else {
+			ent.log.Trace(ent.name, ent.TaskTql, state.String(), rsp.Reason, time.Since(tick).String())
+		}
+	}()
+	if ent.wd.IsTqlDestination() {
+		buff := &bytes.Buffer{}
+		w := csv.NewWriter(buff)
+		for _, dc := range changes {
+			if dc.Status != ua.StatusOK {
+				continue
+			}
+			var value string
+			if v, ok := dc.Float64(); ok {
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			} else {
+				value = fmt.Sprint(dc.Value)
+			}
+			w.Write([]string{dc.NodeId, strconv.FormatInt(dc.Time.UnixNano(), 10), value})
+		}
+		w.Flush()
+		if buff.Len() == 0 {
+			return
+		}
+		params := map[string][]string{}
+		params["BRIDGE"] = []string{ent.Bridge}
+		ent.doTql(buff.Bytes(), params, rsp)
+	} else {
+		rows := make([][]any, 0, len(changes))
+		for _, dc := range changes {
+			if dc.Status != ua.StatusOK {
+				continue
+			}
+			if v, ok := dc.Float64(); ok {
+				rows = append(rows, []any{dc.NodeId, dc.Time.UnixNano(), v})
+			} else {
+				ent.log.Warnf("%s value of %T is not a number", dc.NodeId, dc.Value)
+			}
+		}
+		if len(rows) == 0 {
+			return
+		}
+		payload, err := json.Marshal(rows)
+		if err != nil {
+			rsp.Reason = err.Error()
+			return
+		}
+		if ent.wd.Method == "append" {
+			ent.doAppend(payload, rsp)
+		} else {
+			ent.doInsert(payload, rsp)
+		}
+	}
+}
+
 func (ent *SubscriberEntry) doTql(payload []byte, header map[string][]string, rsp *Reason) {
 	sc, err := ent.s.tqlLoader.Load(ent.TaskTql)
 	if err != nil {
@@ -462,6 +656,9 @@ func (ent *SubscriberEntry) doInsert(payload []byte, rsp *Reason) {
 		ent.log.Errorf("codec %q not found", ent.wd.Format)
 		return
 	}
+	if closer, ok := decoder.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	for {
 		vals, _, err := decoder.NextRow()
@@ -480,7 +677,9 @@ func (ent *SubscriberEntry) doInsert(payload []byte, rsp *Reason) {
 			return
 		}
 	}
-	ent.subscription.AddInserted(rownum)
+	if sub := ent.getSubscription(); sub != nil {
+		sub.AddInserted(rownum)
+	}
 	records := "record"
 	if rownum > 1 {
 		records = "records"
@@ -555,6 +754,9 @@ func (ent *SubscriberEntry) doAppend(payload []byte, rsp *Reason) {
 		ent.log.Errorf("codec %q not found", ent.wd.Format)
 		return
 	}
+	if closer, ok := decoder.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	rownum := uint64(0)
 	for {
@@ -576,7 +778,9 @@ func (ent *SubscriberEntry) doAppend(payload []byte, rsp *Reason) {
 		}
 		rownum++
 	}
-	ent.subscription.AddAppended(rownum)
+	if sub := ent.getSubscription(); sub != nil {
+		sub.AddAppended(rownum)
+	}
 	records := "record"
 	if rownum > 1 {
 		records = "records"
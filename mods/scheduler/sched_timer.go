@@ -1,15 +1,20 @@
 package scheduler
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/machbase/neo-server/v8/mods/logging"
 	"github.com/machbase/neo-server/v8/mods/model"
 	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/machbase/neo-server/v8/mods/util"
 	"github.com/robfig/cron/v3"
 )
 
@@ -17,18 +22,34 @@ type TimerEntry struct {
 	BaseEntry
 	TaskTql  string
 	Schedule string
+	Retry    retryPolicy
+	CatchUp  model.CatchUpPolicy
 	entryId  cron.EntryID
 	s        *Service
 	log      logging.Log
+
+	// runs of the task are not overlapped,
+	// so that the state of the task is carried over from a run to the next run.
+	runLock sync.Mutex
+
+	// cancels the run in progress and the retry waiting for the backoff, when the schedule is stopped.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 var _ Entry = (*TimerEntry)(nil)
 
 func NewTimerEntry(s *Service, def *model.ScheduleDefinition) (*TimerEntry, error) {
+	retry, err := newRetryPolicy(def.Retry)
+	if err != nil {
+		return nil, err
+	}
 	ret := &TimerEntry{
 		BaseEntry: NewBaseEntry(def.Name, STOP, def.AutoStart),
 		TaskTql:   def.Task,
 		Schedule:  def.Schedule,
+		Retry:     retry,
+		CatchUp:   def.CatchUp,
 		log:       logging.GetLog(fmt.Sprintf("timer-%s", strings.ToLower(def.Name))),
 		s:         s,
 	}
@@ -49,6 +70,9 @@ func (ent *TimerEntry) Start() error {
 		ent.setStateError(FAILED, err)
 		return err
 	}
+	ent.mu.Lock()
+	ent.ctx, ent.cancel = context.WithCancel(context.Background())
+	ent.mu.Unlock()
 	if entryId, err := ent.s.crons.AddFunc(ent.Schedule, ent.doTask); err != nil {
 		ent.setStateError(FAILED, err)
 		return err
@@ -68,41 +92,321 @@ func (ent *TimerEntry) Stop() error {
 		}
 	}()
 	ent.s.crons.Remove(ent.entryId)
+	ent.mu.Lock()
+	if ent.cancel != nil {
+		ent.cancel()
+	}
+	ent.mu.Unlock()
 	ent.setState(STOP)
 	return nil
 }
 
+// Next returns the time of the next run, it returns zero time if the schedule is not running.
+func (ent *TimerEntry) Next() time.Time {
+	if ent.Status() != RUNNING {
+		return time.Time{}
+	}
+	return ent.s.crons.Entry(ent.entryId).Next
+}
+
+func (ent *TimerEntry) context() context.Context {
+	ent.mu.RLock()
+	defer ent.mu.RUnlock()
+	if ent.ctx == nil {
+		return context.Background()
+	}
+	return ent.ctx
+}
+
 func (ent *TimerEntry) doTask() {
-	tick := time.Now()
-	ent.log.Info(ent.name, ent.TaskTql, "start")
-	defer func() {
-		state, err := ent.statusError()
-		if err != nil {
-			ent.log.Warn(ent.name, ent.TaskTql, state.String(), err.Error(), "elapsed", time.Since(tick).String())
-		} else {
-			ent.log.Info(ent.name, ent.TaskTql, state.String(), "elapsed", time.Since(tick).String())
+	if !ent.runLock.TryLock() {
+		ent.log.Warn(ent.name, ent.TaskTql, "skip, the previous run is still in progress")
+		return
+	}
+	defer ent.runLock.Unlock()
+
+	ent.runAt(ent.context(), time.Now().Truncate(time.Second), model.TRIGGER_TIMER)
+}
+
+// runAt runs the task of the fire time with the retry policy and records the run into the history.
+// The failure policy is applied to the schedule, except the backfill runs.
+// The caller should hold the runLock.
+func (ent *TimerEntry) runAt(ctx context.Context, fireTime time.Time, trigger model.ScheduleTrigger) *model.ScheduleRun {
+	run := &model.ScheduleRun{FireTime: fireTime, Trigger: trigger, Start: time.Now()}
+	ent.log.Info(ent.name, ent.TaskTql, "start", trigger, fireTime.Format(time.RFC3339))
+
+	var err error
+	for {
+		run.Attempts++
+		if err = ent.runTask(ctx, run); err == nil || run.Attempts >= ent.Retry.maxAttempts {
+			break
 		}
-	}()
-	sc, err := ent.s.tqlLoader.Load(ent.TaskTql)
+		delay := ent.Retry.delay(run.Attempts)
+		ent.log.Warn(ent.name, ent.TaskTql, "attempt", run.Attempts, "failed", err.Error(), "retry after", delay.String())
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+			continue
+		case <-ctx.Done():
+			timer.Stop()
+		}
+		break
+	}
+	run.End = time.Now()
+	run.Elapse = run.End.Sub(run.Start).String()
+	run.Success = err == nil
 	if err != nil {
+		run.Error = err.Error()
+	}
+	if ent.s.models != nil {
+		if err := ent.s.models.AddScheduleRun(ent.name, run); err != nil {
+			ent.log.Warn(ent.name, "fail to save the run history", err.Error())
+		}
+	}
+
+	if err == nil {
+		if trigger != model.TRIGGER_BACKFILL {
+			ent.setError(nil)
+		}
+		ent.log.Info(ent.name, ent.TaskTql, ent.Status().String(), "elapsed", run.Elapse)
+		return run
+	}
+	switch {
+	case trigger == model.TRIGGER_BACKFILL:
+		// backfill does not change the schedule
+	case ent.Retry.keepRunning:
+		ent.setError(err)
+	default:
 		ent.setStateError(FAILED, err)
 		ent.Stop()
+	}
+	ent.log.Warn(ent.name, ent.TaskTql, ent.Status().String(), err.Error(), "elapsed", run.Elapse)
+	return run
+}
+
+// maxBackfillRuns is the limit of the fire times in the range of a backfill.
+const maxBackfillRuns = 1000
+
+// Backfill runs the task for each fire time of the schedule in the range [from, to] in order.
+// The runs are recorded into the history, but they do not commit the state of the task
+// and do not change the state of the schedule by the failure.
+func (ent *TimerEntry) Backfill(ctx context.Context, from time.Time, to time.Time) ([]*model.ScheduleRun, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("invalid backfill range, %s is before %s", to.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+	fires, total, err := ent.fireTimes(from, to, maxBackfillRuns)
+	if err != nil {
+		return nil, err
+	}
+	if total > maxBackfillRuns {
+		return nil, fmt.Errorf("too many runs in the backfill range, %d (max %d)", total, maxBackfillRuns)
+	}
+	runs := []*model.ScheduleRun{}
+	for _, fireTime := range fires {
+		if err := ctx.Err(); err != nil {
+			return runs, err
+		}
+		// lock for each run, so that the timer runs are not blocked by a long backfill
+		ent.runLock.Lock()
+		runs = append(runs, ent.runAt(ctx, fireTime, model.TRIGGER_BACKFILL))
+		ent.runLock.Unlock()
+	}
+	return runs, nil
+}
+
+// catchUp executes the runs those are missed since the last run by the CatchUp policy.
+// The last run is found from the history, so nothing is missed if the schedule has never run.
+func (ent *TimerEntry) catchUp(now time.Time) {
+	if ent.CatchUp == model.CATCHUP_NONE || ent.s.models == nil {
+		return
+	}
+	runs, err := ent.s.models.LoadScheduleRuns(ent.name)
+	if err != nil {
+		ent.log.Warn(ent.name, "catch-up, fail to load the run history", err.Error())
+		return
+	}
+	var last time.Time
+	for i := len(runs) - 1; i >= 0; i-- {
+		if runs[i].Trigger == model.TRIGGER_BACKFILL {
+			continue
+		}
+		if last = runs[i].FireTime; last.IsZero() {
+			last = runs[i].Start.Truncate(time.Second)
+		}
+		break
+	}
+	if last.IsZero() {
+		return
+	}
+	limit := model.CatchUpMaxRuns
+	if ent.CatchUp == model.CATCHUP_LAST {
+		limit = 1
+	}
+	fires, total, err := ent.fireTimes(last.Add(time.Second), now, limit)
+	if err != nil {
+		ent.log.Warn(ent.name, "catch-up", err.Error())
+		return
+	}
+	if len(fires) == 0 {
 		return
 	}
-	task := tql.NewTaskContext(context.TODO())
-	task.SetParams(nil)
+	ent.log.Info(ent.name, "catch-up", len(fires), "of", total, "missed runs")
+	ctx := ent.context()
+	for _, fireTime := range fires {
+		if ctx.Err() != nil || ent.Status() != RUNNING {
+			return
+		}
+		ent.runLock.Lock()
+		ent.runAt(ctx, fireTime, model.TRIGGER_CATCHUP)
+		ent.runLock.Unlock()
+	}
+}
+
+// fireTimes returns the latest limit fire times of the schedule in the range [from, to]
+// and the total number of the fire times in the range.
+func (ent *TimerEntry) fireTimes(from time.Time, to time.Time, limit int) ([]time.Time, int, error) {
+	sched, err := parseSchedule(ent.Schedule)
+	if err != nil {
+		return nil, 0, err
+	}
+	ret := []time.Time{}
+	total := 0
+	for t := sched.Next(from.Add(-time.Second)); !t.IsZero() && !t.After(to); t = sched.Next(t) {
+		if t.Before(from) {
+			continue
+		}
+		total++
+		if len(ret) == limit {
+			ret = ret[1:]
+		}
+		ret = append(ret, t)
+	}
+	return ret, total, nil
+}
+
+// runTask runs the task once, the record count, the result message and the log output
+// are recorded into the run.
+func (ent *TimerEntry) runTask(ctx context.Context, run *model.ScheduleRun) error {
+	sc, err := ent.s.tqlLoader.Load(ent.TaskTql)
+	if err != nil {
+		return err
+	}
+	var state model.ScheduleState
+	if ent.s.models != nil {
+		if state, err = ent.s.models.LoadScheduleState(ent.name); err != nil {
+			return err
+		}
+	}
+	logs := &runLog{}
+	defer func() {
+		run.Log = logs.String()
+	}()
+	task := tql.NewTaskContext(ctx)
+	task.SetParams(map[string][]string{
+		"_fire_time": {strconv.FormatInt(run.FireTime.UnixNano(), 10)},
+	})
 	task.SetInputReader(nil)
 	task.SetOutputWriterJson(io.Discard, true)
+	task.SetLogWriter(logs)
+	task.SetState(state)
 	if err := task.CompileScript(sc); err != nil {
-		ent.setStateError(FAILED, err)
-		ent.Stop()
-		return
+		return err
+	}
+	result := task.Execute()
+	if result == nil {
+		return errors.New("task result is empty")
+	}
+	run.Records, run.Message = result.Rows, result.Message
+	if result.Err != nil {
+		return result.Err
 	}
-	if result := task.Execute(); result == nil || result.Err != nil {
-		if result != nil {
-			err = result.Err
+	// commit the state only if the task succeeded,
+	// the next run starts from the last successful state if the task failed.
+	// The backfill runs replay the past, they do not commit the state.
+	if updated, ok := task.State(); ok && ent.s.models != nil && run.Trigger != model.TRIGGER_BACKFILL {
+		if err := ent.s.models.SaveScheduleState(ent.name, updated); err != nil {
+			return err
 		}
-		ent.setStateError(FAILED, err)
-		ent.Stop()
 	}
+	return nil
+}
+
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = 5 * time.Minute
+)
+
+type retryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	keepRunning bool
+}
+
+// newRetryPolicy returns the policy of the definition,
+// nil definition means a single attempt and stop on failure.
+func newRetryPolicy(def *model.RetryPolicy) (retryPolicy, error) {
+	ret := retryPolicy{maxAttempts: 1, backoff: defaultRetryBackoff, maxBackoff: defaultRetryMaxBackoff}
+	if def == nil {
+		return ret, nil
+	}
+	if def.MaxAttempts < 0 {
+		return ret, fmt.Errorf("invalid retry maxAttempts %d", def.MaxAttempts)
+	} else if def.MaxAttempts > 1 {
+		ret.maxAttempts = def.MaxAttempts
+	}
+	if def.Backoff != "" {
+		if d, err := util.ParseDuration(def.Backoff); err != nil || d <= 0 {
+			return ret, fmt.Errorf("invalid retry backoff %q", def.Backoff)
+		} else {
+			ret.backoff = d
+		}
+	}
+	if def.MaxBackoff != "" {
+		if d, err := util.ParseDuration(def.MaxBackoff); err != nil || d <= 0 {
+			return ret, fmt.Errorf("invalid retry maxBackoff %q", def.MaxBackoff)
+		} else {
+			ret.maxBackoff = d
+		}
+	}
+	ret.keepRunning = def.KeepRunning
+	return ret, nil
+}
+
+// delay returns the delay before the n-th retry.
+func (p retryPolicy) delay(n int) time.Duration {
+	d := p.backoff
+	for i := 1; i < n && d < p.maxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.maxBackoff)
+}
+
+// maxRunLogSize is the limit of the log output of a run those are kept in the history.
+const maxRunLogSize = 16 * 1024
+
+// runLog captures the log output of the task, the lines after the limit are discarded.
+type runLog struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (l *runLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.buf.Len()+len(p) > maxRunLogSize {
+		l.truncated = true
+		return len(p), nil
+	}
+	return l.buf.Write(p)
+}
+
+func (l *runLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.truncated {
+		return l.buf.String() + "...(truncated)\n"
+	}
+	return l.buf.String()
 }
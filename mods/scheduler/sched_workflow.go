@@ -0,0 +1,337 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/logging"
+	"github.com/machbase/neo-server/v8/mods/model"
+	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/robfig/cron/v3"
+)
+
+// WorkflowEntry runs the steps of the workflow by the timer schedule,
+// a step runs when its upstream steps are finished.
+type WorkflowEntry struct {
+	BaseEntry
+	Schedule string
+	Steps    []*model.WorkflowStep
+	entryId  cron.EntryID
+	s        *Service
+	log      logging.Log
+
+	// runs of the workflow are not overlapped
+	runLock sync.Mutex
+	// steps of the run in progress or the last run, guarded by mu
+	stepRuns []*model.WorkflowStepRun
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var _ Entry = (*WorkflowEntry)(nil)
+
+func NewWorkflowEntry(s *Service, def *model.ScheduleDefinition) (*WorkflowEntry, error) {
+	if err := validateWorkflow(def.Steps); err != nil {
+		return nil, err
+	}
+	ret := &WorkflowEntry{
+		BaseEntry: NewBaseEntry(def.Name, STOP, def.AutoStart),
+		Schedule:  def.Schedule,
+		Steps:     def.Steps,
+		log:       logging.GetLog(fmt.Sprintf("workflow-%s", strings.ToLower(def.Name))),
+		s:         s,
+	}
+	return ret, nil
+}
+
+// validateWorkflow checks the names and the edges of the steps,
+// the steps should make a directed acyclic graph.
+func validateWorkflow(steps []*model.WorkflowStep) error {
+	if len(steps) == 0 {
+		return errors.New("workflow has no steps")
+	}
+	byName := map[string]*model.WorkflowStep{}
+	for i, step := range steps {
+		if step.Name == "" {
+			return fmt.Errorf("workflow step %d has no name", i)
+		}
+		if _, ok := byName[step.Name]; ok {
+			return fmt.Errorf("workflow step '%s' is duplicated", step.Name)
+		}
+		if step.Task == "" {
+			return fmt.Errorf("workflow step '%s' has no task", step.Name)
+		}
+		byName[step.Name] = step
+	}
+	for _, step := range steps {
+		for _, up := range step.Upstream() {
+			if _, ok := byName[up]; !ok {
+				return fmt.Errorf("workflow step '%s' depends on undefined step '%s'", step.Name, up)
+			}
+		}
+	}
+	// 1: visiting, 2: visited
+	marks := map[string]int{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch marks[name] {
+		case 1:
+			return fmt.Errorf("workflow step '%s' is in a cycle", name)
+		case 2:
+			return nil
+		}
+		marks[name] = 1
+		for _, up := range byName[name].Upstream() {
+			if err := visit(up); err != nil {
+				return err
+			}
+		}
+		marks[name] = 2
+		return nil
+	}
+	for _, step := range steps {
+		if err := visit(step.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ent *WorkflowEntry) Start() error {
+	ent.setStateError(STARTING, nil)
+
+	if len(ent.Schedule) == 0 {
+		err := fmt.Errorf("invalid configure - missing Schedule")
+		ent.setStateError(FAILED, err)
+		return err
+	}
+	ent.mu.Lock()
+	ent.ctx, ent.cancel = context.WithCancel(context.Background())
+	ent.mu.Unlock()
+	if entryId, err := ent.s.crons.AddFunc(ent.Schedule, ent.doWorkflow); err != nil {
+		ent.setStateError(FAILED, err)
+		return err
+	} else {
+		ent.entryId = entryId
+		ent.setState(RUNNING)
+	}
+	return nil
+}
+
+func (ent *WorkflowEntry) Stop() error {
+	ent.setState(STOPPING)
+	ent.s.crons.Remove(ent.entryId)
+	ent.mu.Lock()
+	if ent.cancel != nil {
+		ent.cancel()
+	}
+	ent.mu.Unlock()
+	ent.setState(STOP)
+	return nil
+}
+
+// Next returns the time of the next run, it returns zero time if the schedule is not running.
+func (ent *WorkflowEntry) Next() time.Time {
+	if ent.Status() != RUNNING {
+		return time.Time{}
+	}
+	return ent.s.crons.Entry(ent.entryId).Next
+}
+
+// StepRuns returns the copy of the steps of the run in progress or the last run.
+func (ent *WorkflowEntry) StepRuns() []*model.WorkflowStepRun {
+	ent.mu.RLock()
+	defer ent.mu.RUnlock()
+	ret := make([]*model.WorkflowStepRun, len(ent.stepRuns))
+	for i, sr := range ent.stepRuns {
+		cp := *sr
+		ret[i] = &cp
+	}
+	return ret
+}
+
+func (ent *WorkflowEntry) context() context.Context {
+	ent.mu.RLock()
+	defer ent.mu.RUnlock()
+	if ent.ctx == nil {
+		return context.Background()
+	}
+	return ent.ctx
+}
+
+type workflowNode struct {
+	step   *model.WorkflowStep
+	run    *model.WorkflowStepRun
+	output []byte
+	// closed when the step is finished or skipped
+	done chan struct{}
+}
+
+func (ent *WorkflowEntry) doWorkflow() {
+	if !ent.runLock.TryLock() {
+		ent.log.Warn(ent.name, "skip, the previous run is still in progress")
+		return
+	}
+	defer ent.runLock.Unlock()
+
+	ctx := ent.context()
+	run := &model.ScheduleRun{Start: time.Now(), Attempts: 1}
+	ent.log.Info(ent.name, "start")
+
+	nodes := map[string]*workflowNode{}
+	stepRuns := make([]*model.WorkflowStepRun, len(ent.Steps))
+	for i, step := range ent.Steps {
+		stepRuns[i] = &model.WorkflowStepRun{Name: step.Name, State: model.WORKFLOW_STEP_WAITING}
+		nodes[step.Name] = &workflowNode{step: step, run: stepRuns[i], done: make(chan struct{})}
+	}
+	ent.mu.Lock()
+	ent.stepRuns = stepRuns
+	ent.mu.Unlock()
+
+	wg := sync.WaitGroup{}
+	for _, step := range ent.Steps {
+		node := nodes[step.Name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(node.done)
+			ent.doStep(ctx, node, nodes)
+		}()
+	}
+	wg.Wait()
+
+	failed := []string{}
+	for _, sr := range ent.StepRuns() {
+		run.Records += sr.Records
+		if sr.State == model.WORKFLOW_STEP_FAILED {
+			failed = append(failed, fmt.Sprintf("step '%s' failed, %s", sr.Name, sr.Error))
+		}
+		run.Steps = append(run.Steps, sr)
+	}
+	run.End = time.Now()
+	run.Elapse = run.End.Sub(run.Start).String()
+	run.Success = len(failed) == 0
+	run.Error = strings.Join(failed, "; ")
+	if ent.s.models != nil {
+		if err := ent.s.models.AddScheduleRun(ent.name, run); err != nil {
+			ent.log.Warn(ent.name, "fail to save the run history", err.Error())
+		}
+	}
+	// the workflow keeps running, the failure is reported by the error and the history
+	if run.Success {
+		ent.setError(nil)
+		ent.log.Info(ent.name, "done", "elapsed", run.Elapse)
+	} else {
+		ent.setError(errors.New(run.Error))
+		ent.log.Warn(ent.name, run.Error, "elapsed", run.Elapse)
+	}
+}
+
+// doStep waits for the upstream steps, then runs the step if its edges are satisfied.
+func (ent *WorkflowEntry) doStep(ctx context.Context, node *workflowNode, nodes map[string]*workflowNode) {
+	upstream := node.step.Upstream()
+	for _, name := range upstream {
+		select {
+		case <-nodes[name].done:
+		case <-ctx.Done():
+		}
+	}
+	if ctx.Err() != nil {
+		ent.updateStep(node, func(sr *model.WorkflowStepRun) {
+			sr.State, sr.Error = model.WORKFLOW_STEP_SKIPPED, "workflow is stopped"
+		})
+		return
+	}
+	if !workflowStepReady(node.step, nodes) {
+		ent.updateStep(node, func(sr *model.WorkflowStepRun) {
+			sr.State = model.WORKFLOW_STEP_SKIPPED
+		})
+		return
+	}
+
+	payload := &bytes.Buffer{}
+	params := map[string][]string{
+		"_workflow": {ent.name},
+		"_step":     {node.step.Name},
+	}
+	for _, name := range upstream {
+		up := nodes[name]
+		switch up.run.State {
+		case model.WORKFLOW_STEP_SUCCESS:
+			payload.Write(up.output)
+		case model.WORKFLOW_STEP_FAILED:
+			params["_failed_step"] = append(params["_failed_step"], name)
+			params["_error"] = append(params["_error"], up.run.Error)
+		}
+	}
+
+	ent.updateStep(node, func(sr *model.WorkflowStepRun) {
+		sr.State, sr.Start = model.WORKFLOW_STEP_RUNNING, time.Now()
+	})
+	output := &bytes.Buffer{}
+	logs := &runLog{}
+	records, err := ent.runStep(ctx, node.step, payload, output, logs, params)
+	node.output = output.Bytes()
+	ent.updateStep(node, func(sr *model.WorkflowStepRun) {
+		sr.End = time.Now()
+		sr.Elapse = sr.End.Sub(sr.Start).String()
+		sr.Records = records
+		sr.Log = logs.String()
+		if err != nil {
+			sr.State, sr.Error = model.WORKFLOW_STEP_FAILED, err.Error()
+		} else {
+			sr.State = model.WORKFLOW_STEP_SUCCESS
+		}
+	})
+}
+
+// workflowStepReady returns true if every step of OnSuccess succeeded
+// and any step of OnFailure failed.
+func workflowStepReady(step *model.WorkflowStep, nodes map[string]*workflowNode) bool {
+	for _, name := range step.OnSuccess {
+		if nodes[name].run.State != model.WORKFLOW_STEP_SUCCESS {
+			return false
+		}
+	}
+	if len(step.OnFailure) == 0 {
+		return true
+	}
+	for _, name := range step.OnFailure {
+		if nodes[name].run.State == model.WORKFLOW_STEP_FAILED {
+			return true
+		}
+	}
+	return false
+}
+
+func (ent *WorkflowEntry) updateStep(node *workflowNode, fn func(sr *model.WorkflowStepRun)) {
+	ent.mu.Lock()
+	defer ent.mu.Unlock()
+	fn(node.run)
+}
+
+func (ent *WorkflowEntry) runStep(ctx context.Context, step *model.WorkflowStep, payload *bytes.Buffer, output *bytes.Buffer, logs *runLog, params map[string][]string) (int64, error) {
+	sc, err := ent.s.tqlLoader.Load(step.Task)
+	if err != nil {
+		return 0, err
+	}
+	task := tql.NewTaskContext(ctx)
+	task.SetParams(params)
+	task.SetInputReader(payload)
+	task.SetOutputWriter(output)
+	task.SetLogWriter(logs)
+	if err := task.CompileScript(sc); err != nil {
+		return 0, err
+	}
+	result := task.Execute()
+	if result == nil {
+		return 0, errors.New("task result is empty")
+	}
+	return result.Rows, result.Err
+}
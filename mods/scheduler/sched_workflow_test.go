@@ -0,0 +1,179 @@
+package scheduler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/machbase/neo-server/v8/mods/model"
+	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/machbase/neo-server/v8/mods/util/ssfs"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateWorkflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []*model.WorkflowStep
+		err   string
+	}{
+		{"empty", nil, "workflow has no steps"},
+		{"no-name", []*model.WorkflowStep{{Task: "a.tql"}}, "workflow step 0 has no name"},
+		{"no-task", []*model.WorkflowStep{{Name: "a"}}, "workflow step 'a' has no task"},
+		{
+			"duplicated",
+			[]*model.WorkflowStep{{Name: "a", Task: "a.tql"}, {Name: "a", Task: "b.tql"}},
+			"workflow step 'a' is duplicated",
+		},
+		{
+			"undefined",
+			[]*model.WorkflowStep{{Name: "a", Task: "a.tql", After: []string{"b"}}},
+			"workflow step 'a' depends on undefined step 'b'",
+		},
+		{
+			"cycle",
+			[]*model.WorkflowStep{
+				{Name: "a", Task: "a.tql", OnSuccess: []string{"c"}},
+				{Name: "b", Task: "b.tql", After: []string{"a"}},
+				{Name: "c", Task: "c.tql", OnFailure: []string{"b"}},
+			},
+			"workflow step 'a' is in a cycle",
+		},
+		{
+			"ok",
+			[]*model.WorkflowStep{
+				{Name: "a", Task: "a.tql"},
+				{Name: "b", Task: "b.tql", OnSuccess: []string{"a"}},
+				{Name: "c", Task: "c.tql", After: []string{"a", "b"}, OnFailure: []string{"b"}},
+			},
+			"",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateWorkflow(tc.steps)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestWorkflowEntryRun(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"extract.tql":   "FAKE(linspace(1, 3, 3))\nCSV()",
+		"transform.tql": "CSV(payload())\nMAPVALUE(0, parseFloat(value(0)) * 2)\nCSV()",
+		"notify.tql":    "FAKE(linspace(1, 1, 1))\nMAPVALUE(0, param('_failed_step'))\nCSV()",
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+	prev := ssfs.Default()
+	fsys, err := ssfs.NewServerSideFileSystem([]string{"/=" + dir})
+	require.NoError(t, err)
+	ssfs.SetDefault(fsys)
+	t.Cleanup(func() { ssfs.SetDefault(prev) })
+	tql.Init()
+	t.Cleanup(tql.Deinit)
+
+	models := newScheduleProvider(t)
+	svc := &Service{crons: cron.New(), models: models, tqlLoader: tql.NewLoader()}
+	ent, err := NewWorkflowEntry(svc, &model.ScheduleDefinition{
+		Name:     "nightly",
+		Type:     model.SCHEDULE_WORKFLOW,
+		Schedule: "@daily",
+		Steps: []*model.WorkflowStep{
+			{Name: "extract", Task: "extract.tql"},
+			{Name: "transform", Task: "transform.tql", OnSuccess: []string{"extract"}},
+			{Name: "export", Task: "missing.tql", OnSuccess: []string{"transform"}},
+			{Name: "archive", Task: "extract.tql", OnSuccess: []string{"export"}},
+			{Name: "notify", Task: "notify.tql", OnFailure: []string{"export"}},
+			{Name: "cleanup", Task: "extract.tql", After: []string{"export"}},
+		},
+	})
+	require.NoError(t, err)
+
+	ent.doWorkflow()
+	states := map[string]model.WorkflowStepState{}
+	for _, sr := range ent.StepRuns() {
+		states[sr.Name] = sr.State
+	}
+	require.Equal(t, map[string]model.WorkflowStepState{
+		"extract":   model.WORKFLOW_STEP_SUCCESS,
+		"transform": model.WORKFLOW_STEP_SUCCESS,
+		"export":    model.WORKFLOW_STEP_FAILED,
+		"archive":   model.WORKFLOW_STEP_SKIPPED,
+		"notify":    model.WORKFLOW_STEP_SUCCESS,
+		"cleanup":   model.WORKFLOW_STEP_SUCCESS,
+	}, states)
+	// the payload of transform is the output of extract
+	require.Equal(t, int64(3), ent.StepRuns()[1].Records)
+	require.EqualError(t, ent.Error(), "step 'export' failed, not found 'missing.tql'")
+
+	runs, err := models.LoadScheduleRuns("nightly")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.False(t, runs[0].Success)
+	require.Len(t, runs[0].Steps, 6)
+}
+
+func TestUpdateScheduleKeepsWorkflow(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "extract.tql"), []byte("FAKE(linspace(1, 3, 3))\nCSV()"), 0o600))
+	prev := ssfs.Default()
+	fsys, err := ssfs.NewServerSideFileSystem([]string{"/=" + dir})
+	require.NoError(t, err)
+	ssfs.SetDefault(fsys)
+	t.Cleanup(func() { ssfs.SetDefault(prev) })
+	tql.Init()
+	t.Cleanup(tql.Deinit)
+
+	models := newScheduleProvider(t)
+	svc := &Service{crons: cron.New(), models: models, tqlLoader: tql.NewLoader()}
+	def := &model.ScheduleDefinition{
+		Name:     "nightly",
+		Type:     model.SCHEDULE_WORKFLOW,
+		Schedule: "@daily",
+		Steps:    []*model.WorkflowStep{{Name: "extract", Task: "extract.tql"}},
+	}
+	registryLock.Lock()
+	registry = map[string]Entry{}
+	registryLock.Unlock()
+	t.Cleanup(UnregisterAll)
+	require.NoError(t, models.SaveSchedule(def))
+	require.NoError(t, Register(svc, def))
+
+	rsp, err := svc.UpdateSchedule(context.TODO(), &UpdateScheduleRequest{
+		Name:     "nightly",
+		Schedule: "@hourly",
+		Task:     "/web/api/timers/nightly",
+	})
+	require.NoError(t, err)
+	require.True(t, rsp.Success, rsp.Reason)
+
+	ent, ok := GetEntry("nightly").(*WorkflowEntry)
+	require.True(t, ok, "the entry should stay a workflow")
+	require.Len(t, ent.Steps, 1)
+
+	stored, err := models.LoadSchedule("nightly")
+	require.NoError(t, err)
+	require.Equal(t, model.SCHEDULE_WORKFLOW, stored.Type)
+	require.Equal(t, "@hourly", stored.Schedule)
+	require.Len(t, stored.Steps, 1)
+
+	// a subscriber can not be updated as a timer
+	sub := &model.ScheduleDefinition{Name: "listener", Type: model.SCHEDULE_SUBSCRIBER, Bridge: "mqtt", Topic: "t", Task: "extract.tql"}
+	require.NoError(t, models.SaveSchedule(sub))
+	registryLock.Lock()
+	registry["LISTENER"] = newSchedulerEntryStub("listener", STOP, false)
+	registryLock.Unlock()
+	rsp, err = svc.UpdateSchedule(context.TODO(), &UpdateScheduleRequest{Name: "listener", Schedule: "@hourly", Task: "extract.tql"})
+	require.NoError(t, err)
+	require.False(t, rsp.Success)
+	require.Equal(t, "schedule 'listener' of subscriber type can not be updated as a timer", rsp.Reason)
+}
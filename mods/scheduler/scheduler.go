@@ -69,6 +69,10 @@ func (s *Service) Start() error {
 	for _, define := range lst {
 		if err := Register(s, define); err == nil {
 			s.log.Infof("add schedule %s type=%s", define.Name, define.Type)
+			if ent, ok := GetEntry(define.Name).(*TimerEntry); ok && ent.Status() == RUNNING {
+				// execute the runs those are missed while the server was down
+				go ent.catchUp(time.Now())
+			}
 		} else {
 			s.log.Errorf("fail to add schedule %s type=%s, %s", define.Name, define.Type, err.Error())
 		}
@@ -54,6 +54,7 @@ func NewHttp(options ...HttpOption) (*httpd, error) {
 	for _, opt := range options {
 		opt(s)
 	}
+	s.cursors = newQueryCursors(s.cursorIdleTimeout, s.cursorLimit)
 	return s, nil
 }
 
@@ -64,6 +65,7 @@ type httpd struct {
 	listenAddresses []string
 	enableTokenAuth bool
 	handlers        []*HandlerConfig
+	routes          gin.RoutesInfo // for the OpenAPI document
 	mqttWsHandler   func(*gin.Context)
 
 	httpServer *http.Server
@@ -88,6 +90,12 @@ type httpd struct {
 	linger                 int
 	keepAlive              int
 	webShellProvider       model.ShellProvider
+	queryProvider          model.QueryProvider
+	cursors                *queryCursors
+	cursorIdleTimeout      time.Duration
+	cursorLimit            int
+	rateLimiter            *RateLimiter
+	writeBodyLimit         int64
 	experimentModeProvider func() bool
 	uiContentFs            http.FileSystem
 
@@ -149,10 +157,12 @@ func (svr *httpd) Start() error {
 			return ctx
 		}
 	}
+	svr.cursors.Start()
 	svr.httpServer = &http.Server{
 		ConnContext: connContext,
 	}
 	router := svr.Router()
+	svr.routes = router.Routes()
 	svr.httpServer.Handler = router
 
 	for _, listen := range svr.listenAddresses {
@@ -176,6 +186,7 @@ func (svr *httpd) Stop() {
 	svr.httpServer.Shutdown(ctx)
 	cancelFunc()
 	svr.httpServer.Close()
+	svr.cursors.Stop()
 }
 
 func (svr *httpd) AdvertiseAddress() string {
@@ -240,7 +251,7 @@ func (svr *httpd) Router() *gin.Engine {
 			if svr.enableTokenAuth && svr.authServer != nil {
 				group.Use(svr.handleAuthToken)
 			}
-			group.POST("/:oper", svr.handleLineProtocol)
+			group.POST("/:oper", svr.handleRateLimit, svr.handleLineProtocol)
 			svr.log.Infof("HTTP path %s for the line protocol", prefix)
 		case HandlerWeb: // web ui
 			contentBase := "/ui/"
@@ -256,6 +267,7 @@ func (svr *httpd) Router() *gin.Engine {
 			group.POST("/api/login", svr.handleLogin)
 			group.GET("/api/term/:term_id/data", svr.handleTermData)
 			group.GET("/api/console/:console_id/data", svr.handleConsoleData)
+			group.GET("/api/watch", svr.handleWebWatchSocket)
 			if svr.mqttWsHandler != nil {
 				group.GET("/api/mqtt", svr.mqttWsHandler)
 				svr.log.Infof("MQTT websocket handler enabled")
@@ -276,17 +288,22 @@ func (svr *httpd) Router() *gin.Engine {
 				svr.handleQuery(c)
 			})
 			group.Any("/api/query", svr.handleQuery)
+			group.POST("/api/query/cursor", svr.handleQueryCursorOpen)
+			group.GET("/api/query/cursor/:id", svr.handleQueryCursorFetch)
+			group.DELETE("/api/query/cursor/:id", svr.handleQueryCursorClose)
 			group.GET("/api/check", svr.handleCheck)
 			group.POST("/api/rpc", svr.handleHttpRpc)
 			group.POST("/api/relogin", svr.handleReLogin)
 			group.POST("/api/logout", svr.handleLogout)
 			group.POST("/api/chpasswd", svr.handleChangePassword)
 			group.GET("/api/timers/:name", svr.handleTimer)
+			group.GET("/api/timers/:name/runs", svr.handleTimerRuns)
 			group.PUT("/api/timers/:name", svr.handleTimersUpdate)
 			group.GET("/api/subscribers/:name", svr.handleSubscriber)
 			group.GET("/api/tables", svr.handleTables)
 			group.GET("/api/tables/:table/tags", svr.handleTags)
 			group.GET("/api/tables/:table/tags/:tag/stat", svr.handleTagStat)
+			group.GET("/api/tables/:table/tags/:tag/series", svr.handleTagSeries)
 			group.Any("/api/files/*path", svr.handleFiles)
 			group.GET("/api/refs/*path", svr.handleRefs)
 			group.GET("/api/license", svr.handleGetLicense)
@@ -297,14 +314,22 @@ func (svr *httpd) Router() *gin.Engine {
 			}
 			svr.log.Infof("HTTP path %s for the web ui", prefix)
 		case HandlerMachbase: // "machbase"
+			group.GET("/openapi.json", svr.handleOpenAPI)
 			if svr.enableTokenAuth && svr.authServer != nil {
 				group.Use(svr.handleAuthToken)
 			}
 			group.GET("/query", svr.handleQuery)
 			group.POST("/query", svr.handleQuery)
-			group.POST("/write", svr.handleWrite)
-			group.POST("/write/:table", svr.handleWrite)
+			group.POST("/write", svr.handleRateLimit, svr.handleWrite)
+			group.POST("/write/:table", svr.handleRateLimit, svr.handleWrite)
 			group.GET("/query/file/:table/:column/:id", svr.handleFileQuery)
+			group.GET("/query/named/:name", svr.handleNamedQuery)
+			group.POST("/query/named/:name", svr.handleNamedQuery)
+			group.POST("/query/cursor", svr.handleQueryCursorOpen)
+			group.GET("/query/cursor/:id", svr.handleQueryCursorFetch)
+			group.DELETE("/query/cursor/:id", svr.handleQueryCursorClose)
+			group.GET("/tables/:table/tags/:tag/series", svr.handleTagSeries)
+			group.GET("/watch", svr.handleWatchSocket)
 			group.GET("/watch/:table", svr.handleWatchQuery)
 			group.GET("/tql/*path", svr.handleTqlFile)
 			group.POST("/tql/*path", svr.handleTqlFile)
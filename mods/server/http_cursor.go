@@ -0,0 +1,435 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/machbase/neo-server/v8/mods/codec/opts"
+	"github.com/machbase/neo-server/v8/spi"
+)
+
+const (
+	defaultCursorIdleTimeout = 1 * time.Minute
+	defaultCursorLimit       = 10
+	defaultCursorFetchSize   = 1000
+	maxCursorFetchSize       = 100000
+)
+
+// QueryCursorResponse is the response of opening a cursor.
+type QueryCursorResponse struct {
+	Success bool             `json:"success"`
+	Reason  string           `json:"reason"`
+	Elapse  string           `json:"elapse"`
+	Data    *QueryCursorData `json:"data,omitempty"`
+}
+
+type QueryCursorData struct {
+	Cursor      string   `json:"cursor"`
+	Columns     []string `json:"columns"`
+	Types       []string `json:"types"`
+	IdleTimeout string   `json:"idleTimeout"`
+	// the anonymous requester should give it by the header 'X-Cursor-Secret' to fetch and close the cursor
+	Secret string `json:"secret,omitempty"`
+}
+
+// queryCursor keeps the result of a query open across the http requests,
+// the rows are encoded by the options of the request that opened the cursor.
+type queryCursor struct {
+	sync.Mutex
+	id          string
+	owner       string
+	secret      string // the anonymous owner only
+	req         *QueryRequest
+	conn        *sql.Conn
+	rows        *sql.Rows
+	cancel      context.CancelFunc
+	columnNames []string
+	columnTypes []*sql.ColumnType
+	pending     []any // the row that has been read ahead to know if there are more rows
+	lastAccess  time.Time
+	closed      bool
+}
+
+// fetch reads up to n rows, it returns true if there are more rows to fetch.
+// it should be called with the lock held.
+func (qc *queryCursor) fetch(n int) ([][]any, bool, error) {
+	ret := make([][]any, 0, min(n, defaultCursorFetchSize))
+	if qc.pending != nil {
+		ret = append(ret, qc.pending)
+		qc.pending = nil
+	}
+	for len(ret) < n && qc.rows.Next() {
+		values := spi.MakeBuffer(qc.columnTypes)
+		if err := qc.rows.Scan(values...); err != nil {
+			return nil, false, err
+		}
+		ret = append(ret, values)
+	}
+	if len(ret) == n && qc.rows.Next() {
+		values := spi.MakeBuffer(qc.columnTypes)
+		if err := qc.rows.Scan(values...); err != nil {
+			return nil, false, err
+		}
+		qc.pending = values
+		return ret, true, nil
+	}
+	return ret, false, qc.rows.Err()
+}
+
+// close releases the rows and the connection, it should be called with the lock held.
+func (qc *queryCursor) close() {
+	if qc.closed {
+		return
+	}
+	qc.closed = true
+	qc.rows.Close()
+	qc.conn.Close()
+	qc.cancel()
+}
+
+type queryCursors struct {
+	lock        sync.Mutex
+	cursors     map[string]*queryCursor
+	idleTimeout time.Duration
+	limit       int
+	stopCh      chan struct{}
+}
+
+func newQueryCursors(idleTimeout time.Duration, limit int) *queryCursors {
+	if idleTimeout <= 0 {
+		idleTimeout = defaultCursorIdleTimeout
+	}
+	if limit <= 0 {
+		limit = defaultCursorLimit
+	}
+	return &queryCursors{
+		cursors:     map[string]*queryCursor{},
+		idleTimeout: idleTimeout,
+		limit:       limit,
+	}
+}
+
+func (qcs *queryCursors) Start() {
+	interval := qcs.idleTimeout / 4
+	if interval < time.Second {
+		interval = time.Second
+	} else if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	qcs.stopCh = make(chan struct{})
+	go func(stopCh chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case now := <-ticker.C:
+				qcs.expire(now)
+			}
+		}
+	}(qcs.stopCh)
+}
+
+func (qcs *queryCursors) Stop() {
+	if qcs.stopCh != nil {
+		close(qcs.stopCh)
+		qcs.stopCh = nil
+	}
+	qcs.lock.Lock()
+	cursors := qcs.cursors
+	qcs.cursors = map[string]*queryCursor{}
+	qcs.lock.Unlock()
+	for _, qc := range cursors {
+		qc.Lock()
+		qc.close()
+		qc.Unlock()
+	}
+}
+
+// expire closes the cursors that have not been accessed for the idle timeout,
+// the cursors that are being fetched are not expired.
+func (qcs *queryCursors) expire(now time.Time) {
+	qcs.lock.Lock()
+	defer qcs.lock.Unlock()
+	for id, qc := range qcs.cursors {
+		if !qc.TryLock() {
+			continue
+		}
+		if now.Sub(qc.lastAccess) >= qcs.idleTimeout {
+			delete(qcs.cursors, id)
+			qc.close()
+		}
+		qc.Unlock()
+	}
+}
+
+// add registers the cursor, it fails if the owner has too many cursors.
+func (qcs *queryCursors) add(qc *queryCursor) error {
+	qcs.lock.Lock()
+	defer qcs.lock.Unlock()
+	count := 0
+	for _, c := range qcs.cursors {
+		if c.owner == qc.owner {
+			count++
+		}
+	}
+	if count >= qcs.limit {
+		return fmt.Errorf("too many cursors, the limit is %d", qcs.limit)
+	}
+	qcs.cursors[qc.id] = qc
+	return nil
+}
+
+// get returns the cursor of the owner, the secret should match for the anonymous owner.
+func (qcs *queryCursors) get(id string, owner string, secret string) (*queryCursor, bool) {
+	qcs.lock.Lock()
+	defer qcs.lock.Unlock()
+	qc, ok := qcs.cursors[id]
+	if !ok || qc.owner != owner {
+		return nil, false
+	}
+	if subtle.ConstantTimeCompare([]byte(qc.secret), []byte(secret)) != 1 {
+		return nil, false
+	}
+	return qc, true
+}
+
+func (qcs *queryCursors) remove(id string) {
+	qcs.lock.Lock()
+	defer qcs.lock.Unlock()
+	delete(qcs.cursors, id)
+}
+
+// cursorOwner returns the identity of the requester that the cursors are limited by,
+// it returns false with the remote address if the requester is anonymous.
+func (svr *httpd) cursorOwner(ctx *gin.Context) (string, bool) {
+	if claim, ok := svr.getJwtClaim(ctx); ok {
+		return claim.Subject, true
+	}
+	tok := ctx.Query("token")
+	if auth := ctx.GetHeader("Authorization"); strings.HasPrefix(strings.ToUpper(auth), "BEARER ") {
+		tok = auth[7:]
+	}
+	if clientId, _, found := strings.Cut(tok, ":"); found {
+		return "client:" + clientId, true
+	}
+	return "addr:" + ctx.ClientIP(), false
+}
+
+// getCursor returns the cursor of the requester.
+func (svr *httpd) getCursor(ctx *gin.Context) (*queryCursor, bool) {
+	owner, _ := svr.cursorOwner(ctx)
+	return svr.cursors.get(ctx.Param("id"), owner, ctx.GetHeader("X-Cursor-Secret"))
+}
+
+func newCursorSecret() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// handleQueryCursorOpen executes the SELECT query and keeps the result open as a cursor.
+// The request is the same as /db/query, the format options are applied to every fetch.
+func (svr *httpd) handleQueryCursorOpen(ctx *gin.Context) {
+	tick := time.Now()
+	req := NewQueryRequest()
+	rsp := &QueryCursorResponse{Success: false, Reason: "not specified"}
+
+	if code, err := svr.decodeQueryRequest(ctx, req); err != nil {
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(code, rsp)
+		return
+	}
+	if len(req.SqlText) == 0 {
+		rsp.Reason = "sql text is empty"
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	if !spi.DetectSQLStatementType(req.SqlText).IsFetch() {
+		rsp.Reason = "cursor is only for the query that returns rows"
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	// the encoder options are checked before running the query
+	if _, err := req.newEncoder(&strings.Builder{}); err != nil {
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+
+	// the cursor outlives the request
+	cursorCtx, cursorCancel := context.WithCancel(context.Background())
+	conn, err := getPoolSqlConn(cursorCtx)
+	if err != nil {
+		cursorCancel()
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusServiceUnavailable, rsp)
+		return
+	}
+	rows, err := conn.QueryContext(cursorCtx, req.SqlText, req.Params...)
+	if err != nil {
+		conn.Close()
+		cursorCancel()
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusInternalServerError, rsp)
+		return
+	}
+	id, _ := idGen.NewV6()
+	qc := &queryCursor{
+		id:         id.String(),
+		req:        req,
+		conn:       conn,
+		rows:       rows,
+		cancel:     cursorCancel,
+		lastAccess: time.Now(),
+	}
+	// the anonymous requesters of the same address share the limit,
+	// but each cursor is accessible only by the secret given to its requester
+	owner, authenticated := svr.cursorOwner(ctx)
+	qc.owner = owner
+	if !authenticated {
+		qc.secret, err = newCursorSecret()
+	}
+	if err == nil {
+		qc.columnTypes, err = rows.ColumnTypes()
+	}
+	if err == nil {
+		qc.columnNames, err = rows.Columns()
+	}
+	if err != nil {
+		qc.close()
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusInternalServerError, rsp)
+		return
+	}
+	if err := svr.cursors.add(qc); err != nil {
+		qc.close()
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusTooManyRequests, rsp)
+		return
+	}
+
+	data := &QueryCursorData{
+		Cursor:      qc.id,
+		Columns:     qc.columnNames,
+		IdleTimeout: svr.cursors.idleTimeout.String(),
+		Secret:      qc.secret,
+	}
+	for _, typ := range spi.ColumnTypesToDataTypes(qc.columnTypes) {
+		data.Types = append(data.Types, string(typ))
+	}
+	rsp.Success, rsp.Reason = true, "success"
+	rsp.Data = data
+	rsp.Elapse = time.Since(tick).String()
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// handleQueryCursorFetch writes the next rows of the cursor, the number of rows is given by 'n'.
+// The header 'X-Cursor-Has-More' tells whether there are more rows,
+// the cursor is closed when all rows are fetched.
+func (svr *httpd) handleQueryCursorFetch(ctx *gin.Context) {
+	tick := time.Now()
+	rsp := &QueryResponse{Success: false, Reason: "not specified"}
+
+	n := strInt(ctx.Query("n"), defaultCursorFetchSize)
+	if n <= 0 || n > maxCursorFetchSize {
+		rsp.Reason = fmt.Sprintf("n should be between 1 and %d", maxCursorFetchSize)
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	qc, ok := svr.getCursor(ctx)
+	if !ok {
+		rsp.Reason = fmt.Sprintf("cursor %q not found", ctx.Param("id"))
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusNotFound, rsp)
+		return
+	}
+	qc.Lock()
+	defer qc.Unlock()
+	if qc.closed {
+		// expired while waiting the lock
+		rsp.Reason = fmt.Sprintf("cursor %q not found", qc.id)
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusNotFound, rsp)
+		return
+	}
+	qc.lastAccess = time.Now()
+
+	rows, hasMore, err := qc.fetch(n)
+	if err != nil {
+		svr.cursors.remove(qc.id)
+		qc.close()
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusInternalServerError, rsp)
+		return
+	}
+	if !hasMore {
+		svr.cursors.remove(qc.id)
+		qc.close()
+	}
+
+	encoder, _ := qc.req.newEncoder(ctx.Writer)
+	if enc, ok := encoder.(opts.CanSetColumns); ok {
+		enc.SetColumns(qc.columnNames...)
+	}
+	if enc, ok := encoder.(opts.CanSetColumnTypes); ok {
+		enc.SetColumnTypes(spi.ColumnTypesToDataTypes(qc.columnTypes)...)
+	}
+	ctx.Header("Content-Type", encoder.ContentType())
+	if qc.req.Compress != "" {
+		ctx.Header("Content-Encoding", qc.req.Compress)
+	}
+	ctx.Header("X-Cursor-Has-More", fmt.Sprintf("%t", hasMore))
+	ctx.Status(http.StatusOK)
+	encoder.Open()
+	defer encoder.Close()
+	for _, values := range rows {
+		if err := encoder.AddRow(values); err != nil {
+			svr.log.Warnf("cursor %s encode %s", qc.id, err.Error())
+			return
+		}
+	}
+}
+
+// handleQueryCursorClose closes the cursor before all rows are fetched.
+func (svr *httpd) handleQueryCursorClose(ctx *gin.Context) {
+	tick := time.Now()
+	rsp := &QueryResponse{Success: false, Reason: "not specified"}
+
+	qc, ok := svr.getCursor(ctx)
+	if !ok {
+		rsp.Reason = fmt.Sprintf("cursor %q not found", ctx.Param("id"))
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusNotFound, rsp)
+		return
+	}
+	svr.cursors.remove(qc.id)
+	qc.Lock()
+	qc.close()
+	qc.Unlock()
+	rsp.Success, rsp.Reason = true, "success"
+	rsp.Elapse = time.Since(tick).String()
+	ctx.JSON(http.StatusOK, rsp)
+}
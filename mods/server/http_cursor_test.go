@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryCursorsLimit(t *testing.T) {
+	qcs := newQueryCursors(0, 2)
+	require.Equal(t, defaultCursorIdleTimeout, qcs.idleTimeout)
+
+	require.NoError(t, qcs.add(&queryCursor{id: "a1", owner: "a"}))
+	require.NoError(t, qcs.add(&queryCursor{id: "a2", owner: "a"}))
+	require.EqualError(t, qcs.add(&queryCursor{id: "a3", owner: "a"}), "too many cursors, the limit is 2")
+	require.NoError(t, qcs.add(&queryCursor{id: "b1", owner: "b"}))
+
+	// the cursor of the other owner is not visible
+	_, ok := qcs.get("a1", "b", "")
+	require.False(t, ok)
+	qc, ok := qcs.get("a1", "a", "")
+	require.True(t, ok)
+	require.Equal(t, "a1", qc.id)
+
+	qcs.remove("a1")
+	_, ok = qcs.get("a1", "a", "")
+	require.False(t, ok)
+	require.NoError(t, qcs.add(&queryCursor{id: "a3", owner: "a"}))
+
+	// the anonymous cursor is accessible only with its secret
+	require.NoError(t, qcs.add(&queryCursor{id: "c1", owner: "addr:127.0.0.1", secret: "s1"}))
+	_, ok = qcs.get("c1", "addr:127.0.0.1", "")
+	require.False(t, ok)
+	_, ok = qcs.get("c1", "addr:127.0.0.1", "s2")
+	require.False(t, ok)
+	_, ok = qcs.get("c1", "addr:127.0.0.1", "s1")
+	require.True(t, ok)
+}
+
+func TestQueryCursorOwner(t *testing.T) {
+	svr := &httpd{}
+	newContext := func(header string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/db/query/cursor/c1", nil)
+		ctx.Request.RemoteAddr = "192.0.2.1:1234"
+		if header != "" {
+			ctx.Request.Header.Set("Authorization", header)
+		}
+		return ctx
+	}
+	owner, authenticated := svr.cursorOwner(newContext("Bearer client1:secret"))
+	require.True(t, authenticated)
+	require.Equal(t, "client:client1", owner)
+
+	owner, authenticated = svr.cursorOwner(newContext(""))
+	require.False(t, authenticated)
+	require.Equal(t, "addr:192.0.2.1", owner)
+
+	secret, err := newCursorSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, 32)
+}
+
+func TestQueryCursor(t *testing.T) {
+	at, _, err := jwtLogin("sys", "manager")
+	require.NoError(t, err)
+	tableName := fmt.Sprintf("CURSOR_%d", testTimeTick.Unix())
+
+	do := func(t *testing.T, method string, path string, body string) (*http.Response, map[string]any) {
+		t.Helper()
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, httpServerAddress+path, reader)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", at))
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		obj := map[string]any{}
+		require.NoError(t, json.NewDecoder(rsp.Body).Decode(&obj))
+		return rsp, obj
+	}
+	doQuery := func(t *testing.T, sqlText string) {
+		t.Helper()
+		rsp, obj := do(t, http.MethodGet, "/db/query?q="+url.QueryEscape(sqlText), "")
+		require.Equal(t, http.StatusOK, rsp.StatusCode, obj)
+	}
+	doQuery(t, fmt.Sprintf("create tag table %s (NAME varchar(200) primary key, TIME datetime basetime, VALUE double summarized)", tableName))
+	t.Cleanup(func() {
+		doQuery(t, "drop table "+tableName)
+	})
+	for i := 0; i < 5; i++ {
+		doQuery(t, fmt.Sprintf("insert into %s values('c1', %d, %d)", tableName, testTimeTick.Add(time.Duration(i)*time.Second).UnixNano(), i))
+	}
+	doQuery(t, "EXEC table_flush("+tableName+")")
+
+	// only the query that returns rows
+	rsp, obj := do(t, http.MethodPost, "/db/query/cursor", fmt.Sprintf(`{"q":"delete from %s"}`, tableName))
+	require.Equal(t, http.StatusBadRequest, rsp.StatusCode)
+	require.Equal(t, "cursor is only for the query that returns rows", obj["reason"])
+
+	rsp, obj = do(t, http.MethodPost, "/db/query/cursor", fmt.Sprintf(`{"q":"select time, value from %s where name = ?", "p":["c1"]}`, tableName))
+	require.Equal(t, http.StatusOK, rsp.StatusCode, obj)
+	data := obj["data"].(map[string]any)
+	require.Equal(t, []any{"TIME", "VALUE"}, data["columns"])
+	require.Equal(t, []any{"datetime", "double"}, data["types"])
+	require.Equal(t, "1m0s", data["idleTimeout"])
+	cursor := data["cursor"].(string)
+
+	expects := []struct {
+		values  []any
+		hasMore string
+	}{
+		{values: []any{0.0, 1.0}, hasMore: "true"},
+		{values: []any{2.0, 3.0}, hasMore: "true"},
+		{values: []any{4.0}, hasMore: "false"},
+	}
+	for _, expect := range expects {
+		rsp, obj = do(t, http.MethodGet, "/db/query/cursor/"+cursor+"?n=2", "")
+		require.Equal(t, http.StatusOK, rsp.StatusCode, obj)
+		require.Equal(t, expect.hasMore, rsp.Header.Get("X-Cursor-Has-More"))
+		rows := obj["data"].(map[string]any)["rows"].([]any)
+		values := []any{}
+		for _, row := range rows {
+			values = append(values, row.([]any)[1])
+		}
+		require.Equal(t, expect.values, values)
+	}
+	// closed after all rows are fetched
+	rsp, _ = do(t, http.MethodGet, "/db/query/cursor/"+cursor, "")
+	require.Equal(t, http.StatusNotFound, rsp.StatusCode)
+
+	// close before all rows are fetched
+	rsp, obj = do(t, http.MethodPost, "/db/query/cursor", fmt.Sprintf(`{"q":"select * from %s"}`, tableName))
+	require.Equal(t, http.StatusOK, rsp.StatusCode, obj)
+	cursor = obj["data"].(map[string]any)["cursor"].(string)
+	rsp, obj = do(t, http.MethodDelete, "/db/query/cursor/"+cursor, "")
+	require.Equal(t, http.StatusOK, rsp.StatusCode, obj)
+	rsp, _ = do(t, http.MethodGet, "/db/query/cursor/"+cursor, "")
+	require.Equal(t, http.StatusNotFound, rsp.StatusCode)
+}
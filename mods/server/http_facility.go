@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/machbase/neo-server/v8/mods/model"
 	"github.com/machbase/neo-server/v8/mods/scheduler"
 )
 
@@ -36,6 +37,36 @@ func (svr *httpd) handleTimer(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+func (svr *httpd) handleTimerRuns(ctx *gin.Context) {
+	tick := time.Now()
+	rsp := gin.H{"success": false, "reason": "not specified"}
+
+	name := ctx.Param("name")
+	limit := strInt(ctx.Query("limit"), 0)
+	histRsp, err := svr.authServer.schedSvc.GetScheduleHistory(ctx, &scheduler.GetScheduleHistoryRequest{
+		Name:  name,
+		Limit: limit,
+	})
+	if err != nil {
+		rsp["reason"] = err.Error()
+		rsp["elapse"] = time.Since(tick).String()
+		ctx.JSON(http.StatusInternalServerError, rsp)
+		return
+	}
+	if !histRsp.Success {
+		rsp["reason"] = histRsp.Reason
+		rsp["elapse"] = time.Since(tick).String()
+		ctx.JSON(http.StatusInternalServerError, rsp)
+		return
+	}
+
+	rsp["success"] = true
+	rsp["reason"] = "success"
+	rsp["data"] = histRsp.Runs
+	rsp["elapse"] = time.Since(tick).String()
+	ctx.JSON(http.StatusOK, rsp)
+}
+
 func (svr *httpd) handleTimersUpdate(ctx *gin.Context) {
 	tick := time.Now()
 	rsp := gin.H{"success": false, "reason": "not specified"}
@@ -43,6 +74,9 @@ func (svr *httpd) handleTimersUpdate(ctx *gin.Context) {
 		AutoStart bool   `json:"autoStart"`
 		Schedule  string `json:"schedule"`
 		Path      string `json:"path"`
+
+		Retry   *model.RetryPolicy `json:"retry"`
+		CatchUp *string            `json:"catchUp"`
 	}{}
 
 	name := ctx.Param("name")
@@ -77,11 +111,20 @@ func (svr *httpd) handleTimersUpdate(ctx *gin.Context) {
 		return
 	}
 
+	// keep the retry and catch-up policy, if the request does not change them
+	if req.Retry == nil {
+		req.Retry = getRsp.Schedule.Retry
+	}
+	if req.CatchUp == nil {
+		req.CatchUp = &getRsp.Schedule.CatchUp
+	}
 	updateRsp, err := svr.authServer.schedSvc.UpdateSchedule(ctx, &scheduler.UpdateScheduleRequest{
 		Name:      name,
 		AutoStart: req.AutoStart,
 		Schedule:  req.Schedule,
 		Task:      req.Path,
+		Retry:     req.Retry,
+		CatchUp:   *req.CatchUp,
 	})
 	if err != nil {
 		rsp["reason"] = err.Error()
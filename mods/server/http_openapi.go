@@ -0,0 +1,499 @@
+package server
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/machbase/neo-server/v8/mods"
+)
+
+// openapiOperation describes a handler of the http api for the OpenAPI document.
+type openapiOperation struct {
+	Summary       string
+	Tag           string
+	Params        []openapiParam
+	QueryOf       any      // the fields of the struct are query parameters
+	Request       any      // the struct of the json request body
+	RequestTypes  []string // the content types of the request body
+	Response      any      // the struct of the json response
+	ResponseTypes []string // the content types of the response other than json
+	Upgrade       bool     // websocket
+	Internal      bool     // for the web ui only, not documented
+}
+
+// openapiKey is the method and the name of the handler,
+// the method openapiAnyMethod matches the methods that have no entry of their own.
+type openapiKey struct {
+	Method  string
+	Handler string
+}
+
+const openapiAnyMethod = "*"
+
+type openapiParam struct {
+	Name        string
+	In          string // "query", "header"
+	Type        string
+	Description string
+}
+
+const (
+	openapiSecurityToken = "clientToken"
+	openapiSecurityJwt   = "jwt"
+)
+
+var openapiQueryOutputTypes = []string{
+	"application/json", "text/csv", "text/plain", "application/x-ndjson",
+	"application/vnd.apache.arrow.stream", "application/vnd.apache.parquet",
+}
+
+var openapiWriteInputTypes = []string{
+	"application/json", "text/csv", "application/x-ndjson",
+	"application/vnd.apache.arrow.stream", "application/vnd.apache.parquet",
+	"multipart/form-data",
+}
+
+var openapiCursorSecretParam = openapiParam{Name: "X-Cursor-Secret", In: "header", Type: "string",
+	Description: "the secret of the cursor opened by the anonymous requester"}
+
+var openapiTqlOutputTypes = []string{
+	"application/json", "text/csv", "text/html", "text/plain", "application/x-ndjson",
+}
+
+// HttpQueryRequest is the QueryRequest of the http api for the OpenAPI document,
+// it has no fields of the mqtt query.
+type HttpQueryRequest struct {
+	SqlText            string `json:"q"`
+	Params             []any  `json:"p,omitempty"`
+	RowsFlatten        bool   `json:"rowsFlatten,omitempty"`
+	RowsArray          bool   `json:"rowsArray,omitempty"`
+	Transpose          bool   `json:"transpose,omitempty"`
+	Timeformat         string `json:"timeformat,omitempty"`
+	TimeLocation       string `json:"tz,omitempty"`
+	Format             string `json:"format,omitempty"`
+	BinaryFormat       string `json:"binaryformat,omitempty"`
+	Compress           string `json:"compress,omitempty"`
+	Precision          int    `json:"precision,omitempty"`
+	Rownum             bool   `json:"rownum,omitempty"`
+	Heading            bool   `json:"heading,omitempty"`
+	Header             string `json:"header,omitempty"`
+	Delimiter          string `json:"delimiter,omitempty"`
+	BoxStyle           string `json:"boxStyle,omitempty"`
+	BoxSeparateColumns bool   `json:"boxSeparateColumns,omitempty"`
+	BoxDrawBorder      bool   `json:"boxDrawBorder,omitempty"`
+}
+
+// openapiHandlers has the entries of all handlers that are routed by Router(),
+// the paths of the document are taken from the routes.
+var openapiHandlers = map[openapiKey]openapiOperation{
+	{http.MethodGet, "handleQuery"}: {Tag: "query", Summary: "Execute a SQL query",
+		QueryOf: HttpQueryRequest{}, Response: QueryResponse{}, ResponseTypes: openapiQueryOutputTypes},
+	{http.MethodPost, "handleQuery"}: {Tag: "query", Summary: "Execute a SQL query",
+		Request: HttpQueryRequest{}, RequestTypes: []string{"application/json", "application/x-www-form-urlencoded"},
+		Response: QueryResponse{}, ResponseTypes: openapiQueryOutputTypes},
+	{openapiAnyMethod, "handleQuery"}: {Internal: true},
+	{http.MethodGet, "handleNamedQuery"}: {Tag: "query", Summary: "Execute a named query",
+		Params:   []openapiParam{{Name: "format", In: "query", Type: "string"}},
+		Response: QueryResponse{}, ResponseTypes: openapiQueryOutputTypes},
+	{http.MethodPost, "handleNamedQuery"}: {Tag: "query", Summary: "Execute a named query, the parameters are given as the form values",
+		RequestTypes: []string{"application/x-www-form-urlencoded"}, Response: QueryResponse{}, ResponseTypes: openapiQueryOutputTypes},
+	{http.MethodPost, "handleQueryCursorOpen"}: {Tag: "query", Summary: "Open a query cursor",
+		Request: HttpQueryRequest{}, RequestTypes: []string{"application/json"}, Response: QueryCursorResponse{}},
+	{http.MethodGet, "handleQueryCursorFetch"}: {Tag: "query", Summary: "Fetch rows from a query cursor",
+		Params: []openapiParam{
+			{Name: "n", In: "query", Type: "integer", Description: "max number of rows to fetch"},
+			openapiCursorSecretParam,
+		},
+		Response: QueryResponse{}, ResponseTypes: openapiQueryOutputTypes},
+	{http.MethodDelete, "handleQueryCursorClose"}: {Tag: "query", Summary: "Close a query cursor",
+		Params: []openapiParam{openapiCursorSecretParam}, Response: QueryCursorResponse{}},
+	{http.MethodGet, "handleFileQuery"}: {Tag: "files", Summary: "Get a file stored in a table column",
+		Params:        []openapiParam{{Name: "tag", In: "query", Type: "string"}},
+		ResponseTypes: []string{"application/octet-stream"}},
+	{http.MethodPost, "handleWrite"}: {Tag: "write", Summary: "Write rows into a table",
+		Params: openapiWriteParams, Request: WriteRequest{}, RequestTypes: openapiWriteInputTypes, Response: WriteResponse{}},
+	{http.MethodPost, "handleLineProtocol"}: {Tag: "write", Summary: "Write rows in the influx line protocol, the path should be 'write'",
+		Params: []openapiParam{
+			{Name: "db", In: "query", Type: "string", Description: "table name"},
+			{Name: "precision", In: "query", Type: "string", Description: "ns, us or ms"},
+		},
+		RequestTypes: []string{"text/plain"}},
+	{http.MethodGet, "handleTagSeries"}: {Tag: "tables", Summary: "Get the downsampled series of a tag",
+		Params: []openapiParam{
+			{Name: "between", In: "query", Type: "string", Description: "'from,to'"},
+			{Name: "agg", In: "query", Type: "string", Description: "avg, min, max, first, last or lttb"},
+			{Name: "points", In: "query", Type: "integer"},
+			{Name: "interval", In: "query", Type: "string"},
+			{Name: "column", In: "query", Type: "string"},
+			{Name: "rollup", In: "query", Type: "boolean"},
+			{Name: "timeformat", In: "query", Type: "string"},
+			{Name: "tz", In: "query", Type: "string"},
+		},
+		Response: QueryResponse{}},
+	{http.MethodGet, "handleWatchSocket"}: {Tag: "watch", Summary: "Watch tables over websocket", Upgrade: true},
+	{http.MethodGet, "handleWatchQuery"}: {Tag: "watch", Summary: "Watch the new rows of a table as server-sent events",
+		Params: []openapiParam{
+			{Name: "tag", In: "query", Type: "string"},
+			{Name: "period", In: "query", Type: "string"},
+			{Name: "max-rows", In: "query", Type: "integer"},
+			{Name: "keep-alive", In: "query", Type: "string"},
+			{Name: "parallelism", In: "query", Type: "integer"},
+			{Name: "timeformat", In: "query", Type: "string"},
+			{Name: "tz", In: "query", Type: "string"},
+		},
+		ResponseTypes: []string{"text/event-stream"}},
+	{http.MethodGet, "handleTqlQuery"}: {Tag: "tql", Summary: "Execute a TQL script given in the query",
+		Params: openapiTqlParams, ResponseTypes: openapiTqlOutputTypes},
+	{http.MethodPost, "handleTqlQuery"}: {Tag: "tql", Summary: "Execute a TQL script given in the body",
+		Params: openapiTqlParams, RequestTypes: []string{"text/plain"}, ResponseTypes: openapiTqlOutputTypes},
+	{http.MethodGet, "handleTqlFile"}: {Tag: "tql", Summary: "Execute a TQL file",
+		Params: openapiTqlParams, ResponseTypes: openapiTqlOutputTypes},
+	{http.MethodPost, "handleTqlFile"}: {Tag: "tql", Summary: "Execute a TQL file with the body as the input",
+		Params: openapiTqlParams, RequestTypes: []string{"application/json", "text/csv", "text/plain"}, ResponseTypes: openapiTqlOutputTypes},
+	{http.MethodGet, "handleTables"}: {Tag: "tables", Summary: "List tables",
+		Params: []openapiParam{
+			{Name: "name", In: "query", Type: "string", Description: "name filter, glob pattern is allowed"},
+			{Name: "showall", In: "query", Type: "boolean"},
+		},
+		Response: QueryResponse{}},
+	{http.MethodGet, "handleTags"}: {Tag: "tables", Summary: "List tags of a table",
+		Params:   []openapiParam{{Name: "name", In: "query", Type: "string", Description: "name filter, glob pattern is allowed"}},
+		Response: QueryResponse{}},
+	{http.MethodGet, "handleTagStat"}: {Tag: "tables", Summary: "Get the statistics of a tag",
+		Params: []openapiParam{
+			{Name: "timeformat", In: "query", Type: "string"},
+			{Name: "tz", In: "query", Type: "string"},
+		},
+		Response: QueryResponse{}},
+	{http.MethodGet, "handleFiles"}: {Tag: "files", Summary: "Read a file or list a directory",
+		Params: []openapiParam{
+			{Name: "filter", In: "query", Type: "string"},
+			{Name: "recursive", In: "query", Type: "boolean"},
+		},
+		Response: SsfsResponse{}, ResponseTypes: []string{"application/octet-stream"}},
+	{http.MethodPost, "handleFiles"}: {Tag: "files", Summary: "Write a file or create a directory",
+		RequestTypes: []string{"application/octet-stream"}, Response: SsfsResponse{}},
+	{http.MethodPut, "handleFiles"}: {Tag: "files", Summary: "Rename a file or a directory",
+		RequestTypes: []string{"application/json"}, Response: SsfsResponse{}},
+	{http.MethodDelete, "handleFiles"}: {Tag: "files", Summary: "Delete a file or a directory",
+		Params:   []openapiParam{{Name: "recursive", In: "query", Type: "boolean"}},
+		Response: SsfsResponse{}},
+	{openapiAnyMethod, "handleFiles"}: {Internal: true},
+	{http.MethodGet, "handleTimer"}:   {Tag: "schedules", Summary: "Get a timer schedule"},
+	{http.MethodGet, "handleTimerRuns"}: {Tag: "schedules", Summary: "List the recent runs of a timer schedule",
+		Params: []openapiParam{{Name: "limit", In: "query", Type: "integer", Description: "max number of runs"}}},
+	{http.MethodPut, "handleTimersUpdate"}: {Tag: "schedules", Summary: "Update a timer schedule",
+		RequestTypes: []string{"application/json"}},
+	{http.MethodGet, "handleSubscriber"}: {Tag: "schedules", Summary: "Get a subscriber schedule"},
+
+	{http.MethodGet, "handleOpenAPI"}:         {Internal: true},
+	{openapiAnyMethod, "handleEula"}:          {Internal: true},
+	{http.MethodPost, "handleLogin"}:          {Internal: true},
+	{http.MethodPost, "handleReLogin"}:        {Internal: true},
+	{http.MethodPost, "handleLogout"}:         {Internal: true},
+	{http.MethodPost, "handleChangePassword"}: {Internal: true},
+	{http.MethodGet, "handleCheck"}:           {Internal: true},
+	{http.MethodGet, "handleTermData"}:        {Internal: true},
+	{http.MethodPost, "handleTermWindowSize"}: {Internal: true},
+	{http.MethodGet, "handleConsoleData"}:     {Internal: true},
+	{http.MethodGet, "handleWebWatchSocket"}:  {Internal: true},
+	{http.MethodGet, "handleTqlQueryExec"}:    {Internal: true},
+	{openapiAnyMethod, "handleServiceProxy"}:  {Internal: true},
+	{http.MethodPost, "handleHttpRpc"}:        {Internal: true},
+	{http.MethodGet, "handleRefs"}:            {Internal: true},
+	{http.MethodGet, "handleGetLicense"}:      {Internal: true},
+	{http.MethodPost, "handleInstallLicense"}: {Internal: true},
+	{openapiAnyMethod, "handlePublic"}:        {Internal: true},
+	{openapiAnyMethod, "handleStatzConfig"}:   {Internal: true},
+}
+
+// openapiLookup returns the entry of the handler for the method.
+func openapiLookup(method string, handler string) (openapiOperation, bool) {
+	if op, ok := openapiHandlers[openapiKey{method, handler}]; ok {
+		return op, true
+	}
+	op, ok := openapiHandlers[openapiKey{openapiAnyMethod, handler}]
+	return op, ok
+}
+
+// openapiHandlerName returns the method name of the handler if it is a method of httpd,
+// e.g. "handleWrite" of "github.com/machbase/neo-server/v8/mods/server.(*httpd).handleWrite-fm".
+// The closures and the handlers of the other packages are not the api, it returns false for them.
+func openapiHandlerName(name string) (string, bool) {
+	_, method, found := strings.Cut(name, ".(*httpd).")
+	if !found {
+		return "", false
+	}
+	method = strings.TrimSuffix(method, "-fm")
+	if strings.Contains(method, ".") {
+		return "", false
+	}
+	return method, true
+}
+
+var openapiTqlParams = []openapiParam{
+	{Name: TQL_PROFILE_PARAM, In: "query", Type: "boolean", Description: "respond the statistics of the nodes instead of the output"},
+	{Name: TQL_EXPLAIN_PARAM, In: "query", Type: "boolean", Description: "respond the compiled nodes without executing"},
+}
+
+var openapiWriteParams = []openapiParam{
+	{Name: "method", In: "query", Type: "string", Description: "insert or append"},
+	{Name: "batch", In: "query", Type: "boolean"},
+	{Name: "format", In: "query", Type: "string"},
+	{Name: "compress", In: "query", Type: "string"},
+	{Name: "timeformat", In: "query", Type: "string"},
+	{Name: "tz", In: "query", Type: "string"},
+	{Name: "delimiter", In: "query", Type: "string"},
+	{Name: "header", In: "query", Type: "string", Description: "skip or columns"},
+	{Name: "Idempotency-Key", In: "header", Type: "string", Description: "the write is done in batch and its result is replayed for the retries, the keys are kept in memory until the server restarts"},
+}
+
+// handleOpenAPI serves the OpenAPI 3 document of the http api.
+func (svr *httpd) handleOpenAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, svr.openapiDocument(svr.routes))
+}
+
+// openapiDocument returns the document of the routes that are registered by Router().
+func (svr *httpd) openapiDocument(routes gin.RoutesInfo) map[string]any {
+	gen := &openapiGenerator{
+		paths:   map[string]any{},
+		schemas: map[string]any{},
+	}
+	for _, route := range routes {
+		name, ok := openapiHandlerName(route.Handler)
+		if !ok {
+			continue
+		}
+		op, ok := openapiLookup(route.Method, name)
+		if !ok || op.Internal {
+			continue
+		}
+		var security []string
+		switch svr.routeHandlerType(route.Path) {
+		case HandlerMachbase, HandlerInflux:
+			if svr.enableTokenAuth {
+				security = append(security, openapiSecurityToken)
+			}
+		case HandlerWeb:
+			security = append(security, openapiSecurityJwt)
+		default:
+			continue
+		}
+		gen.addRoute(route.Method, route.Path, op, security)
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "machbase-neo HTTP API",
+			"version": strings.TrimPrefix(mods.DisplayVersion(), "v"),
+		},
+		"paths": gen.paths,
+		"components": map[string]any{
+			"schemas": gen.schemas,
+			"securitySchemes": map[string]any{
+				openapiSecurityToken: map[string]any{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "client token, '<client-id>:<token>'",
+				},
+				openapiSecurityJwt: map[string]any{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+					"description":  "access token of /web/api/login",
+				},
+			},
+		},
+	}
+}
+
+type openapiGenerator struct {
+	paths   map[string]any
+	schemas map[string]any
+}
+
+var openapiPathParam = regexp.MustCompile(`[:*]([A-Za-z_][A-Za-z0-9_]*)`)
+
+// routeHandlerType returns the type of the handler that the path belongs to.
+func (svr *httpd) routeHandlerType(path string) HandlerType {
+	var ret HandlerType
+	longest := -1
+	for _, h := range svr.handlers {
+		prefix := strings.TrimSuffix(h.Prefix, "/")
+		if strings.HasPrefix(path, prefix+"/") && len(prefix) > longest {
+			ret, longest = h.Handler, len(prefix)
+		}
+	}
+	return ret
+}
+
+func (gen *openapiGenerator) addRoute(method string, routePath string, r openapiOperation, security []string) {
+	path := openapiPathParam.ReplaceAllString(routePath, "{$1}")
+	item, ok := gen.paths[path].(map[string]any)
+	if !ok {
+		item = map[string]any{}
+		gen.paths[path] = item
+	}
+	params := []any{}
+	for _, m := range openapiPathParam.FindAllStringSubmatch(routePath, -1) {
+		params = append(params, map[string]any{
+			"name": m[1], "in": "path", "required": true, "schema": map[string]any{"type": "string"},
+		})
+	}
+	if r.QueryOf != nil {
+		t := reflect.TypeOf(r.QueryOf)
+		for i := 0; i < t.NumField(); i++ {
+			name, _ := openapiFieldName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			params = append(params, map[string]any{
+				"name": name, "in": "query", "schema": gen.schemaOf(t.Field(i).Type),
+			})
+		}
+	}
+	for _, p := range r.Params {
+		param := map[string]any{"name": p.Name, "in": p.In, "schema": map[string]any{"type": p.Type}}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+
+	op := map[string]any{
+		"summary":     r.Summary,
+		"tags":        []string{r.Tag},
+		"operationId": openapiOperationId(method, routePath),
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if len(security) > 0 {
+		sec := []any{}
+		for _, s := range security {
+			sec = append(sec, map[string]any{s: []string{}})
+		}
+		op["security"] = sec
+	}
+	if len(r.RequestTypes) > 0 {
+		content := map[string]any{}
+		for _, ct := range r.RequestTypes {
+			if ct == "application/json" && r.Request != nil {
+				content[ct] = map[string]any{"schema": gen.schemaOf(reflect.TypeOf(r.Request))}
+			} else {
+				content[ct] = map[string]any{}
+			}
+		}
+		op["requestBody"] = map[string]any{"content": content}
+	}
+	responses := map[string]any{}
+	if r.Upgrade {
+		responses["101"] = map[string]any{"description": "Switching Protocols to websocket"}
+	} else {
+		content := map[string]any{}
+		if r.Response != nil {
+			content["application/json"] = map[string]any{"schema": gen.schemaOf(reflect.TypeOf(r.Response))}
+		}
+		for _, ct := range r.ResponseTypes {
+			if _, exists := content[ct]; !exists {
+				content[ct] = map[string]any{}
+			}
+		}
+		responses["200"] = map[string]any{"description": "OK", "content": content}
+	}
+	responses["default"] = map[string]any{"description": "Error"}
+	op["responses"] = responses
+	item[strings.ToLower(method)] = op
+}
+
+// schemaOf returns the json schema of the type,
+// the named structs are registered in the components and referenced.
+func (gen *openapiGenerator) schemaOf(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": gen.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": gen.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return gen.structSchema(t)
+		}
+		if _, exists := gen.schemas[t.Name()]; !exists {
+			// placeholder for the recursive reference
+			gen.schemas[t.Name()] = map[string]any{}
+			gen.schemas[t.Name()] = gen.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		// any
+		return map[string]any{}
+	}
+}
+
+func (gen *openapiGenerator) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty := openapiFieldName(f)
+		if name == "" {
+			continue
+		}
+		props[name] = gen.schemaOf(f.Type)
+		if !omitempty && f.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+	ret := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		ret["required"] = required
+	}
+	return ret
+}
+
+// openapiFieldName returns the json name of the field, it returns empty string
+// if the field is not exported or not serialized.
+func openapiFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+func openapiOperationId(method string, path string) string {
+	sb := &strings.Builder{}
+	sb.WriteString(strings.ToLower(method))
+	for _, tok := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '-' || r == ':' || r == '*'
+	}) {
+		sb.WriteString(strings.ToUpper(tok[:1]))
+		sb.WriteString(tok[1:])
+	}
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	svr, err := NewHttp()
+	require.NoError(t, err)
+	svr.enableTokenAuth = true
+	svr.routes = svr.Router().Routes()
+
+	r := gin.New()
+	r.GET("/db/openapi.json", svr.handleOpenAPI)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/db/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	doc := map[string]any{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	require.Equal(t, "3.0.3", doc["openapi"])
+
+	paths := doc["paths"].(map[string]any)
+	for _, p := range []string{
+		"/db/query", "/db/write", "/db/write/{table}", "/db/watch", "/db/watch/{table}",
+		"/db/tql", "/db/tql/{path}", "/db/query/file/{table}/{column}/{id}",
+		"/web/api/tables", "/web/api/tables/{table}/tags", "/web/api/files/{path}",
+		"/web/api/tables/{table}/tags/{tag}/series", "/web/api/timers/{name}/runs", "/metrics/{oper}",
+	} {
+		require.Contains(t, paths, p)
+	}
+	// not for the api clients
+	require.NotContains(t, paths, "/web/api/login")
+	require.NotContains(t, paths, "/db/openapi.json")
+
+	named := paths["/db/query/named/{name}"].(map[string]any)["post"].(map[string]any)
+	require.Equal(t, map[string]any{"application/x-www-form-urlencoded": map[string]any{}},
+		named["requestBody"].(map[string]any)["content"])
+
+	// the query of the http api has no text/plain body and no mqtt fields
+	query := paths["/db/query"].(map[string]any)["post"].(map[string]any)
+	content := query["requestBody"].(map[string]any)["content"].(map[string]any)
+	require.NotContains(t, content, "text/plain")
+	require.Equal(t, "#/components/schemas/HttpQueryRequest", content["application/json"].(map[string]any)["schema"].(map[string]any)["$ref"])
+	for _, p := range paths["/db/query"].(map[string]any)["get"].(map[string]any)["parameters"].([]any) {
+		require.NotEqual(t, "reply", p.(map[string]any)["name"])
+	}
+
+	write := paths["/db/write/{table}"].(map[string]any)["post"].(map[string]any)
+	require.Equal(t, "postDbWriteTable", write["operationId"])
+	require.Equal(t, []any{map[string]any{"clientToken": []any{}}}, write["security"])
+	content = write["requestBody"].(map[string]any)["content"].(map[string]any)
+	require.Contains(t, content, "application/vnd.apache.parquet")
+	require.Equal(t, "#/components/schemas/WriteRequest", content["application/json"].(map[string]any)["schema"].(map[string]any)["$ref"])
+
+	tables := paths["/web/api/tables"].(map[string]any)["get"].(map[string]any)
+	require.Equal(t, []any{map[string]any{"jwt": []any{}}}, tables["security"])
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	rsp := schemas["QueryResponse"].(map[string]any)
+	props := rsp["properties"].(map[string]any)
+	require.Equal(t, map[string]any{"type": "boolean"}, props["success"])
+	require.Equal(t, map[string]any{"$ref": "#/components/schemas/QueryData"}, props["data"])
+	require.NotContains(t, props, "ContentType")
+	require.Equal(t, []any{"success", "reason", "elapse"}, rsp["required"])
+	require.Contains(t, schemas, "WriteReject")
+	require.Contains(t, schemas, "Entry")
+}
+
+func TestOpenAPIHandlers(t *testing.T) {
+	name, ok := openapiHandlerName("github.com/machbase/neo-server/v8/mods/server.(*httpd).handleWrite-fm")
+	require.True(t, ok)
+	require.Equal(t, "handleWrite", name)
+	_, ok = openapiHandlerName("github.com/machbase/neo-server/v8/mods/server.(*httpd).Router.func1")
+	require.False(t, ok)
+	_, ok = openapiHandlerName("github.com/gin-gonic/gin.WrapF.func1")
+	require.False(t, ok)
+
+	// every route of the httpd handlers should have the entry, documented or internal
+	gin.SetMode(gin.ReleaseMode)
+	svr, err := NewHttp()
+	require.NoError(t, err)
+	for _, route := range svr.Router().Routes() {
+		name, ok := openapiHandlerName(route.Handler)
+		if !ok {
+			continue
+		}
+		_, ok = openapiLookup(route.Method, name)
+		require.True(t, ok, "no openapi entry of %s %s (%s)", route.Method, route.Path, name)
+	}
+}
@@ -137,6 +137,38 @@ func WithHttpWebShellProvider(provider model.ShellProvider) HttpOption {
 	}
 }
 
+func WithHttpQueryProvider(provider model.QueryProvider) HttpOption {
+	return func(s *httpd) {
+		s.queryProvider = provider
+	}
+}
+
+// WithHttpQueryCursor sets the idle timeout of the query cursors, e.g. "1m",
+// and the max number of the open cursors per user.
+func WithHttpQueryCursor(idleTimeout string, limit int) HttpOption {
+	return func(s *httpd) {
+		if idleTimeout != "" {
+			s.cursorIdleTimeout, _ = time.ParseDuration(idleTimeout)
+		}
+		s.cursorLimit = limit
+	}
+}
+
+// WithHttpRateLimiter applies the rate limits to the write requests.
+func WithHttpRateLimiter(rl *RateLimiter) HttpOption {
+	return func(s *httpd) {
+		s.rateLimiter = rl
+	}
+}
+
+// WithHttpWriteBodyLimit sets the max bytes of the write request body,
+// 0 or negative means unlimited.
+func WithHttpWriteBodyLimit(limit int64) HttpOption {
+	return func(s *httpd) {
+		s.writeBodyLimit = limit
+	}
+}
+
 func WithHttpStatzAllow(remotes ...string) HttpOption {
 	return func(s *httpd) {
 		addr := make([]string, 0, len(remotes))
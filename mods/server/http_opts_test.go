@@ -114,6 +114,7 @@ func TestWithHttpMiscOptions(t *testing.T) {
 	WithHttpLinger(7)(h)
 	WithHttpReadBufSize(1024)(h)
 	WithHttpWriteBufSize(2048)(h)
+	WithHttpQueryCursor("30s", 3)(h)
 	WithHttpPathMap("/data", "/tmp/data")(h)
 	WithHttpExperimentModeProvider(func() bool {
 		called = true
@@ -127,6 +128,8 @@ func TestWithHttpMiscOptions(t *testing.T) {
 	require.Equal(t, 7, h.linger)
 	require.Equal(t, 1024, h.readBufSize)
 	require.Equal(t, 2048, h.writeBufSize)
+	require.Equal(t, 30*time.Second, h.cursorIdleTimeout)
+	require.Equal(t, 3, h.cursorLimit)
 	require.Equal(t, "/tmp/data", h.pathMap["/data"])
 	require.NotNil(t, h.experimentModeProvider)
 	require.True(t, h.experimentModeProvider())
@@ -17,6 +17,7 @@ import (
 	client "github.com/machbase/neo-client/v2"
 	"github.com/machbase/neo-client/v2/api"
 	"github.com/machbase/neo-server/v8/mods/logging"
+	"github.com/machbase/neo-server/v8/mods/nums"
 	"github.com/machbase/neo-server/v8/mods/tql"
 	"github.com/machbase/neo-server/v8/mods/util"
 	"github.com/machbase/neo-server/v8/mods/util/glob"
@@ -28,36 +29,36 @@ func (svr *httpd) handleQuery(ctx *gin.Context) {
 	req := NewQueryRequest()
 	rsp := &QueryResponse{Success: false, Reason: "not specified"}
 
+	if code, err := svr.decodeQueryRequest(ctx, req); err != nil {
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(code, rsp)
+		return
+	}
+	svr.executeQuery(ctx, req, rsp, tick)
+}
+
+// decodeQueryRequest decodes the request of handleQuery from the body or the query string,
+// it returns the http status code to reply with the error.
+func (svr *httpd) decodeQueryRequest(ctx *gin.Context, req *QueryRequest) (int, error) {
 	switch ctx.Request.Method {
 	case http.MethodPost:
 		contentType := ctx.ContentType()
 		switch contentType {
 		case "application/json":
 			if err := req.DecodeJSON(ctx.Request.Body); err != nil {
-				rsp.Reason = err.Error()
-				rsp.Elapse = time.Since(tick).String()
-				ctx.JSON(http.StatusBadRequest, rsp)
-				return
+				return http.StatusBadRequest, err
 			}
 		case "application/x-www-form-urlencoded":
 			if err := req.DecodePostForm(ctx); err != nil {
-				rsp.Reason = err.Error()
-				rsp.Elapse = time.Since(tick).String()
-				ctx.JSON(http.StatusBadRequest, rsp)
-				return
+				return http.StatusBadRequest, err
 			}
 		default:
-			rsp.Reason = fmt.Sprintf("unsupported content-type: %s", contentType)
-			rsp.Elapse = time.Since(tick).String()
-			ctx.JSON(http.StatusUnsupportedMediaType, rsp)
-			return
+			return http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content-type: %s", contentType)
 		}
 	case http.MethodGet:
 		if err := req.DecodeQuery(ctx); err != nil {
-			rsp.Reason = err.Error()
-			rsp.Elapse = time.Since(tick).String()
-			ctx.JSON(http.StatusBadRequest, rsp)
-			return
+			return http.StatusBadRequest, err
 		}
 	}
 
@@ -66,15 +67,65 @@ func (svr *httpd) handleQuery(ctx *gin.Context) {
 	if svr.cypherAlg != "" && svr.cypherKey != "" && strings.HasPrefix(req.SqlText, "ENC:") {
 		cypherQ := strings.TrimPrefix(req.SqlText, "ENC:")
 		if sqlText, err := util.DecryptString(cypherQ, svr.cypherAlg, svr.cypherKey, svr.cypherPad); err != nil {
-			rsp.Reason = "decrypt sql fail, " + err.Error()
-			rsp.Elapse = time.Since(tick).String()
-			ctx.JSON(http.StatusBadRequest, rsp)
-			return
+			return http.StatusBadRequest, fmt.Errorf("decrypt sql fail, %s", err.Error())
 		} else {
 			req.SqlText = sqlText
 		}
 	}
+	return http.StatusOK, nil
+}
 
+// handleNamedQuery executes the query that is registered by query.add,
+// the parameters of the query are taken from the query string (or the form) by name.
+func (svr *httpd) handleNamedQuery(ctx *gin.Context) {
+	tick := time.Now()
+	rsp := &QueryResponse{Success: false, Reason: "not specified"}
+
+	if svr.queryProvider == nil {
+		rsp.Reason = "named query is not available"
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusNotImplemented, rsp)
+		return
+	}
+	def, err := svr.queryProvider.LoadQuery(ctx.Param("name"))
+	if err != nil {
+		rsp.Reason = fmt.Sprintf("query %q not found", ctx.Param("name"))
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusNotFound, rsp)
+		return
+	}
+
+	req := NewQueryRequest()
+	if def.Format != "" {
+		req.Format = def.Format
+	}
+	lookup := ctx.GetQuery
+	switch ctx.Request.Method {
+	case http.MethodPost:
+		err = req.DecodePostForm(ctx)
+		lookup = ctx.GetPostForm
+	default:
+		err = req.DecodeQuery(ctx)
+	}
+	if err != nil {
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	// the sql text is not overridable by the request,
+	// and the values are bound to the placeholders instead of being interpolated.
+	req.SqlText = def.SqlText
+	if req.Params, err = def.Bind(lookup); err != nil {
+		rsp.Reason = err.Error()
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusBadRequest, rsp)
+		return
+	}
+	svr.executeQuery(ctx, req, rsp, tick)
+}
+
+func (svr *httpd) executeQuery(ctx *gin.Context, req *QueryRequest, rsp *QueryResponse, tick time.Time) {
 	statusCode := http.StatusOK
 	hook := &QueryHook{
 		SetContentType: func(contentType string) {
@@ -149,6 +200,7 @@ func (svr *httpd) handleWatchQuery(ctx *gin.Context) {
 			Parallelism:  parallelism,
 			ChanSize:     100,
 			MaxRowNum:    maxRowNum,
+			Feed:         spi.DefaultChangeFeed,
 		})
 	if err != nil {
 		svr.log.Debug("Watcher error", err.Error())
@@ -609,6 +661,352 @@ func (svr *httpd) handleTagStat(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, rsp)
 }
 
+// seriesSteps are the intervals of the series that are chosen by the number of points,
+// all of them are supported by DATE_TRUNC() and the steps of one second or longer by ROLLUP().
+var seriesSteps = []time.Duration{
+	time.Millisecond, 2 * time.Millisecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour,
+	24 * time.Hour,
+}
+
+// seriesMaxRawRows limits the number of the raw rows that are loaded into memory for LTTB.
+var seriesMaxRawRows = 1_000_000
+
+// seriesStep returns the smallest step of seriesSteps that makes the points not more than the given points.
+func seriesStep(span time.Duration, points int) time.Duration {
+	for _, step := range seriesSteps {
+		if span/step < time.Duration(points) {
+			return step
+		}
+	}
+	return seriesSteps[len(seriesSteps)-1]
+}
+
+// seriesTruncUnit returns the unit and the count of DATE_TRUNC() for the interval,
+// ok is false if the interval can not be expressed in DATE_TRUNC().
+func seriesTruncUnit(interval time.Duration) (unit string, count int64, ok bool) {
+	switch {
+	case interval == 24*time.Hour:
+		return "day", 1, true
+	case interval%time.Hour == 0 && interval/time.Hour <= 24:
+		return "hour", int64(interval / time.Hour), true
+	case interval%time.Minute == 0 && interval/time.Minute <= 1440:
+		return "minute", int64(interval / time.Minute), true
+	case interval%time.Second == 0 && interval/time.Second <= 86400:
+		return "second", int64(interval / time.Second), true
+	case interval%time.Millisecond == 0 && interval/time.Millisecond <= 1000 && interval > 0:
+		return "millisecond", int64(interval / time.Millisecond), true
+	}
+	return "", 0, false
+}
+
+// seriesBucket returns the start of the bucket of t, the buckets are aligned
+// to the multiples of the interval since the epoch as DATE_TRUNC() and ROLLUP() do.
+// The time before the epoch is floored, not truncated toward the epoch.
+func seriesBucket(t time.Time, interval time.Duration) time.Time {
+	ns := t.UnixNano()
+	rem := ns % int64(interval)
+	if rem < 0 {
+		rem += int64(interval)
+	}
+	return time.Unix(0, ns-rem)
+}
+
+// seriesRollupUnit returns the unit of ROLLUP() for the unit of DATE_TRUNC().
+func seriesRollupUnit(truncUnit string) (string, bool) {
+	switch truncUnit {
+	case "second":
+		return "sec", true
+	case "minute":
+		return "min", true
+	case "hour", "day":
+		return truncUnit, true
+	}
+	return "", false
+}
+
+// Get the downsampled series of a tag
+//
+// @Summary     Get the downsampled series of a tag
+// @Description Get the series of a tag in the time range downsampled by the aggregation.
+// @Description avg, min and max of the summarized column are computed from the rollup of the table if it exists, and from the raw data otherwise.
+// @Description first, last and lttb are computed from the raw data.
+// @Param       table         path string true "table name"
+// @Param       tag           path string true "tag name"
+// @Param       between       query string true "time range 'from,to', e.g. 'now-1h,now'"
+// @Param       points        query int false "target number of points, default 1000"
+// @Param       interval      query string false "interval of the points, e.g. '10s', it precedes 'points'"
+// @Param       agg           query string false "avg, min, max, first, last, lttb, default avg"
+// @Param       column        query string false "value column, default the summarized column"
+// @Param       rollup        query bool false "false to use the raw data only, default true"
+// @Param       timeformat    query string false "timeformat (ns, us, ms, s, timeformat)"
+// @Param       tz            query string false "timezone"
+// @Success     200  {object}  msg.QueryResponse
+// @Failure     400 {object}  msg.QueryResponse
+// @Failure     500 {object}  msg.QueryResponse
+// @Router      /db/tables/:table/tags/:tag/series [get]
+func (svr *httpd) handleTagSeries(ctx *gin.Context) {
+	tick := time.Now()
+	rsp := &QueryResponse{Success: true, Reason: "success"}
+	replyError := func(code int, reason string) {
+		rsp.Success, rsp.Reason = false, reason
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(code, rsp)
+	}
+	table := strings.ToUpper(ctx.Param("table"))
+	tag := ctx.Param("tag")
+	timeformat := strString(ctx.Query("timeformat"), "ns")
+	timeLocation, err := util.ParseTimeLocation(ctx.Query("tz"), time.UTC)
+	if err != nil {
+		replyError(http.StatusBadRequest, err.Error())
+		return
+	}
+	agg := strings.ToLower(strString(ctx.Query("agg"), "avg"))
+	switch agg {
+	case "avg", "min", "max", "first", "last", "lttb":
+	default:
+		replyError(http.StatusBadRequest, fmt.Sprintf("unknown aggregation %q", agg))
+		return
+	}
+
+	var from, to time.Time
+	if between := strings.SplitN(ctx.Query("between"), ",", 2); len(between) != 2 {
+		replyError(http.StatusBadRequest, "between should be 'from,to'")
+		return
+	} else {
+		if from, err = util.ParseTime(between[0], timeformat, timeLocation); err != nil {
+			replyError(http.StatusBadRequest, err.Error())
+			return
+		}
+		if to, err = util.ParseTime(between[1], timeformat, timeLocation); err != nil {
+			replyError(http.StatusBadRequest, err.Error())
+			return
+		}
+		if !to.After(from) {
+			replyError(http.StatusBadRequest, "between should be 'from,to' and 'to' should be after 'from'")
+			return
+		}
+	}
+
+	points := strInt(ctx.Query("points"), 1000)
+	if points <= 0 {
+		replyError(http.StatusBadRequest, "points should be positive")
+		return
+	}
+	var interval time.Duration
+	if str := ctx.Query("interval"); str != "" {
+		if interval, err = time.ParseDuration(str); err != nil {
+			replyError(http.StatusBadRequest, err.Error())
+			return
+		} else if interval <= 0 {
+			replyError(http.StatusBadRequest, "interval should be positive")
+			return
+		}
+		points = int(to.Sub(from)/interval) + 1
+	} else {
+		interval = seriesStep(to.Sub(from), points)
+	}
+	truncUnit, truncCount, ok := seriesTruncUnit(interval)
+	if !ok {
+		replyError(http.StatusBadRequest, fmt.Sprintf("unsupported interval %s", interval))
+		return
+	}
+
+	var conn *sql.Conn
+	if _, hasClaim := svr.getJwtClaim(ctx); hasClaim {
+		conn, err = svr.getUserSqlConn(ctx)
+	} else {
+		conn, err = getPoolSqlConn(ctx)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	defer conn.Close()
+
+	var desc *spi.TableDescription
+	if rs := spi.ShowTable(ctx, conn, "MACHBASEDB", "SYS", table, false); rs.Err() != nil {
+		replyError(http.StatusInternalServerError, rs.Err().Error())
+		return
+	} else {
+		desc = rs.Description
+	}
+	if desc.Type != client.TableTypeTag {
+		replyError(http.StatusBadRequest, "not a tag table")
+		return
+	}
+	var timeColumn, valueColumn string
+	valueColumnName := strings.ToUpper(strString(ctx.Query("column"), strString(desc.SummarizedColumn, "VALUE")))
+	for _, col := range desc.Columns {
+		if col.IsBaseTime() {
+			timeColumn = col.Name
+		}
+		if col.Name == valueColumnName {
+			switch col.DataType {
+			case api.DataTypeFloat64, api.DataTypeFloat32,
+				api.DataTypeInt16, api.DataTypeInt32, api.DataTypeInt64,
+				api.DataTypeUInt16, api.DataTypeUInt32, api.DataTypeUInt64:
+				valueColumn = col.Name
+			default:
+				replyError(http.StatusBadRequest, fmt.Sprintf("column %q is not numeric", valueColumnName))
+				return
+			}
+		}
+	}
+	if timeColumn == "" {
+		replyError(http.StatusInternalServerError, fmt.Sprintf("fail to get basetime column '%s'", table))
+		return
+	}
+	if valueColumn == "" {
+		replyError(http.StatusBadRequest, fmt.Sprintf("column %q does not exist", valueColumnName))
+		return
+	}
+
+	timeToJson := func(v time.Time) any {
+		switch timeformat {
+		case "ns":
+			return v.UnixNano()
+		case "ms":
+			return v.UnixMilli()
+		case "us":
+			return v.UnixMicro()
+		case "s":
+			return v.Unix()
+		default:
+			return v.In(timeLocation).Format(timeformat)
+		}
+	}
+	data := &QueryData{
+		Columns: []string{"TIME", "VALUE"},
+		Types:   []string{string(api.DataTypeDatetime), string(api.DataTypeFloat64)},
+		Rows:    [][]any{},
+	}
+	appendRow := func(t time.Time, v sql.NullFloat64) {
+		if v.Valid {
+			data.Rows = append(data.Rows, []any{timeToJson(t), v.Float64})
+		} else {
+			data.Rows = append(data.Rows, []any{timeToJson(t), nil})
+		}
+	}
+
+	source := "raw"
+	switch agg {
+	case "avg", "min", "max":
+		var rows *sql.Rows
+		// the rollup of the table is kept on the summarized column only
+		useRollup := strBool(ctx.Query("rollup"), true) && desc.Summarized && valueColumn == desc.SummarizedColumn
+		if rollupUnit, ok := seriesRollupUnit(truncUnit); ok && useRollup {
+			rs := spi.ShowRollupGap(ctx, conn)
+			if err := rs.Err(); err != nil {
+				replyError(http.StatusInternalServerError, err.Error())
+				return
+			}
+			for _, r := range rs.Rollups() {
+				if !strings.EqualFold(r.SrcTable, table) {
+					continue
+				}
+				sqlText := fmt.Sprintf("SELECT ROLLUP('%s', %d, %s) AS T, %s(%s) FROM %s WHERE %s = ? AND %s BETWEEN ? AND ? GROUP BY T ORDER BY T",
+					rollupUnit, truncCount, timeColumn, agg, valueColumn, table, desc.TagNameColumn, timeColumn)
+				// fall back to the raw data if the rollup does not fit the interval
+				if rows, err = conn.QueryContext(ctx, sqlText, tag, from, to); err == nil {
+					source = r.RollupName
+				}
+				break
+			}
+		}
+		if rows == nil {
+			sqlText := fmt.Sprintf("SELECT DATE_TRUNC('%s', %s, %d) AS T, %s(%s) FROM %s WHERE %s = ? AND %s BETWEEN ? AND ? GROUP BY T ORDER BY T",
+				truncUnit, timeColumn, truncCount, agg, valueColumn, table, desc.TagNameColumn, timeColumn)
+			if rows, err = conn.QueryContext(ctx, sqlText, tag, from, to); err != nil {
+				replyError(http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var t time.Time
+			var v sql.NullFloat64
+			if err := rows.Scan(&t, &v); err != nil {
+				replyError(http.StatusInternalServerError, err.Error())
+				return
+			}
+			appendRow(t, v)
+		}
+		if err := rows.Err(); err != nil {
+			replyError(http.StatusInternalServerError, err.Error())
+			return
+		}
+	case "first", "last", "lttb":
+		sqlText := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s = ? AND %s BETWEEN ? AND ? ORDER BY %s",
+			timeColumn, valueColumn, table, desc.TagNameColumn, timeColumn, timeColumn)
+		rows, err := conn.QueryContext(ctx, sqlText, tag, from, to)
+		if err != nil {
+			replyError(http.StatusInternalServerError, err.Error())
+			return
+		}
+		defer rows.Close()
+		// the points of lttb are the offsets from 'from' to keep the precision of the time
+		var lttbTimes []time.Time
+		var lttbPoints []nums.Point
+		var bucket time.Time
+		var bucketValue sql.NullFloat64
+		hasBucket := false
+		for rows.Next() {
+			var t time.Time
+			var v sql.NullFloat64
+			if err := rows.Scan(&t, &v); err != nil {
+				replyError(http.StatusInternalServerError, err.Error())
+				return
+			}
+			if agg == "lttb" {
+				if !v.Valid {
+					continue
+				}
+				if len(lttbPoints) >= seriesMaxRawRows {
+					replyError(http.StatusBadRequest, fmt.Sprintf("too many rows to lttb, more than %d", seriesMaxRawRows))
+					return
+				}
+				lttbTimes = append(lttbTimes, t)
+				lttbPoints = append(lttbPoints, nums.Point{float64(t.Sub(from)), v.Float64})
+				continue
+			}
+			b := seriesBucket(t, interval)
+			if hasBucket && b.Equal(bucket) {
+				if agg == "last" {
+					bucketValue = v
+				}
+				continue
+			}
+			if hasBucket {
+				appendRow(bucket, bucketValue)
+			}
+			bucket, bucketValue, hasBucket = b, v, true
+		}
+		if err := rows.Err(); err != nil {
+			replyError(http.StatusInternalServerError, err.Error())
+			return
+		}
+		if hasBucket {
+			appendRow(bucket, bucketValue)
+		}
+		for _, idx := range nums.LTTB(lttbPoints, points) {
+			appendRow(lttbTimes[idx], sql.NullFloat64{Float64: lttbPoints[idx][1], Valid: true})
+		}
+	}
+
+	ctx.Header("X-Series-Source", source)
+	if agg != "lttb" {
+		ctx.Header("X-Series-Interval", interval.String())
+	}
+	rsp.Elapse = time.Since(tick).String()
+	rsp.Data = data
+	ctx.JSON(http.StatusOK, rsp)
+}
+
 const TqlHeaderChartType = "X-Chart-Type"
 const TqlHeaderChartOutput = "X-Chart-Output"
 const TqlHeaderTqlOutput = "X-Tql-Output"
@@ -642,6 +1040,57 @@ func parseConsoleId(ctx *gin.Context) *ConsoleInfo {
 
 const TQL_SCRIPT_PARAM = "$"
 const TQL_TOKEN_PARAM = "$token"
+const TQL_PROFILE_PARAM = "$profile"
+const TQL_EXPLAIN_PARAM = "$explain"
+
+// tqlDiagnosticParams returns the flags of "?$explain=true" and "?$profile=true",
+// and removes them from the params of the script.
+// The names are reserved like "$token", so that param('profile') of the script is not taken.
+func tqlDiagnosticParams(params url.Values) (explain bool, profile bool) {
+	explain = params.Get(TQL_EXPLAIN_PARAM) == "true"
+	profile = params.Get(TQL_PROFILE_PARAM) == "true"
+	params.Del(TQL_EXPLAIN_PARAM)
+	params.Del(TQL_PROFILE_PARAM)
+	return
+}
+
+type TqlProfileResponse struct {
+	Success bool                   `json:"success"`
+	Reason  string                 `json:"reason"`
+	Elapse  string                 `json:"elapse"`
+	Data    TqlProfileResponseData `json:"data"`
+}
+
+type TqlProfileResponseData struct {
+	Message string             `json:"message,omitempty"`
+	Profile []*tql.NodeProfile `json:"profile"`
+}
+
+// executeTqlExplain responds the compiled nodes of the task without executing.
+func executeTqlExplain(ctx *gin.Context, task *tql.Task) {
+	ctx.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(task.Explain()))
+}
+
+// executeTqlProfile executes the task that discards the output,
+// and responds the statistics of the nodes instead.
+func executeTqlProfile(ctx *gin.Context, task *tql.Task, tick time.Time) {
+	go func() {
+		<-ctx.Request.Context().Done()
+		task.Cancel()
+	}()
+	result := task.Execute()
+	rsp := &TqlProfileResponse{Success: true, Reason: "success"}
+	if result == nil {
+		rsp.Success, rsp.Reason = false, "task result is empty"
+	} else if result.Err != nil {
+		rsp.Success, rsp.Reason = false, result.Err.Error()
+	} else {
+		rsp.Data.Message = result.Message
+	}
+	rsp.Data.Profile = task.Profile()
+	rsp.Elapse = time.Since(tick).String()
+	ctx.JSON(http.StatusOK, rsp)
+}
 
 // POST "/tql/tql-exec" accepts the access token in the query parameter
 func (svr *httpd) handleTqlQueryExec(ctx *gin.Context) {
@@ -714,6 +1163,8 @@ func (svr *httpd) handleTqlQuery(ctx *gin.Context) {
 		return
 	}
 
+	// SetParams copies the params, the diagnostic params are taken out before it
+	explain, profile := tqlDiagnosticParams(params)
 	task := tql.NewTaskContext(ctx)
 	task.SetParams(params)
 	task.SetInputReader(input)
@@ -728,6 +1179,10 @@ func (svr *httpd) handleTqlQuery(ctx *gin.Context) {
 		}
 	}
 	task.SetOutputWriterJson(&util.NopCloseWriter{Writer: ctx.Writer}, true)
+	if profile {
+		task.SetProfile(true)
+		task.SetOutputWriter(io.Discard)
+	}
 	if err := task.Compile(codeReader); err != nil {
 		svr.log.Error("tql parse error", err.Error())
 		rsp.Reason = err.Error()
@@ -735,6 +1190,13 @@ func (svr *httpd) handleTqlQuery(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, rsp)
 		return
 	}
+	if explain {
+		executeTqlExplain(ctx, task)
+		return
+	} else if profile {
+		executeTqlProfile(ctx, task, tick)
+		return
+	}
 	ctx.Writer.Header().Set("Content-Type", task.OutputContentType())
 	ctx.Writer.Header().Set("Content-Encoding", task.OutputContentEncoding())
 	if chart := task.OutputChartType(); len(chart) > 0 {
@@ -822,6 +1284,8 @@ func (svr *httpd) handleTqlFile(ctx *gin.Context) {
 		return
 	}
 
+	// SetParams copies the params, the diagnostic params are taken out before it
+	explain, profile := tqlDiagnosticParams(params)
 	task := tql.NewTaskContext(ctx)
 	task.SetInputReader(ctx.Request.Body)
 	task.SetParams(params)
@@ -833,6 +1297,10 @@ func (svr *httpd) handleTqlFile(ctx *gin.Context) {
 	} else {
 		task.SetOutputWriter(&util.NopCloseWriter{Writer: ctx.Writer})
 	}
+	if profile {
+		task.SetProfile(true)
+		task.SetOutputWriter(io.Discard)
+	}
 
 	// Compile the script
 	if err := task.CompileScript(script); err != nil {
@@ -840,6 +1308,13 @@ func (svr *httpd) handleTqlFile(ctx *gin.Context) {
 		handleError(ctx, http.StatusInternalServerError, err.Error(), tick)
 		return
 	}
+	if explain {
+		executeTqlExplain(ctx, task)
+		return
+	} else if profile {
+		executeTqlProfile(ctx, task, tick)
+		return
+	}
 
 	contentType := task.OutputContentType()
 	if contentType == "application/xhtml+xml" {
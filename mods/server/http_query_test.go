@@ -848,6 +848,19 @@ func TestHandleTqlQueryExec(t *testing.T) {
 	})
 }
 
+func TestTqlDiagnosticParams(t *testing.T) {
+	params := url.Values{}
+	params.Set(TQL_PROFILE_PARAM, "true")
+	params.Set(TQL_EXPLAIN_PARAM, "false")
+	params.Set("profile", "true")
+	params.Set("explain", "true")
+	explain, profile := tqlDiagnosticParams(params)
+	require.False(t, explain)
+	require.True(t, profile)
+	// the params of the script are kept
+	require.Equal(t, url.Values{"profile": {"true"}, "explain": {"true"}}, params)
+}
+
 func TestHandleTqlQuery(t *testing.T) {
 	svr := newTestHTTPServer(t)
 
@@ -884,6 +897,17 @@ func TestHandleTqlQuery(t *testing.T) {
 		require.Equal(t, strings.Join([]string{"a,1", "b,2", "\n"}, "\n"), writer.Body.String())
 	})
 
+	t.Run("diagnostic params are not the params of the script", func(t *testing.T) {
+		script := "STRING(param('$profile') ?? 'none')\nCSV()"
+		target := "/web/api/tql?$=" + url.QueryEscape(script) + "&" + url.QueryEscape(TQL_PROFILE_PARAM) + "=false"
+		ctx, writer := newTestHTTPContext(http.MethodGet, target, nil)
+
+		svr.handleTqlQuery(ctx)
+
+		require.Equal(t, http.StatusOK, writer.Code)
+		require.Equal(t, strings.Join([]string{"none", "\n"}, "\n"), writer.Body.String())
+	})
+
 	t.Run("unsupported method returns method not allowed", func(t *testing.T) {
 		ctx, writer := newTestHTTPContext(http.MethodPut, "/web/api/tql?$="+url.QueryEscape("FAKE(linspace(0,1,2))\nCSV()"), nil)
 
@@ -1111,3 +1135,81 @@ func TestQueryBinaryFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestNamedQuery(t *testing.T) {
+	at, _, err := jwtLogin("sys", "manager")
+	require.NoError(t, err)
+
+	JsonRpcTestCase{
+		name:   "addQuery_reserved_param",
+		method: "query.add",
+		params: []interface{}{map[string]any{
+			"name":   "example_stat",
+			"sql":    `select name from v$EXAMPLE_stat where name = ?`,
+			"params": []any{map[string]any{"name": "format", "type": "string"}},
+		}},
+		expectFunc: func(t *testing.T, rsp gjson.Result) {
+			require.Equal(t, `parameter name "format" is reserved`, rsp.Get("error.message").String(), rsp.String())
+		},
+	}.run(t, at)
+	JsonRpcTestCase{
+		name:   "addQuery",
+		method: "query.add",
+		params: []interface{}{map[string]any{
+			"name":   "example_stat",
+			"sql":    `select name from v$EXAMPLE_stat where name = ?`,
+			"params": []any{map[string]any{"name": "tag", "type": "string", "required": true}},
+			"format": "csv",
+		}},
+		expectFunc: func(t *testing.T, rsp gjson.Result) {
+			require.False(t, rsp.Get("error").Exists(), rsp.String())
+		},
+	}.run(t, at)
+	t.Cleanup(func() {
+		JsonRpcTestCase{
+			name:   "deleteQuery",
+			method: "query.delete",
+			params: []interface{}{"example_stat"},
+			expectFunc: func(t *testing.T, rsp gjson.Result) {
+				require.False(t, rsp.Get("error").Exists(), rsp.String())
+			},
+		}.run(t, at)
+	})
+	JsonRpcTestCase{
+		name:   "listQueries",
+		method: "query.list",
+		params: []interface{}{},
+		expectFunc: func(t *testing.T, rsp gjson.Result) {
+			require.Equal(t, "EXAMPLE_STAT", rsp.Get("result.0.name").String(), rsp.String())
+			require.Equal(t, "tag", rsp.Get("result.0.params.0.name").String(), rsp.String())
+			require.Equal(t, "csv", rsp.Get("result.0.format").String(), rsp.String())
+		},
+	}.run(t, at)
+
+	doNamedQuery := func(t *testing.T, path string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, httpServerAddress+path, nil)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", at))
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		body, _ := io.ReadAll(rsp.Body)
+		return rsp.StatusCode, string(body)
+	}
+
+	code, body := doNamedQuery(t, "/db/query/named/example_stat?tag=temp&header=skip")
+	require.Equal(t, http.StatusOK, code, body)
+	require.Equal(t, "temp\n", body)
+
+	// the value is bound, not interpolated into the sql text
+	code, body = doNamedQuery(t, "/db/query/named/example_stat?tag="+url.QueryEscape("temp' or '1'='1")+"&header=skip")
+	require.Equal(t, http.StatusOK, code, body)
+	require.Equal(t, "", body)
+
+	code, body = doNamedQuery(t, "/db/query/named/example_stat?format=json")
+	require.Equal(t, http.StatusBadRequest, code, body)
+	require.Equal(t, `parameter "tag" is required`, gjson.Get(body, "reason").String(), body)
+
+	code, body = doNamedQuery(t, "/db/query/named/not_exists")
+	require.Equal(t, http.StatusNotFound, code, body)
+}
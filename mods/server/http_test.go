@@ -1665,6 +1665,151 @@ func TestHttpTagStat(t *testing.T) {
 	}
 }
 
+func TestHttpTagSeries(t *testing.T) {
+	at, _, err := jwtLogin("sys", "manager")
+	require.NoError(t, err)
+	tableName := fmt.Sprintf("SERIES_%d", testTimeTick.Unix())
+	base := testTimeTick.Truncate(time.Minute)
+
+	doQuery := func(t *testing.T, sqlText string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, httpServerAddress+"/db/query?q="+url.QueryEscape(sqlText), nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", at))
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		rsp.Body.Close()
+	}
+	doQuery(t, fmt.Sprintf("create tag table %s (NAME varchar(200) primary key, TIME datetime basetime, VALUE double summarized, EXTRA double)", tableName))
+	t.Cleanup(func() {
+		doQuery(t, "drop table "+tableName)
+	})
+	// 1.0, 2.0, ... 6.0 every 5 seconds, EXTRA is ten times of VALUE
+	for i := 0; i < 6; i++ {
+		doQuery(t, fmt.Sprintf("insert into %s values('s1', %d, %d.0, %d.0)", tableName, base.Add(time.Duration(i)*5*time.Second).UnixNano(), i+1, (i+1)*10))
+	}
+	doQuery(t, "EXEC table_flush("+tableName+")")
+
+	between := fmt.Sprintf("%d,%d", base.UnixNano(), base.Add(30*time.Second-1).UnixNano())
+	tests := []struct {
+		name       string
+		params     string
+		expectCode int
+		expectRows []any
+	}{
+		{
+			name:       "avg",
+			params:     "agg=avg&interval=10s",
+			expectCode: http.StatusOK,
+			expectRows: []any{
+				[]any{float64(base.UnixNano()), 1.5},
+				[]any{float64(base.Add(10 * time.Second).UnixNano()), 3.5},
+				[]any{float64(base.Add(20 * time.Second).UnixNano()), 5.5},
+			},
+		},
+		{
+			// the rollup is not of the column, the raw data of the column is used
+			name:       "avg_column",
+			params:     "agg=avg&interval=10s&column=extra",
+			expectCode: http.StatusOK,
+			expectRows: []any{
+				[]any{float64(base.UnixNano()), 15.0},
+				[]any{float64(base.Add(10 * time.Second).UnixNano()), 35.0},
+				[]any{float64(base.Add(20 * time.Second).UnixNano()), 55.0},
+			},
+		},
+		{
+			name:       "max_points",
+			params:     "agg=max&points=2",
+			expectCode: http.StatusOK,
+			expectRows: []any{
+				[]any{float64(base.UnixNano()), 3.0},
+				[]any{float64(base.Add(15 * time.Second).UnixNano()), 6.0},
+			},
+		},
+		{
+			name:       "first",
+			params:     "agg=first&interval=15s",
+			expectCode: http.StatusOK,
+			expectRows: []any{
+				[]any{float64(base.UnixNano()), 1.0},
+				[]any{float64(base.Add(15 * time.Second).UnixNano()), 4.0},
+			},
+		},
+		{
+			name:       "last",
+			params:     "agg=last&interval=15s",
+			expectCode: http.StatusOK,
+			expectRows: []any{
+				[]any{float64(base.UnixNano()), 3.0},
+				[]any{float64(base.Add(15 * time.Second).UnixNano()), 6.0},
+			},
+		},
+		{
+			name:       "lttb",
+			params:     "agg=lttb&points=3",
+			expectCode: http.StatusOK,
+			expectRows: []any{
+				[]any{float64(base.UnixNano()), 1.0},
+				[]any{float64(base.Add(5 * time.Second).UnixNano()), 2.0},
+				[]any{float64(base.Add(25 * time.Second).UnixNano()), 6.0},
+			},
+		},
+		{
+			name:       "unknown_agg",
+			params:     "agg=median",
+			expectCode: http.StatusBadRequest,
+		},
+		{
+			name:       "unsupported_interval",
+			params:     "interval=1500ms",
+			expectCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, httpServerAddress+"/db/tables/"+tableName+"/tags/s1/series?between="+between+"&"+tc.params, nil)
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", at))
+			rsp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			result, _ := io.ReadAll(rsp.Body)
+			rsp.Body.Close()
+			require.Equal(t, tc.expectCode, rsp.StatusCode, string(result))
+			if tc.expectCode != http.StatusOK {
+				return
+			}
+			require.Equal(t, "raw", rsp.Header.Get("X-Series-Source"))
+
+			resultObj := map[string]any{}
+			require.NoError(t, json.Unmarshal(result, &resultObj))
+			data := resultObj["data"].(map[string]any)
+			require.Equal(t, []any{"TIME", "VALUE"}, data["columns"])
+			require.Equal(t, tc.expectRows, data["rows"])
+		})
+	}
+}
+
+func TestSeriesBucket(t *testing.T) {
+	tests := []struct {
+		t        time.Time
+		interval time.Duration
+		expect   time.Time
+	}{
+		{time.Unix(25, 0), 10 * time.Second, time.Unix(20, 0)},
+		{time.Unix(20, 0), 10 * time.Second, time.Unix(20, 0)},
+		{time.Unix(0, 0), 10 * time.Second, time.Unix(0, 0)},
+		{time.Unix(-5, 0), 10 * time.Second, time.Unix(-10, 0)},
+		{time.Unix(-10, 0), 10 * time.Second, time.Unix(-10, 0)},
+		{time.Unix(-10, -1), 10 * time.Second, time.Unix(-20, 0)},
+		{time.Date(1969, 12, 31, 23, 30, 0, 0, time.UTC), time.Hour, time.Date(1969, 12, 31, 23, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range tests {
+		require.True(t, tc.expect.Equal(seriesBucket(tc.t, tc.interval)), "%v / %v", tc.t, tc.interval)
+	}
+}
+
 func TestTQL(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,249 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/machbase/neo-server/v8/mods/util"
+	"github.com/machbase/neo-server/v8/spi"
+)
+
+const (
+	WatchMsgSubscribe   = "subscribe"
+	WatchMsgUnsubscribe = "unsubscribe"
+	WatchMsgData        = "data"
+	WatchMsgError       = "error"
+)
+
+// WatchMessage is the message of the watch websocket.
+//
+// The client sends "subscribe" and "unsubscribe" with the table and tags,
+// the server acknowledges them with the same type and sends "data" for every changed tag.
+// "error" has the reason, and the table if the error is of the table.
+type WatchMessage struct {
+	Type   string        `json:"type"`
+	Table  string        `json:"table,omitempty"`
+	Tags   []string      `json:"tags,omitempty"`
+	Tag    string        `json:"tag,omitempty"`
+	Data   spi.WatchData `json:"data,omitempty"`
+	Reason string        `json:"reason,omitempty"`
+}
+
+type watchEvent struct {
+	table string
+	data  any
+}
+
+// handleWatchSocket is the websocket variant of handleWatchQuery,
+// the client can change the table and tags to watch without reconnecting.
+//
+// query params:
+//   - period: polling period, default 1s (minimum 1s)
+//   - keep-alive: ping interval, default 30s
+//   - max-rows: max rows of a log table in a period, default 100
+//   - parallelism: parallelism per table, default 3
+//   - timeformat, tz
+func (svr *httpd) handleWatchSocket(ctx *gin.Context) {
+	var period time.Duration
+	if p, err := time.ParseDuration(ctx.Query("period")); err == nil {
+		period = p
+	}
+	if period < 1*time.Second {
+		period = 1 * time.Second
+	}
+	var keepAlive time.Duration
+	if p, err := time.ParseDuration(ctx.Query("keep-alive")); err == nil {
+		keepAlive = p
+	}
+	if keepAlive == 0 {
+		keepAlive = 30 * time.Second
+	}
+	var maxRowNum = strInt(ctx.Query("max-rows"), 100)
+	var parallelism = strInt(ctx.Query("parallelism"), 3)
+	timeformat := strString(ctx.Query("timeformat"), "ns")
+	tz := time.UTC
+	if timezone := ctx.Query("tz"); timezone != "" {
+		tz, _ = util.ParseTimeLocation(timezone, time.UTC)
+	}
+
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		svr.log.Errorf("watch ws upgrade fail %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// the request context is not cancelled when the websocket is closed
+	wsCtx, wsCancel := context.WithCancel(context.Background())
+	defer wsCancel()
+
+	messages := make(chan *WatchMessage)
+	go func() {
+		defer close(messages)
+		for {
+			msg := &WatchMessage{}
+			if err := conn.ReadJSON(msg); err != nil {
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					svr.log.Debugf("watch ws read %s", err.Error())
+				}
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-wsCtx.Done():
+				return
+			}
+		}
+	}()
+
+	events := make(chan watchEvent, 100)
+	watchers := map[string]*spi.Watcher{}
+	forward := func(table string, watch *spi.Watcher) {
+		for data := range watch.C {
+			select {
+			case events <- watchEvent{table: table, data: data}:
+			case <-wsCtx.Done():
+				// keep draining until the watcher is closed
+			}
+		}
+	}
+	// watcher.Close() waits the running queries that may be blocked
+	// in sending the result, so it is closed out of the event loop.
+	closeWatcher := func(table string) {
+		if watch, ok := watchers[table]; ok {
+			delete(watchers, table)
+			go watch.Close()
+		}
+	}
+	defer func() {
+		wsCancel()
+		for table := range watchers {
+			closeWatcher(table)
+		}
+	}()
+
+	subscribe := func(msg *WatchMessage) error {
+		table := strings.ToUpper(msg.Table)
+		if table == "" {
+			return fmt.Errorf("table is not specified")
+		}
+		if watch, ok := watchers[table]; ok {
+			watch.AddTags(msg.Tags...)
+			watch.Execute()
+			return nil
+		}
+		watch, err := spi.NewWatcher(wsCtx,
+			spi.WatcherConfig{
+				ConnProvider: func() (*sql.Conn, error) { return getPoolSqlConn(wsCtx) },
+				TableName:    table,
+				TagNames:     slices.Clone(msg.Tags),
+				Timeformat:   timeformat,
+				Timezone:     tz,
+				Parallelism:  parallelism,
+				ChanSize:     100,
+				MaxRowNum:    maxRowNum,
+				Feed:         spi.DefaultChangeFeed,
+			})
+		if err != nil {
+			return err
+		}
+		watchers[table] = watch
+		go forward(table, watch)
+		watch.Execute()
+		return nil
+	}
+	unsubscribe := func(msg *WatchMessage) error {
+		table := strings.ToUpper(msg.Table)
+		watch, ok := watchers[table]
+		if !ok {
+			return fmt.Errorf("table '%s' is not subscribed", msg.Table)
+		}
+		// no tags means all tags of the table
+		if len(msg.Tags) == 0 || watch.NameColumn() == "" || watch.RemoveTags(msg.Tags...) == 0 {
+			closeWatcher(table)
+		}
+		return nil
+	}
+
+	periodTick := time.NewTicker(period)
+	defer periodTick.Stop()
+	keepAliveTick := time.NewTicker(keepAlive)
+	defer keepAliveTick.Stop()
+
+	svr.log.Infof("watch ws start period %v, keep-alive %v", period, keepAlive)
+	for {
+		var out *WatchMessage
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				svr.log.Infof("watch ws end")
+				return
+			}
+			switch msg.Type {
+			case WatchMsgSubscribe:
+				err = subscribe(msg)
+			case WatchMsgUnsubscribe:
+				err = unsubscribe(msg)
+			default:
+				err = fmt.Errorf("unknown message type %q", msg.Type)
+			}
+			if err != nil {
+				out = &WatchMessage{Type: WatchMsgError, Table: msg.Table, Reason: err.Error()}
+			} else {
+				out = &WatchMessage{Type: msg.Type, Table: strings.ToUpper(msg.Table), Tags: msg.Tags}
+			}
+		case evt := <-events:
+			watch, ok := watchers[evt.table]
+			if !ok {
+				// unsubscribed while the query was running
+				continue
+			}
+			switch v := evt.data.(type) {
+			case spi.WatchData:
+				out = &WatchMessage{Type: WatchMsgData, Table: evt.table, Data: v}
+				if col := watch.NameColumn(); col != "" {
+					out.Tag, _ = v[col].(string)
+					if !slices.Contains(watch.Tags(), out.Tag) {
+						continue
+					}
+				}
+			case error:
+				out = &WatchMessage{Type: WatchMsgError, Table: evt.table, Reason: v.Error()}
+			}
+		case <-periodTick.C:
+			for _, watch := range watchers {
+				watch.Execute()
+			}
+			continue
+		case <-keepAliveTick.C:
+			if err := conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(5*time.Second)); err != nil {
+				return
+			}
+			continue
+		}
+		if out == nil {
+			continue
+		}
+		if err := conn.WriteJSON(out); err != nil {
+			svr.log.Debugf("watch ws write %s", err.Error())
+			return
+		}
+	}
+}
+
+// handleWebWatchSocket is handleWatchSocket for the web ui,
+// the access token is passed by the query param since the websocket handshake can not have the header.
+func (svr *httpd) handleWebWatchSocket(ctx *gin.Context) {
+	if _, err := svr.verifyAccessToken(ctx.Query("token")); err != nil {
+		ctx.String(http.StatusUnauthorized, "unauthorized access")
+		return
+	}
+	svr.handleWatchSocket(ctx)
+}
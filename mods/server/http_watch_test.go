@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchSocket(t *testing.T) {
+	at, _, err := jwtLogin("sys", "manager")
+	require.NoError(t, err)
+	tableName := fmt.Sprintf("WATCH_WS_%d", testTimeTick.Unix())
+
+	doQuery := func(t *testing.T, sqlText string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, httpServerAddress+"/db/query?q="+url.QueryEscape(sqlText), nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", at))
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		rsp.Body.Close()
+	}
+	doQuery(t, fmt.Sprintf("create tag table %s (NAME varchar(200) primary key, TIME datetime basetime, VALUE double summarized)", tableName))
+	t.Cleanup(func() {
+		doQuery(t, "drop table "+tableName)
+	})
+	doQuery(t, fmt.Sprintf("insert into %s values('ws-a', %d, 1.0)", tableName, testTimeTick.UnixNano()))
+	doQuery(t, fmt.Sprintf("insert into %s values('ws-b', %d, 2.0)", tableName, testTimeTick.UnixNano()))
+
+	u := "ws" + strings.TrimPrefix(httpServerAddress, "http") + "/web/api/watch?period=1s&token=" + at
+	ws, _, err := websocket.DefaultDialer.Dial(u, nil)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	read := func(t *testing.T) *WatchMessage {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		msg := &WatchMessage{}
+		require.NoError(t, ws.ReadJSON(msg))
+		return msg
+	}
+
+	// unknown table
+	require.NoError(t, ws.WriteJSON(&WatchMessage{Type: WatchMsgSubscribe, Table: "not_exists", Tags: []string{"x"}}))
+	msg := read(t)
+	require.Equal(t, WatchMsgError, msg.Type)
+	require.Equal(t, "table 'NOT_EXISTS' does not exist", msg.Reason)
+
+	// the recent row of the new tag comes first
+	require.NoError(t, ws.WriteJSON(&WatchMessage{Type: WatchMsgSubscribe, Table: tableName, Tags: []string{"ws-a"}}))
+	msg = read(t)
+	require.Equal(t, WatchMsgSubscribe, msg.Type)
+	require.Equal(t, []string{"ws-a"}, msg.Tags)
+	msg = read(t)
+	require.Equal(t, WatchMsgData, msg.Type)
+	require.Equal(t, tableName, msg.Table)
+	require.Equal(t, "ws-a", msg.Tag)
+	require.Equal(t, 1.0, msg.Data["VALUE"])
+
+	require.NoError(t, ws.WriteJSON(&WatchMessage{Type: WatchMsgSubscribe, Table: tableName, Tags: []string{"ws-b"}}))
+	msg = read(t)
+	require.Equal(t, WatchMsgSubscribe, msg.Type)
+	msg = read(t)
+	require.Equal(t, WatchMsgData, msg.Type)
+	require.Equal(t, "ws-b", msg.Tag)
+	require.Equal(t, 2.0, msg.Data["VALUE"])
+
+	require.NoError(t, ws.WriteJSON(&WatchMessage{Type: WatchMsgUnsubscribe, Table: tableName, Tags: []string{"ws-a"}}))
+	msg = read(t)
+	require.Equal(t, WatchMsgUnsubscribe, msg.Type)
+
+	// only the changed tag that is still subscribed is sent,
+	// the rows written by /db/write are pushed through the change feed.
+	body := fmt.Sprintf(`{"data":{"columns":["NAME","TIME","VALUE"],"rows":[["ws-a",%d,1.1],["ws-b",%d,2.1]]}}`,
+		testTimeTick.Add(time.Second).UnixNano(), testTimeTick.Add(time.Second).UnixNano())
+	req, err := http.NewRequest(http.MethodPost, httpServerAddress+"/db/write/"+tableName, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", at))
+	req.Header.Set("Content-Type", "application/json")
+	rsp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rsp.StatusCode)
+	rsp.Body.Close()
+	msg = read(t)
+	require.Equal(t, WatchMsgData, msg.Type)
+	require.Equal(t, "ws-b", msg.Tag)
+	require.Equal(t, 2.1, msg.Data["VALUE"])
+
+	// no more changes, nothing is re-sent
+	ws.SetReadDeadline(time.Now().Add(2500 * time.Millisecond))
+	err = ws.ReadJSON(&WatchMessage{})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timeout")
+}
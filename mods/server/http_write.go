@@ -34,10 +34,15 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 		return
 	}
 	format := "json"
-	if ctx.ContentType() == "text/csv" {
+	switch ctx.ContentType() {
+	case "text/csv":
 		format = "csv"
-	} else if ctx.ContentType() == "application/x-ndjson" {
+	case "application/x-ndjson":
 		format = "ndjson"
+	case "application/vnd.apache.arrow.stream":
+		format = "arrow"
+	case "application/vnd.apache.parquet":
+		format = "parquet"
 	}
 	compress := "-"
 	switch ctx.Request.Header.Get("Content-Encoding") {
@@ -61,6 +66,7 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 		return
 	}
 	method := strString(ctx.Query("method"), "insert")
+	batch := strBool(ctx.Query("batch"), false)
 	format = strString(ctx.Query("format"), format)
 	compress = strString(ctx.Query("compress"), compress)
 	delimiter := strString(ctx.Query("delimiter"), ",")
@@ -76,6 +82,33 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 		headerSkip = true
 	default:
 	}
+	if format == "arrow" || format == "parquet" {
+		// the schema always carries the column names
+		headerColumns = true
+	}
+
+	if key := ctx.GetHeader("Idempotency-Key"); key != "" {
+		// the rows are written in batch, so that the result is kept even if some of the rows fail,
+		// otherwise the retry would write the rows that are already written again.
+		batch = true
+		key = idempotencyKey(svr.rateSubject(ctx).requester(), tableName, key)
+		if prev, err := writeKeys.Begin(key); err != nil {
+			errRsp(http.StatusConflict, err.Error())
+			return
+		} else if prev != nil {
+			prev.Elapse = time.Since(tick).String()
+			ctx.JSON(http.StatusOK, prev)
+			return
+		}
+		defer func() {
+			// a batch that has rejected rows is also completed
+			if rsp.Success || rsp.Data != nil {
+				writeKeys.Commit(key, rsp)
+			} else {
+				writeKeys.Abort(key)
+			}
+		}()
+	}
 
 	conn, err := getPoolSqlConn(ctx)
 	if err != nil {
@@ -96,11 +129,14 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 		desc = rs.Description
 	}
 
+	if svr.writeBodyLimit > 0 {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, svr.writeBodyLimit)
+	}
 	var in io.Reader
 	if compress == "gzip" {
 		gr, err := gzip.NewReader(ctx.Request.Body)
 		if err != nil {
-			errRsp(http.StatusInternalServerError, err.Error())
+			errRsp(writeBodyErrStatus(err, http.StatusInternalServerError), err.Error())
 			return
 		}
 		in = bufio.NewReader(gr)
@@ -120,6 +156,15 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 	var appender spi.Appender
 	var recNo int
 	var insertQuery string
+	var insertColumns []string
+
+	defer func() {
+		if rsp.Data != nil {
+			ctx.Set(ctxKeyWriteRows, int(rsp.Data.AffectedRows))
+		} else {
+			ctx.Set(ctxKeyWriteRows, recNo)
+		}
+	}()
 
 	if method == "append" {
 		if aw, err := spi.GetAppendWorker(ctx, tableName); err != nil {
@@ -147,7 +192,7 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 		if format == "json" {
 			bs, err := io.ReadAll(in)
 			if err != nil {
-				errRsp(http.StatusBadRequest, err.Error())
+				errRsp(writeBodyErrStatus(err, http.StatusBadRequest), err.Error())
 				return
 			}
 
@@ -192,6 +237,7 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 			valueHolder := strings.Join(_hold, ",")
 			insertQuery = fmt.Sprintf("INSERT INTO %s VALUES(%s)", tableName, valueHolder)
 		}
+		insertColumns = columnNames
 		codecOpts = append(codecOpts,
 			opts.InputStream(in),
 			opts.Columns(columnNames...),
@@ -205,22 +251,13 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 		errRsp(http.StatusInternalServerError, "codec not found")
 		return
 	}
+	if closer, ok := decoder.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	var prevCols []string
 	var hasProcessedHeader bool
-	for {
-		vals, cols, err := decoder.NextRow()
-		if err != nil {
-			if err != io.EOF {
-				rsp.Reason = err.Error()
-				rsp.Elapse = time.Since(tick).String()
-				ctx.JSON(http.StatusBadRequest, rsp)
-				return
-			}
-			break
-		}
-		recNo++
-
+	writeRow := func(vals []any, cols []string) error {
 		if method == "insert" {
 			if len(cols) > 0 && !slices.Equal(prevCols, cols) {
 				prevCols = cols
@@ -231,19 +268,49 @@ func (svr *httpd) handleWrite(ctx *gin.Context) {
 				insertQuery = fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", tableName, strings.Join(cols, ","), strings.Join(_hold, ","))
 			}
 			if _, err := conn.ExecContext(ctx, insertQuery, vals...); err != nil {
-				errRsp(http.StatusInternalServerError, err.Error())
-				return
+				return err
 			}
-		} else { // append
-			if !hasProcessedHeader && headerColumns && len(cols) > 0 {
-				appender = appender.WithInputColumns(cols...)
-				hasProcessedHeader = true
+			if len(cols) > 0 {
+				spi.DefaultChangeFeed.Publish(tableName, cols, vals)
+			} else {
+				spi.DefaultChangeFeed.Publish(tableName, insertColumns, vals)
 			}
-			err = appender.Append(vals...)
-			if err != nil {
-				errRsp(http.StatusInternalServerError, err.Error())
+			return nil
+		}
+		// append
+		if !hasProcessedHeader && headerColumns && len(cols) > 0 {
+			appender = appender.WithInputColumns(cols...)
+			hasProcessedHeader = true
+		}
+		return appender.Append(vals...)
+	}
+
+	if batch {
+		rows, rejects, err := decodeWriteBatch(decoder)
+		if err != nil {
+			errRsp(writeBodyErrStatus(err, http.StatusBadRequest), err.Error())
+			return
+		}
+		writeBatch(rsp, method, rows, rejects, writeRow)
+		rsp.Elapse = time.Since(tick).String()
+		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	for {
+		vals, cols, err := decoder.NextRow()
+		if err != nil {
+			if err != io.EOF {
+				errRsp(writeBodyErrStatus(err, http.StatusBadRequest), err.Error())
 				return
 			}
+			break
+		}
+		recNo++
+
+		if err := writeRow(vals, cols); err != nil {
+			errRsp(http.StatusInternalServerError, err.Error())
+			return
 		}
 	}
 	rsp.Success, rsp.Reason = true, fmt.Sprintf("success, %d record(s) %sed", recNo, method)
@@ -451,6 +518,7 @@ func (svr *httpd) handleFileWrite(ctx *gin.Context) {
 	}
 
 	insertSucceeded = true
+	spi.DefaultChangeFeed.Publish(tableName, columns, values)
 	rsp.Success, rsp.Reason = true, fmt.Sprintf("success, %d record(s) inserted", 1)
 	rsp.Elapse = time.Since(tick).String()
 	ctx.JSON(http.StatusOK, rsp)
@@ -519,6 +587,11 @@ func (svr *httpd) handleLineWrite(ctx *gin.Context) {
 		body = gz
 	}
 
+	recNo := 0
+	defer func() {
+		ctx.Set(ctxKeyWriteRows, recNo)
+	}()
+
 	dec := lineprotocol.NewDecoder(body)
 	for dec != nil && dec.Next() {
 		m, err := dec.Measurement()
@@ -580,6 +653,7 @@ func (svr *httpd) handleLineWrite(ctx *gin.Context) {
 				gin.H{"error": fmt.Sprintf("%s; %s", err.Error(), result.Message())})
 			return
 		}
+		recNo++
 	}
 	ctx.JSON(http.StatusNoContent, "")
 }
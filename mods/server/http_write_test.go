@@ -428,3 +428,79 @@ func TestWriteBinaryFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteBatchIdempotent(t *testing.T) {
+	jwt := HttpTestLogin(t, "sys", "manager")
+	tableName := fmt.Sprintf("P2_BATCH_%d", testTimeTick.Unix())
+
+	doQuery := func(t *testing.T, sqlText string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, httpServerAddress+"/db/query?q="+url.QueryEscape(sqlText), nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt.AccessToken))
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+		rsp.Body.Close()
+	}
+	doQuery(t, fmt.Sprintf("create tag table %s (NAME varchar(200) primary key, TIME datetime basetime, VALUE double summarized)", tableName))
+	t.Cleanup(func() {
+		doQuery(t, "drop table "+tableName)
+	})
+
+	doWrite := func(t *testing.T, body string, key string, batch bool) (int, WriteResponse) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, httpServerAddress+"/db/write/"+tableName+fmt.Sprintf("?batch=%t", batch), strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", jwt.AccessToken))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer rsp.Body.Close()
+		ret := WriteResponse{}
+		require.NoError(t, json.NewDecoder(rsp.Body).Decode(&ret))
+		return rsp.StatusCode, ret
+	}
+
+	body := `{"data":{"columns":["NAME","TIME","VALUE"],"rows":[` +
+		`["batch-1", 1691800174123456789, 1.0],` +
+		`["batch-2", 1691800174123456789, "not-a-number"],` +
+		`["batch-3", 1691800174123456789, 3.0]]}}`
+
+	status, rsp := doWrite(t, body, "batch-key-1", true)
+	require.Equal(t, http.StatusOK, status)
+	require.False(t, rsp.Success)
+	require.Equal(t, uint64(2), rsp.Data.AffectedRows)
+	require.Len(t, rsp.Data.Rejects, 1)
+	require.Equal(t, 2, rsp.Data.Rejects[0].Row)
+	require.Equal(t, "VALUE", rsp.Data.Rejects[0].Column)
+	require.False(t, rsp.Data.Replayed)
+
+	// retry with the same key is acknowledged without writing
+	status, rsp = doWrite(t, body, "batch-key-1", true)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint64(2), rsp.Data.AffectedRows)
+	require.True(t, rsp.Data.Replayed)
+
+	// broken input is not written at all
+	status, rsp = doWrite(t, `{"data":{"rows":[["batch-4", 1691800174123456789, 4.0],`, "batch-key-2", true)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.False(t, rsp.Success)
+
+	// the keyed write is a batch even without batch=true,
+	// the partial result is kept and the retry does not write the rows again
+	body = `{"data":{"columns":["NAME","TIME","VALUE"],"rows":[` +
+		`["batch-5", 1691800174123456789, 5.0],` +
+		`["batch-6", 1691800174123456789, "not-a-number"]]}}`
+	status, rsp = doWrite(t, body, "batch-key-3", false)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint64(1), rsp.Data.AffectedRows)
+	require.Len(t, rsp.Data.Rejects, 1)
+	status, rsp = doWrite(t, body, "batch-key-3", false)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, uint64(1), rsp.Data.AffectedRows)
+	require.True(t, rsp.Data.Replayed)
+}
@@ -15,6 +15,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	badgerdb "github.com/dgraph-io/badger/v4"
@@ -22,6 +23,9 @@ import (
 	"github.com/machbase/neo-server/v8/mods"
 	"github.com/machbase/neo-server/v8/mods/logging"
 	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/machbase/neo-server/v8/mods/util"
+	"github.com/machbase/neo-server/v8/mods/util/metric"
+	"github.com/machbase/neo-server/v8/spi"
 	mqtt "github.com/mochi-mqtt/server/v2"
 	"github.com/mochi-mqtt/server/v2/hooks/storage/badger"
 	"github.com/mochi-mqtt/server/v2/listeners"
@@ -225,6 +229,14 @@ func WithMqttMaxMessageSizeLimit(limit int) MqttOption {
 	}
 }
 
+// WithMqttRateLimiter applies the rate limits to the messages of 'db/write/#', 'db/append/#' and 'db/metrics/#'.
+func WithMqttRateLimiter(rl *RateLimiter) MqttOption {
+	return func(s *mqttd) error {
+		s.rateLimiter = rl
+		return nil
+	}
+}
+
 func WithMqttOnStarted(fn func()) MqttOption {
 	return func(s *mqttd) error {
 		s.authHook.onStarted = fn
@@ -249,6 +261,8 @@ type mqttd struct {
 	defaultReplyTopic string
 	wsListener        *WsListener
 	restrictTopics    bool
+	rateLimiter       *RateLimiter
+	inlineSubId       atomic.Int32
 }
 
 func (s *mqttd) Start() error {
@@ -261,6 +275,28 @@ func (s *mqttd) Stop() {
 	}
 }
 
+// Subscribe subscribes the topic filter by the inline client of the broker,
+// it is the tql.BrokerSubscriber of SUBSCRIBE().
+func (s *mqttd) Subscribe(filter string, cb func(topic string, payload []byte)) (func(), error) {
+	id := int(s.inlineSubId.Add(1))
+	err := s.broker.Subscribe(filter, id, func(_ *mqtt.Client, _ packets.Subscription, pk packets.Packet) {
+		cb(pk.TopicName, pk.Payload)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		if err := s.broker.Unsubscribe(filter, id); err != nil {
+			s.log.Warn("unsubscribe", filter, err.Error())
+		}
+	}, nil
+}
+
+// Publish publishes the payload to the topic of the built-in broker.
+func (s *mqttd) Publish(topic string, payload []byte) error {
+	return s.broker.Publish(topic, payload, false, 0)
+}
+
 func (s *mqttd) WsHandlerFunc() func(w http.ResponseWriter, r *http.Request) {
 	return s.wsListener.WsHandler
 }
@@ -291,12 +327,62 @@ func (s *mqttd) onACLCheck(_ *mqtt.Client, topic string, write bool) bool {
 	return true
 }
 
+// rateSubject identifies the publisher and the table of the ingestion topics,
+// it returns false if the topic is not an ingestion topic.
+func (s *mqttd) rateSubject(cl *mqtt.Client, topic string) (rateSubject, bool) {
+	var table string
+	if strings.HasPrefix(topic, "db/write/") || strings.HasPrefix(topic, "db/append/") {
+		writePath := strings.TrimPrefix(strings.TrimPrefix(topic, "db/append/"), "db/write/")
+		if wp, err := util.ParseWritePath(strings.ToUpper(writePath)); err == nil {
+			table = wp.Table
+		}
+	} else if strings.HasPrefix(topic, "db/metrics/") {
+		table = strings.ToUpper(strings.TrimPrefix(topic, "db/metrics/"))
+	} else {
+		return rateSubject{}, false
+	}
+	sub := rateSubject{Table: table}
+	username := string(cl.Properties.Username)
+	if clientId := tokenClientId(username); clientId != "" {
+		sub.Token = clientId
+	} else {
+		sub.User = username
+	}
+	if conn, ok := cl.Net.Conn.(*tls.Conn); ok {
+		state := conn.ConnectionState()
+		sub.Cert = certCommonName(&state)
+	}
+	return sub, true
+}
+
+// onPublish rejects the message of the ingestion topics if the publisher is throttled,
+// MQTT v5 clients receive the reason code 'quota exceeded' (0x97) in PUBACK.
+func (s *mqttd) onPublish(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	if s.rateLimiter == nil || cl.Net.Inline {
+		return pk, nil
+	}
+	sub, ok := s.rateSubject(cl, pk.TopicName)
+	if !ok {
+		return pk, nil
+	}
+	if wait, reason := s.rateLimiter.Allow(sub, len(pk.Payload)); wait > 0 {
+		spi.AddMetrics(metric.Measure{Name: "ratelimit:mqtt:throttled", Value: 1, Type: metric.CounterType(metric.UnitShort)})
+		s.log.Debugf("%s throttled %s, retry after %s", cl.Net.Remote, reason, wait)
+		if cl.Properties.ProtocolVersion == 5 && pk.FixedHeader.Qos > 0 {
+			return pk, packets.ErrQuotaExceeded
+		}
+		return pk, packets.ErrRejectPacket
+	}
+	return pk, nil
+}
+
 func (s *mqttd) onPublished(cl *mqtt.Client, pk packets.Packet) {
 	defer func() {
 		if r := recover(); r != nil {
 			s.log.Warn("panic", "onPublished", r)
 		}
 	}()
+	written := 0
 	if pk.TopicName == "db/query" {
 		s.handleQuery(cl, pk)
 	} else if strings.HasPrefix(pk.TopicName, "db/write/") {
@@ -310,17 +396,22 @@ func (s *mqttd) onPublished(cl *mqtt.Client, pk packets.Packet) {
 			}
 		}
 		if useAppend {
-			s.handleAppend(cl, pk)
+			written = s.handleAppend(cl, pk)
 		} else {
-			s.handleWrite(cl, pk)
+			written = s.handleWrite(cl, pk)
 		}
 	} else if strings.HasPrefix(pk.TopicName, "db/append/") {
-		s.handleAppend(cl, pk)
+		written = s.handleAppend(cl, pk)
 	} else if strings.HasPrefix(pk.TopicName, "db/metrics/") {
-		s.handleMetrics(cl, pk)
+		written = s.handleMetrics(cl, pk)
 	} else if strings.HasPrefix(pk.TopicName, "db/tql/") {
 		s.handleTql(cl, pk)
 	}
+	if written > 0 && s.rateLimiter != nil && !cl.Net.Inline {
+		if sub, ok := s.rateSubject(cl, pk.TopicName); ok {
+			s.rateLimiter.ChargeRows(sub, written)
+		}
+	}
 }
 
 func (s *mqttd) onConnect(cl *mqtt.Client, pk packets.Packet) error {
@@ -355,6 +446,7 @@ func (h *AuthHook) Provides(b byte) bool {
 		mqtt.OnConnectAuthenticate,
 		mqtt.OnACLCheck,
 		mqtt.OnConnect,
+		mqtt.OnPublish,
 		mqtt.OnPublished,
 		mqtt.OnDisconnect,
 		mqtt.OnPacketEncode,
@@ -432,6 +524,10 @@ func (h *AuthHook) OnConnect(cl *mqtt.Client, pk packets.Packet) error {
 	return h.svr.onConnect(cl, pk)
 }
 
+func (h *AuthHook) OnPublish(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	return h.svr.onPublish(cl, pk)
+}
+
 func (h *AuthHook) OnPublished(cl *mqtt.Client, pk packets.Packet) {
 	h.svr.onPublished(cl, pk)
 }
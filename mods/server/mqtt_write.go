@@ -23,7 +23,8 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) {
+// handleWrite returns the number of the written rows.
+func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) (written int) {
 	tick := time.Now()
 	var replyTopic string
 	var rsp = &WriteResponse{Reason: "not specified"}
@@ -64,6 +65,8 @@ func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) {
 	delimiter := ","
 	timeformat := "ns"
 	tz := time.UTC
+	batch := false
+	idemKey := ""
 
 	writePath := strings.ToUpper(strings.TrimPrefix(pk.TopicName, "db/write/"))
 	wp, err := util.ParseWritePath(writePath)
@@ -99,6 +102,10 @@ func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) {
 					headerSkip = true
 				default:
 				}
+			case "batch":
+				batch = strBool(p.Val, false)
+			case "Idempotency-Key":
+				idemKey = p.Val
 			}
 		}
 	}
@@ -131,6 +138,30 @@ func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) {
 		return
 	}
 
+	if idemKey != "" {
+		// the keyed write is always a batch, see httpd.handleWrite()
+		batch = true
+		sub, _ := s.rateSubject(cl, pk.TopicName)
+		key := idempotencyKey(sub.requester(), wp.Table, idemKey)
+		if prev, err := writeKeys.Begin(key); err != nil {
+			rsp.Reason = err.Error()
+			s.log.Warn(cl.Net.Remote, pk.TopicName, rsp.Reason)
+			return
+		} else if prev != nil {
+			*rsp = *prev
+			s.log.Trace(cl.Net.Remote, pk.TopicName, "replayed", idemKey)
+			return
+		}
+		defer func() {
+			// a batch that has rejected rows is also completed
+			if rsp.Success || rsp.Data != nil {
+				writeKeys.Commit(key, rsp)
+			} else {
+				writeKeys.Abort(key)
+			}
+		}()
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -274,8 +305,43 @@ func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) {
 		s.log.Error(cl.Net.Remote, rsp.Reason)
 		return
 	}
+	if closer, ok := decoder.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	var prevCols []string
+	writeRow := func(vals []any, cols []string) error {
+		if len(cols) != len(prevCols) && !slices.Equal(prevCols, cols) {
+			prevCols = cols
+			_hold := make([]string, len(cols))
+			for i := range _hold {
+				_hold[i] = "?"
+			}
+			insertQuery = fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", wp.Table, strings.Join(cols, ","), strings.Join(_hold, ","))
+		}
+		if _, err := conn.ExecContext(ctx, insertQuery, vals...); err != nil {
+			return err
+		}
+		if len(cols) > 0 {
+			spi.DefaultChangeFeed.Publish(wp.Table, cols, vals)
+		} else {
+			spi.DefaultChangeFeed.Publish(wp.Table, columnNames, vals)
+		}
+		return nil
+	}
+
+	if batch {
+		rows, rejects, err := decodeWriteBatch(decoder)
+		if err != nil {
+			rsp.Reason = err.Error()
+			s.log.Warn(cl.Net.Remote, pk.TopicName, rsp.Reason)
+			return
+		}
+		writeBatch(rsp, "insert", rows, rejects, writeRow)
+		s.log.Trace(cl.Net.Remote, rsp.Reason)
+		return int(rsp.Data.AffectedRows)
+	}
+
 	for {
 		vals, cols, err := decoder.NextRow()
 		if err != nil {
@@ -288,26 +354,20 @@ func (s *mqttd) handleWrite(cl *mqtt.Client, pk packets.Packet) {
 		}
 		recNo++
 
-		if len(cols) != len(prevCols) && !slices.Equal(prevCols, cols) {
-			prevCols = cols
-			_hold := make([]string, len(cols))
-			for i := range _hold {
-				_hold[i] = "?"
-			}
-			insertQuery = fmt.Sprintf("INSERT INTO %s(%s) VALUES(%s)", wp.Table, strings.Join(cols, ","), strings.Join(_hold, ","))
-		}
-		if _, err := conn.ExecContext(ctx, insertQuery, vals...); err != nil {
+		if err := writeRow(vals, cols); err != nil {
 			rsp.Reason = err.Error()
 			s.log.Warn(cl.Net.Remote, pk.TopicName, rsp.Reason)
-			return
+			return recNo - 1
 		}
 	}
 
 	rsp.Success, rsp.Reason = true, fmt.Sprintf("success, %d record(s) inserted", recNo)
 	s.log.Trace(cl.Net.Remote, rsp.Reason)
+	return recNo
 }
 
-func (s *mqttd) handleAppend(cl *mqtt.Client, pk packets.Packet) {
+// handleAppend returns the number of the appended rows.
+func (s *mqttd) handleAppend(cl *mqtt.Client, pk packets.Packet) (written int) {
 	writePath := strings.TrimPrefix(strings.TrimPrefix(pk.TopicName, "db/append/"), "db/write/")
 	writePath = strings.ToUpper(writePath)
 	wp, err := util.ParseWritePath(writePath)
@@ -432,6 +492,9 @@ func (s *mqttd) handleAppend(cl *mqtt.Client, pk packets.Packet) {
 	}
 
 	decoder := codec.NewDecoder(wp.Format, codecOpts...)
+	if closer, ok := decoder.(io.Closer); ok {
+		defer closer.Close()
+	}
 
 	recNo := 0
 	hasProcessedHeader := false
@@ -440,7 +503,7 @@ func (s *mqttd) handleAppend(cl *mqtt.Client, pk packets.Packet) {
 		if err != nil {
 			if err != io.EOF {
 				s.log.Warn(cl.Net.Remote, "append", wp.Format, err.Error())
-				return
+				return recNo
 			}
 			break
 		}
@@ -456,9 +519,11 @@ func (s *mqttd) handleAppend(cl *mqtt.Client, pk packets.Packet) {
 		recNo++
 	}
 	s.log.Trace(cl.Net.Remote, "appended", recNo, "record(s),", wp.Table)
+	return recNo
 }
 
-func (s *mqttd) handleMetrics(cl *mqtt.Client, pk packets.Packet) {
+// handleMetrics returns the number of the written rows.
+func (s *mqttd) handleMetrics(cl *mqtt.Client, pk packets.Packet) (written int) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -533,8 +598,11 @@ func (s *mqttd) handleMetrics(cl *mqtt.Client, pk packets.Packet) {
 		result := spi.WriteLineProtocol(ctx, conn, tableName, desc.Columns, measurement, fields, tags, ts)
 		if result.Err() != nil {
 			s.log.Warnf(cl.Net.Remote, "lineprotocol fail:", result.Err().Error())
+			continue
 		}
+		written++
 	}
+	return
 }
 
 // extractColumns extracts column names from the payload.
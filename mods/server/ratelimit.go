@@ -0,0 +1,371 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/machbase/neo-server/v8/mods/util/metric"
+	"github.com/machbase/neo-server/v8/spi"
+)
+
+const (
+	RateLimitScopeUser  = "user"
+	RateLimitScopeToken = "token"
+	RateLimitScopeCert  = "cert"
+	RateLimitScopeTable = "table"
+)
+
+// ctxKeyWriteRows is the gin context key that the write handlers set the number of written rows,
+// the rate limit middleware charges the rows after the request is processed.
+const ctxKeyWriteRows = "neo-write-rows"
+
+// RateLimitRule is a token-bucket limit of the ingestion.
+//
+// Scope is one of "user", "token", "cert" and "table".
+// Name is the user name, the client id of the token, the common name of the client certificate
+// or the table name that the rule applies to, "*" applies the rule to each of them separately.
+// A rule of the specific name takes precedence over the "*" rule of the same scope.
+type RateLimitRule struct {
+	Scope    string
+	Name     string
+	Requests float64 // requests per second, 0 means unlimited
+	Rows     float64 // rows per second, 0 means unlimited
+	Bytes    float64 // bytes per second, 0 means unlimited
+	Burst    float64 // bucket capacity in seconds of the rate, 0 means 1 second
+}
+
+// rateSubject identifies the requester and the target table of the ingestion.
+type rateSubject struct {
+	User  string
+	Token string
+	Cert  string
+	Table string
+}
+
+func (sub rateSubject) name(scope string) string {
+	switch scope {
+	case RateLimitScopeUser:
+		return sub.User
+	case RateLimitScopeToken:
+		return sub.Token
+	case RateLimitScopeCert:
+		return sub.Cert
+	case RateLimitScopeTable:
+		return sub.Table
+	}
+	return ""
+}
+
+// requester returns the user name, or the token client id or the cert name if the user is unknown.
+func (sub rateSubject) requester() string {
+	for _, name := range []string{sub.User, sub.Token, sub.Cert} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+type rateBucket struct {
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newRateBucket(rate float64, burst float64, now time.Time) *rateBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	capacity := rate * burst
+	return &rateBucket{rate: rate, capacity: capacity, tokens: capacity, last: now}
+}
+
+func (b *rateBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// wait returns the duration until the bucket has n tokens.
+// n is capped by the capacity, so that a request larger than the capacity
+// is allowed when the bucket is full and leaves the bucket in debt.
+func (b *rateBucket) wait(n float64) time.Duration {
+	n = math.Min(n, b.capacity)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration(math.Ceil((n - b.tokens) / b.rate * float64(time.Second)))
+}
+
+// rateSweepInterval is the interval of evicting the idle buckets.
+const rateSweepInterval = time.Minute
+
+// RateLimiter keeps the token buckets of the rules,
+// it is shared by the http and mqtt servers so that the limits apply across the protocols.
+type RateLimiter struct {
+	lock      sync.Mutex
+	rules     []RateLimitRule
+	buckets   map[string]*rateBucket
+	lastSweep time.Time
+	nowFunc   func() time.Time
+}
+
+func NewRateLimiter(rules []RateLimitRule) *RateLimiter {
+	ret := &RateLimiter{
+		buckets: map[string]*rateBucket{},
+		nowFunc: time.Now,
+	}
+	for _, r := range rules {
+		r.Scope = strings.ToLower(r.Scope)
+		switch r.Scope {
+		case RateLimitScopeUser, RateLimitScopeToken, RateLimitScopeCert:
+		case RateLimitScopeTable:
+			r.Name = strings.ToUpper(r.Name)
+		default:
+			continue
+		}
+		if r.Name == "" {
+			r.Name = "*"
+		}
+		if r.Requests <= 0 && r.Rows <= 0 && r.Bytes <= 0 {
+			continue
+		}
+		ret.rules = append(ret.rules, r)
+	}
+	return ret
+}
+
+// matchRules returns the rules that apply to the subject.
+func (rl *RateLimiter) matchRules(sub rateSubject) []RateLimitRule {
+	ret := []RateLimitRule{}
+	for _, scope := range []string{RateLimitScopeUser, RateLimitScopeToken, RateLimitScopeCert, RateLimitScopeTable} {
+		name := sub.name(scope)
+		if name == "" {
+			continue
+		}
+		var wildcard *RateLimitRule
+		var specific *RateLimitRule
+		for i, r := range rl.rules {
+			if r.Scope != scope {
+				continue
+			}
+			if r.Name == name {
+				specific = &rl.rules[i]
+			} else if r.Name == "*" {
+				wildcard = &rl.rules[i]
+			}
+		}
+		if specific != nil {
+			ret = append(ret, *specific)
+		} else if wildcard != nil {
+			ret = append(ret, *wildcard)
+		}
+	}
+	return ret
+}
+
+// bucket returns the bucket of the key, it should be called with the lock held.
+func (rl *RateLimiter) bucket(key string, rate float64, burst float64, now time.Time) *rateBucket {
+	rl.sweep(now)
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = newRateBucket(rate, burst, now)
+		rl.buckets[key] = b
+	}
+	b.refill(now)
+	return b
+}
+
+// sweep removes the buckets those have been refilled to the capacity,
+// which are the same as the new ones, so that the buckets of the past users,
+// tokens and tables do not pile up. It should be called with the lock held.
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Sub(rl.lastSweep) < rateSweepInterval {
+		return
+	}
+	rl.lastSweep = now
+	for key, b := range rl.buckets {
+		b.refill(now)
+		if b.tokens >= b.capacity {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// Allow takes a request and the bytes from the buckets of the subject.
+// If any of the buckets is exhausted, nothing is taken and it returns
+// the duration to wait before retrying and the reason.
+// The rows buckets are only checked for the debt, since the number of rows
+// is known after the request is processed, see ChargeRows().
+func (rl *RateLimiter) Allow(sub rateSubject, bytes int) (time.Duration, string) {
+	rules := rl.matchRules(sub)
+	if len(rules) == 0 {
+		return 0, ""
+	}
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	now := rl.nowFunc()
+
+	type take struct {
+		b *rateBucket
+		n float64
+	}
+	takes := []take{}
+	var maxWait time.Duration
+	var reason string
+	check := func(r RateLimitRule, kind string, rate float64, n float64, consume bool) {
+		if rate <= 0 {
+			return
+		}
+		key := fmt.Sprintf("%s:%s:%s", r.Scope, sub.name(r.Scope), kind)
+		b := rl.bucket(key, rate, r.Burst, now)
+		if w := b.wait(n); w > 0 {
+			if w > maxWait {
+				maxWait = w
+				reason = fmt.Sprintf("%s %q exceeds %s limit %g/s", r.Scope, sub.name(r.Scope), kind, rate)
+			}
+		} else if consume {
+			takes = append(takes, take{b: b, n: n})
+		}
+	}
+	for _, r := range rules {
+		check(r, "requests", r.Requests, 1, true)
+		check(r, "bytes", r.Bytes, float64(bytes), bytes > 0)
+		check(r, "rows", r.Rows, 1, false)
+	}
+	if maxWait > 0 {
+		return maxWait, reason
+	}
+	for _, t := range takes {
+		t.b.tokens -= t.n
+	}
+	return 0, ""
+}
+
+// ChargeRows takes the rows that have been written from the buckets of the subject,
+// the buckets may go into debt which throttles the following requests.
+func (rl *RateLimiter) ChargeRows(sub rateSubject, rows int) {
+	rl.charge(sub, "rows", func(r RateLimitRule) float64 { return r.Rows }, rows)
+}
+
+// ChargeBytes takes the bytes that have been read from the buckets of the subject,
+// for the requests of which the size is not known in advance.
+func (rl *RateLimiter) ChargeBytes(sub rateSubject, bytes int) {
+	rl.charge(sub, "bytes", func(r RateLimitRule) float64 { return r.Bytes }, bytes)
+}
+
+func (rl *RateLimiter) charge(sub rateSubject, kind string, rateOf func(RateLimitRule) float64, n int) {
+	if n <= 0 {
+		return
+	}
+	rules := rl.matchRules(sub)
+	if len(rules) == 0 {
+		return
+	}
+	rl.lock.Lock()
+	defer rl.lock.Unlock()
+	now := rl.nowFunc()
+	for _, r := range rules {
+		rate := rateOf(r)
+		if rate <= 0 {
+			continue
+		}
+		key := fmt.Sprintf("%s:%s:%s", r.Scope, sub.name(r.Scope), kind)
+		b := rl.bucket(key, rate, r.Burst, now)
+		b.tokens -= float64(n)
+	}
+}
+
+// tokenClientId returns the client id part of the client token, "<client-id>:<...>".
+func tokenClientId(tok string) string {
+	if clientId, _, found := strings.Cut(tok, ":"); found {
+		return clientId
+	}
+	return ""
+}
+
+// certCommonName returns the common name of the peer certificate.
+func certCommonName(state *tls.ConnectionState) string {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return state.PeerCertificates[0].Subject.CommonName
+}
+
+func (svr *httpd) rateSubject(ctx *gin.Context) rateSubject {
+	sub := rateSubject{
+		Cert: certCommonName(ctx.Request.TLS),
+	}
+	if claim, ok := svr.getJwtClaim(ctx); ok {
+		sub.User = claim.Subject
+	}
+	tok := ctx.Query("token")
+	if auth := ctx.GetHeader("Authorization"); strings.HasPrefix(strings.ToUpper(auth), "BEARER ") {
+		tok = auth[7:]
+	}
+	sub.Token = tokenClientId(tok)
+	if table := ctx.Param("table"); table != "" {
+		sub.Table = strings.ToUpper(table)
+	} else if db := ctx.Query("db"); db != "" {
+		// line protocol
+		sub.Table = strings.ToUpper(db)
+	}
+	return sub
+}
+
+// countingBody counts the bytes read from the request body.
+type countingBody struct {
+	io.ReadCloser
+	n int
+}
+
+func (cb *countingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	cb.n += n
+	return n, err
+}
+
+// handleRateLimit is the middleware of the ingestion handlers,
+// it responds 429 with Retry-After header if the requester is throttled.
+// The bytes of Content-Length are taken in advance, and the bytes read more than that,
+// e.g. of a chunked request, are charged after the request is processed.
+func (svr *httpd) handleRateLimit(ctx *gin.Context) {
+	if svr.rateLimiter == nil {
+		return
+	}
+	sub := svr.rateSubject(ctx)
+	bytes := 0
+	if ctx.Request.ContentLength > 0 {
+		bytes = int(ctx.Request.ContentLength)
+	}
+	var body *countingBody
+	if ctx.Request.Body != nil {
+		body = &countingBody{ReadCloser: ctx.Request.Body}
+		ctx.Request.Body = body
+	}
+	if wait, reason := svr.rateLimiter.Allow(sub, bytes); wait > 0 {
+		spi.AddMetrics(metric.Measure{Name: "ratelimit:http:throttled", Value: 1, Type: metric.CounterType(metric.UnitShort)})
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, map[string]any{"success": false, "reason": reason})
+		ctx.Abort()
+		return
+	}
+	ctx.Next()
+	if body != nil && body.n > bytes {
+		svr.rateLimiter.ChargeBytes(sub, body.n-bytes)
+	}
+	if rows := ctx.GetInt(ctxKeyWriteRows); rows > 0 {
+		svr.rateLimiter.ChargeRows(sub, rows)
+		spi.AddMetrics(metric.Measure{Name: "ratelimit:http:rows", Value: float64(rows), Type: metric.CounterType(metric.UnitShort)})
+	}
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterRequests(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rl := NewRateLimiter([]RateLimitRule{
+		{Scope: "token", Name: "*", Requests: 2},
+		{Scope: "token", Name: "vip", Requests: 10},
+		{Scope: "unknown", Name: "*", Requests: 1},
+	})
+	rl.nowFunc = func() time.Time { return now }
+	require.Len(t, rl.rules, 2)
+
+	sub := rateSubject{Token: "dev1"}
+	wait, _ := rl.Allow(sub, 0)
+	require.Zero(t, wait)
+	wait, _ = rl.Allow(sub, 0)
+	require.Zero(t, wait)
+	wait, reason := rl.Allow(sub, 0)
+	require.Equal(t, 500*time.Millisecond, wait)
+	require.Equal(t, `token "dev1" exceeds requests limit 2/s`, reason)
+
+	// the other token has its own bucket
+	wait, _ = rl.Allow(rateSubject{Token: "dev2"}, 0)
+	require.Zero(t, wait)
+
+	// the specific rule takes precedence over the wildcard
+	for i := 0; i < 10; i++ {
+		wait, _ = rl.Allow(rateSubject{Token: "vip"}, 0)
+		require.Zero(t, wait)
+	}
+
+	// refilled
+	now = now.Add(500 * time.Millisecond)
+	wait, _ = rl.Allow(sub, 0)
+	require.Zero(t, wait)
+
+	// no rule for the user scope
+	for i := 0; i < 5; i++ {
+		wait, _ = rl.Allow(rateSubject{User: "sys"}, 0)
+		require.Zero(t, wait)
+	}
+}
+
+func TestRateLimiterBytesAndRows(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rl := NewRateLimiter([]RateLimitRule{
+		{Scope: "table", Name: "example", Rows: 100, Bytes: 1000, Burst: 2},
+	})
+	rl.nowFunc = func() time.Time { return now }
+
+	sub := rateSubject{Table: "EXAMPLE"}
+	// larger than the capacity is allowed when the bucket is full
+	wait, _ := rl.Allow(sub, 3000)
+	require.Zero(t, wait)
+	wait, reason := rl.Allow(sub, 10)
+	require.Equal(t, 1010*time.Millisecond, wait)
+	require.Equal(t, `table "EXAMPLE" exceeds bytes limit 1000/s`, reason)
+
+	now = now.Add(2 * time.Second)
+	wait, _ = rl.Allow(sub, 0)
+	require.Zero(t, wait)
+	rl.ChargeRows(sub, 400)
+	wait, reason = rl.Allow(sub, 0)
+	require.Equal(t, 2010*time.Millisecond, wait)
+	require.Equal(t, `table "EXAMPLE" exceeds rows limit 100/s`, reason)
+}
+
+func TestHandleRateLimit(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	now := time.Unix(1700000000, 0)
+	rl := NewRateLimiter([]RateLimitRule{{Scope: "table", Name: "*", Rows: 10}})
+	rl.nowFunc = func() time.Time { return now }
+	svr := &httpd{rateLimiter: rl}
+
+	r := gin.New()
+	r.POST("/db/write/:table", svr.handleRateLimit, func(ctx *gin.Context) {
+		ctx.Set(ctxKeyWriteRows, 30)
+		ctx.JSON(http.StatusOK, map[string]any{"success": true})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/db/write/example", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/db/write/example", nil))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "3", w.Header().Get("Retry-After"))
+}
+
+func TestHandleRateLimitChunkedBytes(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	now := time.Unix(1700000000, 0)
+	rl := NewRateLimiter([]RateLimitRule{{Scope: "table", Name: "*", Bytes: 100}})
+	rl.nowFunc = func() time.Time { return now }
+	svr := &httpd{rateLimiter: rl}
+
+	r := gin.New()
+	r.POST("/db/write/:table", svr.handleRateLimit, func(ctx *gin.Context) {
+		io.Copy(io.Discard, ctx.Request.Body)
+		ctx.JSON(http.StatusOK, map[string]any{"success": true})
+	})
+
+	// the size of the chunked request is not known in advance
+	req := httptest.NewRequest(http.MethodPost, "/db/write/example", strings.NewReader(strings.Repeat("x", 300)))
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// the bytes read are charged
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/db/write/example", nil))
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	rl := NewRateLimiter([]RateLimitRule{{Scope: "user", Name: "*", Requests: 1}})
+	rl.nowFunc = func() time.Time { return now }
+
+	for _, user := range []string{"a", "b", "c"} {
+		wait, _ := rl.Allow(rateSubject{User: user}, 0)
+		require.Zero(t, wait)
+	}
+	require.Len(t, rl.buckets, 3)
+
+	// the buckets refilled to the capacity are evicted
+	now = now.Add(rateSweepInterval)
+	wait, _ := rl.Allow(rateSubject{User: "d"}, 0)
+	require.Zero(t, wait)
+	require.Len(t, rl.buckets, 1)
+}
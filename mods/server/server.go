@@ -33,6 +33,7 @@ import (
 	"github.com/machbase/neo-server/v8/jsh/service"
 	"github.com/machbase/neo-server/v8/jsh/viz"
 	"github.com/machbase/neo-server/v8/mods"
+	"github.com/machbase/neo-server/v8/mods/alert"
 	"github.com/machbase/neo-server/v8/mods/backup"
 	"github.com/machbase/neo-server/v8/mods/bridge"
 	"github.com/machbase/neo-server/v8/mods/logging"
@@ -63,6 +64,9 @@ type Server struct {
 
 	bridgeSvc *bridge.Service
 	schedSvc  *scheduler.Service
+	alertSvc  *alert.Service
+
+	rateLimiter *RateLimiter
 
 	authorizedKeysDir string
 	licenseFilePath   string
@@ -258,6 +262,11 @@ func (s *Server) Start() error {
 		return fmt.Errorf("mqtt server: %w", err)
 	}
 
+	// alert rules, notify to the mqtt broker
+	if err := s.startAlertService(); err != nil {
+		return fmt.Errorf("alert service: %w", err)
+	}
+
 	// service manager
 	if err := s.initServiceController(); err != nil {
 		return fmt.Errorf("service controller: %w", err)
@@ -877,6 +886,24 @@ func (s *Server) startBridgeAndSchedulerService() error {
 	return nil
 }
 
+func (s *Server) startAlertService() error {
+	s.alertSvc = alert.NewService(
+		alert.WithProvider(s.models.AlertProvider()),
+		alert.WithTqlLoader(tql.NewLoader()),
+		alert.WithBrokerPublisher(func(topic string, payload []byte) error {
+			if s.mqttd == nil {
+				return errors.New("mqtt server is not enabled")
+			}
+			return s.mqttd.Publish(topic, payload)
+		}),
+	)
+	if err := s.alertSvc.Start(); err != nil {
+		return err
+	}
+	util.AddShutdownHook(func() { s.alertSvc.Stop() })
+	return nil
+}
+
 func (s *Server) startMqttServer() error {
 	if len(s.Mqtt.Listeners) == 0 {
 		return nil
@@ -902,6 +929,7 @@ func (s *Server) startMqttServer() error {
 		WithMqttMaxMessageSizeLimit(s.Mqtt.MaxMessageSizeLimit),
 		WithMqttTqlLoader(tql.NewLoader()),
 		WithMqttWsHandleListener(s.Http.Listeners),
+		WithMqttRateLimiter(s.getRateLimiter()),
 	}
 	if s.Mqtt.EnablePersistence {
 		mqtt_dir := filepath.Join(s.homeDirPath, "mqtt", "data")
@@ -932,9 +960,22 @@ func (s *Server) startMqttServer() error {
 		return fmt.Errorf("mqtt server, %s", err.Error())
 	}
 	util.AddShutdownHook(func() { s.mqttd.Stop() })
+	tql.SetBrokerSubscriber(s.mqttd.Subscribe)
 	return nil
 }
 
+// getRateLimiter returns the rate limiter that is shared by the http and mqtt servers,
+// it returns nil if there is no rate limit rule.
+func (s *Server) getRateLimiter() *RateLimiter {
+	if len(s.RateLimits) == 0 {
+		return nil
+	}
+	if s.rateLimiter == nil {
+		s.rateLimiter = NewRateLimiter(s.RateLimits)
+	}
+	return s.rateLimiter
+}
+
 func (s *Server) startHttpServer() error {
 	if len(s.Http.Listeners) == 0 {
 		return nil
@@ -949,6 +990,7 @@ func (s *Server) startHttpServer() error {
 		WithHttpDebugMode(s.Http.DebugMode, s.Http.DebugLatency),
 		WithHttpExperimentModeProvider(func() bool { return s.ExperimentMode }),
 		WithHttpWebShellProvider(s.models.ShellProvider()),
+		WithHttpQueryProvider(s.models.QueryProvider()),
 		WithHttpPathMap("data", s.homeDirPath),
 		WithHttpLinger(s.Http.Linger),
 		WithHttpWriteBufSize(s.Http.WriteBufSize),
@@ -957,6 +999,9 @@ func (s *Server) startHttpServer() error {
 		WithHttpStatzAllow(s.Http.AllowStatz...),
 		WithHttpStatzToken(s.Http.StatzToken),
 		WithHttpQueryCypher(s.Http.QueryCypher),
+		WithHttpQueryCursor(s.Http.CursorIdleTime, s.Http.CursorLimit),
+		WithHttpRateLimiter(s.getRateLimiter()),
+		WithHttpWriteBodyLimit(int64(s.Http.WriteBodyLimit)),
 	}
 	if s.mqttd != nil {
 		if h := s.mqttd.WsHandlerFunc(); h != nil {
@@ -1131,10 +1176,24 @@ func (s *Server) registerJsonRpcHandlers() {
 	ctl.RegisterJsonRpcHandler("schedule.list", s.listSchedules)
 	ctl.RegisterJsonRpcHandler("schedule.timer.add", s.addTimerSchedule)
 	ctl.RegisterJsonRpcHandler("schedule.subscriber.add", s.addSubscriberSchedule)
+	ctl.RegisterJsonRpcHandler("schedule.workflow.add", s.addWorkflowSchedule)
 	ctl.RegisterJsonRpcHandler("schedule.delete", s.deleteSchedule)
 	ctl.RegisterJsonRpcHandler("schedule.start", s.startSchedule)
 	ctl.RegisterJsonRpcHandler("schedule.stop", s.stopSchedule)
-	// TODO: add schedule.update, schedule.status
+	ctl.RegisterJsonRpcHandler("schedule.status", s.scheduleStatus)
+	ctl.RegisterJsonRpcHandler("schedule.history", s.scheduleHistory)
+	ctl.RegisterJsonRpcHandler("schedule.backfill", s.backfillSchedule)
+	// TODO: add schedule.update
+	ctl.RegisterJsonRpcHandler("alert.list", s.listAlerts)
+	ctl.RegisterJsonRpcHandler("alert.get", s.getAlert)
+	ctl.RegisterJsonRpcHandler("alert.add", s.addAlert)
+	ctl.RegisterJsonRpcHandler("alert.delete", s.deleteAlert)
+	ctl.RegisterJsonRpcHandler("alert.silence", s.silenceAlert)
+	ctl.RegisterJsonRpcHandler("alert.unsilence", s.unsilenceAlert)
+	ctl.RegisterJsonRpcHandler("query.list", s.listQueries)
+	ctl.RegisterJsonRpcHandler("query.get", s.getQuery)
+	ctl.RegisterJsonRpcHandler("query.add", s.addQuery)
+	ctl.RegisterJsonRpcHandler("query.delete", s.deleteQuery)
 	ctl.RegisterJsonRpcHandler("server.shutdown", s.Shutdown)
 	ctl.RegisterJsonRpcHandler("http.debug.set", s.setHttpDebug)
 	ctl.RegisterJsonRpcHandler("session.list", s.listSessions)
@@ -1869,6 +1928,9 @@ type addTimerScheduleRequest struct {
 	Spec      string `json:"spec"`
 	Command   string `json:"command"`
 	AutoStart bool   `json:"autoStart,omitempty"`
+
+	Retry   *model.RetryPolicy `json:"retry,omitempty"`
+	CatchUp string             `json:"catchUp,omitempty"`
 }
 
 // addTimerSchedule creates a timer schedule.
@@ -1884,6 +1946,8 @@ func (s *Server) addTimerSchedule(ctx context.Context, req addTimerScheduleReque
 		AutoStart: req.AutoStart,
 		Schedule:  req.Spec,
 		Task:      req.Command,
+		Retry:     req.Retry,
+		CatchUp:   req.CatchUp,
 	}
 	rsp, err := s.schedSvc.AddSchedule(ctx, scheduleReq)
 	if err != nil {
@@ -1922,6 +1986,13 @@ func (s *Server) addSubscriberSchedule(ctx context.Context, req addSubscriberSch
 			StreamName: req.Nats.StreamName,
 		}
 	}
+	if req.Opcua != nil {
+		scheduleReq.Opt.Opcua = &scheduler.OpcuaOption{
+			Nodes:    req.Opcua.Nodes,
+			Browse:   req.Opcua.Browse,
+			Interval: req.Opcua.Interval,
+		}
+	}
 
 	rsp, err := s.schedSvc.AddSchedule(ctx, &scheduleReq)
 	if err != nil {
@@ -1944,13 +2015,52 @@ type addSubscriberScheduleNatsOption struct {
 	StreamName string `json:"streamName,omitempty"`
 }
 
+type addSubscriberScheduleOpcuaOption struct {
+	Nodes    []string `json:"nodes,omitempty"`
+	Browse   string   `json:"browse,omitempty"`
+	Interval string   `json:"interval,omitempty"`
+}
+
 type addSubscriberScheduleRequest struct {
-	Name      string                           `json:"name"`
-	Bridge    string                           `json:"bridge"`
-	Command   string                           `json:"command"`
-	AutoStart bool                             `json:"autoStart,omitempty"`
-	Mqtt      *addSubscriberScheduleMqttOption `json:"mqtt,omitempty"`
-	Nats      *addSubscriberScheduleNatsOption `json:"nats,omitempty"`
+	Name      string                            `json:"name"`
+	Bridge    string                            `json:"bridge"`
+	Command   string                            `json:"command"`
+	AutoStart bool                              `json:"autoStart,omitempty"`
+	Mqtt      *addSubscriberScheduleMqttOption  `json:"mqtt,omitempty"`
+	Nats      *addSubscriberScheduleNatsOption  `json:"nats,omitempty"`
+	Opcua     *addSubscriberScheduleOpcuaOption `json:"opcua,omitempty"`
+}
+
+type addWorkflowScheduleRequest struct {
+	Name      string                `json:"name"`
+	Spec      string                `json:"spec"`
+	AutoStart bool                  `json:"autoStart,omitempty"`
+	Steps     []*model.WorkflowStep `json:"steps"`
+}
+
+// addWorkflowSchedule creates a workflow schedule, the steps run by the timer spec
+// when their upstream steps are finished.
+//
+// params:
+//   - req: workflow schedule request
+//
+// return: null on success
+func (s *Server) addWorkflowSchedule(ctx context.Context, req addWorkflowScheduleRequest) error {
+	scheduleReq := &scheduler.AddScheduleRequest{
+		Name:      strings.ToLower(req.Name),
+		Type:      "WORKFLOW",
+		AutoStart: req.AutoStart,
+		Schedule:  req.Spec,
+		Steps:     req.Steps,
+	}
+	rsp, err := s.schedSvc.AddSchedule(ctx, scheduleReq)
+	if err != nil {
+		return err
+	}
+	if !rsp.Success {
+		return errors.New(rsp.Reason)
+	}
+	return nil
 }
 
 // deleteSchedule removes a schedule by name.
@@ -2007,6 +2117,312 @@ func (s *Server) stopSchedule(ctx context.Context, name string) error {
 	return nil
 }
 
+// scheduleStatus returns the state, the next run time and the last run of a schedule.
+//
+// params:
+//   - name: schedule name
+//
+// return: schedule status
+func (s *Server) scheduleStatus(ctx context.Context, name string) (*scheduler.ScheduleStatus, error) {
+	name = strings.ToLower(name)
+	rsp, err := s.schedSvc.GetScheduleStatus(ctx, &scheduler.GetScheduleStatusRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	return rsp.Status, nil
+}
+
+// scheduleHistory returns the recent runs of a timer schedule, the latest run comes first.
+//
+// params:
+//   - name: schedule name
+//   - limit: max number of runs, 0 returns all kept runs
+//
+// return: list of runs
+func (s *Server) scheduleHistory(ctx context.Context, name string, limit int) ([]*model.ScheduleRun, error) {
+	name = strings.ToLower(name)
+	rsp, err := s.schedSvc.GetScheduleHistory(ctx, &scheduler.GetScheduleHistoryRequest{Name: name, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	if len(rsp.Runs) == 0 {
+		return []*model.ScheduleRun{}, nil
+	}
+	return rsp.Runs, nil
+}
+
+// backfillSchedule replays a timer schedule for each fire time in the range,
+// the fire time is passed to the tql as param("_fire_time").
+//
+// params:
+//   - name: schedule name
+//   - from: start of the range, "2006-01-02 15:04:05", "2006-01-02" in local time or RFC3339
+//   - to: end of the range, inclusive
+//
+// return: list of runs
+func (s *Server) backfillSchedule(ctx context.Context, name string, from string, to string) ([]*model.ScheduleRun, error) {
+	name = strings.ToLower(name)
+	fromTime, err := parseBackfillTime(from)
+	if err != nil {
+		return nil, err
+	}
+	toTime, err := parseBackfillTime(to)
+	if err != nil {
+		return nil, err
+	}
+	rsp, err := s.schedSvc.BackfillSchedule(ctx, &scheduler.BackfillScheduleRequest{Name: name, From: fromTime, To: toTime})
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	if len(rsp.Runs) == 0 {
+		return []*model.ScheduleRun{}, nil
+	}
+	return rsp.Runs, nil
+}
+
+func parseBackfillTime(str string) (time.Time, error) {
+	if ts, err := time.Parse(time.RFC3339, str); err == nil {
+		return ts, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02"} {
+		if ts, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return ts, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", str)
+}
+
+// listAlerts returns the alert rules and their states.
+//
+// params:
+//
+// return: alert list
+func (s *Server) listAlerts(ctx context.Context) ([]*alert.Alert, error) {
+	rsp, err := s.alertSvc.ListAlert(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	if len(rsp.Alerts) == 0 {
+		return []*alert.Alert{}, nil
+	}
+	return rsp.Alerts, nil
+}
+
+// getAlert returns an alert rule and its state.
+//
+// params:
+//   - name: alert name
+//
+// return: alert
+func (s *Server) getAlert(ctx context.Context, name string) (*alert.Alert, error) {
+	rsp, err := s.alertSvc.GetAlert(ctx, &alert.GetAlertRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	return rsp.Alert, nil
+}
+
+type addAlertRequest struct {
+	Name           string                `json:"name"`
+	Disabled       bool                  `json:"disabled,omitempty"`
+	Interval       string                `json:"interval"`
+	Sql            string                `json:"sql,omitempty"`
+	Tql            string                `json:"tql,omitempty"`
+	Operator       string                `json:"operator"`
+	Threshold      float64               `json:"threshold"`
+	Hysteresis     float64               `json:"hysteresis,omitempty"`
+	For            string                `json:"for,omitempty"`
+	RepeatInterval string                `json:"repeatInterval,omitempty"`
+	Message        string                `json:"message,omitempty"`
+	Channels       []*model.AlertChannel `json:"channels,omitempty"`
+}
+
+// addAlert creates an alert rule, the condition is evaluated at every interval
+// and the notifications are sent to the channels when the rule fires or resolves.
+//
+// params:
+//   - req: alert rule request
+//
+// return: null on success
+func (s *Server) addAlert(ctx context.Context, req addAlertRequest) error {
+	rsp, err := s.alertSvc.AddAlert(ctx, &alert.AddAlertRequest{Rule: &model.AlertRuleDefinition{
+		Name:           req.Name,
+		Disabled:       req.Disabled,
+		Interval:       req.Interval,
+		Sql:            req.Sql,
+		Tql:            req.Tql,
+		Operator:       req.Operator,
+		Threshold:      req.Threshold,
+		Hysteresis:     req.Hysteresis,
+		For:            req.For,
+		RepeatInterval: req.RepeatInterval,
+		Message:        req.Message,
+		Channels:       req.Channels,
+	}})
+	if err != nil {
+		return err
+	}
+	if !rsp.Success {
+		return errors.New(rsp.Reason)
+	}
+	return nil
+}
+
+// deleteAlert removes an alert rule and its state.
+//
+// params:
+//   - name: alert name
+//
+// return: null on success
+func (s *Server) deleteAlert(ctx context.Context, name string) error {
+	rsp, err := s.alertSvc.DelAlert(ctx, &alert.DelAlertRequest{Name: name})
+	if err != nil {
+		return err
+	}
+	if !rsp.Success {
+		return errors.New(rsp.Reason)
+	}
+	return nil
+}
+
+// silenceAlert suppresses the notifications of an alert rule for the duration,
+// the rule keeps being evaluated.
+//
+// params:
+//   - name: alert name
+//   - duration: silence duration, e.g. "30m", "1h", "1d"
+//
+// return: alert state
+func (s *Server) silenceAlert(ctx context.Context, name string, duration string) (*model.AlertState, error) {
+	d, err := util.ParseDuration(duration)
+	if err != nil {
+		return nil, err
+	}
+	if d <= 0 {
+		return nil, fmt.Errorf("invalid duration %q", duration)
+	}
+	rsp, err := s.alertSvc.SilenceAlert(ctx, &alert.SilenceAlertRequest{Name: name, Duration: d})
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	return rsp.State, nil
+}
+
+// unsilenceAlert cancels the silence of an alert rule.
+//
+// params:
+//   - name: alert name
+//
+// return: alert state
+func (s *Server) unsilenceAlert(ctx context.Context, name string) (*model.AlertState, error) {
+	rsp, err := s.alertSvc.SilenceAlert(ctx, &alert.SilenceAlertRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	if !rsp.Success {
+		return nil, errors.New(rsp.Reason)
+	}
+	return rsp.State, nil
+}
+
+type namedQueryInfo struct {
+	Name        string                        `json:"name"`
+	Sql         string                        `json:"sql"`
+	Params      []*model.QueryParamDefinition `json:"params"`
+	Format      string                        `json:"format,omitempty"`
+	Description string                        `json:"description,omitempty"`
+}
+
+func newNamedQueryInfo(def *model.QueryDefinition) *namedQueryInfo {
+	ret := &namedQueryInfo{
+		Name:        def.Name,
+		Sql:         def.SqlText,
+		Params:      def.Params,
+		Format:      def.Format,
+		Description: def.Description,
+	}
+	if ret.Params == nil {
+		ret.Params = []*model.QueryParamDefinition{}
+	}
+	return ret
+}
+
+// listQueries returns all named queries.
+//
+// params:
+//
+// return: named query list
+func (s *Server) listQueries() ([]*namedQueryInfo, error) {
+	list, err := s.models.QueryProvider().LoadAllQueries()
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]*namedQueryInfo, len(list))
+	for i, def := range list {
+		ret[i] = newNamedQueryInfo(def)
+	}
+	return ret, nil
+}
+
+// getQuery returns the named query.
+//
+// params:
+//   - name: query name
+//
+// return: named query
+func (s *Server) getQuery(name string) (*namedQueryInfo, error) {
+	def, err := s.models.QueryProvider().LoadQuery(name)
+	if err != nil {
+		return nil, fmt.Errorf("query %q not found", name)
+	}
+	return newNamedQueryInfo(def), nil
+}
+
+// addQuery creates or replaces the named query, it is executed by /db/query/named/:name.
+//
+// params:
+//   - req: named query; sql has '?' placeholders bound to params in order
+//
+// return: null on success
+func (s *Server) addQuery(req namedQueryInfo) error {
+	def := &model.QueryDefinition{
+		Name:        req.Name,
+		SqlText:     req.Sql,
+		Params:      req.Params,
+		Format:      req.Format,
+		Description: req.Description,
+	}
+	return s.models.QueryProvider().SaveQuery(def)
+}
+
+// deleteQuery removes the named query.
+//
+// params:
+//   - name: query name
+//
+// return: null on success
+func (s *Server) deleteQuery(name string) error {
+	return s.models.QueryProvider().RemoveQuery(name)
+}
+
 // setHttpDebug updates and returns HTTP debug settings.
 //
 // params:
@@ -76,6 +76,7 @@ type Config struct {
 	Mqtt           MqttConfig
 	Jwt            JwtConfig
 	NavelCord      *NavelCordConfig
+	RateLimits     []RateLimitRule // token-bucket limits of the http and mqtt ingestion
 
 	CreateDBQueries     []string // sql sentences
 	CreateDBScriptFiles []string // file path
@@ -147,6 +148,9 @@ type HttpConfig struct {
 	ReadBufSize     int
 	Linger          int
 	KeepAlive       int
+	CursorIdleTime  string
+	CursorLimit     int
+	WriteBodyLimit  int // max bytes of a write request body, 0 means unlimited
 }
 
 type MqttConfig struct {
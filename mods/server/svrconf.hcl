@@ -47,6 +47,9 @@ define VARS {
     HTTP_ALLOW_STATZ      = flag("--http-allow-statz", "")  // allow statz for the given IP address
     HTTP_STATZ_TOKEN      = flag("--http-statz-token", "")  // Bearer token for statz
     HTTP_QUERY_CYPHER     = flag("--http-query-cypher", "") // format: "alg=AES key=1234567890abcdef pad=pkcs5"
+    HTTP_CURSOR_IDLETIME  = flag("--http-cursor-idletime", "1m") // query cursors are closed if not fetched for the duration
+    HTTP_CURSOR_LIMIT     = flag("--http-cursor-limit", 10)      // max number of open query cursors per user
+    HTTP_WRITE_BODY_LIMIT = flag("--http-write-body-limit", 0)   // max bytes of a write request body, 0 means unlimited
 
     MAX_OPEN_CONN         = flag("--max-open-conn", -1)
     MAX_IDLE_CONN         = flag("--max-idle-conn", 2)
@@ -130,6 +133,9 @@ module "machbase.com/neo-server" {
             AllowStatz       = ["${VARS_HTTP_ALLOW_STATZ}"]
             StatzToken       = VARS_HTTP_STATZ_TOKEN
             QueryCypher      = VARS_HTTP_QUERY_CYPHER
+            CursorIdleTime   = VARS_HTTP_CURSOR_IDLETIME
+            CursorLimit      = VARS_HTTP_CURSOR_LIMIT
+            WriteBodyLimit   = VARS_HTTP_WRITE_BODY_LIMIT
         }
         Mqtt = {
             Listeners           = [
@@ -141,6 +147,9 @@ module "machbase.com/neo-server" {
             MaxMessageSizeLimit = VARS_MQTT_MAXMESSAGE
             EnablePersistence   = VARS_MQTT_PERSISTENCE
         }
+        // token-bucket limits of the ingestion, Scope is one of "user", "token", "cert" and "table"
+        // e.g. { Scope = "token", Name = "*", Requests = 100, Rows = 10000, Bytes = 1048576, Burst = 2 }
+        RateLimits = []
         Jwt = {
             AtDuration = flag("--jwt-at-expire", "5m")
             RtDuration = flag("--jwt-rt-expire", "60m")
@@ -153,7 +153,7 @@ func (req *QueryRequest) Execute(ctx context.Context, w io.Writer, hook *QueryHo
 		return fmt.Errorf("sql text is empty")
 	}
 
-	timeLocation, err := util.ParseTimeLocation(req.TimeLocation, time.UTC)
+	encoder, err := req.newEncoder(w)
 	if err != nil {
 		if hook.SetStatusCode != nil {
 			hook.SetStatusCode(400)
@@ -161,31 +161,6 @@ func (req *QueryRequest) Execute(ctx context.Context, w io.Writer, hook *QueryHo
 		return err
 	}
 
-	var output io.Writer
-	switch req.Compress {
-	case "gzip":
-		output = gzip.NewWriter(w)
-	default:
-		req.Compress = ""
-		output = w
-	}
-
-	encoder := codec.NewEncoder(req.Format,
-		opts.OutputStream(output),
-		opts.Timeformat(req.Timeformat),
-		opts.Binaryformat(req.BinaryFormat),
-		opts.Precision(req.Precision),
-		opts.Rownum(req.Rownum),
-		opts.Header(req.Heading),
-		opts.TimeLocation(timeLocation),
-		opts.Delimiter(req.Delimiter),
-		opts.BoxStyle(req.BoxStyle),
-		opts.BoxSeparateColumns(req.BoxSeparateColumns),
-		opts.BoxDrawBorder(req.BoxDrawBorder),
-		opts.RowsFlatten(req.RowsFlatten),
-		opts.RowsArray(req.RowsArray),
-		opts.Transpose(req.Transpose),
-	)
 	conn, err := getPoolSqlConn(ctx)
 	if err != nil {
 		if hook.SetStatusCode != nil {
@@ -281,6 +256,40 @@ func (req *QueryRequest) Execute(ctx context.Context, w io.Writer, hook *QueryHo
 	return nil
 }
 
+// newEncoder makes the encoder of the result by the options of the request.
+func (req *QueryRequest) newEncoder(w io.Writer) (codec.RowsEncoder, error) {
+	timeLocation, err := util.ParseTimeLocation(req.TimeLocation, time.UTC)
+	if err != nil {
+		return nil, err
+	}
+
+	var output io.Writer
+	switch req.Compress {
+	case "gzip":
+		output = gzip.NewWriter(w)
+	default:
+		req.Compress = ""
+		output = w
+	}
+
+	return codec.NewEncoder(req.Format,
+		opts.OutputStream(output),
+		opts.Timeformat(req.Timeformat),
+		opts.Binaryformat(req.BinaryFormat),
+		opts.Precision(req.Precision),
+		opts.Rownum(req.Rownum),
+		opts.Header(req.Heading),
+		opts.TimeLocation(timeLocation),
+		opts.Delimiter(req.Delimiter),
+		opts.BoxStyle(req.BoxStyle),
+		opts.BoxSeparateColumns(req.BoxSeparateColumns),
+		opts.BoxDrawBorder(req.BoxDrawBorder),
+		opts.RowsFlatten(req.RowsFlatten),
+		opts.RowsArray(req.RowsArray),
+		opts.Transpose(req.Transpose),
+	), nil
+}
+
 func parseQueryParams(raw string) ([]any, error) {
 	if strings.TrimSpace(raw) == "" {
 		return nil, nil
@@ -371,6 +380,16 @@ type WriteResponse struct {
 type WriteResponseData struct {
 	AffectedRows uint64                   `json:"affectedRows,omitempty"`
 	Files        map[string]*UserFileData `json:"files,omitempty"`
+	Rejects      []*WriteReject           `json:"rejects,omitempty"`
+	// Replayed is true if the response is of the previous write that has the same Idempotency-Key
+	Replayed bool `json:"replayed,omitempty"`
+}
+
+// WriteReject is a row that is not written in the batch write.
+type WriteReject struct {
+	Row    int    `json:"row"` // 1-based row number in the batch
+	Column string `json:"column,omitempty"`
+	Reason string `json:"reason"`
 }
 
 type UserFileData struct {
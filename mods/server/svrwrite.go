@@ -0,0 +1,148 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/machbase/neo-server/v8/mods/codec"
+)
+
+// IdempotencyKeyTTL is how long the result of a write that has Idempotency-Key is kept.
+var IdempotencyKeyTTL = 24 * time.Hour
+
+const idempotencyKeyCapacity = 100_000
+
+// MaxWriteBatchRows is the max number of records of a batch write, including the rejected ones.
+// The batch keeps all records in memory before writing, the write body limit may be unlimited.
+var MaxWriteBatchRows = 100_000
+
+var errWriteBatchTooLarge = errors.New("too many records for a batch write")
+
+var errWriteInProgress = errors.New("write with the same idempotency key is in progress")
+
+// writeKeys remembers the responses of the writes that have Idempotency-Key,
+// so that a retried batch is acknowledged without being written twice.
+// The keys are kept in memory only, they are lost when the server restarts.
+var writeKeys = newIdempotentWrites(idempotencyKeyCapacity)
+
+type idempotentWrites struct {
+	cache *ttlcache.Cache[string, *WriteResponse]
+}
+
+func newIdempotentWrites(capacity uint64) *idempotentWrites {
+	return &idempotentWrites{
+		cache: ttlcache.New(ttlcache.WithCapacity[string, *WriteResponse](capacity)),
+	}
+}
+
+// Begin reserves the key for a new write.
+// It returns the response of the previous write if the key has been used already,
+// or errWriteInProgress if the previous write has not finished yet.
+func (iw *idempotentWrites) Begin(key string) (*WriteResponse, error) {
+	// nil response marks the write in progress
+	item, found := iw.cache.GetOrSet(key, nil,
+		ttlcache.WithTTL[string, *WriteResponse](IdempotencyKeyTTL),
+		ttlcache.WithDisableTouchOnHit[string, *WriteResponse]())
+	if !found {
+		return nil, nil
+	}
+	prev := item.Value()
+	if prev == nil {
+		return nil, errWriteInProgress
+	}
+	ret := *prev
+	if ret.Data != nil {
+		data := *ret.Data
+		data.Replayed = true
+		ret.Data = &data
+	} else {
+		ret.Data = &WriteResponseData{Replayed: true}
+	}
+	return &ret, nil
+}
+
+// Commit keeps the response of the write for the following retries.
+func (iw *idempotentWrites) Commit(key string, rsp *WriteResponse) {
+	saved := *rsp
+	iw.cache.Set(key, &saved, IdempotencyKeyTTL)
+}
+
+// Abort releases the key, so that the write can be retried.
+func (iw *idempotentWrites) Abort(key string) {
+	iw.cache.Delete(key)
+}
+
+// idempotencyKey scopes the key by the requester and the table,
+// the same key of the other users does not replay the result.
+func idempotencyKey(requester string, table string, key string) string {
+	return requester + "/" + strings.ToUpper(table) + "/" + key
+}
+
+type writeRow struct {
+	recNo   int
+	values  []any
+	columns []string
+}
+
+// decodeWriteBatch reads all rows of the decoder before any of them is written.
+// The rows that can not be decoded are returned as rejects,
+// it returns an error if the input itself is broken or it has more than MaxWriteBatchRows records;
+// none of the rows should be written then.
+func decodeWriteBatch(decoder codec.RowsDecoder) ([]*writeRow, []*WriteReject, error) {
+	rows := []*writeRow{}
+	rejects := []*WriteReject{}
+	for recNo := 1; ; recNo++ {
+		if recNo > MaxWriteBatchRows {
+			// more records than the limit, if the next is not the end of the input
+			if _, _, err := decoder.NextRow(); err == io.EOF {
+				return rows, rejects, nil
+			}
+			return nil, nil, fmt.Errorf("%w, the limit is %d", errWriteBatchTooLarge, MaxWriteBatchRows)
+		}
+		vals, cols, err := decoder.NextRow()
+		if err != nil {
+			if err == io.EOF {
+				return rows, rejects, nil
+			}
+			var rowErr *codec.RowError
+			if errors.As(err, &rowErr) {
+				rejects = append(rejects, &WriteReject{Row: recNo, Column: rowErr.Column, Reason: rowErr.Error()})
+				continue
+			}
+			return nil, nil, fmt.Errorf("rows[%d] %w", recNo, err)
+		}
+		rows = append(rows, &writeRow{recNo: recNo, values: vals, columns: cols})
+	}
+}
+
+// writeBodyErrStatus returns 413 if err is caused by the body size limit
+// or the batch size limit, otherwise status.
+func writeBodyErrStatus(err error, status int) int {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) || errors.Is(err, errWriteBatchTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return status
+}
+
+// writeBatch writes the decoded rows and fills the result of the batch into rsp.
+func writeBatch(rsp *WriteResponse, method string, rows []*writeRow, rejects []*WriteReject, write func(vals []any, cols []string) error) {
+	var affected uint64
+	for _, row := range rows {
+		if err := write(row.values, row.columns); err != nil {
+			rejects = append(rejects, &WriteReject{Row: row.recNo, Reason: err.Error()})
+			continue
+		}
+		affected++
+	}
+	slices.SortFunc(rejects, func(a, b *WriteReject) int { return a.Row - b.Row })
+	rsp.Success = len(rejects) == 0
+	rsp.Reason = fmt.Sprintf("%d record(s) %sed, %d rejected", affected, method, len(rejects))
+	rsp.Data = &WriteResponseData{AffectedRows: affected, Rejects: rejects}
+}
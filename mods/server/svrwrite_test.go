@@ -0,0 +1,138 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/machbase/neo-server/v8/mods/codec"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRowsDecoder struct {
+	rows []fakeRow
+}
+
+type fakeRow struct {
+	values []any
+	err    error
+}
+
+func (dec *fakeRowsDecoder) Open() {}
+
+func (dec *fakeRowsDecoder) NextRow() ([]any, []string, error) {
+	if len(dec.rows) == 0 {
+		return nil, nil, io.EOF
+	}
+	row := dec.rows[0]
+	dec.rows = dec.rows[1:]
+	return row.values, nil, row.err
+}
+
+func TestIdempotentWrites(t *testing.T) {
+	iw := newIdempotentWrites(10)
+
+	prev, err := iw.Begin("T/key-1")
+	require.NoError(t, err)
+	require.Nil(t, prev)
+
+	// the same key while the first write is in progress
+	_, err = iw.Begin("T/key-1")
+	require.ErrorIs(t, err, errWriteInProgress)
+
+	rsp := &WriteResponse{Success: true, Reason: "success, 2 record(s) inserted", Data: &WriteResponseData{AffectedRows: 2}}
+	iw.Commit("T/key-1", rsp)
+
+	prev, err = iw.Begin("T/key-1")
+	require.NoError(t, err)
+	require.NotNil(t, prev)
+	require.True(t, prev.Success)
+	require.Equal(t, uint64(2), prev.Data.AffectedRows)
+	require.True(t, prev.Data.Replayed)
+	require.False(t, rsp.Data.Replayed, "committed response should not be modified")
+
+	// aborted key can be used again
+	_, err = iw.Begin("T/key-2")
+	require.NoError(t, err)
+	iw.Abort("T/key-2")
+	prev, err = iw.Begin("T/key-2")
+	require.NoError(t, err)
+	require.Nil(t, prev)
+
+	// the key is scoped by the requester and the table
+	require.Equal(t, "sys/EXAMPLE/key-1", idempotencyKey(rateSubject{User: "sys"}.requester(), "example", "key-1"))
+	require.Equal(t, "dev1/EXAMPLE/key-1", idempotencyKey(rateSubject{Token: "dev1", Cert: "cn"}.requester(), "example", "key-1"))
+	require.NotEqual(t, idempotencyKey("sys", "example", "key-1"), idempotencyKey("dev1", "example", "key-1"))
+}
+
+func TestWriteBatch(t *testing.T) {
+	dec := &fakeRowsDecoder{rows: []fakeRow{
+		{values: []any{"a", 1.0}},
+		{err: &codec.RowError{Column: "VALUE", Err: errors.New("rows[2] field[VALUE] is not a double, but string")}},
+		{values: []any{"fail", 3.0}},
+		{values: []any{"b", 4.0}},
+	}}
+	rows, rejects, err := decodeWriteBatch(dec)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	require.Len(t, rejects, 1)
+
+	written := []any{}
+	rsp := &WriteResponse{}
+	writeBatch(rsp, "insert", rows, rejects, func(vals []any, cols []string) error {
+		if vals[0] == "fail" {
+			return errors.New("write failure")
+		}
+		written = append(written, vals[0])
+		return nil
+	})
+	require.False(t, rsp.Success)
+	require.Equal(t, "2 record(s) inserted, 2 rejected", rsp.Reason)
+	require.Equal(t, []any{"a", "b"}, written)
+	require.Equal(t, uint64(2), rsp.Data.AffectedRows)
+	require.Equal(t, []*WriteReject{
+		{Row: 2, Column: "VALUE", Reason: "rows[2] field[VALUE] is not a double, but string"},
+		{Row: 3, Reason: "write failure"},
+	}, rsp.Data.Rejects)
+}
+
+func TestWriteBatchBrokenInput(t *testing.T) {
+	dec := &fakeRowsDecoder{rows: []fakeRow{
+		{values: []any{"a", 1.0}},
+		{err: errors.New("invalid character")},
+	}}
+	_, _, err := decodeWriteBatch(dec)
+	require.EqualError(t, err, "rows[2] invalid character")
+	require.Equal(t, http.StatusBadRequest, writeBodyErrStatus(err, http.StatusBadRequest))
+
+	dec = &fakeRowsDecoder{rows: []fakeRow{
+		{err: &http.MaxBytesError{Limit: 1024}},
+	}}
+	_, _, err = decodeWriteBatch(dec)
+	require.EqualError(t, err, "rows[1] http: request body too large")
+	require.Equal(t, http.StatusRequestEntityTooLarge, writeBodyErrStatus(err, http.StatusBadRequest))
+}
+
+func TestWriteBatchTooLarge(t *testing.T) {
+	prev := MaxWriteBatchRows
+	MaxWriteBatchRows = 2
+	t.Cleanup(func() { MaxWriteBatchRows = prev })
+
+	dec := &fakeRowsDecoder{rows: []fakeRow{
+		{values: []any{"a", 1.0}},
+		{values: []any{"b", 2.0}},
+	}}
+	rows, _, err := decodeWriteBatch(dec)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	dec = &fakeRowsDecoder{rows: []fakeRow{
+		{values: []any{"a", 1.0}},
+		{err: &codec.RowError{Column: "VALUE", Err: errors.New("not a double")}},
+		{values: []any{"c", 3.0}},
+	}}
+	_, _, err = decodeWriteBatch(dec)
+	require.EqualError(t, err, "too many records for a batch write, the limit is 2")
+	require.Equal(t, http.StatusRequestEntityTooLarge, writeBodyErrStatus(err, http.StatusBadRequest))
+}
@@ -0,0 +1,208 @@
+package tql
+
+import (
+	"fmt"
+	"math"
+
+	client "github.com/machbase/neo-client/v2"
+	"github.com/machbase/neo-server/v8/mods/nums/anomaly"
+	"github.com/machbase/neo-server/v8/mods/util"
+)
+
+// mapAnomaly keeps the detector of the MAP_ZSCORE(), MAP_EWMA(), MAP_MAD(), MAP_CUSUM() and MAP_SEASONAL().
+//
+// The functions insert the score and the boolean flag columns at the idx,
+// the score is NULL while the detector is not ready unless noWait(true) is given.
+// The infinite score, a change after a window of the constant values, is NULL with the flag true,
+// since it can not be represented by the sinks e.g. JSON().
+// With lazy(true), the records are buffered until the end of the stream and
+// scored at once by the statistics of the whole series.
+type mapAnomaly struct {
+	detector  anomaly.Detector
+	threshold float64
+	noWait    bool
+	lazy      bool
+	name      string
+	records   []*anomalyRecord
+}
+
+type anomalyRecord struct {
+	key    any
+	values []any // with the score and the flag columns at the idx
+	idx    int
+	value  *float64
+}
+
+func (node *Node) fmMapZScore(idx int, value any, window int, threshold float64, opts ...any) (any, error) {
+	return node.mapAnomaly(idx, value, threshold, opts, func() (anomaly.Detector, error) {
+		if window <= 1 {
+			return nil, ErrArgs("MAP_ZSCORE", 2, "window should be larger than 1")
+		}
+		return anomaly.NewZScore(window), nil
+	})
+}
+
+func (node *Node) fmMapEWMA(idx int, value any, lambda float64, threshold float64, opts ...any) (any, error) {
+	return node.mapAnomaly(idx, value, threshold, opts, func() (anomaly.Detector, error) {
+		if lambda <= 0 || lambda > 1 {
+			return nil, ErrArgs("MAP_EWMA", 2, "lambda should be 0 < lambda <= 1")
+		}
+		return anomaly.NewEWMA(lambda), nil
+	})
+}
+
+func (node *Node) fmMapMAD(idx int, value any, window int, threshold float64, opts ...any) (any, error) {
+	return node.mapAnomaly(idx, value, threshold, opts, func() (anomaly.Detector, error) {
+		if window <= 1 {
+			return nil, ErrArgs("MAP_MAD", 2, "window should be larger than 1")
+		}
+		return anomaly.NewMAD(window), nil
+	})
+}
+
+// MAP_CUSUM flags the value when the cumulative sum exceeds the decision interval h.
+func (node *Node) fmMapCUSUM(idx int, value any, baseline int, k float64, h float64, opts ...any) (any, error) {
+	return node.mapAnomaly(idx, value, h, opts, func() (anomaly.Detector, error) {
+		if baseline <= 1 {
+			return nil, ErrArgs("MAP_CUSUM", 2, "baseline should be larger than 1")
+		}
+		if k < 0 {
+			return nil, ErrArgs("MAP_CUSUM", 3, "k should not be negative")
+		}
+		if h <= 0 {
+			return nil, ErrArgs("MAP_CUSUM", 4, "h should be larger than 0")
+		}
+		return anomaly.NewCUSUM(baseline, k, h), nil
+	})
+}
+
+func (node *Node) fmMapSeasonal(idx int, value any, period int, cycles int, threshold float64, opts ...any) (any, error) {
+	return node.mapAnomaly(idx, value, threshold, opts, func() (anomaly.Detector, error) {
+		if period <= 1 {
+			return nil, ErrArgs("MAP_SEASONAL", 2, "period should be larger than 1")
+		}
+		if cycles <= 0 {
+			return nil, ErrArgs("MAP_SEASONAL", 3, "cycles should be larger than 0")
+		}
+		return anomaly.NewSeasonal(period, cycles), nil
+	})
+}
+
+func (node *Node) mapAnomaly(idx int, value any, threshold float64, opts []any, newDetector func() (anomaly.Detector, error)) (any, error) {
+	var ma *mapAnomaly
+	if v, ok := node.GetValue("mapAnomaly"); ok {
+		ma = v.(*mapAnomaly)
+	} else {
+		detector, err := newDetector()
+		if err != nil {
+			return nil, err
+		}
+		ma = &mapAnomaly{detector: detector, threshold: threshold, name: "score"}
+		for _, opt := range opts {
+			switch v := opt.(type) {
+			case NoWait:
+				ma.noWait = bool(v)
+			case *lazyOption:
+				ma.lazy = v.flag
+			case string:
+				ma.name = v
+			}
+		}
+		node.SetValue("mapAnomaly", ma)
+		if ma.lazy {
+			node.SetEOF(ma.onEOF)
+		}
+	}
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	var values []any
+	switch val := inflight.value.(type) {
+	case []any:
+		values = val
+	default:
+		values = []any{val}
+	}
+	if idx < 0 {
+		idx = 0
+	} else if idx > len(values) {
+		idx = len(values)
+	}
+	if _, ok := node.GetValue("isFirst"); !ok {
+		node.SetValue("isFirst", true)
+		ma.updateColumns(node, idx)
+	}
+
+	var fv *float64
+	if f, err := util.ToFloat64(value); err == nil && !math.IsNaN(f) {
+		fv = &f
+	}
+	if ma.lazy {
+		ma.records = append(ma.records, &anomalyRecord{key: inflight.key, values: insertAnomaly(values, idx, nil, false), idx: idx, value: fv})
+		return nil, nil
+	}
+	var score any
+	var flag bool
+	if fv != nil {
+		s, ready := ma.detector.Push(*fv)
+		if (ready || ma.noWait) && !math.IsNaN(s) {
+			score, flag = ma.score(s)
+		}
+	}
+	return NewRecordVars(inflight.key, insertAnomaly(values, idx, score, flag), inflight.vars), nil
+}
+
+// updateColumns inserts the score and the flag columns into the result columns.
+func (ma *mapAnomaly) updateColumns(node *Node, idx int) {
+	cols := node.task.ResultColumns() // cols contains "ROWNUM"
+	if len(cols) == 0 {
+		return
+	}
+	for i := len(cols); i <= idx; i++ {
+		cols = append(cols, client.MakeColumnAny(fmt.Sprintf("column%d", i)))
+	}
+	updateCols := []*client.Column{}
+	updateCols = append(updateCols, cols[0:idx+1]...)
+	updateCols = append(updateCols, client.MakeColumnDouble(ma.name), client.MakeColumnBoolean("anomaly"))
+	updateCols = append(updateCols, cols[idx+1:]...)
+	node.task.SetResultColumns(updateCols)
+}
+
+func (ma *mapAnomaly) onEOF(node *Node) {
+	series := make([]float64, 0, len(ma.records))
+	for _, rec := range ma.records {
+		if rec.value != nil {
+			series = append(series, *rec.value)
+		}
+	}
+	scores := ma.detector.Batch(series)
+	n := 0
+	for _, rec := range ma.records {
+		if rec.value != nil {
+			if s := scores[n]; !math.IsNaN(s) {
+				rec.values[rec.idx], rec.values[rec.idx+1] = ma.score(s)
+			}
+			n++
+		}
+		node.yield(rec.key, rec.values)
+	}
+	ma.records = nil
+}
+
+// score returns the score column and the flag of the score.
+func (ma *mapAnomaly) score(s float64) (any, bool) {
+	if math.IsInf(s, 0) {
+		return nil, true
+	}
+	return s, math.Abs(s) > ma.threshold
+}
+
+// insertAnomaly returns a copy of the values with the score and the flag inserted at the idx.
+func insertAnomaly(values []any, idx int, score any, flag bool) []any {
+	ret := make([]any, 0, len(values)+2)
+	ret = append(ret, values[0:idx]...)
+	ret = append(ret, score, flag)
+	ret = append(ret, values[idx:]...)
+	return ret
+}
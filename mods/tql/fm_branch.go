@@ -0,0 +1,75 @@
+package tql
+
+import (
+	"fmt"
+)
+
+type branchName struct {
+	name string
+	file string // absolute path of the file that the branch sink writes to
+}
+
+// BRANCH('name') or BRANCH('name', file('/path')) is the source of the branch
+// that TEE('name') feeds.
+func (node *Node) fmBranch(name string, opts ...any) (*branchName, error) {
+	ret := &branchName{name: name}
+	for i, opt := range opts {
+		switch v := opt.(type) {
+		case *FilePath:
+			if v.AbsPath == "" {
+				return nil, ErrArgs("BRANCH", i+1, fmt.Sprintf("%q is not writable", v.Path))
+			}
+			ret.file = v.AbsPath
+		default:
+			return nil, ErrArgs("BRANCH", i+1, fmt.Sprintf("unknown option %T", opt))
+		}
+	}
+	return ret, nil
+}
+
+// TEE('name', ...)
+//
+// TEE passes the records to the next as they are, and duplicates them to the named branches.
+// It blocks until all the branches receive the record, so that the slowest branch throttles the pipeline.
+func (node *Node) fmTee(names ...string) (*Record, error) {
+	if len(names) == 0 {
+		return nil, ErrInvalidNumOfArgs("TEE", 1, 0)
+	}
+	var branches []*branch
+	if v, ok := node.GetValue("tee"); ok {
+		branches = v.([]*branch)
+	} else {
+		for _, name := range names {
+			b, err := node.task.lookupBranch(name)
+			if err != nil {
+				return nil, fmt.Errorf("f(TEE) %s", err.Error())
+			}
+			if err := b.claim(); err != nil {
+				return nil, fmt.Errorf("f(TEE) %s", err.Error())
+			}
+			branches = append(branches, b)
+		}
+		node.SetValue("tee", branches)
+	}
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	for _, b := range branches {
+		b.colsOnce.Do(func() {
+			b.task.SetResultColumns(node.task.ResultColumns())
+		})
+		b.Receive(copyRecord(inflight))
+	}
+	return inflight, nil
+}
+
+// copyRecord returns a shallow copy of the record with its own values,
+// so that the branches can modify the values without affecting each other.
+func copyRecord(rec *Record) *Record {
+	ret := &Record{key: rec.key, value: rec.value, contentType: rec.contentType, vars: rec.vars}
+	if values, ok := rec.value.([]any); ok {
+		ret.value = append([]any{}, values...)
+	}
+	return ret
+}
@@ -18,6 +18,10 @@ func (node *Node) fmSqlSelect(args ...any) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if node.task.explain {
+		node.SetValue(explainSqlKey, ret.ToSQL())
+		return nil, nil
+	}
 	ret.version = 1
 
 	tick := time.Now()
@@ -58,6 +62,10 @@ func (node *Node) fmQuery(args ...any) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if node.task.explain {
+		node.SetValue(explainSqlKey, ret.ToSQL())
+		return nil, nil
+	}
 	tick := time.Now()
 	var ds *DataGenMachbase
 	var sqlText string
@@ -241,6 +249,10 @@ type DataGenMachbase struct {
 }
 
 func (dc *DataGenMachbase) gen(node *Node) {
+	if node.task.sqlStub != nil {
+		dc.resultMsg = sqlStubQuery(node, spi.DetectSQLStatementType(dc.sqlText), dc.sqlText, dc.params...)
+		return
+	}
 	conn, err := spi.Connect(node.task.ctx, node.task.consoleUser)
 	if err != nil {
 		ErrorRecord(err).Tell(node.next)
@@ -317,6 +329,17 @@ func (x *Node) fmSql(args ...any) (any, error) {
 	if len(args) == 0 {
 		return nil, ErrInvalidNumOfArgs("SQL", 1, 0)
 	}
+	if x.task.explain {
+		switch v := args[0].(type) {
+		case string:
+			x.SetValue(explainSqlKey, strings.TrimSuffix(strings.TrimSpace(v), ";"))
+		case *bridgeName:
+			if len(args) > 1 {
+				x.SetValue(explainSqlKey, fmt.Sprintf("bridge('%s') %v", v.name, args[1]))
+			}
+		}
+		return nil, nil
+	}
 	tick := time.Now()
 	var conn *sql.Conn
 	var sqlText string
@@ -326,24 +349,28 @@ func (x *Node) fmSql(args ...any) (any, error) {
 
 	switch v := args[0].(type) {
 	case string:
-		if c, err := spi.Connect(x.task.ctx, x.task.consoleUser); err != nil {
-			return nil, err
-		} else {
-			conn = c
+		if x.task.sqlStub == nil {
+			if c, err := spi.Connect(x.task.ctx, x.task.consoleUser); err != nil {
+				return nil, err
+			} else {
+				conn = c
+			}
+			defer conn.Close()
 		}
-		defer conn.Close()
 		sqlText = strings.TrimSuffix(strings.TrimSpace(v), ";")
 		sqlParams = args[1:]
 	case *bridgeName:
-		dbm, err := connector.Database(v.name)
-		if err != nil {
-			return nil, err
-		}
-		conn, err = dbm.Conn(x.task.ctx)
-		if err != nil {
-			return nil, err
+		if x.task.sqlStub == nil {
+			dbm, err := connector.Database(v.name)
+			if err != nil {
+				return nil, err
+			}
+			conn, err = dbm.Conn(x.task.ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer conn.Close()
 		}
-		defer conn.Close()
 		if str, ok := args[1].(string); ok {
 			sqlText = strings.TrimSuffix(strings.TrimSpace(str), ";")
 		}
@@ -365,6 +392,8 @@ func (x *Node) fmSql(args ...any) (any, error) {
 	stmtType := spi.DetectSQLStatementType(sqlText)
 	x.task.LogInfo("╭─", prompt, sqlText)
 	switch {
+	case x.task.sqlStub != nil:
+		resultMsg = sqlStubQuery(x, stmtType, sqlText, sqlParams...)
 	case stmtType == spi.SQLStatementTypeShow:
 		resultMsg = sqlShow(x, conn, sqlText)
 	case stmtType == spi.SQLStatementTypeDescribe:
@@ -444,6 +473,34 @@ func sqlQuery(node *Node, stmtType spi.SQLStatementType, conn *sql.Conn, sqlText
 	return userMsg
 }
 
+// sqlStubQuery yields the records that the sql stub of the task returns.
+func sqlStubQuery(node *Node, stmtType spi.SQLStatementType, sqlText string, sqlParams ...any) string {
+	cols, rows, err := node.task.sqlStub(sqlText, sqlParams)
+	if err != nil {
+		ErrorRecord(err).Tell(node.next)
+		return err.Error()
+	}
+	if !stmtType.IsFetch() {
+		userMsg := spi.MakeUserMessage(stmtType, int64(len(rows)))
+		node.task.SetResultColumns(client.Columns{
+			client.MakeColumnRownum(),
+			client.MakeColumnString("MESSAGE"),
+		})
+		NewRecord(1, userMsg).Tell(node.next)
+		return userMsg
+	}
+	node.task.SetResultColumns(append(client.Columns{client.MakeColumnRownum()}, cols...))
+	nrow := int64(0)
+	for _, values := range rows {
+		nrow++
+		if node.task.shouldStop() {
+			return spi.MakeUserMessage(stmtType, nrow) + ", cancelled"
+		}
+		NewRecord(nrow, values).Tell(node.next)
+	}
+	return spi.MakeUserMessage(stmtType, nrow)
+}
+
 type Explainer interface {
 	Explain(ctx context.Context, sqlText string, full bool) (string, error)
 }
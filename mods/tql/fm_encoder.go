@@ -99,6 +99,14 @@ func (node *Node) fmNDJson(args ...any) (*Encoder, error) {
 	return newEncoder("ndjson", args...)
 }
 
+func (node *Node) fmArrow(args ...any) (*Encoder, error) {
+	return newEncoder("arrow", args...)
+}
+
+func (node *Node) fmParquet(args ...any) (*Encoder, error) {
+	return newEncoder("parquet", args...)
+}
+
 func (node *Node) fmGeoMap(args ...any) (*Encoder, error) {
 	return newEncoder("geomap", args...)
 }
@@ -142,6 +142,18 @@ func TestEncoderNodeFunctions(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "discard", enc.format)
 
+	enc, err = node.fmArrow()
+	require.NoError(t, err)
+	require.Equal(t, "arrow", enc.format)
+
+	enc, err = node.fmParquet(time.UTC)
+	require.NoError(t, err)
+	require.Equal(t, "parquet", enc.format)
+	require.Len(t, enc.opts, 1)
+
+	_, err = node.fmParquet(&CacheParam{})
+	require.EqualError(t, err, "encoder 'parquet' does not support cache")
+
 	dir := t.TempDir()
 	path := filepath.Join(dir, "template.html")
 	require.NoError(t, os.WriteFile(path, []byte("<p>{{ . }}</p>"), 0o600))
@@ -52,15 +52,39 @@ func (node *Node) fmFastFourierTransform(args ...any) (any, error) {
 		return nil, nil
 	}
 
+	sampleTimes, sampleValues, err := fourierSamples("FFT", value)
+	if err != nil {
+		return nil, err
+	}
+
+	freqs, values := fft.FastFourierTransform(sampleTimes, sampleValues)
+
+	newVal := [][]any{}
+	for i := range freqs {
+		hz := freqs[i]
+		amplitude := values[i]
+		if hz == 0 || hz < minHz || hz > maxHz {
+			continue
+		}
+		newVal = append(newVal, []any{hz, amplitude})
+	}
+
+	ret := NewRecord(key, newVal)
+	return ret, nil
+}
+
+// fourierSamples converts the (time, value) tuples of the record into the sample times and values.
+func fourierSamples(fname string, value []any) ([]time.Time, []float64, error) {
+	lenSamples := len(value)
 	sampleTimes := make([]time.Time, lenSamples)
 	sampleValues := make([]float64, lenSamples)
 	for i := range value {
 		tuple, ok := value[i].([]any)
 		if !ok {
-			return nil, fmt.Errorf("f(FFT) sample should be a tuple of (time, value), but %T (%v)", value[i], value[i])
+			return nil, nil, fmt.Errorf("f(%s) sample should be a tuple of (time, value), but %T (%v)", fname, value[i], value[i])
 		}
 		if len(tuple) != 2 {
-			return nil, fmt.Errorf("f(FFT) sample should be a tuple of (time, value), but len=%d", len(tuple))
+			return nil, nil, fmt.Errorf("f(%s) sample should be a tuple of (time, value), but len=%d", fname, len(tuple))
 		}
 		switch val := tuple[0].(type) {
 		case time.Time:
@@ -68,7 +92,7 @@ func (node *Node) fmFastFourierTransform(args ...any) (any, error) {
 		case *time.Time:
 			sampleTimes[i] = *val
 		default:
-			return nil, fmt.Errorf("f(FFT) invalid %dth sample time, but %T", i, tuple[0])
+			return nil, nil, fmt.Errorf("f(%s) invalid %dth sample time, but %T", fname, i, tuple[0])
 		}
 		switch val := tuple[1].(type) {
 		case float64:
@@ -80,22 +104,8 @@ func (node *Node) fmFastFourierTransform(args ...any) (any, error) {
 		case *int64:
 			sampleValues[i] = float64(*val)
 		default:
-			return nil, fmt.Errorf("f(FFT) invalid %dth sample value, but %T", i, tuple[1])
+			return nil, nil, fmt.Errorf("f(%s) invalid %dth sample value, but %T", fname, i, tuple[1])
 		}
 	}
-
-	freqs, values := fft.FastFourierTransform(sampleTimes, sampleValues)
-
-	newVal := [][]any{}
-	for i := range freqs {
-		hz := freqs[i]
-		amplitude := values[i]
-		if hz == 0 || hz < minHz || hz > maxHz {
-			continue
-		}
-		newVal = append(newVal, []any{hz, amplitude})
-	}
-
-	ret := NewRecord(key, newVal)
-	return ret, nil
+	return sampleTimes, sampleValues, nil
 }
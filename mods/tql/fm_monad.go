@@ -119,6 +119,18 @@ func (node *Node) fmFilter(flag bool) *Record {
 	return node.Inflight()
 }
 
+// ASSERT(cond, msg) passes the record if cond is true,
+// otherwise the record is dropped and the task fails with the msg.
+func (node *Node) fmAssert(cond bool, msg string) (*Record, error) {
+	if !cond {
+		if msg == "" {
+			msg = "assertion failed"
+		}
+		return nil, fmt.Errorf("f(ASSERT) %s", msg)
+	}
+	return node.Inflight(), nil
+}
+
 func (node *Node) fmFilterChanged(value any, args ...any) any {
 	inflight := node.Inflight()
 	if inflight == nil {
@@ -353,6 +365,11 @@ func (node *Node) fmDictionary(args ...any) (any, error) {
 }
 
 func (node *Node) fmGroup(args ...any) any {
+	for _, arg := range args {
+		if v, ok := arg.(*GroupAggregate); ok && (v.Type == GroupBySlidingWindow || v.Type == GroupBySessionWindow) {
+			return node.fmGroupWindow(args)
+		}
+	}
 	var gr *Group
 	var columns []*GroupAggregate
 	var by *GroupAggregate
@@ -654,7 +671,11 @@ func (node *Node) fmBy(value any, args ...any) (any, error) {
 	}
 	ret.Name = name
 	if ret.Name == "" {
-		ret.Name = "GROUP"
+		if ret.Type == GroupBySlidingWindow || ret.Type == GroupBySessionWindow {
+			ret.Name = "START"
+		} else {
+			ret.Name = "GROUP"
+		}
 	}
 
 	ret.Value = client.Unbox(value)
@@ -664,6 +685,12 @@ func (node *Node) fmBy(value any, args ...any) (any, error) {
 			return nil, ErrArgs("timewindow()", 0, "value should be time")
 		}
 		ret.Value = time.Unix(0, (ts.UnixNano()/int64(ret.twPeriod))*int64(ret.twPeriod))
+	} else if ret.Type == GroupBySlidingWindow || ret.Type == GroupBySessionWindow {
+		ts, err := util.ToTime(ret.Value)
+		if err != nil {
+			return nil, ErrArgs("by()", 0, "value should be time for the window")
+		}
+		ret.Value = ts
 	}
 
 	return ret, nil
@@ -710,6 +737,11 @@ type GroupAggregate struct {
 	twFrom    time.Time
 	twUntil   time.Time
 	twPeriod  time.Duration
+	swSize    time.Duration
+	swStep    time.Duration
+	swGap     time.Duration
+	swKey     any
+	swHasKey  bool
 	where     WherePredicate
 	nullValue any
 	predict   PredictType
@@ -774,7 +806,7 @@ func (ga *GroupAggregate) ColumnType() api.DataType {
 			return api.DataTypeString
 		}
 		return api.DataTypeOf(ga.Value)
-	case GroupByTimeWindow:
+	case GroupByTimeWindow, GroupBySlidingWindow, GroupBySessionWindow:
 		return api.DataTypeDatetime
 	case "chunk":
 		return api.DataTypeList
@@ -0,0 +1,395 @@
+package tql
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	client "github.com/machbase/neo-client/v2"
+	"github.com/machbase/neo-server/v8/mods/util"
+)
+
+type pipeName struct {
+	name string
+}
+
+// PIPE('name') is the sink of the named sub-pipeline.
+func (node *Node) fmPipe(name string) *pipeName {
+	return &pipeName{name: name}
+}
+
+type pipeOn struct {
+	mainIdx int
+	pipeIdx int
+}
+
+// on(idx) or on(mainIdx, pipeIdx) selects the value index of the join column,
+// the default is on(0, 0).
+func (node *Node) fmOn(idx ...int) (*pipeOn, error) {
+	switch len(idx) {
+	case 1:
+		return &pipeOn{mainIdx: idx[0], pipeIdx: idx[0]}, nil
+	case 2:
+		return &pipeOn{mainIdx: idx[0], pipeIdx: idx[1]}, nil
+	default:
+		return nil, ErrInvalidNumOfArgs("on", 2, len(idx))
+	}
+}
+
+type pipeTolerance struct {
+	value float64
+}
+
+// tolerance('1s') is the maximum distance of the join columns those are aligned,
+// a number is the distance of the numeric columns or nanoseconds of the time columns.
+func (node *Node) fmTolerance(tol any) (*pipeTolerance, error) {
+	if str, ok := tol.(string); ok {
+		d, err := util.ParseDuration(str)
+		if err != nil {
+			return nil, ErrArgs("tolerance", 0, err.Error())
+		}
+		return &pipeTolerance{value: float64(d)}, nil
+	}
+	f, err := util.ToFloat64(tol)
+	if err != nil {
+		return nil, ErrWrongTypeOfArgs("tolerance", 0, "duration or number", tol)
+	}
+	return &pipeTolerance{value: f}, nil
+}
+
+const (
+	pipeCombinatorJoin  = "JOIN"
+	pipeCombinatorMerge = "MERGE"
+	pipeCombinatorUnion = "UNION"
+)
+
+func isPipeCombinator(nodeName string) bool {
+	switch nodeName {
+	case pipeCombinatorJoin + "()", pipeCombinatorMerge + "()", pipeCombinatorUnion + "()":
+		return true
+	}
+	return false
+}
+
+type pipeCombinator struct {
+	kind      string
+	pipe      *pipe
+	left      bool
+	on        pipeOn
+	tolerance float64
+
+	buf        []*Record // records read ahead from the pipe
+	eof        bool
+	width      int // number of the values of the pipe records
+	columnsSet bool
+}
+
+func (node *Node) pipeCombinator(kind string, name string, opts []any) (*pipeCombinator, error) {
+	if v, ok := node.GetValue("pipe"); ok {
+		return v.(*pipeCombinator), nil
+	}
+	p, err := node.task.lookupPipe(name)
+	if err != nil {
+		return nil, fmt.Errorf("f(%s) %s", kind, err.Error())
+	}
+	if err := p.claim(); err != nil {
+		return nil, fmt.Errorf("f(%s) %s", kind, err.Error())
+	}
+	ret := &pipeCombinator{kind: kind, pipe: p}
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case *pipeOn:
+			ret.on = *v
+		case *pipeTolerance:
+			ret.tolerance = math.Abs(v.value)
+		case string:
+			switch strings.ToLower(v) {
+			case "inner":
+				ret.left = false
+			case "left":
+				ret.left = true
+			default:
+				return nil, fmt.Errorf("f(%s) unknown join type %q, expect 'inner' or 'left'", kind, v)
+			}
+		}
+	}
+	node.SetValue("pipe", ret)
+	return ret, nil
+}
+
+// JOIN('name', on(0, 0), tolerance('1s'), 'inner' | 'left')
+//
+// JOIN aligns the records of the pipeline by the join columns
+// and appends the values of the nearest record within the tolerance, except the join column.
+// Both of the records should be in ascending order of the join columns.
+func (node *Node) fmJoin(name string, opts ...any) (any, error) {
+	pc, err := node.pipeCombinator(pipeCombinatorJoin, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	values := pipeRecordValues(inflight)
+	if pc.on.mainIdx < 0 || pc.on.mainIdx >= len(values) {
+		return nil, fmt.Errorf("f(JOIN) join column %d is out of range", pc.on.mainIdx)
+	}
+	key := values[pc.on.mainIdx]
+	if pc.width == 0 && !pc.eof {
+		// read ahead for the width of the pipe records,
+		// the unmatched record of the left join is padded with it.
+		pc.fill(node)
+	}
+
+	var match *Record
+	if key != nil {
+		// read the pipe until the record is after the key over the tolerance
+		for !pc.eof {
+			if n := len(pc.buf); n > 0 {
+				if d, err := pipeDiff(pc.keyOf(pc.buf[n-1]), key); err != nil {
+					return nil, fmt.Errorf("f(JOIN) %s", err.Error())
+				} else if d > pc.tolerance {
+					break
+				}
+			}
+			if !pc.fill(node) {
+				break
+			}
+		}
+		// the records before the key over the tolerance are not used anymore
+		for len(pc.buf) > 0 {
+			if d, err := pipeDiff(key, pc.keyOf(pc.buf[0])); err != nil {
+				return nil, fmt.Errorf("f(JOIN) %s", err.Error())
+			} else if d > pc.tolerance {
+				pc.buf = pc.buf[1:]
+			} else {
+				break
+			}
+		}
+		nearest := math.Inf(1)
+		for _, r := range pc.buf {
+			d, _ := pipeDiff(pc.keyOf(r), key)
+			if d > pc.tolerance {
+				break
+			}
+			if math.Abs(d) < nearest {
+				nearest = math.Abs(d)
+				match = r
+			}
+		}
+	}
+	if match == nil && !pc.left {
+		return nil, nil
+	}
+
+	ret := append([]any{}, values...)
+	if match != nil {
+		for i, v := range pipeRecordValues(match) {
+			if i != pc.on.pipeIdx {
+				ret = append(ret, v)
+			}
+		}
+	} else {
+		for i := 0; i < pc.width; i++ {
+			if i != pc.on.pipeIdx {
+				ret = append(ret, nil)
+			}
+		}
+	}
+	pc.setJoinColumns(node)
+	return NewRecord(inflight.key, ret), nil
+}
+
+// MERGE('name', on(0, 0))
+//
+// MERGE yields the records of the pipeline and the records in the ascending order of the join columns.
+// Both of the records should be in ascending order of the join columns.
+func (node *Node) fmMerge(name string, opts ...any) (any, error) {
+	pc, err := node.pipeCombinator(pipeCombinatorMerge, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	values := pipeRecordValues(inflight)
+	if pc.on.mainIdx < 0 || pc.on.mainIdx >= len(values) {
+		return nil, fmt.Errorf("f(MERGE) merge column %d is out of range", pc.on.mainIdx)
+	}
+	key := values[pc.on.mainIdx]
+
+	ret := []*Record{}
+	for key != nil {
+		if len(pc.buf) == 0 && !pc.fill(node) {
+			break
+		}
+		// the record of the main pipeline goes first if the keys are equal
+		if d, err := pipeDiff(pc.keyOf(pc.buf[0]), key); err != nil {
+			return nil, fmt.Errorf("f(MERGE) %s", err.Error())
+		} else if d >= 0 {
+			break
+		}
+		ret = append(ret, pc.buf[0])
+		pc.buf = pc.buf[1:]
+	}
+	return append(ret, inflight), nil
+}
+
+// UNION('name')
+//
+// UNION yields the records of the pipeline and the records as they arrive.
+func (node *Node) fmUnion(name string, opts ...any) (any, error) {
+	pc, err := node.pipeCombinator(pipeCombinatorUnion, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	ret := []*Record{}
+	for !pc.eof {
+		rec, ready, ok := pc.pipe.tryRead()
+		if !ready {
+			break
+		}
+		if !ok {
+			pc.eof = true
+			break
+		}
+		ret = append(ret, rec)
+	}
+	return append(ret, inflight), nil
+}
+
+// pipeCombinatorEOF yields the rest of the pipe records for MERGE() and UNION().
+func pipeCombinatorEOF(node *Node) {
+	v, ok := node.GetValue("pipe")
+	if !ok {
+		// no record has arrived, claim the pipe with the arguments
+		node.SetInflight(nil)
+		if _, err := node.expr.Eval(node); err != nil {
+			ErrorRecord(err).Tell(node.next)
+			return
+		}
+		if v, ok = node.GetValue("pipe"); !ok {
+			return
+		}
+	}
+	pc := v.(*pipeCombinator)
+	if pc.kind == pipeCombinatorJoin {
+		return
+	}
+	for _, rec := range pc.buf {
+		rec.Tell(node.next)
+	}
+	pc.buf = nil
+	for !pc.eof {
+		rec, ok := pc.pipe.read()
+		if !ok {
+			pc.eof = true
+			break
+		}
+		rec.Tell(node.next)
+	}
+}
+
+// fill reads a record from the pipe into the buffer,
+// the error records are passed to the next and the records without the join column are dropped.
+// It returns false if there are no more records.
+func (pc *pipeCombinator) fill(node *Node) bool {
+	for {
+		rec, ok := pc.pipe.read()
+		if !ok {
+			pc.eof = true
+			return false
+		}
+		if !rec.IsTuple() {
+			rec.Tell(node.next)
+			continue
+		}
+		values := pipeRecordValues(rec)
+		if len(values) > pc.width {
+			pc.width = len(values)
+		}
+		if pc.on.pipeIdx < 0 || pc.on.pipeIdx >= len(values) || values[pc.on.pipeIdx] == nil {
+			continue
+		}
+		pc.buf = append(pc.buf, rec)
+		return true
+	}
+}
+
+func (pc *pipeCombinator) keyOf(rec *Record) any {
+	return pipeRecordValues(rec)[pc.on.pipeIdx]
+}
+
+// setJoinColumns appends the columns of the pipe to the result columns, except the join column.
+// The columns are set once a record of the pipe is read, so that its width and columns are known.
+func (pc *pipeCombinator) setJoinColumns(node *Node) {
+	if pc.columnsSet {
+		return
+	}
+	cols := node.task.ResultColumns() // cols contains "ROWNUM"
+	if len(cols) == 0 || pc.width == 0 {
+		return
+	}
+	pc.columnsSet = true
+	ret := append(client.Columns{}, cols...)
+	pipeCols := pc.pipe.task.ResultColumns()
+	for i := 0; i < pc.width; i++ {
+		if i == pc.on.pipeIdx {
+			continue
+		}
+		if i+1 < len(pipeCols) {
+			ret = append(ret, pipeCols[i+1])
+		} else {
+			ret = append(ret, client.MakeColumnAny(fmt.Sprintf("column%d", len(ret)-1)))
+		}
+	}
+	node.task.SetResultColumns(ret)
+}
+
+func pipeRecordValues(rec *Record) []any {
+	switch v := rec.value.(type) {
+	case []any:
+		return v
+	case nil:
+		return []any{}
+	default:
+		return []any{v}
+	}
+}
+
+// pipeDiff returns a - b of the join columns, the times are compared in nanoseconds
+// and the strings are compared lexically.
+func pipeDiff(a, b any) (float64, error) {
+	switch av := a.(type) {
+	case time.Time, *time.Time:
+		at, _ := util.ToTime(av)
+		bt, err := util.ToTime(b)
+		if err != nil {
+			return 0, fmt.Errorf("incompatible join columns %T and %T", a, b)
+		}
+		return float64(at.Sub(bt)), nil
+	case string:
+		bs, ok := b.(string)
+		if !ok {
+			return 0, fmt.Errorf("incompatible join columns %T and %T", a, b)
+		}
+		return float64(strings.Compare(av, bs)), nil
+	default:
+		af, err := util.ToFloat64(a)
+		if err != nil {
+			return 0, fmt.Errorf("incompatible join columns %T and %T", a, b)
+		}
+		bf, err := util.ToFloat64(b)
+		if err != nil {
+			return 0, fmt.Errorf("incompatible join columns %T and %T", a, b)
+		}
+		return af - bf, nil
+	}
+}
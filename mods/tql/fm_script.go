@@ -204,6 +204,14 @@ func newJSContext(node *Node, initCode string, mainCode string, deinitCode strin
 		// $.inflight()
 		ctx.obj.Set("inflight", ctx.jsFuncInflight(vm))
 
+		// modules of the #include directives
+		for _, mod := range node.task.jsModules {
+			if _, err := vm.RunScript(mod.path, mod.code); err != nil {
+				initErr = fmt.Errorf("%s, %s", mod.path, err.Error())
+				return
+			}
+		}
+
 		if strings.TrimSpace(initCode) != "" {
 			_, initErr = vm.RunString(initCode)
 		}
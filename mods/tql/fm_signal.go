@@ -0,0 +1,214 @@
+package tql
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/machbase/neo-server/v8/mods/nums/fft"
+	"github.com/machbase/neo-server/v8/mods/util"
+)
+
+// inflightSamples returns the (time, value) samples of the inflight record that GROUPBYKEY() made,
+// it returns nil times if the samples are not enough to be analyzed.
+func (node *Node) inflightSamples(fname string, minSamples int) (any, []time.Time, []float64, error) {
+	inflight := node.Inflight()
+	if inflight == nil || inflight.key == nil {
+		return nil, nil, nil, nil
+	}
+	var value []any
+	if v, ok := inflight.value.([]any); ok {
+		value = v
+	} else {
+		value = []any{inflight.value}
+	}
+	if len(value) < minSamples {
+		// drop input, instead of raising error
+		return nil, nil, nil, nil
+	}
+	times, values, err := fourierSamples(fname, value)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return inflight.key, times, values, nil
+}
+
+// tql function: SPECTROGRAM(size, hop [, minHz(), maxHz()])
+//
+// SPECTROGRAM yields the (time, hz, amplitude) tuples of the short-time Fourier transform
+// of the segments of the size samples at every hop samples, the time is the center of the segment.
+// FLATTEN() makes the records those CHART_SURFACE3D() and the heatmap charts take.
+func (node *Node) fmSpectrogram(size int, hop int, args ...any) (any, error) {
+	if size < 2 {
+		return nil, ErrArgs("SPECTROGRAM", 0, "size should be larger than 1")
+	}
+	if hop < 1 {
+		return nil, ErrArgs("SPECTROGRAM", 1, "hop should be larger than 0")
+	}
+	minHz, maxHz := hzRange(args)
+	key, times, values, err := node.inflightSamples("SPECTROGRAM", size)
+	if err != nil || times == nil {
+		return nil, err
+	}
+	sp := fft.STFT(values, fft.SampleRate(times), size, hop)
+	if sp == nil {
+		return nil, nil
+	}
+	newVal := [][]any{}
+	for _, frame := range sp.Frames {
+		ts := times[frame.Offset+size/2]
+		for i, hz := range sp.Hz {
+			if hz < minHz || hz > maxHz {
+				continue
+			}
+			newVal = append(newVal, []any{ts, hz, frame.Amplitude[i]})
+		}
+	}
+	return NewRecord(key, newVal), nil
+}
+
+// tql function: PSD(size [, minHz(), maxHz()])
+//
+// PSD yields the (hz, power spectral density) tuples by the Welch's method
+// with the segments of the size samples.
+func (node *Node) fmPSD(size int, args ...any) (any, error) {
+	if size < 2 {
+		return nil, ErrArgs("PSD", 0, "size should be larger than 1")
+	}
+	minHz, maxHz := hzRange(args)
+	key, times, values, err := node.inflightSamples("PSD", 16)
+	if err != nil || times == nil {
+		return nil, err
+	}
+	freqs, psd := fft.Welch(values, fft.SampleRate(times), size)
+	newVal := [][]any{}
+	for i, hz := range freqs {
+		if hz == 0 || hz < minHz || hz > maxHz {
+			continue
+		}
+		newVal = append(newVal, []any{hz, psd[i]})
+	}
+	return NewRecord(key, newVal), nil
+}
+
+// tql function: ENVELOPE()
+//
+// ENVELOPE yields the (time, envelope) tuples of the amplitude envelope by the Hilbert transform.
+func (node *Node) fmEnvelope() (any, error) {
+	key, times, values, err := node.inflightSamples("ENVELOPE", 16)
+	if err != nil || times == nil {
+		return nil, err
+	}
+	env := fft.Envelope(values)
+	newVal := make([][]any, len(env))
+	for i, v := range env {
+		newVal[i] = []any{times[i], v}
+	}
+	return NewRecord(key, newVal), nil
+}
+
+// tql function: PEAKS(minHeight [, minDistance])
+//
+// PEAKS keeps the tuples those are the local maxima of the second element in the record,
+// e.g. the (hz, amplitude) of FFT() or the (time, value) of GROUPBYKEY().
+func (node *Node) fmPeaks(minHeight float64, args ...any) (any, error) {
+	minDistance := 0
+	if len(args) > 1 {
+		return nil, ErrInvalidNumOfArgs("PEAKS", 2, len(args)+1)
+	} else if len(args) == 1 {
+		if d, err := util.ToFloat64(args[0]); err != nil {
+			return nil, ErrArgs("PEAKS", 1, fmt.Sprintf("minDistance should be a number, but %T", args[0]))
+		} else {
+			minDistance = int(d)
+		}
+	}
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	var tuples []any
+	switch val := inflight.value.(type) {
+	case []any:
+		tuples = val
+	case [][]any:
+		tuples = make([]any, len(val))
+		for i, v := range val {
+			tuples[i] = v
+		}
+	default:
+		return nil, fmt.Errorf("f(PEAKS) value should be tuples, but %T", val)
+	}
+	values := make([]float64, len(tuples))
+	for i, t := range tuples {
+		tuple, ok := t.([]any)
+		if !ok || len(tuple) < 2 {
+			return nil, fmt.Errorf("f(PEAKS) %dth tuple should have two elements at least, but %v", i, t)
+		}
+		f, err := util.ToFloat64(tuple[1])
+		if err != nil {
+			return nil, fmt.Errorf("f(PEAKS) %dth tuple, %s", i, err.Error())
+		}
+		values[i] = f
+	}
+	newVal := [][]any{}
+	for _, idx := range fft.FindPeaks(values, minHeight, minDistance) {
+		newVal = append(newVal, tuples[idx].([]any))
+	}
+	return NewRecord(inflight.key, newVal), nil
+}
+
+// tql function: MAP_HIGHPASS(idx, value, cutoff, sampleRate [, order])
+func (node *Node) fmMapHighPass(idx int, value any, cutoff float64, sampleRate float64, opts ...any) (any, error) {
+	return node.mapButterworth("MAP_HIGHPASS", idx, value, opts, func(order int) (*fft.Butterworth, error) {
+		return fft.NewHighPass(order, cutoff, sampleRate)
+	})
+}
+
+// tql function: MAP_BANDPASS(idx, value, low, high, sampleRate [, order])
+func (node *Node) fmMapBandPass(idx int, value any, low float64, high float64, sampleRate float64, opts ...any) (any, error) {
+	return node.mapButterworth("MAP_BANDPASS", idx, value, opts, func(order int) (*fft.Butterworth, error) {
+		return fft.NewBandPass(order, low, high, sampleRate)
+	})
+}
+
+// mapButterworth filters the value by the Butterworth filter of the order (default 4),
+// the first number of the options is the order.
+func (node *Node) mapButterworth(fname string, idx int, value any, opts []any, newFilter func(order int) (*fft.Butterworth, error)) (any, error) {
+	var bw *fft.Butterworth
+	if v, ok := node.GetValue("butterworth"); ok {
+		bw = v.(*fft.Butterworth)
+	} else {
+		order := 4
+		for _, opt := range opts {
+			if f, ok := opt.(float64); ok {
+				order = int(f)
+				break
+			}
+		}
+		filter, err := newFilter(order)
+		if err != nil {
+			return nil, fmt.Errorf("f(%s) %s", fname, err.Error())
+		}
+		bw = filter
+		node.SetValue("butterworth", bw)
+	}
+	fv, err := util.ToFloat64(value)
+	if err != nil || math.IsNaN(fv) {
+		return node.fmMapValue(idx, nil, opts...)
+	}
+	return node.fmMapValue(idx, bw.Step(fv), opts...)
+}
+
+func hzRange(args []any) (float64, float64) {
+	minHz := math.NaN()
+	maxHz := math.NaN()
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case minHzOption:
+			minHz = float64(v)
+		case maxHzOption:
+			maxHz = float64(v)
+		}
+	}
+	return minHz, maxHz
+}
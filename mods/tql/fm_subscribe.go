@@ -0,0 +1,183 @@
+package tql
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	client "github.com/machbase/neo-client/v2"
+	"github.com/machbase/neo-server/v8/mods/bridge"
+	"github.com/machbase/neo-server/v8/mods/util"
+	"github.com/nats-io/nats.go"
+)
+
+// BrokerSubscriber subscribes the topic filter of the built-in mqtt broker,
+// the callback is called for every message and the returned function cancels the subscription.
+type BrokerSubscriber func(filter string, cb func(topic string, payload []byte)) (func(), error)
+
+var _brokerSubscriber BrokerSubscriber
+
+// SetBrokerSubscriber sets the function that SUBSCRIBE() uses for the topics of the built-in mqtt broker.
+func SetBrokerSubscriber(fn BrokerSubscriber) {
+	_brokerSubscriber = fn
+}
+
+// subscribeBufferSize is the number of the messages those are waiting for the task,
+// the messages are dropped if the task is slower than the topic.
+const subscribeBufferSize = 1000
+
+type subscribeDuration struct {
+	d time.Duration
+}
+
+// tql function: duration(d)
+//
+// SUBSCRIBE('sensor/#', duration('1m'))
+func (node *Node) fmDuration(d any) (*subscribeDuration, error) {
+	dur, err := util.ToDuration(d)
+	if err != nil {
+		return nil, ErrArgs("duration", 0, fmt.Sprintf("duration is not compatible, %T", d))
+	}
+	if dur <= 0 {
+		return nil, ErrArgs("duration", 0, "duration should be positive")
+	}
+	return &subscribeDuration{d: dur}, nil
+}
+
+type subscribeMessage struct {
+	ts      time.Time
+	topic   string
+	payload []byte
+}
+
+// tql function: SUBSCRIBE([bridge('name'),] topic [, limit(), duration()])
+//
+// SUBSCRIBE yields the (time, topic, payload) records of the messages as they arrive,
+// from the mqtt or nats bridge, or from the built-in mqtt broker if the bridge is omitted.
+// It keeps the task running until the limit(count) messages are received, the duration is elapsed
+// or the task is cancelled, e.g. the http client is disconnected.
+// The sink flushes every record, so that the clients take the records in streaming.
+func (node *Node) fmSubscribe(args ...any) (any, error) {
+	if len(args) == 0 {
+		return nil, ErrInvalidNumOfArgs("SUBSCRIBE", 1, 0)
+	}
+	topicIdx := 0
+	var br *bridgeName
+	if v, ok := args[0].(*bridgeName); ok {
+		br = v
+		topicIdx = 1
+	}
+	if len(args) <= topicIdx {
+		return nil, ErrArgs("SUBSCRIBE", topicIdx, "topic is missing")
+	}
+	topic, ok := args[topicIdx].(string)
+	if !ok || topic == "" {
+		return nil, ErrWrongTypeOfArgs("SUBSCRIBE", topicIdx, "topic", args[topicIdx])
+	}
+	skip, limit := 0, 0
+	var duration time.Duration
+	for i := topicIdx + 1; i < len(args); i++ {
+		switch v := args[i].(type) {
+		case *QueryLimit:
+			skip, limit = v.Offset, v.Limit
+		case *subscribeDuration:
+			duration = v.d
+		default:
+			return nil, ErrWrongTypeOfArgs("SUBSCRIBE", i, "limit() or duration()", v)
+		}
+	}
+
+	msgs := make(chan *subscribeMessage, subscribeBufferSize)
+	var dropped atomic.Int64
+	unsubscribe, err := subscribeTopic(br, topic, func(topic string, payload []byte) {
+		msg := &subscribeMessage{ts: time.Now(), topic: topic, payload: bytes.Clone(payload)}
+		select {
+		case msgs <- msg:
+		default:
+			// do not block the bridge or the broker that is shared with others
+			dropped.Add(1)
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("f(SUBSCRIBE) %s", err.Error())
+	}
+	defer func() {
+		unsubscribe()
+		if n := dropped.Load(); n > 0 {
+			node.task.LogWarnf("%s dropped %d messages", node.Name(), n)
+		}
+	}()
+
+	node.task.setStreaming()
+	node.task.SetResultColumns([]*client.Column{
+		client.MakeColumnRownum(),
+		client.MakeColumnDatetime("TIME"),
+		client.MakeColumnString("TOPIC"),
+		client.MakeColumnString("PAYLOAD"),
+	})
+
+	stop := make(chan struct{})
+	node.task.AddShouldStopListener(func() { close(stop) })
+	var expired <-chan time.Time
+	if duration > 0 {
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	count := 0
+	for {
+		select {
+		case <-node.task.ctx.Done():
+			return nil, nil
+		case <-stop:
+			return nil, nil
+		case <-expired:
+			return nil, nil
+		case msg := <-msgs:
+			if count++; count <= skip {
+				continue
+			}
+			NewRecord(count-skip, []any{msg.ts, msg.topic, string(msg.payload)}).Tell(node.next)
+			if limit > 0 && count-skip >= limit {
+				return nil, nil
+			}
+		}
+	}
+}
+
+func subscribeTopic(br *bridgeName, topic string, cb func(topic string, payload []byte)) (func(), error) {
+	if br == nil {
+		if _brokerSubscriber == nil {
+			return nil, errors.New("mqtt broker is not available")
+		}
+		return _brokerSubscriber(topic, cb)
+	}
+	br0, err := bridge.GetBridge(br.name)
+	if err != nil {
+		return nil, err
+	}
+	var sub bridge.Subscription
+	switch br1 := br0.(type) {
+	case *bridge.MqttBridge:
+		s, err := br1.Subscribe(topic, 0, func(topic string, payload []byte, _ int, _ bool, _ bool) {
+			cb(topic, payload)
+		})
+		if err != nil {
+			return nil, err
+		}
+		sub = s
+	case *bridge.NatsBridge:
+		s, err := br1.Subscribe(topic, func(msg *nats.Msg) {
+			cb(msg.Subject, msg.Data)
+		})
+		if err != nil {
+			return nil, err
+		}
+		sub = s
+	default:
+		return nil, fmt.Errorf("bridge '%s' is not a mqtt or nats bridge", br.name)
+	}
+	return func() { sub.Unsubscribe() }, nil
+}
@@ -0,0 +1,271 @@
+package tql
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	client "github.com/machbase/neo-client/v2"
+	"github.com/machbase/neo-client/v2/api"
+	"github.com/machbase/neo-server/v8/mods/util"
+)
+
+const (
+	GroupBySlidingWindow = "bySlidingWindow"
+	GroupBySessionWindow = "bySessionWindow"
+)
+
+// tql function: slidingwindow(size, step)
+//
+// GROUP( by(value(0), slidingwindow('5m', '30s')), mean(value(1)) )
+func (node *Node) fmBySlidingWindow(size any, step any) (any, error) {
+	ret := &GroupAggregate{Type: GroupBySlidingWindow}
+	fnName := "slidingwindow()"
+	if d, err := util.ToDuration(size); err != nil {
+		return nil, ErrArgs(fnName, 0, fmt.Sprintf("size is not compatible, %T", size))
+	} else if d <= 0 {
+		return nil, ErrArgs(fnName, 0, "size should be positive")
+	} else {
+		ret.swSize = d
+	}
+	if d, err := util.ToDuration(step); err != nil {
+		return nil, ErrArgs(fnName, 1, fmt.Sprintf("step is not compatible, %T", step))
+	} else if d <= 0 {
+		return nil, ErrArgs(fnName, 1, "step should be positive")
+	} else {
+		ret.swStep = d
+	}
+	return ret, nil
+}
+
+// tql function: sessionwindow(gap [, key])
+//
+// GROUP( by(value(1), sessionwindow('10s', value(0))), count(value(2)) )
+func (node *Node) fmBySessionWindow(gap any, key ...any) (any, error) {
+	ret := &GroupAggregate{Type: GroupBySessionWindow}
+	fnName := "sessionwindow()"
+	if d, err := util.ToDuration(gap); err != nil {
+		return nil, ErrArgs(fnName, 0, fmt.Sprintf("gap is not compatible, %T", gap))
+	} else if d <= 0 {
+		return nil, ErrArgs(fnName, 0, "gap should be positive")
+	} else {
+		ret.swGap = d
+	}
+	if len(key) > 1 {
+		return nil, ErrInvalidNumOfArgs("sessionwindow", 2, len(key)+1)
+	} else if len(key) == 1 {
+		ret.swHasKey = true
+		ret.swKey = client.Unbox(key[0])
+	}
+	return ret, nil
+}
+
+// windowGroup is the GROUP() with the sliding or the session window.
+//
+// The windows are closed by the event time, a sliding window is closed when a record
+// at or after the end of the window arrives, and a session window is closed when a record
+// comes later than the gap after the last record of the session.
+// The closed windows are yielded right away in the order of the start time,
+// so that it works on the unbounded streams. The windows those are still open are yielded at the end of the stream.
+// With lazy(true), all windows are yielded at the end of the stream.
+type windowGroup struct {
+	lazy      bool
+	watermark time.Time
+	windows   map[any]*groupWindow
+	closed    []*groupWindow
+}
+
+type groupWindow struct {
+	key     any
+	start   time.Time
+	end     time.Time
+	count   int64
+	buffers []GroupColumn
+}
+
+func (node *Node) fmGroupWindow(args []any) any {
+	var wg *windowGroup
+	var columns []*GroupAggregate
+	var by *GroupAggregate
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case *GroupAggregate:
+			columns = append(columns, v)
+			if v.Type == GroupBySlidingWindow || v.Type == GroupBySessionWindow {
+				if by != nil {
+					return ErrorRecord(fmt.Errorf("GROUP() has multiple windows"))
+				}
+				by = v
+			} else if v.Type == GroupBy || v.Type == GroupByTimeWindow {
+				return ErrorRecord(fmt.Errorf("GROUP() %s can not be used with the sliding or session window", v.Type))
+			}
+		case *lazyOption:
+		default:
+			return ErrorRecord(fmt.Errorf("GROUP() unknown type '%T' in arguments", v))
+		}
+	}
+	ts, ok := by.Value.(time.Time)
+	if !ok {
+		return ErrorRecord(fmt.Errorf("GROUP() has by() with NULL"))
+	}
+
+	if obj, ok := node.GetValue("group_window"); ok {
+		wg = obj.(*windowGroup)
+	} else {
+		wg = &windowGroup{windows: map[any]*groupWindow{}}
+		for _, arg := range args {
+			if v, ok := arg.(*lazyOption); ok {
+				wg.lazy = v.flag
+			}
+		}
+		node.SetValue("group_window", wg)
+		node.SetEOF(func(node *Node) {
+			for _, w := range wg.windows {
+				wg.closed = append(wg.closed, w)
+			}
+			wg.windows = nil
+			wg.yield(node, columns)
+		})
+		cols := []*client.Column{client.MakeColumnRownum()}
+		for _, c := range columns {
+			if c != by {
+				resultType := c.ColumnType()
+				if c.ValueType != "" {
+					resultType = api.ParseDataType(c.ValueType)
+				}
+				cols = append(cols, &client.Column{Name: c.Name, DataType: resultType})
+				continue
+			}
+			if by.swHasKey {
+				cols = append(cols, client.MakeColumnOf("KEY", by.swKey))
+			}
+			cols = append(cols, client.MakeColumnDatetime(by.Name), client.MakeColumnDatetime("END"), client.MakeColumnInt64("COUNT"))
+		}
+		node.task.SetResultColumns(cols)
+	}
+
+	if by.Type == GroupBySlidingWindow {
+		wg.pushSliding(node, by, ts, columns)
+	} else {
+		wg.pushSession(node, by, ts, columns)
+	}
+	if ts.After(wg.watermark) {
+		wg.watermark = ts
+	}
+	if by.Type == GroupBySlidingWindow {
+		for k, w := range wg.windows {
+			if !w.end.After(wg.watermark) {
+				wg.closed = append(wg.closed, w)
+				delete(wg.windows, k)
+			}
+		}
+	} else {
+		for k, w := range wg.windows {
+			if wg.watermark.Sub(w.end) > by.swGap {
+				wg.closed = append(wg.closed, w)
+				delete(wg.windows, k)
+			}
+		}
+	}
+	if !wg.lazy {
+		wg.yield(node, columns)
+	}
+	return nil
+}
+
+// pushSliding appends the record to all windows those contain the time,
+// except the windows already closed.
+func (wg *windowGroup) pushSliding(node *Node, by *GroupAggregate, ts time.Time, columns []*GroupAggregate) {
+	first := time.Unix(0, (ts.UnixNano()/int64(by.swStep))*int64(by.swStep))
+	for start := first; start.Add(by.swSize).After(ts); start = start.Add(-by.swStep) {
+		if !wg.watermark.IsZero() && !start.Add(by.swSize).After(wg.watermark) {
+			// late record for the closed window
+			break
+		}
+		key := start.UnixNano()
+		w, ok := wg.windows[key]
+		if !ok {
+			if w = newGroupWindow(node, nil, start, start.Add(by.swSize), columns); w == nil {
+				return
+			}
+			wg.windows[key] = w
+		}
+		w.append(columns)
+	}
+}
+
+// pushSession appends the record to the session of the key,
+// the session is closed and a new one is started if the record comes later than the gap.
+func (wg *windowGroup) pushSession(node *Node, by *GroupAggregate, ts time.Time, columns []*GroupAggregate) {
+	key := fmt.Sprintf("%v", by.swKey)
+	w, ok := wg.windows[key]
+	if ok && ts.Sub(w.end) > by.swGap {
+		wg.closed = append(wg.closed, w)
+		ok = false
+	}
+	if !ok {
+		if w = newGroupWindow(node, by.swKey, ts, ts, columns); w == nil {
+			return
+		}
+		wg.windows[key] = w
+	}
+	if ts.Before(w.start) {
+		w.start = ts
+	}
+	if ts.After(w.end) {
+		w.end = ts
+	}
+	w.append(columns)
+}
+
+func newGroupWindow(node *Node, key any, start time.Time, end time.Time, columns []*GroupAggregate) *groupWindow {
+	ret := &groupWindow{key: key, start: start, end: end, buffers: make([]GroupColumn, len(columns))}
+	for i, c := range columns {
+		if c.Type == GroupBySlidingWindow || c.Type == GroupBySessionWindow {
+			continue
+		}
+		if ret.buffers[i] = c.NewBuffer(); ret.buffers[i] == nil {
+			node.task.LogErrorf("%s, invalid aggregate %q", node.Name(), c.Type)
+			return nil
+		}
+	}
+	return ret
+}
+
+func (w *groupWindow) append(columns []*GroupAggregate) {
+	w.count++
+	for i, c := range columns {
+		if w.buffers[i] != nil && c.where {
+			w.buffers[i].Append(c.Value)
+		}
+	}
+}
+
+// yield yields the closed windows in the order of the start time.
+func (wg *windowGroup) yield(node *Node, columns []*GroupAggregate) {
+	sort.SliceStable(wg.closed, func(i, j int) bool {
+		if wg.closed[i].start.Equal(wg.closed[j].start) {
+			return fmt.Sprintf("%v", wg.closed[i].key) < fmt.Sprintf("%v", wg.closed[j].key)
+		}
+		return wg.closed[i].start.Before(wg.closed[j].start)
+	})
+	for _, w := range wg.closed {
+		values := []any{}
+		for i, c := range columns {
+			if w.buffers[i] != nil {
+				v := w.buffers[i].Result()
+				if v == nil {
+					v = c.nullValue
+				}
+				values = append(values, v)
+				continue
+			}
+			if c.swHasKey {
+				values = append(values, w.key)
+			}
+			values = append(values, w.start, w.end, w.count)
+		}
+		node.yield(w.start, values)
+	}
+	wg.closed = nil
+}
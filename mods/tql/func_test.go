@@ -861,6 +861,24 @@ func TestMapFunc_FILTER(t *testing.T) {
 	}.run(t)
 }
 
+func TestMapFunc_ASSERT(t *testing.T) {
+	MapFuncTestCase{
+		input:  `ASSERT(len(value()) == 3, 'should have 3 values')`,
+		params: FuncParamMock("x", []any{1, 2, 3}),
+		expect: tql.NewRecord("x", []any{1, 2, 3}),
+	}.run(t)
+	MapFuncTestCase{
+		input:     `ASSERT(value(0) > 1, 'value should be larger than 1')`,
+		params:    FuncParamMock("x", []any{1, 2, 3}),
+		expectErr: "f(ASSERT) value should be larger than 1",
+	}.run(t)
+	MapFuncTestCase{
+		input:     `ASSERT(false, '')`,
+		params:    FuncParamMock("x", []any{1, 2, 3}),
+		expectErr: "f(ASSERT) assertion failed",
+	}.run(t)
+}
+
 func TestMapFunc_GROUPBYKEY(t *testing.T) {
 	MapFuncTestCase{
 		input:  `GROUPBYKEY()`,
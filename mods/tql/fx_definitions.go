@@ -29,6 +29,8 @@ var fxStatementKinds = map[string]StatementKind{
 	"JSON":            StatementSink,
 	"NDJSON":          StatementSink,
 	"MARKDOWN":        StatementSink,
+	"ARROW":           StatementSink,
+	"PARQUET":         StatementSink,
 	"HTML":            StatementSink,
 	"TEXT":            StatementSink,
 	"BOX":             StatementSink,
@@ -42,6 +44,9 @@ var fxStatementKinds = map[string]StatementKind{
 	"CHART_BAR3D":     StatementSink,
 	"CHART_SURFACE3D": StatementSink,
 	"CHART_SCATTER3D": StatementSink,
+	"PIPE":            StatementSink,
+	"BRANCH":          StatementSource,
+	"SUBSCRIBE":       StatementSource,
 }
 
 func statementKindByFunctionName(name string) (StatementKind, bool) {
@@ -74,6 +79,8 @@ var FxDefinitions = []Definition{
 	{"escapeParam", defTask.EscapeParam},
 	{"option", defTask.fmOption},
 	{"ARGS", defTask.fmArgs},
+	{"state", defTask.fmState},
+	{"SET_STATE", defTask.fmSetState},
 	// math
 	{"// math", nil},
 	{"abs", `mathWrap("abs", math.Abs)`},
@@ -157,6 +164,7 @@ var FxDefinitions = []Definition{
 	{"DROP", defTask.fmDrop},
 	{"FILTER", defTask.fmFilter},
 	{"FILTER_CHANGED", defTask.fmFilterChanged},
+	{"ASSERT", defTask.fmAssert},
 	{"retain", defTask.fmRetain},
 	{"useFirstWithLast", defTask.fmUseFirstWithLast},
 	{"FLATTEN", defTask.fmFlatten},
@@ -173,6 +181,11 @@ var FxDefinitions = []Definition{
 	{"MAP_LOWPASS", defTask.fmMapLowPass},
 	{"MAP_KALMAN", defTask.fmMapKalman},
 	{"model", defTask.fmKalmanModel},
+	{"MAP_ZSCORE", defTask.fmMapZScore},
+	{"MAP_EWMA", defTask.fmMapEWMA},
+	{"MAP_MAD", defTask.fmMapMAD},
+	{"MAP_CUSUM", defTask.fmMapCUSUM},
+	{"MAP_SEASONAL", defTask.fmMapSeasonal},
 	{"MAP_DIFF", defTask.fmDiff},
 	{"MAP_ABSDIFF", defTask.fmAbsDiff},
 	{"MAP_NONEGDIFF", defTask.fmNonNegativeDiff},
@@ -196,6 +209,16 @@ var FxDefinitions = []Definition{
 	{"args", defTask.fmArgsParam},
 	{"WHEN", defTask.fmWhen},
 	{"THROTTLE", defTask.fmThrottle},
+	// pipelines
+	{"// pipelines", nil},
+	{"PIPE", defTask.fmPipe},
+	{"JOIN", defTask.fmJoin},
+	{"MERGE", defTask.fmMerge},
+	{"UNION", defTask.fmUnion},
+	{"on", defTask.fmOn},
+	{"tolerance", defTask.fmTolerance},
+	{"TEE", defTask.fmTee},
+	{"BRANCH", defTask.fmBranch},
 	// database source
 	{"// database source", nil},
 	{"from", defTask.fmFrom},
@@ -214,11 +237,19 @@ var FxDefinitions = []Definition{
 	// bridge
 	{"// bridge", nil},
 	{"bridge", defTask.fmBridge},
+	{"duration", defTask.fmDuration},
+	{"SUBSCRIBE", defTask.fmSubscribe},
 	// fourier transform
 	{"// fourier transform", nil},
 	{"minHz", defTask.fmMinHz},
 	{"maxHz", defTask.fmMaxHz},
 	{"FFT", defTask.fmFastFourierTransform},
+	{"SPECTROGRAM", defTask.fmSpectrogram},
+	{"PSD", defTask.fmPSD},
+	{"ENVELOPE", defTask.fmEnvelope},
+	{"PEAKS", defTask.fmPeaks},
+	{"MAP_HIGHPASS", defTask.fmMapHighPass},
+	{"MAP_BANDPASS", defTask.fmMapBandPass},
 	// encoder
 	{"// encoder", nil},
 	{"cache", defTask.fmCache},
@@ -226,6 +257,8 @@ var FxDefinitions = []Definition{
 	{"JSON", defTask.fmJson},
 	{"NDJSON", defTask.fmNDJson},
 	{"MARKDOWN", defTask.fmMarkdown},
+	{"ARROW", defTask.fmArrow},
+	{"PARQUET", defTask.fmParquet},
 	{"HTML", defTask.fmHtml},
 	{"TEXT", defTask.fmText},
 	{"BOX", defTask.fmBox},
@@ -297,6 +330,8 @@ var FxDefinitions = []Definition{
 	{"GROUP", defTask.fmGroup},
 	{"by", defTask.fmBy},
 	{"timewindow", defTask.fmByTimeWindow},
+	{"slidingwindow", defTask.fmBySlidingWindow},
+	{"sessionwindow", defTask.fmBySessionWindow},
 	{"where", defTask.fmWhere},
 	{"predict", defTask.fmPredict},
 	{"weight", defTask.fmWeight},
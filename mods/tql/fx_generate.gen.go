@@ -24,6 +24,8 @@ func NewNode(task *Task) *Node {
 		"escapeParam": x.gen_escapeParam,
 		"option":      x.gen_option,
 		"ARGS":        x.gen_ARGS,
+		"state":       x.gen_state,
+		"SET_STATE":   x.gen_SET_STATE,
 		// math
 		"abs":       mathWrap("abs", math.Abs),
 		"acos":      mathWrap("acos", math.Acos),
@@ -101,6 +103,7 @@ func NewNode(task *Task) *Node {
 		"DROP":             x.gen_DROP,
 		"FILTER":           x.gen_FILTER,
 		"FILTER_CHANGED":   x.gen_FILTER_CHANGED,
+		"ASSERT":           x.gen_ASSERT,
 		"retain":           x.gen_retain,
 		"useFirstWithLast": x.gen_useFirstWithLast,
 		"FLATTEN":          x.gen_FLATTEN,
@@ -117,6 +120,11 @@ func NewNode(task *Task) *Node {
 		"MAP_LOWPASS":      x.gen_MAP_LOWPASS,
 		"MAP_KALMAN":       x.gen_MAP_KALMAN,
 		"model":            x.gen_model,
+		"MAP_ZSCORE":       x.gen_MAP_ZSCORE,
+		"MAP_EWMA":         x.gen_MAP_EWMA,
+		"MAP_MAD":          x.gen_MAP_MAD,
+		"MAP_CUSUM":        x.gen_MAP_CUSUM,
+		"MAP_SEASONAL":     x.gen_MAP_SEASONAL,
 		"MAP_DIFF":         x.gen_MAP_DIFF,
 		"MAP_ABSDIFF":      x.gen_MAP_ABSDIFF,
 		"MAP_NONEGDIFF":    x.gen_MAP_NONEGDIFF,
@@ -139,6 +147,15 @@ func NewNode(task *Task) *Node {
 		"args":      x.gen_args,
 		"WHEN":      x.gen_WHEN,
 		"THROTTLE":  x.gen_THROTTLE,
+		// pipelines
+		"PIPE":      x.gen_PIPE,
+		"JOIN":      x.gen_JOIN,
+		"MERGE":     x.gen_MERGE,
+		"UNION":     x.gen_UNION,
+		"on":        x.gen_on,
+		"tolerance": x.gen_tolerance,
+		"TEE":       x.gen_TEE,
+		"BRANCH":    x.gen_BRANCH,
 		// database source
 		"from":       x.gen_from,
 		"limit":      x.gen_limit,
@@ -153,17 +170,27 @@ func NewNode(task *Task) *Node {
 		"INSERT": x.gen_INSERT,
 		"APPEND": x.gen_APPEND,
 		// bridge
-		"bridge": x.gen_bridge,
+		"bridge":    x.gen_bridge,
+		"duration":  x.gen_duration,
+		"SUBSCRIBE": x.gen_SUBSCRIBE,
 		// fourier transform
-		"minHz": x.gen_minHz,
-		"maxHz": x.gen_maxHz,
-		"FFT":   x.gen_FFT,
+		"minHz":        x.gen_minHz,
+		"maxHz":        x.gen_maxHz,
+		"FFT":          x.gen_FFT,
+		"SPECTROGRAM":  x.gen_SPECTROGRAM,
+		"PSD":          x.gen_PSD,
+		"ENVELOPE":     x.gen_ENVELOPE,
+		"PEAKS":        x.gen_PEAKS,
+		"MAP_HIGHPASS": x.gen_MAP_HIGHPASS,
+		"MAP_BANDPASS": x.gen_MAP_BANDPASS,
 		// encoder
 		"cache":           x.gen_cache,
 		"CSV":             x.gen_CSV,
 		"JSON":            x.gen_JSON,
 		"NDJSON":          x.gen_NDJSON,
 		"MARKDOWN":        x.gen_MARKDOWN,
+		"ARROW":           x.gen_ARROW,
+		"PARQUET":         x.gen_PARQUET,
 		"HTML":            x.gen_HTML,
 		"TEXT":            x.gen_TEXT,
 		"BOX":             x.gen_BOX,
@@ -230,6 +257,8 @@ func NewNode(task *Task) *Node {
 		"GROUP":                x.gen_GROUP,
 		"by":                   x.gen_by,
 		"timewindow":           x.gen_timewindow,
+		"slidingwindow":        x.gen_slidingwindow,
+		"sessionwindow":        x.gen_sessionwindow,
 		"where":                x.gen_where,
 		"predict":              x.gen_predict,
 		"weight":               x.gen_weight,
@@ -457,6 +486,46 @@ func (x *Node) gen_ARGS(args ...any) (any, error) {
 	return x.fmArgs()
 }
 
+// gen_state
+//
+// syntax: state(string, ...interface {})
+func (x *Node) gen_state(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("state", 1, len(args))
+	}
+	p0, err := convString(args, 0, "state", "string")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "state", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmState(p0, p1...)
+}
+
+// gen_SET_STATE
+//
+// syntax: SET_STATE(string, interface {})
+func (x *Node) gen_SET_STATE(args ...any) (any, error) {
+	if len(args) != 2 {
+		return nil, ErrInvalidNumOfArgs("SET_STATE", 2, len(args))
+	}
+	p0, err := convString(args, 0, "SET_STATE", "string")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "SET_STATE", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	return x.fmSetState(p0, p1)
+}
+
 // gen_latlon
 //
 // syntax: latlon(float64, float64)
@@ -1252,6 +1321,24 @@ func (x *Node) gen_FILTER_CHANGED(args ...any) (any, error) {
 	return ret, nil
 }
 
+// gen_ASSERT
+//
+// syntax: ASSERT(bool, string)
+func (x *Node) gen_ASSERT(args ...any) (any, error) {
+	if len(args) != 2 {
+		return nil, ErrInvalidNumOfArgs("ASSERT", 2, len(args))
+	}
+	p0, err := convBool(args, 0, "ASSERT", "bool")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convString(args, 1, "ASSERT", "string")
+	if err != nil {
+		return nil, err
+	}
+	return x.fmAssert(p0, p1)
+}
+
 // gen_retain
 //
 // syntax: retain(, )
@@ -1564,6 +1651,184 @@ func (x *Node) gen_model(args ...any) (any, error) {
 	return x.fmKalmanModel(p0...)
 }
 
+// gen_MAP_ZSCORE
+//
+// syntax: MAP_ZSCORE(int, , int, float64, ...interface {})
+func (x *Node) gen_MAP_ZSCORE(args ...any) (any, error) {
+	if len(args) < 4 {
+		return nil, ErrInvalidNumOfArgs("MAP_ZSCORE", 4, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_ZSCORE", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_ZSCORE", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convInt(args, 2, "MAP_ZSCORE", "int")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convFloat64(args, 3, "MAP_ZSCORE", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p4 := []interface{}{}
+	for n := 4; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_ZSCORE", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p4 = append(p4, argv)
+	}
+	return x.fmMapZScore(p0, p1, p2, p3, p4...)
+}
+
+// gen_MAP_EWMA
+//
+// syntax: MAP_EWMA(int, , float64, float64, ...interface {})
+func (x *Node) gen_MAP_EWMA(args ...any) (any, error) {
+	if len(args) < 4 {
+		return nil, ErrInvalidNumOfArgs("MAP_EWMA", 4, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_EWMA", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_EWMA", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convFloat64(args, 2, "MAP_EWMA", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convFloat64(args, 3, "MAP_EWMA", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p4 := []interface{}{}
+	for n := 4; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_EWMA", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p4 = append(p4, argv)
+	}
+	return x.fmMapEWMA(p0, p1, p2, p3, p4...)
+}
+
+// gen_MAP_MAD
+//
+// syntax: MAP_MAD(int, , int, float64, ...interface {})
+func (x *Node) gen_MAP_MAD(args ...any) (any, error) {
+	if len(args) < 4 {
+		return nil, ErrInvalidNumOfArgs("MAP_MAD", 4, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_MAD", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_MAD", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convInt(args, 2, "MAP_MAD", "int")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convFloat64(args, 3, "MAP_MAD", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p4 := []interface{}{}
+	for n := 4; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_MAD", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p4 = append(p4, argv)
+	}
+	return x.fmMapMAD(p0, p1, p2, p3, p4...)
+}
+
+// gen_MAP_CUSUM
+//
+// syntax: MAP_CUSUM(int, , int, float64, float64, ...interface {})
+func (x *Node) gen_MAP_CUSUM(args ...any) (any, error) {
+	if len(args) < 5 {
+		return nil, ErrInvalidNumOfArgs("MAP_CUSUM", 5, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_CUSUM", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_CUSUM", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convInt(args, 2, "MAP_CUSUM", "int")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convFloat64(args, 3, "MAP_CUSUM", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p4, err := convFloat64(args, 4, "MAP_CUSUM", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p5 := []interface{}{}
+	for n := 5; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_CUSUM", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p5 = append(p5, argv)
+	}
+	return x.fmMapCUSUM(p0, p1, p2, p3, p4, p5...)
+}
+
+// gen_MAP_SEASONAL
+//
+// syntax: MAP_SEASONAL(int, , int, int, float64, ...interface {})
+func (x *Node) gen_MAP_SEASONAL(args ...any) (any, error) {
+	if len(args) < 5 {
+		return nil, ErrInvalidNumOfArgs("MAP_SEASONAL", 5, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_SEASONAL", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_SEASONAL", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convInt(args, 2, "MAP_SEASONAL", "int")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convInt(args, 3, "MAP_SEASONAL", "int")
+	if err != nil {
+		return nil, err
+	}
+	p4, err := convFloat64(args, 4, "MAP_SEASONAL", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p5 := []interface{}{}
+	for n := 5; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_SEASONAL", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p5 = append(p5, argv)
+	}
+	return x.fmMapSeasonal(p0, p1, p2, p3, p4, p5...)
+}
+
 // gen_MAP_DIFF
 //
 // syntax: MAP_DIFF(int, , ...interface {})
@@ -1976,6 +2241,153 @@ func (x *Node) gen_THROTTLE(args ...any) (any, error) {
 	return ret, nil
 }
 
+// gen_PIPE
+//
+// syntax: PIPE(string)
+func (x *Node) gen_PIPE(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, ErrInvalidNumOfArgs("PIPE", 1, len(args))
+	}
+	p0, err := convString(args, 0, "PIPE", "string")
+	if err != nil {
+		return nil, err
+	}
+	ret := x.fmPipe(p0)
+	return ret, nil
+}
+
+// gen_JOIN
+//
+// syntax: JOIN(string, ...interface {})
+func (x *Node) gen_JOIN(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("JOIN", 1, len(args))
+	}
+	p0, err := convString(args, 0, "JOIN", "string")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "JOIN", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmJoin(p0, p1...)
+}
+
+// gen_MERGE
+//
+// syntax: MERGE(string, ...interface {})
+func (x *Node) gen_MERGE(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("MERGE", 1, len(args))
+	}
+	p0, err := convString(args, 0, "MERGE", "string")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "MERGE", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmMerge(p0, p1...)
+}
+
+// gen_UNION
+//
+// syntax: UNION(string, ...interface {})
+func (x *Node) gen_UNION(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("UNION", 1, len(args))
+	}
+	p0, err := convString(args, 0, "UNION", "string")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "UNION", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmUnion(p0, p1...)
+}
+
+// gen_on
+//
+// syntax: on(...int)
+func (x *Node) gen_on(args ...any) (any, error) {
+	p0 := []int{}
+	for n := 0; n < len(args); n++ {
+		argv, err := convInt(args, n, "on", "...int")
+		if err != nil {
+			return nil, err
+		}
+		p0 = append(p0, argv)
+	}
+	return x.fmOn(p0...)
+}
+
+// gen_tolerance
+//
+// syntax: tolerance(interface {})
+func (x *Node) gen_tolerance(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, ErrInvalidNumOfArgs("tolerance", 1, len(args))
+	}
+	p0, err := convAny(args, 0, "tolerance", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	return x.fmTolerance(p0)
+}
+
+// gen_TEE
+//
+// syntax: TEE(...string)
+func (x *Node) gen_TEE(args ...any) (any, error) {
+	p0 := []string{}
+	for n := 0; n < len(args); n++ {
+		argv, err := convString(args, n, "TEE", "...string")
+		if err != nil {
+			return nil, err
+		}
+		p0 = append(p0, argv)
+	}
+	return x.fmTee(p0...)
+}
+
+// gen_BRANCH
+//
+// syntax: BRANCH(string, ...interface {})
+func (x *Node) gen_BRANCH(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("BRANCH", 1, len(args))
+	}
+	p0, err := convString(args, 0, "BRANCH", "string")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "BRANCH", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmBranch(p0, p1...)
+}
+
 // gen_from
 //
 // syntax: from(string, string, ...string)
@@ -2189,6 +2601,35 @@ func (x *Node) gen_bridge(args ...any) (any, error) {
 	return ret, nil
 }
 
+// gen_duration
+//
+// syntax: duration()
+func (x *Node) gen_duration(args ...any) (any, error) {
+	if len(args) != 1 {
+		return nil, ErrInvalidNumOfArgs("duration", 1, len(args))
+	}
+	p0, err := convAny(args, 0, "duration", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	return x.fmDuration(p0)
+}
+
+// gen_SUBSCRIBE
+//
+// syntax: SUBSCRIBE(...interface {})
+func (x *Node) gen_SUBSCRIBE(args ...any) (any, error) {
+	p0 := []interface{}{}
+	for n := 0; n < len(args); n++ {
+		argv, err := convAny(args, n, "SUBSCRIBE", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p0 = append(p0, argv)
+	}
+	return x.fmSubscribe(p0...)
+}
+
 // gen_minHz
 //
 // syntax: minHz(float64)
@@ -2234,6 +2675,158 @@ func (x *Node) gen_FFT(args ...any) (any, error) {
 	return x.fmFastFourierTransform(p0...)
 }
 
+// gen_SPECTROGRAM
+//
+// syntax: SPECTROGRAM(int, int, ...interface {})
+func (x *Node) gen_SPECTROGRAM(args ...any) (any, error) {
+	if len(args) < 2 {
+		return nil, ErrInvalidNumOfArgs("SPECTROGRAM", 2, len(args))
+	}
+	p0, err := convInt(args, 0, "SPECTROGRAM", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convInt(args, 1, "SPECTROGRAM", "int")
+	if err != nil {
+		return nil, err
+	}
+	p2 := []interface{}{}
+	for n := 2; n < len(args); n++ {
+		argv, err := convAny(args, n, "SPECTROGRAM", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p2 = append(p2, argv)
+	}
+	return x.fmSpectrogram(p0, p1, p2...)
+}
+
+// gen_PSD
+//
+// syntax: PSD(int, ...interface {})
+func (x *Node) gen_PSD(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("PSD", 1, len(args))
+	}
+	p0, err := convInt(args, 0, "PSD", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "PSD", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmPSD(p0, p1...)
+}
+
+// gen_ENVELOPE
+//
+// syntax: ENVELOPE()
+func (x *Node) gen_ENVELOPE(args ...any) (any, error) {
+	if len(args) != 0 {
+		return nil, ErrInvalidNumOfArgs("ENVELOPE", 0, len(args))
+	}
+	return x.fmEnvelope()
+}
+
+// gen_PEAKS
+//
+// syntax: PEAKS(float64, ...interface {})
+func (x *Node) gen_PEAKS(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("PEAKS", 1, len(args))
+	}
+	p0, err := convFloat64(args, 0, "PEAKS", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "PEAKS", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmPeaks(p0, p1...)
+}
+
+// gen_MAP_HIGHPASS
+//
+// syntax: MAP_HIGHPASS(int, , float64, float64, ...interface {})
+func (x *Node) gen_MAP_HIGHPASS(args ...any) (any, error) {
+	if len(args) < 4 {
+		return nil, ErrInvalidNumOfArgs("MAP_HIGHPASS", 4, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_HIGHPASS", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_HIGHPASS", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convFloat64(args, 2, "MAP_HIGHPASS", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convFloat64(args, 3, "MAP_HIGHPASS", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p4 := []interface{}{}
+	for n := 4; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_HIGHPASS", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p4 = append(p4, argv)
+	}
+	return x.fmMapHighPass(p0, p1, p2, p3, p4...)
+}
+
+// gen_MAP_BANDPASS
+//
+// syntax: MAP_BANDPASS(int, , float64, float64, float64, ...interface {})
+func (x *Node) gen_MAP_BANDPASS(args ...any) (any, error) {
+	if len(args) < 5 {
+		return nil, ErrInvalidNumOfArgs("MAP_BANDPASS", 5, len(args))
+	}
+	p0, err := convInt(args, 0, "MAP_BANDPASS", "int")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "MAP_BANDPASS", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p2, err := convFloat64(args, 2, "MAP_BANDPASS", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p3, err := convFloat64(args, 3, "MAP_BANDPASS", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p4, err := convFloat64(args, 4, "MAP_BANDPASS", "float64")
+	if err != nil {
+		return nil, err
+	}
+	p5 := []interface{}{}
+	for n := 5; n < len(args); n++ {
+		argv, err := convAny(args, n, "MAP_BANDPASS", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p5 = append(p5, argv)
+	}
+	return x.fmMapBandPass(p0, p1, p2, p3, p4, p5...)
+}
+
 // gen_cache
 //
 // syntax: cache(string, string, ...float64)
@@ -2320,6 +2913,36 @@ func (x *Node) gen_MARKDOWN(args ...any) (any, error) {
 	return x.fmMarkdown(p0...)
 }
 
+// gen_ARROW
+//
+// syntax: ARROW(...interface {})
+func (x *Node) gen_ARROW(args ...any) (any, error) {
+	p0 := []interface{}{}
+	for n := 0; n < len(args); n++ {
+		argv, err := convAny(args, n, "ARROW", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p0 = append(p0, argv)
+	}
+	return x.fmArrow(p0...)
+}
+
+// gen_PARQUET
+//
+// syntax: PARQUET(...interface {})
+func (x *Node) gen_PARQUET(args ...any) (any, error) {
+	p0 := []interface{}{}
+	for n := 0; n < len(args); n++ {
+		argv, err := convAny(args, n, "PARQUET", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p0 = append(p0, argv)
+	}
+	return x.fmParquet(p0...)
+}
+
 // gen_HTML
 //
 // syntax: HTML(...interface {})
@@ -3396,6 +4019,46 @@ func (x *Node) gen_timewindow(args ...any) (any, error) {
 	return x.fmByTimeWindow(p0, p1, p2)
 }
 
+// gen_slidingwindow
+//
+// syntax: slidingwindow(, )
+func (x *Node) gen_slidingwindow(args ...any) (any, error) {
+	if len(args) != 2 {
+		return nil, ErrInvalidNumOfArgs("slidingwindow", 2, len(args))
+	}
+	p0, err := convAny(args, 0, "slidingwindow", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p1, err := convAny(args, 1, "slidingwindow", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	return x.fmBySlidingWindow(p0, p1)
+}
+
+// gen_sessionwindow
+//
+// syntax: sessionwindow(, ...interface {})
+func (x *Node) gen_sessionwindow(args ...any) (any, error) {
+	if len(args) < 1 {
+		return nil, ErrInvalidNumOfArgs("sessionwindow", 1, len(args))
+	}
+	p0, err := convAny(args, 0, "sessionwindow", "interface {}")
+	if err != nil {
+		return nil, err
+	}
+	p1 := []interface{}{}
+	for n := 1; n < len(args); n++ {
+		argv, err := convAny(args, n, "sessionwindow", "...interface {}")
+		if err != nil {
+			return nil, err
+		}
+		p1 = append(p1, argv)
+	}
+	return x.fmBySessionWindow(p0, p1...)
+}
+
 // gen_where
 //
 // syntax: where(bool)
@@ -267,3 +267,57 @@ func TestCompileLogsAbsoluteParseErrorLocations(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateScriptStructurePipe(t *testing.T) {
+	script, err := ParseScript("FAKE(json({[1]}))\nPIPE('a')\nFAKE(json({[2]}))\nMERGE('a')\nCSV()", nil)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if err := ValidateScriptStructure(script); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	script, err = ParseScript("FAKE(json({[1]}))\nPIPE('a')", nil)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	err = ValidateScriptStructure(script)
+	var scriptErr *ScriptError
+	if !errors.As(err, &scriptErr) {
+		t.Fatalf("expected ScriptError, got %T", err)
+	}
+	if scriptErr.Kind != "no_sink" {
+		t.Fatalf("unexpected script error kind: %s", scriptErr.Kind)
+	}
+}
+
+func TestValidateScriptStructureBranch(t *testing.T) {
+	script, err := ParseScript("FAKE(json({[1]}))\nTEE('a')\nCSV()\n// branch\nBRANCH('a')\nJSON()", nil)
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if err := ValidateScriptStructure(script); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	for _, tc := range []struct {
+		source string
+		kind   string
+	}{
+		{"BRANCH('a')\nCSV()", "no_sink"},
+		{"FAKE(json({[1]}))\nCSV()\nBRANCH('a')\nPIPE('b')", "invalid_sink"},
+	} {
+		script, err := ParseScript(tc.source, nil)
+		if err != nil {
+			t.Fatalf("unexpected parse error: %v", err)
+		}
+		err = ValidateScriptStructure(script)
+		var scriptErr *ScriptError
+		if !errors.As(err, &scriptErr) {
+			t.Fatalf("expected ScriptError, got %T", err)
+		}
+		if scriptErr.Kind != tc.kind {
+			t.Fatalf("unexpected script error kind: %s", scriptErr.Kind)
+		}
+	}
+}
@@ -16,6 +16,29 @@ const (
 	StatementSourceOrMapOrSink
 )
 
+func (k StatementKind) String() string {
+	switch k {
+	case StatementComment:
+		return "comment"
+	case StatementPragma:
+		return "pragma"
+	case StatementSource:
+		return "source"
+	case StatementMap:
+		return "map"
+	case StatementSink:
+		return "sink"
+	case StatementSourceOrMap:
+		return "source_or_map"
+	case StatementSourceOrSink:
+		return "source_or_sink"
+	case StatementSourceOrMapOrSink:
+		return "source_or_map_or_sink"
+	default:
+		return "unknown"
+	}
+}
+
 type TQLScript struct {
 	Source     string
 	Statements []*Statement
@@ -17,6 +17,41 @@ func ValidateScriptStructure(script *TQLScript) error {
 	if len(codes) == 0 {
 		return newScriptError("no_source", nil, "no source exists", nil)
 	}
+	// the statements that end with PIPE('name') are the named sub-pipelines
+	// and the statements that begin with BRANCH('name') are the branches of TEE(),
+	// each of them should have the source and the sink the same as the main pipeline.
+	hasMain := false
+	for _, seg := range splitPipeSegments(codes) {
+		if len(seg) == 0 {
+			continue
+		}
+		head, tail := seg[0], seg[len(seg)-1]
+		switch {
+		case isPipeStatement(tail):
+			if len(seg) == 1 {
+				return newScriptError("no_source", tail, "no source exists for PIPE()", nil)
+			}
+			if isBranchStatement(head) {
+				return newScriptError("invalid_sink", tail, "PIPE() is not applicable for the SINK of BRANCH()", nil)
+			}
+		case isBranchStatement(head):
+			if !hasMain {
+				return newScriptError("no_sink", head, "no sink exists, the main pipeline should be before BRANCH()", nil)
+			}
+		default:
+			hasMain = true
+		}
+		if err := validateSegment(seg); err != nil {
+			return err
+		}
+	}
+	if !hasMain {
+		return newScriptError("no_sink", codes[len(codes)-1], "no sink exists, PIPE() is not applicable for the SINK of the script", nil)
+	}
+	return nil
+}
+
+func validateSegment(codes []*Statement) error {
 	if len(codes) == 1 {
 		return newScriptError("no_sink", codes[0], "no sink exists", nil)
 	}
@@ -40,6 +75,14 @@ func ValidateScriptStructure(script *TQLScript) error {
 	return nil
 }
 
+func isPipeStatement(stmt *Statement) bool {
+	return stmt.Name == "PIPE()"
+}
+
+func isBranchStatement(stmt *Statement) bool {
+	return stmt.Name == "BRANCH()"
+}
+
 func isApplicableForSource(kind StatementKind) bool {
 	switch kind {
 	case StatementSource, StatementSourceOrMap, StatementSourceOrSink, StatementSourceOrMapOrSink:
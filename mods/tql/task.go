@@ -28,6 +28,7 @@ import (
 
 const (
 	PRAGMA_LOG_LEVEL = "log-level"
+	PRAGMA_PROFILE   = "profile"
 )
 
 type Task struct {
@@ -53,8 +54,25 @@ type Task struct {
 
 	httpClientFactory func() *http.Client
 
+	// sqlStub answers SQL(), SQL_SELECT() and QUERY() instead of the database if set
+	sqlStub SqlStub
+
 	volatileAssetsProvider VolatileAssetsProvider
 
+	// collect the statistics of the nodes, see task_profile.go
+	// use `#pragma profile` in the tql script to enable it
+	profile bool
+	// the database sources describe the SQL statements instead of executing, see task_explain.go
+	explain bool
+
+	// checkpoint that is carried over to the next run, see task_state.go
+	state taskState
+
+	// #include and #define directives, see task_include.go
+	loader    Loader
+	defines   []*funcDefine
+	jsModules []*jsModule
+
 	// compiled result
 	sourcePath string
 	sourceHash string
@@ -63,12 +81,21 @@ type Task struct {
 	output     *output
 	nodes      []*Node
 
+	// named sub-pipelines, see task_pipe.go
+	parent   *Task   // the main task if it is the task of a sub-pipeline
+	pipes    []*pipe // sub-pipelines of the main task in the order of the definitions
+	sinkPipe *pipe   // the sink if it is the task of a sub-pipeline
+
+	// branches of TEE(), see task_branch.go
+	branches []*branch
+
 	// preemptive cache update
 	_preemptiveCacheUpdateStarted bool
 	_preemptiveCacheUpdateTimeout time.Duration
 
 	_shouldStop          bool
 	_shouldStopListeners []func()
+	_streaming           bool
 
 	_resultColumns client.Columns
 	_stateLock     sync.RWMutex
@@ -108,6 +135,14 @@ func (x *Task) SetHttpClientFactory(factory func() *http.Client) {
 	x.httpClientFactory = factory
 }
 
+// SqlStub returns the columns and the rows of the sql statement,
+// it replaces the database to run the scripts against the fixtures.
+type SqlStub func(sqlText string, params []any) (client.Columns, [][]any, error)
+
+func (x *Task) SetSqlStub(stub SqlStub) {
+	x.sqlStub = stub
+}
+
 func (x *Task) SetInputReader(r io.Reader) {
 	x.inputReader = r
 }
@@ -220,7 +255,17 @@ func (x *Task) Compile(codeReader io.Reader) error {
 }
 
 func (x *Task) compile(codeReader io.Reader) error {
-	script, err := ParseScriptReader(codeReader, functions)
+	code, err := io.ReadAll(codeReader)
+	if err != nil {
+		x.compileErr = err
+		return err
+	}
+	fns, err := x.preprocess(code)
+	if err != nil {
+		x.compileErr = err
+		return err
+	}
+	script, err := ParseScriptReader(bytes.NewReader(code), fns)
 	if err != nil {
 		x.compileErr = err
 		return err
@@ -234,17 +279,88 @@ func (x *Task) compile(codeReader io.Reader) error {
 		return err
 	}
 
+	// the statements that end with PIPE('name') are the named sub-pipelines,
+	// the statements that begin with BRANCH('name') are the branches of TEE(),
+	// and the others are the main pipeline.
+	for _, seg := range splitPipeSegments(script.Statements) {
+		head, tail := firstCode(seg), lastCode(seg)
+		var err error
+		switch {
+		case head == nil:
+			continue
+		case isPipeStatement(tail):
+			err = x.compilePipe(seg)
+		case isBranchStatement(head):
+			err = x.compileBranch(seg)
+		default:
+			err = x.compileStatements(seg)
+		}
+		if err != nil {
+			x.compileErr = err
+			return err
+		}
+	}
+	if x.output == nil {
+		x.compileErr = errors.New("no sink exists")
+		return x.compileErr
+	}
+	x.compiled = true
+	return nil
+}
+
+// splitPipeSegments splits the statements after PIPE() and before BRANCH() statements.
+func splitPipeSegments(stmts []*Statement) [][]*Statement {
+	ret := [][]*Statement{}
+	var seg []*Statement
+	for _, stmt := range stmts {
+		if stmt.IsCode() && isBranchStatement(stmt) {
+			// the comments and pragmas right before BRANCH() belong to the branch
+			n := len(seg)
+			for n > 0 && !seg[n-1].IsCode() {
+				n--
+			}
+			ret = append(ret, seg[:n])
+			seg = append([]*Statement{}, seg[n:]...)
+		}
+		seg = append(seg, stmt)
+		if stmt.IsCode() && isPipeStatement(stmt) {
+			ret = append(ret, seg)
+			seg = nil
+		}
+	}
+	return append(ret, seg)
+}
+
+func firstCode(stmts []*Statement) *Statement {
+	for _, stmt := range stmts {
+		if stmt.IsCode() {
+			return stmt
+		}
+	}
+	return nil
+}
+
+func lastCode(stmts []*Statement) *Statement {
+	for i := len(stmts) - 1; i >= 0; i-- {
+		if stmts[i].IsCode() {
+			return stmts[i]
+		}
+	}
+	return nil
+}
+
+func (x *Task) compileStatements(stmts []*Statement) error {
 	nodeIdx := 0
 	var pragmas map[string]string
 	var codes []*Statement
-	for _, stmt := range script.Statements {
+	for _, stmt := range stmts {
 		if stmt.IsCode() {
 			codes = append(codes, stmt)
 		}
 	}
 	tailStmt := codes[len(codes)-1]
 
-	for _, stmt := range script.Statements {
+	for _, stmt := range stmts {
 		curLine := stmt.toLine()
 		if stmt.IsPragma {
 			kvs := util.ParseNameValuePairs(stmt.Text)
@@ -253,6 +369,13 @@ func (x *Task) compile(codeReader io.Reader) error {
 				case PRAGMA_LOG_LEVEL:
 					x.SetLogLevel(ParseLogLevel(kv.Value))
 					continue
+				case PRAGMA_PROFILE:
+					owner := x
+					if x.parent != nil {
+						owner = x.parent
+					}
+					owner.profile = kv.Value == "" || strings.ToLower(kv.Value) == "true"
+					continue
 				default:
 					if pragmas == nil {
 						pragmas = map[string]string{}
@@ -265,8 +388,20 @@ func (x *Task) compile(codeReader io.Reader) error {
 		if stmt.IsComment {
 			continue
 		}
-		if stmt == tailStmt {
+		if stmt == tailStmt && isPipeStatement(stmt) {
+			// sink of the sub-pipeline
+			p, err := x.compilePipeSink(stmt.Text)
+			if err != nil {
+				x.compileErr = wrapCompileStatementError("pipe_compile_error", stmt, curLine, err)
+				return x.compileErr
+			}
+			x.sinkPipe = p
+			if nodeIdx > 0 {
+				x.nodes[nodeIdx-1].next = p
+			}
+		} else if stmt == tailStmt {
 			// sink
+			var err error
 			x.output, err = NewNode(x).compileSink(curLine)
 			if err != nil {
 				x.compileErr = wrapCompileStatementError("sink_compile_error", stmt, curLine, err)
@@ -289,6 +424,9 @@ func (x *Task) compile(codeReader io.Reader) error {
 			}
 			node.pragma = pragmas
 			node.tqlLine = curLine
+			if isPipeCombinator(node.name) {
+				node.SetEOF(pipeCombinatorEOF)
+			}
 			x.nodes = append(x.nodes, node)
 			if nodeIdx > 0 {
 				x.nodes[nodeIdx-1].next = x.nodes[nodeIdx]
@@ -297,12 +435,6 @@ func (x *Task) compile(codeReader io.Reader) error {
 		}
 		pragmas = nil
 	}
-
-	if x.output == nil {
-		x.compileErr = errors.New("no sink exists")
-		return x.compileErr
-	}
-	x.compiled = true
 	return nil
 }
 
@@ -330,6 +462,7 @@ type Result struct {
 	Err      error
 	Message  string
 	IsDbSink bool
+	Rows     int64 // number of the records written to the sink
 	_created time.Time
 }
 
@@ -370,6 +503,9 @@ func (x *Task) Execute() *Result {
 	} else {
 		x.LogDebug("Task elapsed", time.Since(x._created).String())
 	}
+	if x.profile {
+		x.logProfile()
+	}
 	return result
 }
 
@@ -415,10 +551,18 @@ func (x *Task) execute() *Result {
 	x.executeOutput()
 
 	if x.output != nil {
+		err := x.output.lastError
+		for _, b := range x.branches {
+			if err != nil {
+				break
+			}
+			err = b.err()
+		}
 		return &Result{
-			Err:      x.output.lastError,
+			Err:      err,
 			Message:  x.output.lastMessage,
 			IsDbSink: x.output.dbSink != nil,
+			Rows:     x.output.rows,
 			_created: x._created,
 		}
 	}
@@ -429,10 +573,20 @@ func (x *Task) execute() *Result {
 }
 
 func (x *Task) executeOutput() {
+	if x.profile && x.parent == nil {
+		x.instrument("main")
+	}
 	// start output
 	if x.output != nil {
 		x.output.start()
 	}
+	// start branches and sub-pipelines
+	for _, b := range x.branches {
+		b.start()
+	}
+	for _, p := range x.pipes {
+		p.start()
+	}
 	// start nodes
 	for _, child := range x.nodes {
 		child.start()
@@ -444,6 +598,15 @@ func (x *Task) executeOutput() {
 	for _, child := range x.nodes {
 		child.stop()
 	}
+	// the records of the sub-pipelines those are not consumed are discarded
+	for _, p := range x.pipes {
+		p.stop()
+	}
+	// the branches are closed in the order of the definitions,
+	// since a branch can feed the branches those are defined after itself.
+	for _, b := range x.branches {
+		b.stop()
+	}
 	if x.output != nil {
 		x.output.stop()
 	}
@@ -495,6 +658,21 @@ func (x *Task) shouldStop() bool {
 	return ret
 }
 
+// setStreaming makes the sink flush every record, it is called by the sources
+// those yield the records of the unbounded streams, e.g. SUBSCRIBE().
+func (x *Task) setStreaming() {
+	x._stateLock.Lock()
+	x._streaming = true
+	x._stateLock.Unlock()
+}
+
+func (x *Task) isStreaming() bool {
+	x._stateLock.RLock()
+	ret := x._streaming
+	x._stateLock.RUnlock()
+	return ret
+}
+
 func (x *Task) SetResultColumns(cols client.Columns) {
 	x._stateLock.Lock()
 	ts := make([]*client.Column, len(cols))
@@ -0,0 +1,213 @@
+package tql
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+)
+
+// branch is a chain of statements that begins with BRANCH('name'),
+// TEE('name') of the main pipeline duplicates the records to the branch.
+//
+// Each branch has its own task and sink, so that a script can write the same records
+// into several destinations without reading the source again.
+// The output of the branch sink is discarded unless BRANCH('name', file('/path')) is specified,
+// the sink of the main pipeline is always the output of the script (e.g. HTTP response).
+type branch struct {
+	name string
+	task *Task
+	file *branchFile
+	ch   chan *Record
+
+	done      chan struct{}
+	doneOnce  sync.Once
+	closeOnce sync.Once
+	claimed   atomic.Bool
+	colsOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+var _ Receiver = (*branch)(nil)
+
+func (b *branch) Name() string {
+	return "BRANCH()"
+}
+
+// Receive passes the record from TEE() to the branch,
+// the record is dropped if the branch has been finished.
+func (b *branch) Receive(rec *Record) {
+	select {
+	case b.ch <- rec:
+	case <-b.done:
+	case <-b.task.ctx.Done():
+	}
+}
+
+// claim marks the branch as fed by TEE(), a branch can be fed only once.
+func (b *branch) claim() error {
+	if !b.claimed.CompareAndSwap(false, true) {
+		return fmt.Errorf("branch '%s' is already fed by TEE()", b.name)
+	}
+	return nil
+}
+
+func (b *branch) start() {
+	var target Receiver = b.task.output
+	if len(b.task.nodes) > 0 {
+		target = b.task.nodes[0]
+	}
+	b.wg.Add(1)
+	go func() {
+		defer func() {
+			b.doneOnce.Do(func() { close(b.done) })
+			b.wg.Done()
+		}()
+		b.task.output.start()
+		for _, child := range b.task.nodes {
+			child.start()
+		}
+	loop:
+		for !b.task.shouldStop() {
+			select {
+			case rec, ok := <-b.ch:
+				if !ok {
+					break loop
+				}
+				rec.Tell(target)
+			case <-b.task.ctx.Done():
+				break loop
+			}
+		}
+		EofRecord.Tell(target)
+		for _, child := range b.task.nodes {
+			child.stop()
+		}
+		b.task.output.stop()
+		if b.file != nil {
+			if err := b.file.Close(); err != nil && b.task.output.lastError == nil {
+				b.task.output.lastError = err
+			}
+		}
+	}()
+}
+
+// stop closes the branch and waits until the sink of the branch is finished.
+func (b *branch) stop() {
+	b.closeOnce.Do(func() { close(b.ch) })
+	b.wg.Wait()
+	b.task.ctxCancel()
+}
+
+// err returns the error of the branch sink.
+func (b *branch) err() error {
+	if b.task.output == nil || b.task.output.lastError == nil {
+		return nil
+	}
+	return fmt.Errorf("BRANCH('%s') %s", b.name, b.task.output.lastError.Error())
+}
+
+// branchFile is the output of the branch sink that creates the file on the first write,
+// so that the file is not truncated if the script fails to compile.
+type branchFile struct {
+	path string
+	f    *os.File
+}
+
+var _ io.WriteCloser = (*branchFile)(nil)
+
+func (bf *branchFile) Write(p []byte) (int, error) {
+	if bf.f == nil {
+		if err := os.MkdirAll(filepath.Dir(bf.path), 0755); err != nil {
+			return 0, err
+		}
+		f, err := os.Create(bf.path)
+		if err != nil {
+			return 0, err
+		}
+		bf.f = f
+	}
+	return bf.f.Write(p)
+}
+
+func (bf *branchFile) Close() error {
+	if bf.f == nil {
+		return nil
+	}
+	err := bf.f.Close()
+	bf.f = nil
+	return err
+}
+
+// compileBranch compiles the statements of a branch that begins with BRANCH('name').
+func (x *Task) compileBranch(stmts []*Statement) error {
+	head := firstCode(stmts)
+	child := x.newPipeTask()
+	node := NewNode(child)
+	if err := node.compile(head.Text); err != nil {
+		return wrapCompileStatementError("branch_compile_error", head, head.toLine(), err)
+	}
+	ret, err := node.expr.Eval(node)
+	if err != nil {
+		return wrapCompileStatementError("branch_compile_error", head, head.toLine(), err)
+	}
+	name, ok := ret.(*branchName)
+	if !ok || name.name == "" {
+		return wrapCompileStatementError("branch_compile_error", head, head.toLine(),
+			fmt.Errorf("BRANCH() requires the name of the branch"))
+	}
+	for _, b := range x.branches {
+		if b.name == name.name {
+			return wrapCompileStatementError("branch_compile_error", head, head.toLine(),
+				fmt.Errorf("branch '%s' is already defined", b.name))
+		}
+	}
+	b := &branch{
+		name: name.name,
+		task: child,
+		ch:   make(chan *Record),
+		done: make(chan struct{}),
+	}
+	if name.file != "" {
+		b.file = &branchFile{path: name.file}
+		child.SetOutputWriter(b.file)
+	} else {
+		child.SetOutputWriter(io.Discard)
+	}
+
+	rest := make([]*Statement, 0, len(stmts)-1)
+	for _, stmt := range stmts {
+		if stmt != head {
+			rest = append(rest, stmt)
+		}
+	}
+	if err := child.compileStatements(rest); err != nil {
+		return err
+	}
+	x.branches = append(x.branches, b)
+	return nil
+}
+
+// lookupBranch returns the branch of the name.
+// A branch can feed the branches those are defined after itself only.
+func (x *Task) lookupBranch(name string) (*branch, error) {
+	owner := x
+	if x.parent != nil {
+		owner = x.parent
+	}
+	candidates := owner.branches
+	for i, b := range owner.branches {
+		if b.task == x {
+			candidates = owner.branches[i+1:]
+			break
+		}
+	}
+	for _, b := range candidates {
+		if b.name == name {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("branch '%s' is not defined", name)
+}
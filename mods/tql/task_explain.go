@@ -0,0 +1,97 @@
+package tql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// explainSqlKey is the node value that the database sources set
+// with the SQL statement instead of running it in the explain mode.
+const explainSqlKey = "explain-sql"
+
+// Explain describes the compiled nodes without executing the task,
+// the database sources show the SQL statements those would be executed.
+//
+//	[main]
+//	  #1   SQL_SELECT()     source      line 1
+//	       SQL: SELECT ...
+//	  #2   MAPVALUE()       map         line 2
+//	  #3   CSV()            sink        line 3
+func (x *Task) Explain() string {
+	sb := &strings.Builder{}
+	for _, p := range x.pipes {
+		p.task.explainTo(sb, fmt.Sprintf("pipe:%s", p.name), "")
+	}
+	x.explainTo(sb, "main", "")
+	for _, b := range x.branches {
+		b.task.explainTo(sb, fmt.Sprintf("branch:%s", b.name), fmt.Sprintf("BRANCH('%s')", b.name))
+	}
+	return sb.String()
+}
+
+func (x *Task) explainTo(sb *strings.Builder, pipeline string, source string) {
+	fmt.Fprintf(sb, "[%s]\n", pipeline)
+	seq := 0
+	line := func(name string, kind StatementKind, tqlLine *Line) {
+		seq++
+		str := fmt.Sprintf("  #%-3d %-16s %-11s", seq, name, kind.String())
+		if tqlLine != nil {
+			str = fmt.Sprintf("%s line %d", str, tqlLine.line)
+		}
+		sb.WriteString(strings.TrimRight(str, " "))
+		sb.WriteString("\n")
+	}
+	if source != "" {
+		line(source, StatementSource, nil)
+	}
+	for i, node := range x.nodes {
+		kind, _ := StatementKindByFunctionName(node.name)
+		line(node.name, kind, node.tqlLine)
+		if i == 0 && source == "" {
+			if sqlText, err := x.explainSql(node); err != nil {
+				fmt.Fprintf(sb, "       ERROR: %s\n", err.Error())
+			} else if sqlText != "" {
+				fmt.Fprintf(sb, "       SQL: %s\n", sqlText)
+			}
+		}
+	}
+	if x.sinkPipe != nil {
+		line(fmt.Sprintf("PIPE('%s')", x.sinkPipe.name), StatementSink, nil)
+	}
+	if x.output != nil {
+		kind, _ := StatementKindByFunctionName(x.output.name)
+		line(x.output.name, kind, x.output.tqlLine)
+		switch sink := x.output.dbSink.(type) {
+		case *sqlSink:
+			fmt.Fprintf(sb, "       SQL: %s\n", sink.sqlText)
+		case *insert:
+			fmt.Fprintf(sb, "       TABLE: %s\n", sink.table.Name)
+		case *appender:
+			fmt.Fprintf(sb, "       TABLE: %s\n", sink.table.Name)
+		}
+	}
+}
+
+// explainSql evaluates the database source in the explain mode,
+// it returns the SQL statement that the source would execute.
+func (x *Task) explainSql(node *Node) (string, error) {
+	switch node.name {
+	case "SQL()", "SQL_SELECT()", "QUERY()":
+	default:
+		return "", nil
+	}
+	x.explain = true
+	defer func() {
+		x.explain = false
+		node.SetInflight(nil)
+		node.DeleteValue(explainSqlKey)
+	}()
+	node.SetInflight(NewRecord("", nil))
+	if _, err := node.expr.Eval(node); err != nil {
+		return "", err
+	}
+	if v, ok := node.GetValue(explainSqlKey); ok {
+		return fmt.Sprintf("%v", v), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,291 @@
+package tql
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/machbase/neo-server/v8/mods/tql/expression"
+)
+
+const (
+	DIRECTIVE_INCLUDE = "#include"
+	DIRECTIVE_DEFINE  = "#define"
+)
+
+// maximum depth of the nested #include directives
+const maxIncludeDepth = 16
+
+// funcDefine is a user-defined function that is declared by `#define name(a, b) expression`.
+//
+// The body is an expression of the tql functions and the parameters,
+// e.g. `#define celsius(f) (f - 32) * 5 / 9` makes `MAPVALUE(1, celsius(value(1)))` available.
+// A function can call the functions those are defined before itself only.
+type funcDefine struct {
+	name   string
+	params []string
+	body   string
+	origin string // where the function is defined, e.g. "lib/common.tql:3"
+}
+
+// jsModule is a javascript file that is declared by `#include "path.js"`,
+// SCRIPT() runs the modules before its init code.
+type jsModule struct {
+	path string
+	code string
+}
+
+var (
+	includeRegexp = regexp.MustCompile(`^#include\s+(?:"([^"]+)"|'([^']+)')\s*$`)
+	defineRegexp  = regexp.MustCompile(`^#define\s+([A-Za-z_][A-Za-z0-9_]*)\s*\(([^)]*)\)\s*(.*)$`)
+	identRegexp   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// SetLoader sets the loader that resolves the #include directives,
+// the default is the loader of the server file system.
+func (x *Task) SetLoader(ldr Loader) {
+	x.loader = ldr
+}
+
+// preprocess collects the #include and #define directives of the script,
+// it returns the functions that the statements of the script are parsed with.
+func (x *Task) preprocess(code []byte) (map[string]expression.Function, error) {
+	pp := &preprocessor{task: x, included: map[string]bool{}}
+	if err := pp.scan("", code, 0); err != nil {
+		return nil, err
+	}
+	x.defines = pp.defines
+	x.jsModules = pp.jsModules
+	if len(x.defines) == 0 {
+		return functions, nil
+	}
+	return pp.functions(len(pp.defines)), nil
+}
+
+type preprocessor struct {
+	task      *Task
+	included  map[string]bool
+	stack     []string
+	defines   []*funcDefine
+	jsModules []*jsModule
+}
+
+// functions returns the builtin functions and the first n user-defined functions,
+// those are placeholders for parsing, the actual functions are bound to each node.
+func (pp *preprocessor) functions(n int) map[string]expression.Function {
+	ret := maps.Clone(functions)
+	for _, def := range pp.defines[:n] {
+		ret[def.name] = func(args ...any) (any, error) { return nil, nil }
+	}
+	return ret
+}
+
+// scan collects the directives of the code. The lines are classified as scanLines does,
+// a line that starts with '#' outside of a multi-line statement is a comment,
+// and it is a directive only if the name is followed by a space, e.g. "#defines" is a comment.
+func (pp *preprocessor) scan(file string, code []byte, depth int) error {
+	origin := func(lineNo int) string {
+		if file == "" {
+			return fmt.Sprintf("line %d", lineNo)
+		}
+		return fmt.Sprintf("%s:%d", file, lineNo)
+	}
+	fns := pp.functions(len(pp.defines))
+	stmt := []string{}
+	for i, line := range strings.Split(string(code), "\n") {
+		lineNo := i + 1
+		text := strings.TrimSpace(line)
+		if len(stmt) > 0 {
+			// the lines of a multi-line statement, e.g. the code of SCRIPT()
+			stmt = append(stmt, line)
+			if text != "" && !isUnbalanced(stmt, fns) {
+				stmt = stmt[:0]
+			}
+			continue
+		}
+		switch {
+		case text == "":
+		case isDirective(text, DIRECTIVE_INCLUDE):
+			m := includeRegexp.FindStringSubmatch(text)
+			if m == nil {
+				return fmt.Errorf("%s invalid syntax, %s", origin(lineNo), text)
+			}
+			if err := pp.include(file, m[1]+m[2], depth); err != nil {
+				return fmt.Errorf("%s %s", origin(lineNo), err.Error())
+			}
+			fns = pp.functions(len(pp.defines))
+		case isDirective(text, DIRECTIVE_DEFINE):
+			def, err := pp.define(text)
+			if err != nil {
+				return fmt.Errorf("%s %s", origin(lineNo), err.Error())
+			}
+			def.origin = origin(lineNo)
+			pp.defines = append(pp.defines, def)
+			fns = pp.functions(len(pp.defines))
+		case strings.HasPrefix(text, "#") || strings.HasPrefix(text, "//"):
+			// comments
+		case file == "":
+			// statements of the script itself
+			if isUnbalanced([]string{line}, fns) {
+				stmt = append(stmt, line)
+			}
+		default:
+			return fmt.Errorf("%s only #include, #define and comments are allowed in the included file", origin(lineNo))
+		}
+	}
+	return nil
+}
+
+// isDirective returns true if the text is the directive name followed by a space.
+func isDirective(text string, name string) bool {
+	if !strings.HasPrefix(text, name) {
+		return false
+	}
+	rest := text[len(name):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// isUnbalanced returns true if the statement continues on the next line,
+// the other errors are left to the parser.
+func isUnbalanced(stmt []string, fns map[string]expression.Function) bool {
+	_, _, err := expression.ParseTokens(strings.Join(stmt, "\n"), fns)
+	var parseErr *expression.ParseError
+	return err != nil && errors.As(err, &parseErr) && parseErr.Kind == "unbalanced_parenthesis"
+}
+
+func (pp *preprocessor) include(from string, target string, depth int) error {
+	if depth >= maxIncludeDepth {
+		return fmt.Errorf("#include %q exceeds the maximum depth %d", target, maxIncludeDepth)
+	}
+	if !strings.HasPrefix(target, "/") {
+		base := path.Dir(from)
+		if from == "" {
+			base = path.Dir("/" + pp.task.sourcePath)
+		}
+		target = path.Join(base, target)
+	}
+	target = path.Clean("/" + target)
+	for _, p := range pp.stack {
+		if p == target {
+			return fmt.Errorf("#include %q is circular", target)
+		}
+	}
+	if pp.included[target] {
+		// already included
+		return nil
+	}
+	ldr := pp.task.loader
+	if ldr == nil && instance != nil {
+		ldr = instance
+	}
+	if ldr == nil {
+		return fmt.Errorf("#include %q no loader is available", target)
+	}
+	sc, err := ldr.Load(target)
+	if err != nil {
+		return fmt.Errorf("#include %s", err.Error())
+	}
+	pp.included[target] = true
+
+	switch strings.ToLower(path.Ext(target)) {
+	case ".tql":
+		pp.stack = append(pp.stack, target)
+		defer func() { pp.stack = pp.stack[:len(pp.stack)-1] }()
+		return pp.scan(target, sc.content, depth+1)
+	case ".js":
+		pp.jsModules = append(pp.jsModules, &jsModule{path: target, code: string(sc.content)})
+		return nil
+	default:
+		return fmt.Errorf("#include %q unsupported file type", target)
+	}
+}
+
+func (pp *preprocessor) define(text string) (*funcDefine, error) {
+	m := defineRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return nil, fmt.Errorf("invalid syntax, %s", text)
+	}
+	ret := &funcDefine{name: m[1], body: strings.TrimSpace(m[3])}
+	if _, exists := functions[ret.name]; exists {
+		return nil, fmt.Errorf("#define %s, the builtin function can not be redefined", ret.name)
+	}
+	for _, def := range pp.defines {
+		if def.name == ret.name {
+			return nil, fmt.Errorf("#define %s, already defined at %s", ret.name, def.origin)
+		}
+	}
+	if params := strings.TrimSpace(m[2]); params != "" {
+		for _, p := range strings.Split(params, ",") {
+			p = strings.TrimSpace(p)
+			if !identRegexp.MatchString(p) {
+				return nil, fmt.Errorf("#define %s, invalid parameter %q", ret.name, p)
+			}
+			if _, exists := functions[p]; exists {
+				return nil, fmt.Errorf("#define %s, parameter %q conflicts with the function", ret.name, p)
+			}
+			ret.params = append(ret.params, p)
+		}
+	}
+	if ret.body == "" {
+		return nil, fmt.Errorf("#define %s, the body is empty", ret.name)
+	}
+	// check the syntax of the body with the functions defined so far
+	if _, err := expression.NewWithFunctions(ret.body, pp.functions(len(pp.defines))); err != nil {
+		return nil, fmt.Errorf("#define %s, %s", ret.name, err.Error())
+	}
+	return ret, nil
+}
+
+// bindDefines adds the user-defined functions of the task to the node.
+// The body of the function is compiled on the first call and cached in the node.
+func (node *Node) bindDefines() {
+	if node.task == nil || len(node.task.defines) == 0 {
+		return
+	}
+	if _, exists := node.functions[node.task.defines[0].name]; exists {
+		return
+	}
+	for _, def := range node.task.defines {
+		node.functions[def.name] = node.udf(def)
+	}
+}
+
+func (node *Node) udf(def *funcDefine) expression.Function {
+	var expr *expression.Expression
+	return func(args ...any) (any, error) {
+		if len(args) != len(def.params) {
+			return nil, ErrInvalidNumOfArgs(def.name, len(def.params), len(args))
+		}
+		if expr == nil {
+			compiled, err := expression.NewWithFunctions(def.body, node.functions)
+			if err != nil {
+				return nil, fmt.Errorf("f(%s) %s", def.name, err.Error())
+			}
+			expr = compiled
+		}
+		return expr.Eval(&udfParameters{node: node, def: def, args: args})
+	}
+}
+
+// udfParameters resolves the parameters of the user-defined function,
+// the other names are resolved by the node.
+type udfParameters struct {
+	node *Node
+	def  *funcDefine
+	args []any
+}
+
+func (up *udfParameters) Get(name string) (any, error) {
+	for i, p := range up.def.params {
+		if p == name {
+			if up.args[i] == nil {
+				return expression.NullValue, nil
+			}
+			return up.args[i], nil
+		}
+	}
+	return up.node.Get(name)
+}
@@ -38,6 +38,8 @@ type Node struct {
 
 	pragma  map[string]string
 	tqlLine *Line
+
+	profile *NodeProfile // nil if the profiling is not enabled
 }
 
 var _ expression.Parameters = (*Node)(nil)
@@ -57,6 +59,7 @@ func (node *Node) compile(code string) error {
 }
 
 func (node *Node) Parse(text string) (*expression.Expression, error) {
+	node.bindDefines()
 	return expression.NewWithFunctions(text, node.functions)
 }
 
@@ -218,7 +221,15 @@ func (node *Node) start() {
 					if node.debug {
 						node.task.LogDebug("->", node.Name(), "RECV", fmt.Sprintf("%v", rec.key), rec.StringValueTypes(), " ")
 					}
-					ret, err := node.expr.Eval(node)
+					var ret any
+					var err error
+					if node.profile != nil {
+						tick, blocked := node.profile.begin()
+						ret, err = node.expr.Eval(node)
+						node.profile.end(tick, blocked, nil)
+					} else {
+						ret, err = node.expr.Eval(node)
+					}
 					if err != nil {
 						ErrorRecord(err).Tell(node.next)
 						continue
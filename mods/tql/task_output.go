@@ -52,6 +52,7 @@ type output struct {
 	closeWg     sync.WaitGroup
 	lastError   error
 	lastMessage string
+	rows        int64
 
 	cacheOption *CacheParam
 	cacheWriter *bytes.Buffer
@@ -59,6 +60,8 @@ type output struct {
 
 	pragma  map[string]string
 	tqlLine *Line
+
+	profile *NodeProfile // nil if the profiling is not enabled
 }
 
 func (node *Node) compileSink(code *Line) (ret *output, err error) {
@@ -179,8 +182,12 @@ func (out *output) start() {
 					break loop
 				} else if rec.IsError() {
 					out.lastError = rec.Error()
+					if out.profile != nil {
+						out.profile.addError()
+					}
 					continue
 				}
+
 				if !shouldClose && saneEncoder {
 					resultColumns := out.task.ResultColumns()
 					if len(resultColumns) == 0 {
@@ -224,6 +231,9 @@ func (out *output) start() {
 						out.task.LogError(err.Error())
 					}
 				}
+				if out.encoder != nil && out.task.isStreaming() {
+					out.encoder.Flush(false)
+				}
 			}
 		}
 		if saneEncoder {
@@ -328,15 +338,26 @@ func (out *output) closeEncoder() {
 	}
 }
 
-func (out *output) addRow(rec *Record) error {
-	var addFunc func([]any) error
+func (out *output) addRow(rec *Record) (err error) {
+	if out.profile != nil && !rec.IsArray() {
+		tick, blocked := out.profile.begin()
+		defer func() { out.profile.end(tick, blocked, err) }()
+	}
+	var addRow func([]any) error
 	if out.encoder != nil {
-		addFunc = out.encoder.AddRow
+		addRow = out.encoder.AddRow
 	} else if out.dbSink != nil {
-		addFunc = out.dbSink.AddRow
+		addRow = out.dbSink.AddRow
 	} else {
 		return fmt.Errorf("%s has no destination", out.name)
 	}
+	addFunc := func(values []any) error {
+		if err := addRow(values); err != nil {
+			return err
+		}
+		out.rows++
+		return nil
+	}
 
 	if rec.IsArray() {
 		for _, r := range rec.Array() {
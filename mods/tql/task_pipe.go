@@ -0,0 +1,185 @@
+package tql
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// pipe is the sink of a named sub-pipeline that ends with PIPE('name'),
+// the records are consumed by JOIN(), MERGE() and UNION() of the other pipelines.
+//
+// Each sub-pipeline has its own task that shares the context, the params and the logger
+// of the main task, so that the result columns of the branches do not interfere with each other.
+// The sub-pipelines run concurrently with the main pipeline.
+type pipe struct {
+	name string
+	task *Task
+	ch   chan *Record
+
+	done      chan struct{}
+	doneOnce  sync.Once
+	closeOnce sync.Once
+	claimed   atomic.Bool
+	wg        sync.WaitGroup
+}
+
+var _ Receiver = (*pipe)(nil)
+
+func (p *pipe) Name() string {
+	return "PIPE()"
+}
+
+func (p *pipe) Receive(rec *Record) {
+	if rec.IsEOF() || rec.IsCircuitBreak() {
+		p.closeOnce.Do(func() { close(p.ch) })
+		return
+	}
+	if rec.IsArray() {
+		for _, r := range rec.Array() {
+			p.Receive(r)
+		}
+		return
+	}
+	select {
+	case p.ch <- rec:
+	case <-p.done:
+		// no one consumes the records anymore
+	}
+}
+
+// claim marks the pipe as consumed, a pipe can be consumed only once.
+func (p *pipe) claim() error {
+	if !p.claimed.CompareAndSwap(false, true) {
+		return fmt.Errorf("pipeline '%s' is already consumed", p.name)
+	}
+	return nil
+}
+
+// read returns the next record of the pipe,
+// it returns false if the sub-pipeline is finished or the task is cancelled.
+func (p *pipe) read() (*Record, bool) {
+	select {
+	case rec, ok := <-p.ch:
+		return rec, ok
+	case <-p.done:
+		return nil, false
+	case <-p.task.ctx.Done():
+		return nil, false
+	}
+}
+
+// tryRead returns the next record of the pipe if it is ready without blocking.
+func (p *pipe) tryRead() (rec *Record, ready bool, ok bool) {
+	select {
+	case rec, ok := <-p.ch:
+		return rec, true, ok
+	default:
+		return nil, false, true
+	}
+}
+
+func (p *pipe) start() {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for _, child := range p.task.nodes {
+			child.start()
+		}
+		NewRecord("", nil).Tell(p.task.nodes[0])
+		EofRecord.Tell(p.task.nodes[0])
+		for _, child := range p.task.nodes {
+			child.stop()
+		}
+	}()
+}
+
+func (p *pipe) stop() {
+	p.doneOnce.Do(func() { close(p.done) })
+	p.wg.Wait()
+	p.task.ctxCancel()
+}
+
+// newPipeTask returns the task of a sub-pipeline.
+func (x *Task) newPipeTask() *Task {
+	ret := NewTaskContext(x.ctx)
+	ret.parent = x
+	ret.params = x.params
+	ret.logWriter = x.logWriter
+	ret.logLevel = x.logLevel
+	ret.consoleUser = x.consoleUser
+	ret.consoleId = x.consoleId
+	ret.consoleTopic = x.consoleTopic
+	ret.consoleOtp = x.consoleOtp
+	ret.consoleLogLevel = x.consoleLogLevel
+	ret.argValues = x.argValues
+	ret.httpClientFactory = x.httpClientFactory
+	ret.sqlStub = x.sqlStub
+	ret.volatileAssetsProvider = x.volatileAssetsProvider
+	ret.sourcePath = x.sourcePath
+	ret.sourceHash = x.sourceHash
+	ret.loader = x.loader
+	ret.defines = x.defines
+	ret.jsModules = x.jsModules
+	x.AddShouldStopListener(func() {
+		ret.fireCircuitBreak(nil)
+	})
+	return ret
+}
+
+// compilePipe compiles the statements of a sub-pipeline that ends with PIPE('name').
+func (x *Task) compilePipe(stmts []*Statement) error {
+	child := x.newPipeTask()
+	if err := child.compileStatements(stmts); err != nil {
+		return err
+	}
+	for _, p := range x.pipes {
+		if p.name == child.sinkPipe.name {
+			tail := stmts[len(stmts)-1]
+			return wrapCompileStatementError("pipe_compile_error", tail, tail.toLine(),
+				fmt.Errorf("pipeline '%s' is already defined", p.name))
+		}
+	}
+	x.pipes = append(x.pipes, child.sinkPipe)
+	return nil
+}
+
+// compilePipeSink makes the sink of the sub-pipeline from PIPE('name').
+func (x *Task) compilePipeSink(code string) (*pipe, error) {
+	node := NewNode(x)
+	if err := node.compile(code); err != nil {
+		return nil, err
+	}
+	ret, err := node.expr.Eval(node)
+	if err != nil {
+		return nil, err
+	}
+	name, ok := ret.(*pipeName)
+	if !ok || name.name == "" {
+		return nil, fmt.Errorf("PIPE() requires the name of the pipeline")
+	}
+	return &pipe{
+		name: name.name,
+		task: x,
+		ch:   make(chan *Record),
+		done: make(chan struct{}),
+	}, nil
+}
+
+// lookupPipe returns the sub-pipeline of the name.
+// The sub-pipeline can refer to the sub-pipelines those are defined before itself only.
+func (x *Task) lookupPipe(name string) (*pipe, error) {
+	owner := x
+	if x.parent != nil {
+		owner = x.parent
+	}
+	for _, p := range owner.pipes {
+		if p.task == x {
+			break
+		}
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("pipeline '%s' is not defined", name)
+}
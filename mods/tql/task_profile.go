@@ -0,0 +1,174 @@
+package tql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NodeProfile is the statistics of a node that is collected
+// while the task is executed with profiling enabled,
+// by "#pragma profile" or Task.SetProfile(true).
+type NodeProfile struct {
+	Pipeline   string        // "main", "pipe:<name>" or "branch:<name>"
+	Name       string        // name of the node, e.g. "SQL()"
+	Kind       StatementKind // kind of the statement
+	Line       int           // line number of the statement
+	RecordsIn  int64         // number of the records the node received
+	RecordsOut int64         // number of the records the node yielded to the next
+	Errors     int64         // number of the errors the node yielded
+	Elapsed    time.Duration // time spent in the function of the node, excluding Blocked
+	Blocked    time.Duration // time spent waiting for the next to receive the records
+
+	mu sync.Mutex
+}
+
+func (np *NodeProfile) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"pipeline":   np.Pipeline,
+		"name":       np.Name,
+		"kind":       np.Kind.String(),
+		"line":       np.Line,
+		"recordsIn":  np.RecordsIn,
+		"recordsOut": np.RecordsOut,
+		"errors":     np.Errors,
+		"elapsed":    np.Elapsed.String(),
+		"blocked":    np.Blocked.String(),
+	})
+}
+
+// begin is called before the node function is evaluated,
+// it returns the start time and the blocked time so far.
+func (np *NodeProfile) begin() (time.Time, time.Duration) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	np.RecordsIn++
+	return time.Now(), np.Blocked
+}
+
+// end is called after the node function is evaluated, the time blocked by the records
+// that the function yielded directly (e.g. source functions) is excluded from Elapsed.
+func (np *NodeProfile) end(tick time.Time, blocked time.Duration, err error) {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	np.Elapsed += time.Since(tick) - (np.Blocked - blocked)
+	if err != nil {
+		np.Errors++
+	}
+}
+
+func (np *NodeProfile) addError() {
+	np.mu.Lock()
+	defer np.mu.Unlock()
+	np.Errors++
+}
+
+// profileProbe is placed between a node and its next,
+// it counts the records and measures the time the node is blocked by the next.
+type profileProbe struct {
+	profile *NodeProfile
+	next    Receiver
+}
+
+var _ Receiver = (*profileProbe)(nil)
+
+func (pp *profileProbe) Name() string {
+	return pp.next.Name()
+}
+
+func (pp *profileProbe) Receive(rec *Record) {
+	tick := time.Now()
+	pp.next.Receive(rec)
+	elapsed := time.Since(tick)
+
+	pp.profile.mu.Lock()
+	defer pp.profile.mu.Unlock()
+	pp.profile.Blocked += elapsed
+	switch {
+	case rec.IsEOF() || rec.IsCircuitBreak():
+	case rec.IsError():
+		pp.profile.Errors++
+	case rec.IsArray():
+		pp.profile.RecordsOut += int64(len(rec.Array()))
+	default:
+		pp.profile.RecordsOut++
+	}
+}
+
+func (x *Task) SetProfile(flag bool) {
+	x.profile = flag
+}
+
+func (x *Task) IsProfile() bool {
+	return x.profile
+}
+
+// instrument places the probes to the nodes of the task and the sub-tasks.
+func (x *Task) instrument(pipeline string) {
+	newProfile := func(name string, line *Line) *NodeProfile {
+		ret := &NodeProfile{Pipeline: pipeline, Name: name}
+		ret.Kind, _ = StatementKindByFunctionName(name)
+		if line != nil {
+			ret.Line = line.line
+		}
+		return ret
+	}
+	for _, node := range x.nodes {
+		node.profile = newProfile(node.name, node.tqlLine)
+		node.next = &profileProbe{profile: node.profile, next: node.next}
+	}
+	if x.output != nil {
+		x.output.profile = newProfile(x.output.name, x.output.tqlLine)
+	}
+	for _, p := range x.pipes {
+		p.task.instrument("pipe:" + p.name)
+	}
+	for _, b := range x.branches {
+		b.task.instrument("branch:" + b.name)
+	}
+}
+
+// Profile returns the statistics of the nodes, in the order of
+// the sub-pipelines, the main pipeline and the branches.
+// It returns nil if the profiling is not enabled.
+func (x *Task) Profile() []*NodeProfile {
+	if !x.profile {
+		return nil
+	}
+	return x.collectProfile()
+}
+
+func (x *Task) collectProfile() []*NodeProfile {
+	ret := []*NodeProfile{}
+	for _, p := range x.pipes {
+		ret = append(ret, p.task.collectProfile()...)
+	}
+	for _, node := range x.nodes {
+		if node.profile != nil {
+			ret = append(ret, node.profile)
+		}
+	}
+	if x.output != nil && x.output.profile != nil {
+		ret = append(ret, x.output.profile)
+	}
+	for _, b := range x.branches {
+		ret = append(ret, b.task.collectProfile()...)
+	}
+	return ret
+}
+
+// logProfile writes the statistics of the nodes into the log,
+// at the level that is not filtered out by the log levels of the task.
+func (x *Task) logProfile() {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "profile\n%-16s %-20s %5s %10s %10s %7s %14s %14s",
+		"PIPELINE", "NODE", "LINE", "IN", "OUT", "ERRORS", "ELAPSED", "BLOCKED")
+	for _, np := range x.Profile() {
+		fmt.Fprintf(sb, "\n%-16s %-20s %5d %10d %10d %7d %14s %14s",
+			np.Pipeline, np.Name, np.Line, np.RecordsIn, np.RecordsOut, np.Errors,
+			np.Elapsed.Round(time.Microsecond), np.Blocked.Round(time.Microsecond))
+	}
+	x._log(max(INFO, x.logLevel, x.consoleLogLevel), sb.String())
+}
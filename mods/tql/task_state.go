@@ -0,0 +1,121 @@
+package tql
+
+import (
+	"fmt"
+	"maps"
+	"sync"
+	"time"
+)
+
+// taskState is the checkpoint of the task that is carried over to the next run.
+//
+// state() reads the values that are given by SetState() before the task is executed,
+// SET_STATE() stores the values aside and those are returned by State() after the task finishes,
+// the caller (e.g. the timer of the scheduler) saves them only if the task succeeded.
+type taskState struct {
+	mu      sync.Mutex
+	initial map[string]any
+	updates map[string]any
+}
+
+// SetState sets the state that state() of the script reads.
+func (x *Task) SetState(state map[string]any) {
+	st := x.stateOwner()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.initial = maps.Clone(state)
+	st.updates = nil
+}
+
+// State returns the state merged with the values that SET_STATE() set,
+// the second return value is false if SET_STATE() has not been called.
+func (x *Task) State() (map[string]any, bool) {
+	st := x.stateOwner()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.updates == nil {
+		return st.initial, false
+	}
+	ret := maps.Clone(st.initial)
+	if ret == nil {
+		ret = map[string]any{}
+	}
+	for k, v := range st.updates {
+		if v == nil {
+			delete(ret, k)
+		} else {
+			ret[k] = v
+		}
+	}
+	return ret, true
+}
+
+func (x *Task) stateOwner() *taskState {
+	if x.parent != nil {
+		return &x.parent.state
+	}
+	return &x.state
+}
+
+// tql function: state(key, default)
+//
+// state returns the value of the key that the last successful run set by SET_STATE(),
+// or the default value if the key does not exist.
+func (node *Node) fmState(key string, defaultValue ...any) (any, error) {
+	if len(defaultValue) > 1 {
+		return nil, ErrInvalidNumOfArgs("state", 2, len(defaultValue)+1)
+	}
+	st := node.task.stateOwner()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if v, ok := st.initial[key]; ok {
+		return v, nil
+	}
+	if len(defaultValue) == 1 {
+		return defaultValue[0], nil
+	}
+	return nil, nil
+}
+
+// tql function: SET_STATE(key, value)
+//
+// SET_STATE passes the records to the next as they are, and keeps the value of the last record
+// as the new state of the key. The new state is committed only if the task succeeds.
+// The value nil removes the key.
+func (node *Node) fmSetState(key string, value any) (*Record, error) {
+	inflight := node.Inflight()
+	if inflight == nil {
+		return nil, nil
+	}
+	switch v := value.(type) {
+	case nil, time.Time, int64, float64, bool, string:
+	case *time.Time:
+		value = *v
+	case int:
+		value = int64(v)
+	case int32:
+		value = int64(v)
+	case int16:
+		value = int64(v)
+	case int8:
+		value = int64(v)
+	case uint32:
+		value = int64(v)
+	case uint16:
+		value = int64(v)
+	case uint8:
+		value = int64(v)
+	case float32:
+		value = float64(v)
+	default:
+		return nil, fmt.Errorf("f(SET_STATE) unsupported type %T", value)
+	}
+	st := node.task.stateOwner()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.updates == nil {
+		st.updates = map[string]any{}
+	}
+	st.updates[key] = value
+	return inflight, nil
+}
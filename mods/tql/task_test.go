@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -2182,6 +2183,55 @@ func TestGroupByTimeWindow(t *testing.T) {
 
 }
 
+func TestGroupSlidingWindow(t *testing.T) {
+	payload := []string{
+		"1700256261,1",
+		"1700256262,2",
+		"1700256263,3",
+		"1700256264,4",
+		"1700256266,5",
+	}
+	codeLines := []string{
+		`CSV(payload(), field(0, datetimeType("s"), "time"), field(1, doubleType(), "value"))`,
+		`GROUP( by(value(0), slidingwindow("4s", "2s")), avg(value(1)) )`,
+		`CSV(timeformat("s"), heading(true), precision(2))`,
+	}
+	resultLines := []string{
+		"START,END,COUNT,AVG",
+		"1700256258,1700256262,1,1.00",
+		"1700256260,1700256264,3,2.00",
+		"1700256262,1700256266,3,3.00",
+		"1700256264,1700256268,2,4.50",
+		"1700256266,1700256270,1,5.00",
+		"",
+	}
+	runTest(t, codeLines, resultLines, Payload(strings.Join(payload, "\n")))
+}
+
+func TestGroupSessionWindow(t *testing.T) {
+	payload := []string{
+		"m1,1700256261,1",
+		"m2,1700256262,2",
+		"m1,1700256263,3",
+		"m2,1700256270,4",
+		"m1,1700256271,5",
+	}
+	codeLines := []string{
+		`CSV(payload(), field(1, datetimeType("s"), "time"), field(2, doubleType(), "value"))`,
+		`GROUP( by(value(1), sessionwindow("3s", value(0))), avg(value(2)) )`,
+		`CSV(timeformat("s"), heading(true), precision(2))`,
+	}
+	resultLines := []string{
+		"KEY,START,END,COUNT,AVG",
+		"m1,1700256261,1700256263,2,2.00",
+		"m2,1700256262,1700256262,1,2.00",
+		"m2,1700256270,1700256270,1,4.00",
+		"m1,1700256271,1700256271,1,5.00",
+		"",
+	}
+	runTest(t, codeLines, resultLines, Payload(strings.Join(payload, "\n")))
+}
+
 func TestTimeWindow(t *testing.T) {
 	var codeLines, payload, resultLines []string
 
@@ -3083,3 +3133,192 @@ func TestLoader(t *testing.T) {
 		}
 	}
 }
+
+func TestTaskProfile(t *testing.T) {
+	code := strings.Join([]string{
+		`#pragma profile`,
+		`FAKE( linspace(1, 10, 10) )`,
+		`FILTER( value(0) > 5 )`,
+		`CSV()`,
+	}, "\n")
+	log := &bytes.Buffer{}
+	task := tql.NewTaskContext(context.Background())
+	task.SetLogWriter(log)
+	task.SetOutputWriter(io.Discard)
+	require.NoError(t, task.Compile(bytes.NewBufferString(code)))
+	require.True(t, task.IsProfile())
+	result := task.Execute()
+	require.NoError(t, result.Err)
+
+	profile := task.Profile()
+	require.Len(t, profile, 3)
+	require.Equal(t, "FAKE()", profile[0].Name)
+	require.Equal(t, "main", profile[0].Pipeline)
+	require.Equal(t, tql.StatementSource, profile[0].Kind)
+	require.Equal(t, int64(1), profile[0].RecordsIn)
+	require.Equal(t, int64(10), profile[0].RecordsOut)
+	require.Equal(t, "FILTER()", profile[1].Name)
+	require.Equal(t, int64(10), profile[1].RecordsIn)
+	require.Equal(t, int64(5), profile[1].RecordsOut)
+	require.Equal(t, "CSV()", profile[2].Name)
+	require.Equal(t, int64(5), profile[2].RecordsIn)
+	require.Equal(t, 4, profile[2].Line)
+	require.Contains(t, log.String(), "PIPELINE")
+
+	b, err := json.Marshal(profile[1])
+	require.NoError(t, err)
+	require.Equal(t, "map", gjson.GetBytes(b, "kind").String())
+	require.Equal(t, int64(5), gjson.GetBytes(b, "recordsOut").Int())
+}
+
+func TestTaskExplain(t *testing.T) {
+	code := strings.Join([]string{
+		`FAKE( linspace(1, 3, 3) )`,
+		`PIPE('a')`,
+		`SQL('select * from example where name = ?', 'sig.1')`,
+		`MERGE('a')`,
+		`TEE('b')`,
+		`CSV()`,
+		`BRANCH('b')`,
+		`JSON()`,
+	}, "\n")
+	task := tql.NewTaskContext(context.Background())
+	task.SetOutputWriter(io.Discard)
+	require.NoError(t, task.Compile(bytes.NewBufferString(code)))
+	explain := task.Explain()
+	require.Equal(t, strings.Join([]string{
+		`[pipe:a]`,
+		`  #1   FAKE()           source      line 1`,
+		`  #2   PIPE('a')        sink`,
+		`[main]`,
+		`  #1   SQL()            source_or_map_or_sink line 3`,
+		`       SQL: select * from example where name = ?`,
+		`  #2   MERGE()          map         line 4`,
+		`  #3   TEE()            map         line 5`,
+		`  #4   CSV()            source_or_sink line 6`,
+		`[branch:b]`,
+		`  #1   BRANCH('b')      source`,
+		`  #2   JSON()           sink        line 8`,
+		``,
+	}, "\n"), explain)
+}
+
+func TestTaskState(t *testing.T) {
+	code := strings.Join([]string{
+		`FAKE( linspace(1, 5, 5) )`,
+		`FILTER( value(0) > state("last", 3) )`,
+		`SET_STATE("last", value(0))`,
+		`CSV()`,
+	}, "\n")
+	run := func(state map[string]any) (string, *tql.Task) {
+		out := &bytes.Buffer{}
+		task := tql.NewTaskContext(context.Background())
+		task.SetOutputWriter(out)
+		task.SetState(state)
+		require.NoError(t, task.Compile(bytes.NewBufferString(code)))
+		result := task.Execute()
+		require.NoError(t, result.Err)
+		return out.String(), task
+	}
+
+	out, task := run(nil)
+	require.Equal(t, "4\n5\n\n", out)
+	state, updated := task.State()
+	require.True(t, updated)
+	require.Equal(t, map[string]any{"last": 5.0}, state)
+
+	// nothing new since the last run, the state is not updated
+	out, task = run(state)
+	require.Empty(t, strings.TrimSpace(out))
+	state, updated = task.State()
+	require.False(t, updated)
+	require.Equal(t, map[string]any{"last": 5.0}, state)
+
+	// unsupported type
+	task = tql.NewTaskContext(context.Background())
+	task.SetOutputWriter(io.Discard)
+	require.NoError(t, task.Compile(bytes.NewBufferString(strings.Join([]string{
+		`FAKE( linspace(1, 2, 2) )`,
+		`SET_STATE("values", list(1, 2))`,
+		`CSV()`,
+	}, "\n"))))
+	result := task.Execute()
+	require.EqualError(t, result.Err, "f(SET_STATE) unsupported type []interface {}")
+}
+
+func TestTaskInclude(t *testing.T) {
+	f, _ := ssfs.NewServerSideFileSystem([]string{"/=./test"})
+	ssfs.SetDefault(f)
+
+	code := strings.Join([]string{
+		`#include "lib/units.tql"`,
+		`#include "lib/greet.js"`,
+		`#define round1(v) round(v * 10) / 10`,
+		`FAKE( linspace(32, 212, 2) )`,
+		`MAPVALUE(1, round1(celsius(value(0))))`,
+		`MAPVALUE(2, fahrenheit(value(1)))`,
+		`SCRIPT({ $.yield(greet("tql"), $.values[0], $.values[1], $.values[2]) })`,
+		`CSV()`,
+	}, "\n")
+	out := &bytes.Buffer{}
+	task := tql.NewTaskContext(context.Background())
+	task.SetOutputWriter(out)
+	require.NoError(t, task.Compile(bytes.NewBufferString(code)))
+	result := task.Execute()
+	require.NoError(t, result.Err)
+	require.Equal(t, "hello tql,32,0,32\nhello tql,212,100,212\n\n", out.String())
+
+	// the comments that look like the directives and the lines in the statements are not the directives
+	code = strings.Join([]string{
+		`#defines the limit`,
+		`#included from the template`,
+		`FAKE( linspace(1, 2, 2) )`,
+		`SCRIPT({`,
+		"    $.yield(`",
+		`#define not a directive`,
+		"`)",
+		`})`,
+		"MAPVALUE(0, `",
+		`#include "lib/none.tql"`,
+		"`)",
+		`CSV()`,
+	}, "\n")
+	task = tql.NewTaskContext(context.Background())
+	task.SetOutputWriter(io.Discard)
+	require.NoError(t, task.Compile(bytes.NewBufferString(code)))
+
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{
+			code: "#include \"lib/none.tql\"\nFAKE(linspace(1,2,2))\nCSV()",
+			err:  "line 1 #include not found '/lib/none.tql'",
+		},
+		{
+			code: "#define value(x) x\nFAKE(linspace(1,2,2))\nCSV()",
+			err:  "line 1 #define value, the builtin function can not be redefined",
+		},
+		{
+			code: "#define twice(x) twice(x)\nFAKE(linspace(1,2,2))\nCSV()",
+			err:  `line 1 #define twice, undefined function twice (line=1, column=1, near="twice")`,
+		},
+		{
+			code: "#define twice(x) x * 2\nFAKE(linspace(1,2,2))\nMAPVALUE(0, twice(1, 2))\nCSV()",
+			err:  "f(twice) invalid number of args; expect:1, actual:2",
+		},
+		{
+			code: "#define\nFAKE(linspace(1,2,2))\nCSV()",
+			err:  "line 1 invalid syntax, #define",
+		},
+	}
+	for _, tt := range tests {
+		task := tql.NewTaskContext(context.Background())
+		task.SetOutputWriter(io.Discard)
+		err := task.Compile(bytes.NewBufferString(tt.code))
+		if err == nil {
+			err = task.Execute().Err
+		}
+		require.EqualError(t, err, tt.err)
+	}
+}
@@ -0,0 +1,298 @@
+package tql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	client "github.com/machbase/neo-client/v2"
+	"github.com/machbase/neo-server/v8/mods/util/ssfs"
+)
+
+// TestRunner runs the tql scripts of a directory headless and compares
+// the output of each script with its golden file.
+//
+//	example.tql          the script
+//	example.golden       the expected output of the sink
+//	example.sql.json     (optional) fixtures that SQL(), SQL_SELECT() and QUERY() return
+//	example.params       (optional) query parameters of the script, "name=value&..."
+//
+// A script fails if any ASSERT() fails or the output is different from the golden file.
+type TestRunner struct {
+	Dir     string
+	Update  bool           // write the golden files with the outputs instead of comparing
+	Match   *regexp.Regexp // runs the scripts whose path matches only if set
+	Timeout time.Duration  // timeout of each script, default 30s
+}
+
+type TestResult struct {
+	Path    string
+	Err     error
+	Updated bool
+	Elapsed time.Duration
+}
+
+func (tr *TestResult) Passed() bool {
+	return tr.Err == nil
+}
+
+// SqlFixture is an entry of the "*.sql.json" file.
+// An empty SQL matches any statement, otherwise the statements are compared
+// ignoring the cases, the white spaces and the trailing ';'.
+type SqlFixture struct {
+	SQL     string   `json:"sql"`
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Run runs the scripts of the directory in the order of the paths.
+func (r *TestRunner) Run(ctx context.Context) ([]*TestResult, error) {
+	ret := []*TestResult{}
+	err := filepath.WalkDir(r.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".tql" {
+			return nil
+		}
+		if r.Match != nil && !r.Match.MatchString(filepath.ToSlash(path)) {
+			return nil
+		}
+		ret = append(ret, r.RunFile(ctx, path))
+		return nil
+	})
+	return ret, err
+}
+
+// RunFile runs the script and compares the output with the golden file.
+func (r *TestRunner) RunFile(ctx context.Context, path string) *TestResult {
+	tick := time.Now()
+	ret := &TestResult{Path: path}
+	defer func() {
+		ret.Elapsed = time.Since(tick)
+	}()
+
+	base := strings.TrimSuffix(path, ".tql")
+	output, err := r.execute(ctx, path, base)
+	if err != nil {
+		ret.Err = err
+		return ret
+	}
+	goldenPath := base + ".golden"
+	if r.Update {
+		if err := os.WriteFile(goldenPath, output, 0644); err != nil {
+			ret.Err = err
+		}
+		ret.Updated = true
+		return ret
+	}
+	expect, err := os.ReadFile(goldenPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			ret.Err = fmt.Errorf("golden file %q not found, run with -update to create", goldenPath)
+		} else {
+			ret.Err = err
+		}
+		return ret
+	}
+	ret.Err = compareGolden(normalizeOutput(expect), output)
+	return ret
+}
+
+func (r *TestRunner) execute(ctx context.Context, path string, base string) ([]byte, error) {
+	code, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	output := &bytes.Buffer{}
+	log := &bytes.Buffer{}
+	task := NewTaskContext(ctx)
+	task.SetLogWriter(log)
+	task.SetOutputWriterJson(output, true)
+	fixtures := []SqlFixture{}
+	if b, err := os.ReadFile(base + ".sql.json"); err == nil {
+		if err := json.Unmarshal(b, &fixtures); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s.sql.json, %s", base, err.Error())
+		}
+	}
+	task.SetSqlStub(sqlFixtureStub(fixtures))
+	if b, err := os.ReadFile(base + ".params"); err == nil {
+		params, err := parseTestParams(string(b))
+		if err != nil {
+			return nil, fmt.Errorf("invalid params %s.params, %s", base, err.Error())
+		}
+		task.SetParams(params)
+	}
+	if err := task.Compile(bytes.NewBuffer(code)); err != nil {
+		return nil, err
+	}
+	result := task.Execute()
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return normalizeOutput(output.Bytes()), nil
+}
+
+func parseTestParams(str string) (map[string][]string, error) {
+	ret := map[string][]string{}
+	for _, line := range strings.Split(str, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, kv := range strings.Split(line, "&") {
+			k, v, found := strings.Cut(kv, "=")
+			if !found {
+				return nil, fmt.Errorf("%q should be name=value", kv)
+			}
+			ret[k] = append(ret[k], v)
+		}
+	}
+	return ret, nil
+}
+
+// sqlFixtureStub returns the SqlStub that answers with the fixtures,
+// it fails for the statements that have no fixture, so that the tests
+// never access the database.
+func sqlFixtureStub(fixtures []SqlFixture) SqlStub {
+	normalize := func(s string) string {
+		return strings.ToLower(strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(s), ";")), " "))
+	}
+	return func(sqlText string, params []any) (client.Columns, [][]any, error) {
+		for _, f := range fixtures {
+			if f.SQL != "" && normalize(f.SQL) != normalize(sqlText) {
+				continue
+			}
+			if f.Error != "" {
+				return nil, nil, errors.New(f.Error)
+			}
+			cols := make(client.Columns, len(f.Columns))
+			for i, name := range f.Columns {
+				var sample any
+				if len(f.Rows) > 0 && i < len(f.Rows[0]) {
+					sample = f.Rows[0][i]
+				}
+				cols[i] = client.MakeColumnOf(name, sample)
+			}
+			return cols, f.Rows, nil
+		}
+		return nil, nil, fmt.Errorf("no fixture for %q", sqlText)
+	}
+}
+
+var elapseRegexp = regexp.MustCompile(`"elapse":"[^"]*"`)
+
+// normalizeOutput removes the parts of the output that change on every run.
+func normalizeOutput(b []byte) []byte {
+	b = elapseRegexp.ReplaceAll(b, []byte(`"elapse":""`))
+	return bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+}
+
+// compareGolden returns the error that describes the first different line.
+func compareGolden(expect []byte, actual []byte) error {
+	if bytes.Equal(expect, actual) {
+		return nil
+	}
+	expectLines := strings.Split(string(expect), "\n")
+	actualLines := strings.Split(string(actual), "\n")
+	for i := 0; i < len(expectLines) || i < len(actualLines); i++ {
+		var e, a string
+		if i < len(expectLines) {
+			e = expectLines[i]
+		}
+		if i < len(actualLines) {
+			a = actualLines[i]
+		}
+		if e != a || i >= len(expectLines) || i >= len(actualLines) {
+			return fmt.Errorf("output differs from the golden file at line %d\n  expect: %q\n  actual: %q", i+1, e, a)
+		}
+	}
+	return nil
+}
+
+// RunTestMain handles "machbase-neo tql test [flags] <dir>..."
+func RunTestMain(flags *flag.FlagSet, args []string) int {
+	update := flags.Bool("update", false, "write the golden files with the outputs")
+	match := flags.String("run", "", "run only the scripts whose path matches the regular expression")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of each script")
+	verbose := flags.Bool("v", false, "print the passed scripts too")
+	if err := flags.Parse(args); err != nil {
+		fmt.Println("Error parsing flags:", err.Error())
+		return 1
+	}
+	dirs := flags.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	var matchRegexp *regexp.Regexp
+	if *match != "" {
+		if re, err := regexp.Compile(*match); err != nil {
+			fmt.Println("Error invalid -run:", err.Error())
+			return 1
+		} else {
+			matchRegexp = re
+		}
+	}
+
+	Init()
+	defer Deinit()
+
+	failed, total := 0, 0
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return 1
+		}
+		// file("/data.csv") refers to the file of the test directory
+		serverFs, err := ssfs.NewServerSideFileSystem([]string{"/=" + abs})
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return 1
+		}
+		ssfs.SetDefault(serverFs)
+
+		runner := &TestRunner{Dir: dir, Update: *update, Match: matchRegexp, Timeout: *timeout}
+		results, err := runner.Run(context.Background())
+		if err != nil {
+			fmt.Println("Error", err.Error())
+			return 1
+		}
+		for _, r := range results {
+			total++
+			switch {
+			case !r.Passed():
+				failed++
+				fmt.Printf("--- FAIL: %s (%s)\n", r.Path, r.Elapsed.Round(time.Millisecond))
+				fmt.Println("    " + strings.ReplaceAll(r.Err.Error(), "\n", "\n    "))
+			case r.Updated:
+				fmt.Printf("--- UPDATE: %s (%s)\n", r.Path, r.Elapsed.Round(time.Millisecond))
+			case *verbose:
+				fmt.Printf("--- PASS: %s (%s)\n", r.Path, r.Elapsed.Round(time.Millisecond))
+			}
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("FAIL %d of %d\n", failed, total)
+		return 1
+	}
+	fmt.Printf("ok %d\n", total)
+	return 0
+}
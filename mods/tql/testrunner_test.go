@@ -0,0 +1,68 @@
+package tql_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/machbase/neo-server/v8/mods/tql"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestRunner(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	write("fake.tql", "FAKE(linspace(1, 3, 3))\nASSERT(value(0) >= 1, 'should be >= 1')\nCSV()\n")
+	write("sql.tql", "SQL('select name, value from example where name = ?', param('name'))\nCSV()\n")
+	write("sql.params", "name=sig.1\n")
+	write("sql.sql.json", `[
+		{"sql": "SELECT name, value  FROM example WHERE name = ?;", "columns": ["NAME", "VALUE"], "rows": [["sig.1", 1.5], ["sig.1", 2.5]]}
+	]`)
+
+	// create the golden files
+	runner := &tql.TestRunner{Dir: dir, Update: true}
+	results, err := runner.Run(t.Context())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		require.NoError(t, r.Err, r.Path)
+		require.True(t, r.Updated)
+	}
+	golden, err := os.ReadFile(filepath.Join(dir, "sql.golden"))
+	require.NoError(t, err)
+	require.Equal(t, "sig.1,1.5\nsig.1,2.5\n\n", string(golden))
+
+	// compare with the golden files
+	runner.Update = false
+	results, err = runner.Run(t.Context())
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for _, r := range results {
+		require.True(t, r.Passed(), r.Path)
+	}
+
+	// the output is different from the golden file
+	write("sql.golden", "sig.1,1.5\nsig.1,3.5\n\n")
+	ret := runner.RunFile(t.Context(), filepath.Join(dir, "sql.tql"))
+	require.Error(t, ret.Err)
+	require.Contains(t, ret.Err.Error(), "golden file at line 2")
+
+	// no fixture for the statement
+	write("nofixture.tql", "SQL('select * from example')\nCSV()\n")
+	write("nofixture.golden", "")
+	ret = runner.RunFile(t.Context(), filepath.Join(dir, "nofixture.tql"))
+	require.Error(t, ret.Err)
+	require.Contains(t, ret.Err.Error(), `no fixture for "select * from example"`)
+
+	// assertion fails
+	write("assert.tql", "FAKE(linspace(1, 3, 3))\nASSERT(value(0) > 1, 'should be > 1')\nCSV()\n")
+	runner.Match = regexp.MustCompile(`assert\.tql$`)
+	results, err = runner.Run(t.Context())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.False(t, results[0].Passed())
+	require.Equal(t, "f(ASSERT) should be > 1", results[0].Err.Error())
+}
@@ -1710,6 +1710,67 @@ func TestTql(t *testing.T) {
 				"\n",
 			},
 		},
+		{
+			Name: "MAP_ZSCORE",
+			Script: `
+				FAKE(json({[1.0], [1.1], [0.9], [1.0], [1.2], [9.0], [1.1]}))
+				MAP_ZSCORE(1, value(0), 5, 3.0)
+				CSV(precision(2))
+				`,
+			ExpectCSV: []string{
+				`1.00,NULL,false`,
+				`1.10,NULL,false`,
+				`0.90,NULL,false`,
+				`1.00,NULL,false`,
+				`1.20,NULL,false`,
+				`9.00,69.81,true`,
+				`1.10,-0.43,false`,
+				"\n",
+			},
+		},
+		{
+			Name: "MAP_ZSCORE_step_after_flat",
+			Script: `
+				FAKE(json({[1.0], [1.0], [1.0], [1.0], [1.0], [5.0], [1.0]}))
+				MAP_ZSCORE(1, value(0), 5, 3.0)
+				JSON(rowsArray(true))
+				`,
+			ExpectFunc: func(t *testing.T, result string) {
+				// the score of the step after the constant window is not +Inf
+				require.True(t, gjson.Get(result, "success").Bool(), "result: %q", result)
+				require.Equal(t, "null", gjson.Get(result, "data.rows.5.score").Raw, result)
+				require.True(t, gjson.Get(result, "data.rows.5.anomaly").Bool(), result)
+				require.InDelta(t, -0.45, gjson.Get(result, "data.rows.6.score").Float(), 0.01, result)
+				require.False(t, gjson.Get(result, "data.rows.6.anomaly").Bool(), result)
+			},
+		},
+		{
+			Name: "MAP_MAD_lazy",
+			Script: `
+				FAKE(json({[1.0], [1.1], [0.9], [1.0], [1.2], [9.0], [1.1]}))
+				MAP_MAD(0, value(0), 5, 3.5, lazy(true))
+				CSV(precision(2))
+				`,
+			ExpectCSV: []string{
+				`-0.67,false,1.00`,
+				`0.00,false,1.10`,
+				`-1.35,false,0.90`,
+				`-0.67,false,1.00`,
+				`0.67,false,1.20`,
+				`53.29,true,9.00`,
+				`0.00,false,1.10`,
+				"\n",
+			},
+		},
+		{
+			Name: "MAP_CUSUM_invalid_h",
+			Script: `
+				FAKE(json({[1.0], [1.1]}))
+				MAP_CUSUM(1, value(0), 10, 0.5, 0)
+				CSV()
+				`,
+			ExpectErr: "f(MAP_CUSUM) arg(4) h should be larger than 0",
+		},
 		{
 			Name: "MAP_DIFF",
 			Script: `
@@ -1898,6 +1959,44 @@ func TestTql(t *testing.T) {
 				`,
 			ExpectCSV: loadLines("./test/fft3d.csv"),
 		},
+		{
+			Name: "SPECTROGRAM",
+			Script: `
+				FAKE( oscillator( range(timeAdd(1685714509*1000000000,'1s'), '1s', '1ms'), freq(50, 1.0)))
+				MAPKEY('samples')
+				GROUPBYKEY()
+				SPECTROGRAM(200, 100, minHz(49), maxHz(51))
+				FLATTEN()
+				POPKEY()
+				CSV(precision(2))
+				`,
+			ExpectFunc: func(t *testing.T, result string) {
+				lines := strings.Split(strings.TrimSpace(result), "\n")
+				require.Equal(t, 9, len(lines), result)
+				for _, line := range lines {
+					require.True(t, strings.HasSuffix(line, ",50.00,1.00"), line)
+				}
+			},
+		},
+		{
+			Name: "PEAKS",
+			Script: `
+				FAKE( json({ [[1, 0], [2, 3], [3, 1], [4, 5], [5, 0], [6, 0.5], [7, 0]] }) )
+				PEAKS(1)
+				FLATTEN()
+				CSV(precision(0))
+				`,
+			ExpectCSV: []string{"2,3", "4,5", "\n"},
+		},
+		{
+			Name: "MAP_HIGHPASS_invalid_cutoff",
+			Script: `
+				FAKE( linspace(0, 1, 10) )
+				MAP_HIGHPASS(1, value(0), 600, 1000)
+				CSV()
+				`,
+			ExpectErr: "f(MAP_HIGHPASS) cutoff 600 should be between 0 and the Nyquist frequency 500",
+		},
 	}
 
 	tql.ShellExecutable = func(addr, path string) ([]string, error) {
@@ -2347,6 +2446,54 @@ func TestScriptInterrupt(t *testing.T) {
 	}
 }
 
+func TestSubscribe(t *testing.T) {
+	unsubscribed := 0
+	tql.SetBrokerSubscriber(func(filter string, cb func(topic string, payload []byte)) (func(), error) {
+		if filter == "sensor/#" {
+			cb("sensor/1", []byte("1.5"))
+			cb("sensor/2", []byte("2.5"))
+			cb("sensor/1", []byte("3.5"))
+		}
+		return func() { unsubscribed++ }, nil
+	})
+	defer tql.SetBrokerSubscriber(nil)
+
+	tests := []TqlTestCase{
+		{
+			Name: "subscribe-limit",
+			Script: `
+				SUBSCRIBE('sensor/#', limit(1, 2))
+				POPVALUE(0)
+				CSV()
+			`,
+			ExpectCSV: []string{"sensor/2,2.5", "sensor/1,3.5", "\n"},
+		},
+		{
+			Name: "subscribe-duration",
+			Script: `
+				SUBSCRIBE('status/#', duration('100ms'))
+				CSV(header(true))
+			`,
+			ExpectCSV: []string{"TIME,TOPIC,PAYLOAD", "\n"},
+		},
+		{
+			Name: "subscribe-bridge-not-found",
+			Script: `
+				SUBSCRIBE(bridge('not-exists'), 'sensor/#')
+				CSV()
+			`,
+			ExpectErr: "f(SUBSCRIBE) undefined bridge name 'not-exists'",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			runTestCase(t, tc)
+		})
+	}
+	require.Equal(t, 2, unsubscribed)
+}
+
 func TestBridgeSqlite(t *testing.T) {
 	tests := []TqlTestCase{
 		{
@@ -2883,3 +3030,128 @@ func TestRestClient(t *testing.T) {
 		})
 	}
 }
+
+func TestPipeline(t *testing.T) {
+	tests := []TqlTestCase{
+		{
+			Name: "pipe-join-inner",
+			Script: `
+				FAKE( linspace(0, 4, 3) )
+				MAPVALUE(1, value(0) * 10)
+				PIPE('tens')
+
+				FAKE( linspace(0, 4, 5) )
+				JOIN('tens', on(0))
+				CSV()
+				`,
+			ExpectCSV: []string{"0,0", "2,20", "4,40", "\n"},
+		},
+		{
+			Name: "pipe-join-left",
+			Script: `
+				FAKE( linspace(0, 4, 3) )
+				MAPVALUE(1, value(0) * 10)
+				PIPE('tens')
+
+				FAKE( linspace(0.25, 4.25, 5) )
+				JOIN('tens', tolerance(0.5), 'left')
+				CSV()
+				`,
+			ExpectCSV: []string{"0.25,0", "1.25,NULL", "2.25,20", "3.25,NULL", "4.25,40", "\n"},
+		},
+		{
+			Name: "pipe-join-left-columns",
+			Script: `
+				FAKE( linspace(0, 4, 3) )
+				MAPVALUE(1, value(0) * 10)
+				PIPE('tens')
+
+				FAKE( linspace(0, 4, 3) )
+				MAPVALUE(0, NULL, where(value(0) == 0))
+				JOIN('tens', 'left')
+				CSV(header(true))
+				`,
+			ExpectFunc: func(t *testing.T, result string) {
+				// the first record has no join key, the columns of the pipe are set by the following records
+				lines := strings.Split(strings.TrimSpace(result), "\n")
+				require.Len(t, lines, 4)
+				require.Len(t, strings.Split(lines[0], ","), 2, lines[0])
+				require.Equal(t, []string{"NULL,NULL", "2,20", "4,40"}, lines[1:])
+			},
+		},
+		{
+			Name: "pipe-merge",
+			Script: `
+				FAKE( linspace(0, 8, 5) )
+				PIPE('even')
+
+				FAKE( linspace(1, 5, 3) )
+				MERGE('even')
+				CSV()
+				`,
+			ExpectCSV: []string{"0", "1", "2", "3", "4", "5", "6", "8", "\n"},
+		},
+		{
+			Name: "pipe-union",
+			Script: `
+				FAKE( linspace(0, 8, 5) )
+				PIPE('even')
+
+				FAKE( linspace(1, 9, 5) )
+				UNION('even')
+				JSON()
+				`,
+			ExpectFunc: func(t *testing.T, result string) {
+				require.True(t, gjson.Get(result, "success").Bool())
+				require.Equal(t, `10`, gjson.Get(result, "data.rows.#").String())
+			},
+		},
+		{
+			Name: "tee-branch",
+			Script: `
+				FAKE( linspace(1, 3, 3) )
+				TEE('archive', 'none')
+				CSV()
+
+				BRANCH('archive', file('/tee_archive.csv'))
+				MAPVALUE(0, value(0) * 10)
+				CSV()
+
+				// the output of the branch without file() is discarded
+				BRANCH('none')
+				JSON()
+				`,
+			ExpectFunc: func(t *testing.T, result string) {
+				require.Equal(t, "1\n2\n3\n\n", result)
+				archive, err := os.ReadFile("test/tee_archive.csv")
+				require.NoError(t, err)
+				os.Remove("test/tee_archive.csv")
+				require.Equal(t, "10\n20\n30\n\n", string(archive))
+			},
+		},
+		{
+			Name: "tee-branch-not-defined",
+			Script: `
+				FAKE( linspace(1, 3, 3) )
+				TEE('none')
+				CSV()
+				`,
+			ExpectErr: "f(TEE) branch 'none' is not defined",
+		},
+		{
+			Name: "pipe-not-defined",
+			Script: `
+				FAKE( linspace(0, 4, 5) )
+				MERGE('none')
+				CSV()
+				`,
+			ExpectErr: "f(MERGE) pipeline 'none' is not defined",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.Name, func(t *testing.T) {
+			runTestCase(t, tc)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"strings"
@@ -26,6 +27,9 @@ func (w *NopCloseWriter) Close() error {
 func (w *NopCloseWriter) Flush() error {
 	if f, ok := w.Writer.(interface{ Flush() error }); ok {
 		return f.Flush()
+	} else if f, ok := w.Writer.(http.Flusher); ok {
+		// e.g. http.ResponseWriter, for the chunked streaming responses
+		f.Flush()
 	}
 	return nil
 }
@@ -3,6 +3,7 @@ package util_test
 import (
 	"bytes"
 	"io"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -34,6 +35,18 @@ func TestNopCloseWriterFlush(t *testing.T) {
 	require.Equal(t, "payload", recorder.String())
 }
 
+func TestNopCloseWriterFlushHttp(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	writer := &util.NopCloseWriter{Writer: recorder}
+
+	_, err := writer.Write([]byte("payload"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Flush())
+
+	require.True(t, recorder.Flushed)
+	require.Equal(t, "payload", recorder.Body.String())
+}
+
 func TestNewFileWriterWritesToFile(t *testing.T) {
 	filePath := filepath.Join(t.TempDir(), "out.log")
 
@@ -18,6 +18,7 @@ type AppendWorker struct {
 	ctxCancel context.CancelFunc
 
 	tableDesc *TableDescription
+	feedCols  []string // column names of the rows to publish, nil if not a tag table
 	lastTime  time.Time
 	refCount  int32
 	// append runner
@@ -164,6 +165,9 @@ func GetAppendWorker(ctx context.Context, tableName string) (*AppendWorker, erro
 		refCount:  1,
 		log:       logging.GetLog(fmt.Sprintf("appender-%s", tableName)),
 	}
+	if appender.TableType() == client.TableTypeTag {
+		ret.feedCols = appender.Columns().Names()
+	}
 	appenders[tableName] = ret
 	ret.Start()
 	return ret, nil
@@ -184,22 +188,26 @@ func (aw *AppendWorker) Start() {
 			case <-aw.appendStop:
 				break loop
 			case vals := <-aw.appendC:
-				err := aw.appender.Append(vals...)
-				if err != nil {
-					aw.log.Error("error:", err)
-				}
+				aw.append(vals)
 			}
 		}
 		for len(aw.appendC) > 0 {
 			vals := <-aw.appendC
-			err := aw.appender.Append(vals...)
-			if err != nil {
-				aw.log.Error("error:", err)
-			}
+			aw.append(vals)
 		}
 	}(aw)
 }
 
+func (aw *AppendWorker) append(vals []any) {
+	if err := aw.appender.Append(vals...); err != nil {
+		aw.log.Error("error:", err)
+		return
+	}
+	if aw.feedCols != nil {
+		DefaultChangeFeed.Publish(aw.tableDesc.Name, aw.feedCols, vals)
+	}
+}
+
 func (aw *AppendWorker) Stop() {
 	if aw.appendC != nil {
 		close(aw.appendStop)
@@ -0,0 +1,211 @@
+package spi
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// DefaultChangeFeed is fed by the append workers and the write handlers of the server,
+// watchers subscribe it to receive the written rows instead of polling the table.
+var DefaultChangeFeed = NewChangeFeed()
+
+// FeedRow is a row that has been written to the table.
+// Columns are the names of the values, it may be a subset of the columns of the table.
+type FeedRow struct {
+	Table   string
+	Tag     string
+	Columns []string
+	Values  []any
+}
+
+// ChangeFeed fans out the written rows to the subscribers by table and tag,
+// so that the subscribers of the same table/tag share a single stream.
+type ChangeFeed struct {
+	lock   sync.RWMutex
+	tables map[string]*feedTable
+}
+
+type feedTable struct {
+	nameColumn string
+	tags       map[string]map[*FeedSubscriber]struct{}
+}
+
+func NewChangeFeed() *ChangeFeed {
+	return &ChangeFeed{tables: map[string]*feedTable{}}
+}
+
+// feedTableName makes the table name the same regardless of the form
+// that the writer uses, e.g. "example", "sys.example", "machbasedb.sys.example".
+func feedTableName(table string) string {
+	table = strings.ToUpper(table)
+	table = strings.TrimPrefix(table, "MACHBASEDB.")
+	table = strings.TrimPrefix(table, "SYS.")
+	return table
+}
+
+// HasSubscribers returns true if the table has any subscriber.
+func (cf *ChangeFeed) HasSubscribers(table string) bool {
+	cf.lock.RLock()
+	defer cf.lock.RUnlock()
+	_, ok := cf.tables[feedTableName(table)]
+	return ok
+}
+
+// Publish sends the row to the subscribers of the table and the tag of the row.
+// It never blocks the writer, if a subscriber is not able to receive the row
+// the tag is marked as dropped, see FeedSubscriber.TakeDropped().
+func (cf *ChangeFeed) Publish(table string, columns []string, values []any) {
+	table = feedTableName(table)
+	cf.lock.RLock()
+	defer cf.lock.RUnlock()
+	ft, ok := cf.tables[table]
+	if !ok {
+		return
+	}
+	tag := ""
+	for i, col := range columns {
+		if i < len(values) && strings.EqualFold(col, ft.nameColumn) {
+			tag, _ = values[i].(string)
+			break
+		}
+	}
+	subs, ok := ft.tags[tag]
+	if !ok {
+		return
+	}
+	row := &FeedRow{Table: table, Tag: tag, Columns: columns, Values: values}
+	for sub := range subs {
+		sub.send(row)
+	}
+}
+
+// Subscribe returns a new subscriber that receives the rows through C.
+func (cf *ChangeFeed) Subscribe(chanSize int) *FeedSubscriber {
+	if chanSize <= 0 {
+		chanSize = 100
+	}
+	ch := make(chan *FeedRow, chanSize)
+	return &FeedSubscriber{feed: cf, ch: ch, C: ch, tables: map[string][]string{}, dropped: map[string]bool{}}
+}
+
+type FeedSubscriber struct {
+	C <-chan *FeedRow
+
+	feed   *ChangeFeed
+	ch     chan *FeedRow
+	closed bool
+	// protected by feed.lock
+	tables map[string][]string
+
+	droppedLock sync.Mutex
+	dropped     map[string]bool
+}
+
+// Watch starts receiving the rows of the tags of the table.
+// nameColumn is the tag name column of the table.
+func (sub *FeedSubscriber) Watch(table string, nameColumn string, tags ...string) {
+	table = feedTableName(table)
+	cf := sub.feed
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+	if sub.closed {
+		return
+	}
+	ft, ok := cf.tables[table]
+	if !ok {
+		ft = &feedTable{nameColumn: nameColumn, tags: map[string]map[*FeedSubscriber]struct{}{}}
+		cf.tables[table] = ft
+	}
+	for _, tag := range tags {
+		subs, ok := ft.tags[tag]
+		if !ok {
+			subs = map[*FeedSubscriber]struct{}{}
+			ft.tags[tag] = subs
+		}
+		if _, exists := subs[sub]; !exists {
+			subs[sub] = struct{}{}
+			sub.tables[table] = append(sub.tables[table], tag)
+		}
+	}
+}
+
+// Unwatch stops receiving the rows of the tags of the table.
+func (sub *FeedSubscriber) Unwatch(table string, tags ...string) {
+	table = feedTableName(table)
+	cf := sub.feed
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+	sub.unwatch(table, tags)
+}
+
+// unwatch should be called with feed.lock held
+func (sub *FeedSubscriber) unwatch(table string, tags []string) {
+	cf := sub.feed
+	ft, ok := cf.tables[table]
+	if !ok {
+		return
+	}
+	remains := sub.tables[table][:0]
+	for _, tag := range sub.tables[table] {
+		if !slices.Contains(tags, tag) {
+			remains = append(remains, tag)
+			continue
+		}
+		if subs, ok := ft.tags[tag]; ok {
+			delete(subs, sub)
+			if len(subs) == 0 {
+				delete(ft.tags, tag)
+			}
+		}
+	}
+	if len(remains) == 0 {
+		delete(sub.tables, table)
+	} else {
+		sub.tables[table] = remains
+	}
+	if len(ft.tags) == 0 {
+		delete(cf.tables, table)
+	}
+}
+
+// Close unwatches all tables and closes C.
+func (sub *FeedSubscriber) Close() {
+	cf := sub.feed
+	cf.lock.Lock()
+	defer cf.lock.Unlock()
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	for table, tags := range sub.tables {
+		sub.unwatch(table, append([]string{}, tags...))
+	}
+	close(sub.ch)
+}
+
+// TakeDropped returns the tags that have lost rows since the last call.
+func (sub *FeedSubscriber) TakeDropped() []string {
+	sub.droppedLock.Lock()
+	defer sub.droppedLock.Unlock()
+	if len(sub.dropped) == 0 {
+		return nil
+	}
+	ret := make([]string, 0, len(sub.dropped))
+	for tag := range sub.dropped {
+		ret = append(ret, tag)
+	}
+	clear(sub.dropped)
+	return ret
+}
+
+// send should be called with feed.lock held
+func (sub *FeedSubscriber) send(row *FeedRow) {
+	select {
+	case sub.ch <- row:
+	default:
+		sub.droppedLock.Lock()
+		sub.dropped[row.Tag] = true
+		sub.droppedLock.Unlock()
+	}
+}
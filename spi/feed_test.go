@@ -0,0 +1,47 @@
+package spi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangeFeed(t *testing.T) {
+	cf := NewChangeFeed()
+	require.False(t, cf.HasSubscribers("example"))
+
+	s1 := cf.Subscribe(1)
+	s1.Watch("example", "NAME", "a", "b")
+	s2 := cf.Subscribe(10)
+	s2.Watch("sys.example", "NAME", "a")
+	require.True(t, cf.HasSubscribers("MACHBASEDB.SYS.EXAMPLE"))
+
+	ts := time.Unix(0, 1)
+	cf.Publish("EXAMPLE", []string{"name", "time", "value"}, []any{"a", ts, 1.0})
+	cf.Publish("example", []string{"name", "time", "value"}, []any{"c", ts, 3.0})
+
+	for _, sub := range []*FeedSubscriber{s1, s2} {
+		row := <-sub.C
+		require.Equal(t, "EXAMPLE", row.Table)
+		require.Equal(t, "a", row.Tag)
+		require.Equal(t, []any{"a", ts, 1.0}, row.Values)
+		require.Len(t, sub.C, 0)
+	}
+
+	// s1 is full, the row of "b" is dropped
+	cf.Publish("example", []string{"name", "time", "value"}, []any{"b", ts, 2.0})
+	cf.Publish("example", []string{"name", "time", "value"}, []any{"b", ts, 2.1})
+	require.Equal(t, []string{"b"}, s1.TakeDropped())
+	require.Nil(t, s1.TakeDropped())
+	require.Nil(t, s2.TakeDropped())
+	require.Len(t, s2.C, 0)
+
+	s1.Unwatch("example", "a")
+	s2.Close()
+	_, ok := <-s2.C
+	require.False(t, ok)
+	require.True(t, cf.HasSubscribers("example"))
+	s1.Close()
+	require.False(t, cf.HasSubscribers("example"))
+}
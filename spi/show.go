@@ -1222,6 +1222,11 @@ func (rgi *ShowRollupGapResultSet) Iter(callback func(values []interface{}) bool
 	}
 }
 
+// Rollups returns the rollups of the result set.
+func (rgi *ShowRollupGapResultSet) Rollups() []*RollupGapInfo {
+	return rgi.list
+}
+
 func ShowRollupGap(ctx context.Context, conn *sql.Conn) *ShowRollupGapResultSet {
 	sqlText := SqlTidy(`SELECT
             U.NAME AS USER_NAME,
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -25,14 +26,23 @@ type WatcherConfig struct {
 	TableName    string
 	TagNames     []string // required in watching tag table
 	MaxRowNum    int      // affects on watching log table
+	// Feed delivers the rows of the tag table as soon as they are written,
+	// the tags are polled only every WatcherFeedPollInterval for the rows
+	// that are written by other paths, or when the feed has dropped rows.
+	Feed *ChangeFeed
 }
 
+// WatcherFeedPollInterval is the polling interval of the tags which are watched through the feed.
+var WatcherFeedPollInterval = 30 * time.Second
+
 type Watcher struct {
 	WatcherConfig
 	initialized bool
 	ctx         context.Context
 	parallelCh  chan bool
 	out         chan any
+	done        chan struct{}
+	closeOnce   sync.Once
 	C           <-chan any
 	// table info
 	isTagTable  bool
@@ -44,6 +54,10 @@ type Watcher struct {
 	basetimeColumn  string
 	tagLastTime     map[string]time.Time
 	tagLastTimeLock sync.Mutex
+	tagNamesLock    sync.RWMutex
+	tagLastPoll     map[string]time.Time
+	feedSub         *FeedSubscriber
+	feedWg          sync.WaitGroup
 	// log table
 	lastArrivalTime time.Time
 }
@@ -58,18 +72,89 @@ func NewWatcher(ctx context.Context, conf WatcherConfig) (*Watcher, error) {
 }
 
 func (w *Watcher) String() string {
-	return fmt.Sprintf("Watcher {table:%s, tags:%v, parallelism:%d}", w.TableName, w.TagNames, w.Parallelism)
+	return fmt.Sprintf("Watcher {table:%s, tags:%v, parallelism:%d}", w.TableName, w.Tags(), w.Parallelism)
+}
+
+// Tags returns the names of the tags being watched.
+func (w *Watcher) Tags() []string {
+	w.tagNamesLock.RLock()
+	defer w.tagNamesLock.RUnlock()
+	return append([]string{}, w.TagNames...)
+}
+
+// AddTags adds the tags to be watched from the next Execute(),
+// the recent row of the new tag is sent at first regardless of the change.
+func (w *Watcher) AddTags(tags ...string) {
+	w.tagNamesLock.Lock()
+	defer w.tagNamesLock.Unlock()
+	for _, tag := range tags {
+		if !slices.Contains(w.TagNames, tag) {
+			w.TagNames = append(w.TagNames, tag)
+		}
+	}
+	if w.feedSub != nil {
+		w.feedSub.Watch(w.TableName, w.nameColumn, tags...)
+	}
+}
+
+// RemoveTags stops watching the tags.
+// It returns the number of the remaining tags.
+func (w *Watcher) RemoveTags(tags ...string) int {
+	w.tagNamesLock.Lock()
+	w.TagNames = slices.DeleteFunc(w.TagNames, func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+	remains := len(w.TagNames)
+	w.tagNamesLock.Unlock()
+	if w.feedSub != nil {
+		w.feedSub.Unwatch(w.TableName, tags...)
+	}
+
+	w.tagLastTimeLock.Lock()
+	for _, tag := range tags {
+		delete(w.tagLastTime, tag)
+		delete(w.tagLastPoll, tag)
+	}
+	w.tagLastTimeLock.Unlock()
+	return remains
+}
+
+// NameColumn returns the name of the tag name column, it is empty if the table is not a tag table.
+func (w *Watcher) NameColumn() string {
+	return w.nameColumn
 }
 
 func (w *Watcher) handleData(obj WatchData) {
-	w.out <- obj
+	w.send(obj)
 }
 
 func (w *Watcher) handleError(err error) {
-	w.out <- err
+	w.send(err)
+}
+
+// send gives up if the watcher is closing, since the consumer may not read anymore.
+func (w *Watcher) send(v any) {
+	select {
+	case w.out <- v:
+	case <-w.done:
+	case <-w.ctx.Done():
+	}
 }
 
+// Close stops the watcher and closes C, it is safe to call more than once.
 func (w *Watcher) Close() {
+	w.closeOnce.Do(w.close)
+}
+
+func (w *Watcher) close() {
+	if w.done != nil {
+		// unblock the senders before waiting for them
+		close(w.done)
+	}
+	if w.feedSub != nil {
+		w.feedSub.Close()
+		w.feedWg.Wait()
+	}
 	if w.parallelCh != nil {
 		// drain the channel, waiting for all goroutines to finish
 		for i := 0; i < w.Parallelism; i++ {
@@ -92,6 +177,7 @@ func (w *Watcher) init(ctx context.Context) error {
 		w.ChanSize = 100
 	}
 	w.out = make(chan any, w.ChanSize)
+	w.done = make(chan struct{})
 	w.C = w.out
 
 	conn, err := w.ConnProvider()
@@ -114,7 +200,7 @@ func (w *Watcher) init(ctx context.Context) error {
 		if len(w.TagNames) == 0 {
 			return fmt.Errorf("table '%s' is TAG table, no tag specified", w.TableName)
 		}
-		if w.Parallelism <= 0 || w.Parallelism > len(w.TagNames) {
+		if w.Parallelism <= 0 {
 			w.Parallelism = len(w.TagNames)
 		}
 		w.parallelCh = make(chan bool, w.Parallelism)
@@ -159,13 +245,45 @@ func (w *Watcher) init(ctx context.Context) error {
 			return fmt.Errorf("fail to get basetime column '%s'", w.TableName)
 		}
 		w.tagLastTime = map[string]time.Time{}
+		w.tagLastPoll = map[string]time.Time{}
+		if w.Feed != nil {
+			w.feedSub = w.Feed.Subscribe(w.ChanSize)
+			w.feedSub.Watch(w.TableName, w.nameColumn, w.TagNames...)
+			w.feedWg.Add(1)
+			go func() {
+				defer w.feedWg.Done()
+				for row := range w.feedSub.C {
+					w.handleFeedRow(row)
+				}
+			}()
+		}
 	}
 	return nil
 }
 
 func (w *Watcher) Execute() {
 	if w.isTagTable {
-		for _, tag := range w.TagNames {
+		if w.feedSub != nil {
+			// the rows of the dropped tags should be polled
+			dropped := w.feedSub.TakeDropped()
+			w.tagLastTimeLock.Lock()
+			for _, tag := range dropped {
+				delete(w.tagLastPoll, tag)
+			}
+			w.tagLastTimeLock.Unlock()
+		}
+		now := time.Now()
+		for _, tag := range w.Tags() {
+			if w.feedSub != nil {
+				w.tagLastTimeLock.Lock()
+				lastPoll, polled := w.tagLastPoll[tag]
+				if polled && now.Sub(lastPoll) < WatcherFeedPollInterval {
+					w.tagLastTimeLock.Unlock()
+					continue
+				}
+				w.tagLastPoll[tag] = now
+				w.tagLastTimeLock.Unlock()
+			}
 			go w.executeTag(tag)
 		}
 	} else {
@@ -247,9 +365,69 @@ func (w *Watcher) executeTag(tag string) {
 	w.handleData(obj)
 }
 
+// handleFeedRow sends the row of the feed if it is more recent than the last row of the tag.
+// If the row does not have all columns of the table, the tag is polled at the next Execute().
+func (w *Watcher) handleFeedRow(row *FeedRow) {
+	values := make([]any, len(w.columns))
+	for i, col := range w.columns {
+		idx := slices.IndexFunc(row.Columns, func(name string) bool { return strings.EqualFold(name, col.Name) })
+		if idx < 0 || idx >= len(row.Values) {
+			w.pollNext(row.Tag)
+			return
+		}
+		values[i] = row.Values[idx]
+	}
+	var recentTime time.Time
+	for i, col := range w.columns {
+		if col.Name != w.basetimeColumn {
+			continue
+		}
+		switch v := values[i].(type) {
+		case time.Time:
+			recentTime = v
+		case int64:
+			recentTime = time.Unix(0, v)
+		default:
+			w.pollNext(row.Tag)
+			return
+		}
+	}
+	w.tagLastTimeLock.Lock()
+	if lt, ok := w.tagLastTime[row.Tag]; ok && !recentTime.After(lt) {
+		w.tagLastTimeLock.Unlock()
+		return
+	}
+	w.tagLastTime[row.Tag] = recentTime
+	w.tagLastTimeLock.Unlock()
+
+	obj := WatchData{}
+	for i, col := range w.columns {
+		if col.Type == api.ColumnTypeDatetime {
+			switch v := values[i].(type) {
+			case time.Time:
+				obj[col.Name] = w.timeformat.FormatEpoch(v)
+				continue
+			case int64:
+				obj[col.Name] = w.timeformat.FormatEpoch(time.Unix(0, v))
+				continue
+			}
+		}
+		obj[col.Name] = values[i]
+	}
+	w.handleData(obj)
+}
+
+func (w *Watcher) pollNext(tag string) {
+	w.tagLastTimeLock.Lock()
+	delete(w.tagLastPoll, tag)
+	w.tagLastTimeLock.Unlock()
+}
+
 func (w *Watcher) executeLog() {
 	if w.parallelCh != nil {
-		<-w.parallelCh
+		if _, isOpen := <-w.parallelCh; !isOpen {
+			return
+		}
 		defer func() {
 			w.parallelCh <- true
 		}()
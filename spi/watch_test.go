@@ -44,3 +44,128 @@ func TestWatchLogTableInitialEmptyExecuteDoesNotError(t *testing.T) {
 	case <-time.After(250 * time.Millisecond):
 	}
 }
+
+func TestWatcherTags(t *testing.T) {
+	w := &spi.Watcher{WatcherConfig: spi.WatcherConfig{TagNames: []string{"a"}}}
+	w.AddTags("b", "a", "c")
+	require.Equal(t, []string{"a", "b", "c"}, w.Tags())
+
+	require.Equal(t, 1, w.RemoveTags("a", "c", "x"))
+	require.Equal(t, []string{"b"}, w.Tags())
+	require.Equal(t, 0, w.RemoveTags("b"))
+	require.Empty(t, w.Tags())
+}
+
+func TestWatchTagTableFeed(t *testing.T) {
+	db, err := spi.DefaultPool()
+	require.NoError(t, err, "connect fail")
+
+	conn, err := db.Conn(t.Context())
+	require.NoError(t, err, "connect fail")
+	_, err = conn.ExecContext(t.Context(), `create tag table if not exists watch_tag_feed (
+		name varchar(80) primary key,
+		time datetime basetime,
+		value double
+	)`)
+	require.NoError(t, err, "create table fail")
+	conn.Close()
+
+	feed := spi.NewChangeFeed()
+	conf := spi.WatcherConfig{
+		ConnProvider: func() (*sql.Conn, error) {
+			return db.Conn(t.Context())
+		},
+		Timeformat: "ns",
+		Timezone:   time.UTC,
+		TableName:  "watch_tag_feed",
+		TagNames:   []string{"feed-1"},
+		Feed:       feed,
+	}
+	w, err := spi.NewWatcher(t.Context(), conf)
+	require.NoError(t, err, "new watcher fail")
+	defer w.Close()
+	require.True(t, feed.HasSubscribers("WATCH_TAG_FEED"))
+
+	// no row yet, nothing is polled
+	w.Execute()
+
+	expectNothing := func() {
+		select {
+		case data := <-w.C:
+			require.Failf(t, "unexpected watcher output", "%T: %#v", data, data)
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+	expectNothing()
+
+	ts := time.Unix(0, 1705291859000000000)
+	columns := []string{"NAME", "TIME", "VALUE"}
+	feed.Publish("watch_tag_feed", columns, []any{"feed-1", ts, 1.5})
+	select {
+	case data := <-w.C:
+		require.Equal(t, spi.WatchData{"NAME": "feed-1", "TIME": ts.UnixNano(), "VALUE": 1.5}, data)
+	case <-time.After(time.Second):
+		require.Fail(t, "no data from the feed")
+	}
+
+	// older row is not sent
+	feed.Publish("watch_tag_feed", columns, []any{"feed-1", ts.Add(-time.Second), 0.5})
+	// not watching tag
+	feed.Publish("watch_tag_feed", columns, []any{"feed-2", ts.Add(time.Second), 2.5})
+	expectNothing()
+
+	w.RemoveTags("feed-1")
+	require.False(t, feed.HasSubscribers("watch_tag_feed"))
+}
+
+func TestWatchTagTableFeedCloseWithoutReader(t *testing.T) {
+	db, err := spi.DefaultPool()
+	require.NoError(t, err, "connect fail")
+
+	conn, err := db.Conn(t.Context())
+	require.NoError(t, err, "connect fail")
+	_, err = conn.ExecContext(t.Context(), `create tag table if not exists watch_tag_feed (
+		name varchar(80) primary key,
+		time datetime basetime,
+		value double
+	)`)
+	require.NoError(t, err, "create table fail")
+	conn.Close()
+
+	feed := spi.NewChangeFeed()
+	conf := spi.WatcherConfig{
+		ConnProvider: func() (*sql.Conn, error) {
+			return db.Conn(t.Context())
+		},
+		Timeformat: "ns",
+		Timezone:   time.UTC,
+		TableName:  "watch_tag_feed",
+		TagNames:   []string{"feed-close"},
+		ChanSize:   1,
+		Feed:       feed,
+	}
+	w, err := spi.NewWatcher(t.Context(), conf)
+	require.NoError(t, err, "new watcher fail")
+
+	// nobody reads w.C, the feed goroutine is blocked on the second row
+	ts := time.Unix(0, 1705291859000000000)
+	columns := []string{"NAME", "TIME", "VALUE"}
+	feed.Publish("watch_tag_feed", columns, []any{"feed-close", ts, 1.0})
+	require.Eventually(t, func() bool { return len(w.C) == 1 }, time.Second, 10*time.Millisecond)
+	feed.Publish("watch_tag_feed", columns, []any{"feed-close", ts.Add(time.Second), 2.0})
+
+	closed := make(chan struct{})
+	go func() {
+		w.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		require.Fail(t, "watcher close is blocked")
+	}
+	require.False(t, feed.HasSubscribers("watch_tag_feed"))
+
+	// closing again does nothing
+	require.NotPanics(t, w.Close)
+}